@@ -91,7 +91,8 @@ type Module interface {
 	// ExportedFunction returns a function exported from this module or nil if it wasn't.
 	ExportedFunction(name string) Function
 
-	// TODO: Table
+	// ExportedTable returns a table exported from this module or nil if it wasn't.
+	ExportedTable(name string) Table
 
 	// ExportedMemory returns a memory exported from this module or nil if it wasn't.
 	//
@@ -129,6 +130,50 @@ type Function interface {
 	Call(ctx context.Context, params ...uint64) ([]uint64, error)
 }
 
+// FunctionDefinition includes information about a function available pre-instantiation.
+type FunctionDefinition interface {
+	// ModuleName is the possibly empty name of the module defining this function.
+	ModuleName() string
+
+	// Index is the position in the module's function index namespace, imports first.
+	Index() uint32
+
+	// Name is the module-defined name of the function, which is not necessarily the same as its export name.
+	Name() string
+
+	// ExportNames include all exported names for the given function.
+	ExportNames() []string
+
+	// ParamTypes are the parameters of the function.
+	ParamTypes() []ValueType
+
+	// ParamNames are index-correlated with ParamTypes or nil if not available for one or more parameters.
+	ParamNames() []string
+
+	// ResultTypes are the results of the function.
+	ResultTypes() []ValueType
+}
+
+// Table is a WebAssembly table exported from an instantiated module (wazero.Runtime InstantiateModule).
+//
+// Ex. To dump the targets of an indirect dispatch table:
+//	table := mod.ExportedTable("table")
+//	for i := uint32(0); i < table.Size(ctx); i++ {
+//		if fn, _ := table.GetFunction(ctx, i); fn != nil {
+//			fmt.Printf("%d: %s.%s\n", i, fn.ModuleName(), fn.Name())
+//		}
+//	}
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-table
+type Table interface {
+	// Size returns the count of elements in the table.
+	Size(context.Context) uint32
+
+	// GetFunction returns the definition of the function referenced by the element at the index, or nil if the
+	// element is a null reference. This errs if the index is out of range or the table isn't of funcref.
+	GetFunction(ctx context.Context, index uint32) (FunctionDefinition, error)
+}
+
 // Global is a WebAssembly 1.0 (20191205) global exported from an instantiated module (wazero.Runtime InstantiateModule).
 //
 // Ex. If the value is not mutable, you can read it once:
@@ -214,7 +259,25 @@ type Memory interface {
 	// See math.Float64bits
 	ReadFloat64Le(ctx context.Context, offset uint32) (float64, bool)
 
+	// ReadUint16Be reads a uint16 in big-endian encoding from the underlying buffer at the offset in or returns
+	// false if out of range.
+	//
+	// Note: WebAssembly is little-endian, so this is only for data a guest deliberately encoded big-endian, ex. a
+	// network protocol. Use ReadUint16Le otherwise.
+	ReadUint16Be(ctx context.Context, offset uint32) (uint16, bool)
+
+	// ReadUint32Be reads a uint32 in big-endian encoding from the underlying buffer at the offset in or returns
+	// false if out of range. See ReadUint16Be
+	ReadUint32Be(ctx context.Context, offset uint32) (uint32, bool)
+
+	// ReadUint64Be reads a uint64 in big-endian encoding from the underlying buffer at the offset in or returns
+	// false if out of range. See ReadUint16Be
+	ReadUint64Be(ctx context.Context, offset uint32) (uint64, bool)
+
 	// Read reads byteCount bytes from the underlying buffer at the offset or returns false if out of range.
+	//
+	// The result is a copy, so it remains valid after the memory changes or grows, but writing to it doesn't change
+	// the memory. Use Write for that.
 	Read(ctx context.Context, offset, byteCount uint32) ([]byte, bool)
 
 	// WriteByte writes a single byte to the underlying buffer at the offset in or returns false if out of range.
@@ -242,6 +305,21 @@ type Memory interface {
 	// See math.Float64bits
 	WriteFloat64Le(ctx context.Context, offset uint32, v float64) bool
 
+	// WriteUint16Be writes the value in big-endian encoding to the underlying buffer at the offset in or returns
+	// false if out of range.
+	//
+	// Note: WebAssembly is little-endian, so this is only for data a guest deliberately decodes big-endian, ex. a
+	// network protocol. Use WriteUint16Le otherwise.
+	WriteUint16Be(ctx context.Context, offset uint32, v uint16) bool
+
+	// WriteUint32Be writes the value in big-endian encoding to the underlying buffer at the offset in or returns
+	// false if out of range. See WriteUint16Be
+	WriteUint32Be(ctx context.Context, offset, v uint32) bool
+
+	// WriteUint64Be writes the value in big-endian encoding to the underlying buffer at the offset in or returns
+	// false if out of range. See WriteUint16Be
+	WriteUint64Be(ctx context.Context, offset uint32, v uint64) bool
+
 	// Write writes the slice to the underlying buffer at the offset or returns false if out of range.
 	Write(ctx context.Context, offset uint32, v []byte) bool
 }
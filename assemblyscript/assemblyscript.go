@@ -0,0 +1,185 @@
+// Package assemblyscript contains Go-defined functions for the imports of modules compiled by AssemblyScript without
+// WASI, such as "abort". These are accessible from WebAssembly-defined functions via importing ModuleEnv.
+//
+// See https://www.assemblyscript.org/concepts.html#special-imports
+package assemblyscript
+
+import (
+	"context"
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// ModuleEnv is the module name AssemblyScript imports its special functions from.
+const ModuleEnv = "env"
+
+const (
+	// functionAbort is called on unrecoverable errors, such as a failed assertion.
+	// See https://github.com/AssemblyScript/assemblyscript/blob/v0.20.13/std/assembly/builtins.ts#L2341
+	functionAbort = "abort"
+
+	// importAbort is the WebAssembly 1.0 (20191205) Text format import of functionAbort.
+	importAbort = `(import "env" "abort"
+    (func $env.abort (param $message i32) (param $fileName i32) (param $lineNumber i32) (param $columnNumber i32)))`
+
+	// functionTrace is called by the `trace` function to print a message and up to five numbers.
+	// See https://github.com/AssemblyScript/assemblyscript/blob/v0.20.13/std/assembly/builtins.ts#L2351
+	functionTrace = "trace"
+
+	// importTrace is the WebAssembly 1.0 (20191205) Text format import of functionTrace.
+	importTrace = `(import "env" "trace"
+    (func $env.trace (param $message i32) (param $nArgs i32) (param $arg0 f64) (param $arg1 f64) (param $arg2 f64)
+                     (param $arg3 f64) (param $arg4 f64)))`
+
+	// functionSeed returns the seed of `Math.random`.
+	// See https://github.com/AssemblyScript/assemblyscript/blob/v0.20.13/std/assembly/builtins.ts#L2346
+	functionSeed = "seed"
+
+	// importSeed is the WebAssembly 1.0 (20191205) Text format import of functionSeed.
+	importSeed = `(import "env" "seed" (func $env.seed (result f64)))`
+)
+
+// Instantiate instantiates ModuleEnv with the functions AssemblyScript imports, so that modules it compiled can
+// import them:
+// * "abort" writes the message and location to the standard error of the calling module, then traps with them.
+// * "trace" writes the message and numbers to the standard error of the calling module.
+// * "seed" returns a random number read from the experimental.Sys of the context, or crypto/rand.
+//
+// Ex. The error of a guest that calls `abort("boom")` at line 3 and column 5 of "index.ts":
+//	abort: boom at index.ts:3:5 (recovered by wazero)
+//
+// Note: Only one module can be named ModuleEnv, so a guest which imports other functions from it needs them to be
+// exported by a module built with wazero.ModuleBuilder instead. See Functions
+func Instantiate(ctx context.Context, r wazero.Runtime) (api.Module, error) {
+	return r.NewModuleBuilder(ModuleEnv).ExportFunctions(Functions(ctx)).Instantiate(ctx)
+}
+
+// Functions returns the functions Instantiate exports, keyed by name, so that they can be exported along with others
+// from ModuleEnv. Ex.
+//
+//	fns := assemblyscript.Functions(ctx)
+//	fns["log"] = log
+//	env, err := r.NewModuleBuilder(assemblyscript.ModuleEnv).ExportFunctions(fns).Instantiate(ctx)
+func Functions(ctx context.Context) map[string]interface{} {
+	a := &assemblyScript{sys: &defaultSys{}}
+	if ctx != nil { // Test to see if internal code are using an experimental feature.
+		if sys := ctx.Value(experimental.SysKey{}); sys != nil {
+			a.sys = sys.(experimental.Sys)
+		}
+	}
+	return map[string]interface{}{
+		functionAbort: a.Abort,
+		functionTrace: a.Trace,
+		functionSeed:  a.Seed,
+	}
+}
+
+type assemblyScript struct {
+	sys experimental.Sys
+}
+
+// Abort is the AssemblyScript function named functionAbort. Any string which is null (zero) or can't be read is
+// omitted from the message, so this always traps with an error beginning with "abort".
+//
+// Note: importAbort shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+func (a *assemblyScript) Abort(ctx context.Context, m api.Module, message, fileName, lineNumber, columnNumber uint32) {
+	var msg strings.Builder
+	msg.WriteString("abort")
+	if s, ok := readString(ctx, m.Memory(), message); ok {
+		msg.WriteString(": ")
+		msg.WriteString(s)
+	}
+	if s, ok := readString(ctx, m.Memory(), fileName); ok {
+		fmt.Fprintf(&msg, " at %s:%d:%d", s, lineNumber, columnNumber)
+	}
+	_, _ = fmt.Fprintln(sysCtx(m).Stderr(), msg.String())
+	panic(fmt.Errorf("%s", msg.String()))
+}
+
+// Trace is the AssemblyScript function named functionTrace. nArgs is the count of numbers to write, up to five.
+//
+// Note: importTrace shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+func (a *assemblyScript) Trace(ctx context.Context, m api.Module, message, nArgs uint32, arg0, arg1, arg2, arg3, arg4 float64) {
+	var msg strings.Builder
+	msg.WriteString("trace: ")
+	if s, ok := readString(ctx, m.Memory(), message); ok {
+		msg.WriteString(s)
+	}
+	for i, arg := range []float64{arg0, arg1, arg2, arg3, arg4} {
+		if uint32(i) >= nArgs {
+			break
+		}
+		if i == 0 {
+			msg.WriteByte(' ')
+		} else {
+			msg.WriteString(", ")
+		}
+		fmt.Fprintf(&msg, "%v", arg)
+	}
+	_, _ = fmt.Fprintln(sysCtx(m).Stderr(), msg.String())
+}
+
+// Seed is the AssemblyScript function named functionSeed.
+//
+// Note: importSeed shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+func (a *assemblyScript) Seed() float64 {
+	buf := make([]byte, 8)
+	if err := a.sys.RandSource(buf); err != nil {
+		panic(fmt.Errorf("error reading random seed: %w", err))
+	}
+	return float64(binary.LittleEndian.Uint64(buf))
+}
+
+// readString reads an AssemblyScript string, which is UTF-16LE encoded at the offset, prefixed by its length in bytes
+// as a uint32. This returns false if the offset is zero (null) or the string is out of memory bounds.
+//
+// See https://www.assemblyscript.org/runtime.html#memory-layout
+func readString(ctx context.Context, mem api.Memory, offset uint32) (string, bool) {
+	if offset < 4 || mem == nil {
+		return "", false
+	}
+	size, ok := mem.ReadUint32Le(ctx, offset-4)
+	if !ok || size%2 != 0 {
+		return "", false
+	}
+	buf, ok := mem.Read(ctx, offset, size)
+	if !ok {
+		return "", false
+	}
+	units := make([]uint16, size/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(buf[i*2:])
+	}
+	return string(utf16.Decode(units)), true
+}
+
+func sysCtx(m api.Module) *wasm.SysContext {
+	if internal, ok := m.(*wasm.CallContext); !ok {
+		panic(fmt.Errorf("unsupported wasm.Module implementation: %v", m))
+	} else {
+		return internal.Sys
+	}
+}
+
+// compile-time check to ensure defaultSys implements experimental.Sys.
+var _ experimental.Sys = &defaultSys{}
+
+type defaultSys struct{}
+
+func (d *defaultSys) TimeNowUnixNano() uint64 {
+	return uint64(time.Now().UnixNano())
+}
+
+func (d *defaultSys) RandSource(bytes []byte) error {
+	_, err := crand.Read(bytes)
+	return err
+}
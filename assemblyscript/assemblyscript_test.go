@@ -0,0 +1,216 @@
+package assemblyscript
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"math"
+	"testing"
+	"unicode/utf16"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	wasmbinary "github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// compile-time check to ensure fakeSys implements experimental.Sys.
+var _ experimental.Sys = fakeSys{}
+
+type fakeSys struct{}
+
+func (d fakeSys) TimeNowUnixNano() uint64 {
+	return 0
+}
+
+func (d fakeSys) RandSource(p []byte) error {
+	for i := range p {
+		p[i] = byte(i + 1)
+	}
+	return nil
+}
+
+// testCtx ensures fakeSys is used for AssemblyScript functions.
+var testCtx = context.WithValue(context.Background(), experimental.SysKey{}, fakeSys{})
+
+const (
+	messageOffset = 16 // arbitrary offset of the message "boom"
+	fileOffset    = 32 // arbitrary offset of the file name "index.ts"
+)
+
+// asString returns the AssemblyScript memory layout of s, which begins 4 bytes before its offset.
+func asString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	buf := make([]byte, 4+len(units)*2)
+	binary.LittleEndian.PutUint32(buf, uint32(len(units)*2))
+	for i, u := range units {
+		binary.LittleEndian.PutUint16(buf[4+i*2:], u)
+	}
+	return buf
+}
+
+// dataSegment returns a data segment of the AssemblyScript string s at the offset.
+func dataSegment(offset uint32, s string) *wasm.DataSegment {
+	return &wasm.DataSegment{
+		OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{byte(offset - 4)}},
+		Init:             asString(s),
+	}
+}
+
+// i32Const returns the instruction i32.const of a value less than 64, which is a single byte in LEB128.
+func i32Const(v byte) []byte {
+	return []byte{wasm.OpcodeI32Const, v}
+}
+
+// f64Const returns the instruction f64.const of v.
+func f64Const(v float64) []byte {
+	buf := []byte{wasm.OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.LittleEndian.PutUint64(buf[1:], math.Float64bits(v))
+	return buf
+}
+
+// instantiateGuest instantiates a guest which imports the functions compiled by AssemblyScript, and exports each
+// body as a function named by its key.
+func instantiateGuest(t *testing.T, r wazero.Runtime, config wazero.ModuleConfig, bodies map[string][]byte) api.Module {
+	abortType := &wasm.FunctionType{Params: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32, wasm.ValueTypeI32}}
+	traceType := &wasm.FunctionType{Params: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32,
+		wasm.ValueTypeF64, wasm.ValueTypeF64, wasm.ValueTypeF64, wasm.ValueTypeF64, wasm.ValueTypeF64}}
+	seedType := &wasm.FunctionType{Results: []wasm.ValueType{wasm.ValueTypeF64}}
+	m := &wasm.Module{
+		TypeSection: []*wasm.FunctionType{abortType, traceType, seedType, {}},
+		ImportSection: []*wasm.Import{
+			{Module: ModuleEnv, Name: functionAbort, Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: ModuleEnv, Name: functionTrace, Type: wasm.ExternTypeFunc, DescFunc: 1},
+			{Module: ModuleEnv, Name: functionSeed, Type: wasm.ExternTypeFunc, DescFunc: 2},
+		},
+		MemorySection: &wasm.Memory{Min: 1},
+		DataSection:   []*wasm.DataSegment{dataSegment(messageOffset, "boom"), dataSegment(fileOffset, "index.ts")},
+		ExportSection: []*wasm.Export{{Name: "memory", Type: wasm.ExternTypeMemory, Index: 0}},
+		NameSection:   &wasm.NameSection{ModuleName: "guest"},
+	}
+	for name, body := range bodies {
+		m.ExportSection = append(m.ExportSection,
+			&wasm.Export{Name: name, Type: wasm.ExternTypeFunc, Index: wasm.Index(3 + len(m.FunctionSection))})
+		m.FunctionSection = append(m.FunctionSection, 3)
+		m.CodeSection = append(m.CodeSection, &wasm.Code{Body: append(body, wasm.OpcodeEnd)})
+	}
+
+	code, err := r.CompileModule(testCtx, wasmbinary.EncodeModule(m))
+	require.NoError(t, err)
+	mod, err := r.InstantiateModuleWithConfig(testCtx, code, config)
+	require.NoError(t, err)
+	return mod
+}
+
+func TestAbort(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           []byte
+		expectedErr    string
+		expectedStderr string
+	}{
+		{
+			name: "message and location",
+			body: bytes.Join([][]byte{i32Const(messageOffset), i32Const(fileOffset), i32Const(3), i32Const(5),
+				{wasm.OpcodeCall, 0}}, nil),
+			expectedErr: `abort: boom at index.ts:3:5 (recovered by wazero)
+wasm stack trace:
+	env.abort(i32,i32,i32,i32)
+	guest.[3]()`,
+			expectedStderr: "abort: boom at index.ts:3:5\n",
+		},
+		{
+			name: "null message and file",
+			body: bytes.Join([][]byte{i32Const(0), i32Const(0), i32Const(0), i32Const(0),
+				{wasm.OpcodeCall, 0}}, nil),
+			expectedErr: `abort (recovered by wazero)
+wasm stack trace:
+	env.abort(i32,i32,i32,i32)
+	guest.[3]()`,
+			expectedStderr: "abort\n",
+		},
+		{
+			name: "message out of range",
+			body: bytes.Join([][]byte{{wasm.OpcodeI32Const, 0x7f}, i32Const(0), i32Const(0), i32Const(0),
+				{wasm.OpcodeCall, 0}}, nil),
+			expectedErr: `abort (recovered by wazero)
+wasm stack trace:
+	env.abort(i32,i32,i32,i32)
+	guest.[3]()`,
+			expectedStderr: "abort\n",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+			_, err := Instantiate(testCtx, r)
+			require.NoError(t, err)
+
+			stderr := bytes.NewBuffer(nil)
+			mod := instantiateGuest(t, r, wazero.NewModuleConfig().WithStderr(stderr), map[string][]byte{"run": tc.body})
+
+			_, err = mod.ExportedFunction("run").Call(testCtx)
+			require.EqualError(t, err, tc.expectedErr)
+			require.Equal(t, tc.expectedStderr, stderr.String())
+		})
+	}
+}
+
+func TestTrace(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	_, err := Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	stderr := bytes.NewBuffer(nil)
+	body := bytes.Join([][]byte{i32Const(messageOffset), i32Const(2),
+		f64Const(1), f64Const(2.5), f64Const(3), f64Const(0), f64Const(0), {wasm.OpcodeCall, 1}}, nil)
+	mod := instantiateGuest(t, r, wazero.NewModuleConfig().WithStderr(stderr), map[string][]byte{"run": body})
+
+	_, err = mod.ExportedFunction("run").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, "trace: boom 1, 2.5\n", stderr.String())
+}
+
+func TestSeed(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	_, err := Instantiate(testCtx, r)
+	require.NoError(t, err)
+
+	body := []byte{wasm.OpcodeCall, 2, wasm.OpcodeDrop}
+	mod := instantiateGuest(t, r, wazero.NewModuleConfig(), map[string][]byte{"run": body})
+	_, err = mod.ExportedFunction("run").Call(testCtx)
+	require.NoError(t, err)
+
+	// fakeSys fills the seed with bytes 1 to 8.
+	seed := Functions(testCtx)[functionSeed].(func() float64)()
+	require.Equal(t, float64(0x0807060504030201), seed)
+}
+
+func TestReadString(t *testing.T) {
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	_, err := Instantiate(testCtx, r)
+	require.NoError(t, err)
+	mem := instantiateGuest(t, r, wazero.NewModuleConfig(), nil).Memory()
+
+	s, ok := readString(testCtx, mem, messageOffset)
+	require.True(t, ok)
+	require.Equal(t, "boom", s)
+
+	// Odd lengths can't be UTF-16.
+	require.True(t, mem.WriteUint32Le(testCtx, messageOffset-4, 3))
+	_, ok = readString(testCtx, mem, messageOffset)
+	require.False(t, ok)
+
+	// The length exceeds the memory.
+	require.True(t, mem.WriteUint32Le(testCtx, messageOffset-4, mem.Size(testCtx)))
+	_, ok = readString(testCtx, mem, messageOffset)
+	require.False(t, ok)
+
+	_, ok = readString(testCtx, mem, 0)
+	require.False(t, ok)
+}
@@ -34,6 +34,9 @@ import (
 // * ModuleBuilder is mutable. WithXXX functions return the same instance for chaining.
 // * WithXXX methods do not return errors, to allow chaining. Any validation errors are deferred until Build.
 // * Insertion order is not retained. Anything defined by this builder is sorted lexicographically on Build.
+// * A built module has no start section, so it can be used as a reactor: after InstantiateModuleWithConfig, its
+//   exports are called directly. A function exported as "_start" is called on instantiation, unless skipped via
+//   ModuleConfig.WithStartFunctions with no arguments.
 type ModuleBuilder interface {
 	// Note: until golang/go#5860, we can't use example tests to embed code in interface godocs.
 
@@ -77,7 +80,7 @@ type ModuleBuilder interface {
 	//		results, err := fn(ctx, offset, byteCount)
 	//	--snip--
 	//
-	// Note: If a function is already exported with the same name, this overwrites it.
+	// Note: If a function is already exported with the same name, this overwrites it. See WithStrictExports
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#host-functions%E2%91%A2
 	ExportFunction(name string, goFunc interface{}) ModuleBuilder
 
@@ -94,7 +97,7 @@ type ModuleBuilder interface {
 	//	builder.ExportMemory(1)
 	//
 	// Note: This is allowed to grow to RuntimeConfig.WithMemoryLimitPages (4GiB). To bound it, use ExportMemoryWithMax.
-	// Note: If a memory is already exported with the same name, this overwrites it.
+	// Note: If a memory is already exported with the same name, this overwrites it. See WithStrictExports
 	// Note: Version 1.0 (20191205) of the WebAssembly spec allows at most one memory per module.
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-section%E2%91%A0
 	ExportMemory(name string, minPages uint32) ModuleBuilder
@@ -114,44 +117,89 @@ type ModuleBuilder interface {
 	//	// (global (export "canvas_width") i32 (i32.const 1024))
 	//	builder.ExportGlobalI32("canvas_width", 1024)
 	//
-	// Note: If a global is already exported with the same name, this overwrites it.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
 	// Note: The maximum value of v is math.MaxInt32 to match constraints of initialization in binary format.
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#value-types%E2%91%A0
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-globaltype
 	ExportGlobalI32(name string, v int32) ModuleBuilder
 
+	// ExportGlobalsI32 is a convenience that calls ExportGlobalI32 for each key/value in the provided map.
+	//
+	// Ex. builder.ExportGlobalsI32(map[string]int32{"canvas_width": 1024, "canvas_height": 768})
+	//
+	// Note: Globals are sorted by name on Build, so the resulting order doesn't depend on map iteration order.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
+	ExportGlobalsI32(nameToValue map[string]int32) ModuleBuilder
+
 	// ExportGlobalI64 exports a global constant of type api.ValueTypeI64.
 	//
 	// For example, the WebAssembly 1.0 Text Format below is the equivalent of this builder method:
 	//	// (global (export "start_epoch") i64 (i64.const 1620216263544))
 	//	builder.ExportGlobalI64("start_epoch", 1620216263544)
 	//
-	// Note: If a global is already exported with the same name, this overwrites it.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
 	// Note: The maximum value of v is math.MaxInt64 to match constraints of initialization in binary format.
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#value-types%E2%91%A0
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-globaltype
 	ExportGlobalI64(name string, v int64) ModuleBuilder
 
+	// ExportGlobalsI64 is a convenience that calls ExportGlobalI64 for each key/value in the provided map.
+	//
+	// Ex. builder.ExportGlobalsI64(map[string]int64{"start_epoch": 1620216263544, "end_epoch": 1620216264544})
+	//
+	// Note: Globals are sorted by name on Build, so the resulting order doesn't depend on map iteration order.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
+	ExportGlobalsI64(nameToValue map[string]int64) ModuleBuilder
+
 	// ExportGlobalF32 exports a global constant of type api.ValueTypeF32.
 	//
 	// For example, the WebAssembly 1.0 Text Format below is the equivalent of this builder method:
 	//	// (global (export "math/pi") f32 (f32.const 3.1415926536))
 	//	builder.ExportGlobalF32("math/pi", 3.1415926536)
 	//
-	// Note: If a global is already exported with the same name, this overwrites it.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-globaltype
 	ExportGlobalF32(name string, v float32) ModuleBuilder
 
+	// ExportGlobalsF32 is a convenience that calls ExportGlobalF32 for each key/value in the provided map.
+	//
+	// Ex. builder.ExportGlobalsF32(map[string]float32{"math/pi": 3.1415926536, "math/e": 2.7182818285})
+	//
+	// Note: Globals are sorted by name on Build, so the resulting order doesn't depend on map iteration order.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
+	ExportGlobalsF32(nameToValue map[string]float32) ModuleBuilder
+
 	// ExportGlobalF64 exports a global constant of type api.ValueTypeF64.
 	//
 	// For example, the WebAssembly 1.0 Text Format below is the equivalent of this builder method:
 	//	// (global (export "math/pi") f64 (f64.const 3.14159265358979323846264338327950288419716939937510582097494459))
 	//	builder.ExportGlobalF64("math/pi", 3.14159265358979323846264338327950288419716939937510582097494459)
 	//
-	// Note: If a global is already exported with the same name, this overwrites it.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#syntax-globaltype
 	ExportGlobalF64(name string, v float64) ModuleBuilder
 
+	// ExportGlobalsF64 is a convenience that calls ExportGlobalF64 for each key/value in the provided map.
+	//
+	// Ex. builder.ExportGlobalsF64(map[string]float64{"math/pi": math.Pi, "math/e": math.E})
+	//
+	// Note: Globals are sorted by name on Build, so the resulting order doesn't depend on map iteration order.
+	// Note: If a global is already exported with the same name, this overwrites it. See WithStrictExports
+	ExportGlobalsF64(nameToValue map[string]float64) ModuleBuilder
+
+	// WithStrictExports configures whether Build errs when a name was exported more than once, as opposed to the
+	// default of the last export overwriting earlier ones. This catches accidentally exporting two different functions
+	// under the same name.
+	//
+	// Ex. This errs on Build with "func[add] is exported more than once":
+	//	_, err := r.NewModuleBuilder("env").WithStrictExports(true).
+	//		ExportFunction("add", addInts).
+	//		ExportFunction("add", addFloats).
+	//		Build(ctx)
+	//
+	// Note: This applies regardless of whether it is called before or after the duplicate exports.
+	WithStrictExports(strict bool) ModuleBuilder
+
 	// Build returns a module to instantiate, or returns an error if any of the configuration is invalid.
 	Build(context.Context) (CompiledCode, error)
 
@@ -168,6 +216,10 @@ type moduleBuilder struct {
 	nameToGoFunc map[string]interface{}
 	nameToMemory map[string]*wasm.Memory
 	nameToGlobal map[string]*wasm.Global
+	// strictExports is set by WithStrictExports.
+	strictExports bool
+	// duplicateExports are exports which overwrote an earlier one of the same kind, ex. "func[add]".
+	duplicateExports []string
 }
 
 // NewModuleBuilder implements Runtime.NewModuleBuilder
@@ -183,6 +235,9 @@ func (r *runtime) NewModuleBuilder(moduleName string) ModuleBuilder {
 
 // ExportFunction implements ModuleBuilder.ExportFunction
 func (b *moduleBuilder) ExportFunction(name string, goFunc interface{}) ModuleBuilder {
+	if _, ok := b.nameToGoFunc[name]; ok {
+		b.duplicateExports = append(b.duplicateExports, fmt.Sprintf("func[%s]", name))
+	}
 	b.nameToGoFunc[name] = goFunc
 	return b
 }
@@ -199,7 +254,7 @@ func (b *moduleBuilder) ExportFunctions(nameToGoFunc map[string]interface{}) Mod
 func (b *moduleBuilder) ExportMemory(name string, minPages uint32) ModuleBuilder {
 	mem := &wasm.Memory{Min: minPages, Max: b.r.memoryLimitPages}
 	mem.Cap = b.r.memoryCapacityPages(mem.Min, nil)
-	b.nameToMemory[name] = mem
+	b.setMemory(name, mem)
 	return b
 }
 
@@ -207,50 +262,108 @@ func (b *moduleBuilder) ExportMemory(name string, minPages uint32) ModuleBuilder
 func (b *moduleBuilder) ExportMemoryWithMax(name string, minPages, maxPages uint32) ModuleBuilder {
 	mem := &wasm.Memory{Min: minPages, Max: maxPages, IsMaxEncoded: true}
 	mem.Cap = b.r.memoryCapacityPages(mem.Min, &maxPages)
-	b.nameToMemory[name] = mem
+	b.setMemory(name, mem)
 	return b
 }
 
 // ExportGlobalI32 implements ModuleBuilder.ExportGlobalI32
 func (b *moduleBuilder) ExportGlobalI32(name string, v int32) ModuleBuilder {
-	b.nameToGlobal[name] = &wasm.Global{
+	b.setGlobal(name, &wasm.Global{
 		Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
 		// Treat constants as signed as their interpretation is not yet known per /RATIONALE.md
 		Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(v)},
+	})
+	return b
+}
+
+// ExportGlobalsI32 implements ModuleBuilder.ExportGlobalsI32
+func (b *moduleBuilder) ExportGlobalsI32(nameToValue map[string]int32) ModuleBuilder {
+	for k, v := range nameToValue {
+		b.ExportGlobalI32(k, v)
 	}
 	return b
 }
 
 // ExportGlobalI64 implements ModuleBuilder.ExportGlobalI64
 func (b *moduleBuilder) ExportGlobalI64(name string, v int64) ModuleBuilder {
-	b.nameToGlobal[name] = &wasm.Global{
+	b.setGlobal(name, &wasm.Global{
 		Type: &wasm.GlobalType{ValType: wasm.ValueTypeI64},
 		// Treat constants as signed as their interpretation is not yet known per /RATIONALE.md
 		Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: leb128.EncodeInt64(v)},
+	})
+	return b
+}
+
+// ExportGlobalsI64 implements ModuleBuilder.ExportGlobalsI64
+func (b *moduleBuilder) ExportGlobalsI64(nameToValue map[string]int64) ModuleBuilder {
+	for k, v := range nameToValue {
+		b.ExportGlobalI64(k, v)
 	}
 	return b
 }
 
 // ExportGlobalF32 implements ModuleBuilder.ExportGlobalF32
 func (b *moduleBuilder) ExportGlobalF32(name string, v float32) ModuleBuilder {
-	b.nameToGlobal[name] = &wasm.Global{
+	b.setGlobal(name, &wasm.Global{
 		Type: &wasm.GlobalType{ValType: wasm.ValueTypeF32},
 		Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF32Const, Data: u64.LeBytes(api.EncodeF32(v))},
+	})
+	return b
+}
+
+// ExportGlobalsF32 implements ModuleBuilder.ExportGlobalsF32
+func (b *moduleBuilder) ExportGlobalsF32(nameToValue map[string]float32) ModuleBuilder {
+	for k, v := range nameToValue {
+		b.ExportGlobalF32(k, v)
 	}
 	return b
 }
 
 // ExportGlobalF64 implements ModuleBuilder.ExportGlobalF64
 func (b *moduleBuilder) ExportGlobalF64(name string, v float64) ModuleBuilder {
-	b.nameToGlobal[name] = &wasm.Global{
+	b.setGlobal(name, &wasm.Global{
 		Type: &wasm.GlobalType{ValType: wasm.ValueTypeF64},
 		Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF64Const, Data: u64.LeBytes(api.EncodeF64(v))},
+	})
+	return b
+}
+
+// ExportGlobalsF64 implements ModuleBuilder.ExportGlobalsF64
+func (b *moduleBuilder) ExportGlobalsF64(nameToValue map[string]float64) ModuleBuilder {
+	for k, v := range nameToValue {
+		b.ExportGlobalF64(k, v)
 	}
 	return b
 }
 
+// setMemory sets the memory exported under the name, recording if it overwrites another.
+func (b *moduleBuilder) setMemory(name string, mem *wasm.Memory) {
+	if _, ok := b.nameToMemory[name]; ok {
+		b.duplicateExports = append(b.duplicateExports, fmt.Sprintf("memory[%s]", name))
+	}
+	b.nameToMemory[name] = mem
+}
+
+// setGlobal sets the global exported under the name, recording if it overwrites another.
+func (b *moduleBuilder) setGlobal(name string, g *wasm.Global) {
+	if _, ok := b.nameToGlobal[name]; ok {
+		b.duplicateExports = append(b.duplicateExports, fmt.Sprintf("global[%s]", name))
+	}
+	b.nameToGlobal[name] = g
+}
+
+// WithStrictExports implements ModuleBuilder.WithStrictExports
+func (b *moduleBuilder) WithStrictExports(strict bool) ModuleBuilder {
+	b.strictExports = strict
+	return b
+}
+
 // Build implements ModuleBuilder.Build
 func (b *moduleBuilder) Build(ctx context.Context) (CompiledCode, error) {
+	if b.strictExports && len(b.duplicateExports) > 0 {
+		return nil, fmt.Errorf("%s is exported more than once", b.duplicateExports[0])
+	}
+
 	// Verify the maximum limit here, so we don't have to pass it to wasm.NewHostModule
 	memoryLimitPages := b.r.memoryLimitPages
 	for name, mem := range b.nameToMemory {
@@ -271,7 +384,11 @@ func (b *moduleBuilder) Build(ctx context.Context) (CompiledCode, error) {
 		return nil, err
 	}
 
-	return &compiledCode{module: module, compiledEngine: b.r.store.Engine}, nil
+	return &compiledCode{
+		module:            module,
+		compiledEngine:    b.r.store.Engine,
+		usesFloatingPoint: module.UsesFloatingPoint(),
+	}, nil
 }
 
 // Instantiate implements ModuleBuilder.Instantiate
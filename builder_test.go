@@ -1,6 +1,7 @@
 package wazero
 
 import (
+	"fmt"
 	"math"
 	"reflect"
 	"testing"
@@ -10,6 +11,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/u64"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
 )
 
 // TestNewModuleBuilder_Build only covers a few scenarios to avoid duplicating tests in internal/wasm/host_test.go
@@ -235,6 +237,62 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportGlobalsI32",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportGlobalsI32(map[string]int32{"canvas_width": 1024, "canvas_height": 768})
+			},
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(768)},
+					},
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(1024)},
+					},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "canvas_height", Type: wasm.ExternTypeGlobal, Index: 0},
+					{Name: "canvas_width", Type: wasm.ExternTypeGlobal, Index: 1},
+				},
+			},
+		},
+		{
+			name: "ExportGlobalsI32 overwrites",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportGlobalI32("canvas_width", 1024).ExportGlobalsI32(map[string]int32{"canvas_width": math.MaxInt32})
+			},
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(math.MaxInt32)},
+					},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "canvas_width", Type: wasm.ExternTypeGlobal, Index: 0},
+				},
+			},
+		},
+		{
+			name: "ExportGlobalsI32 overwritten",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportGlobalsI32(map[string]int32{"canvas_width": math.MaxInt32}).ExportGlobalI32("canvas_width", 1024)
+			},
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(1024)},
+					},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "canvas_width", Type: wasm.ExternTypeGlobal, Index: 0},
+				},
+			},
+		},
 		{
 			name: "ExportGlobalI64",
 			input: func(r Runtime) ModuleBuilder {
@@ -269,6 +327,23 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportGlobalsI64",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportGlobalsI64(map[string]int64{"start_epoch": 1620216263544})
+			},
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeI64},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: leb128.EncodeInt64(1620216263544)},
+					},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "start_epoch", Type: wasm.ExternTypeGlobal, Index: 0},
+				},
+			},
+		},
 		{
 			name: "ExportGlobalF32",
 			input: func(r Runtime) ModuleBuilder {
@@ -303,6 +378,23 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportGlobalsF32",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportGlobalsF32(map[string]float32{"math/pi": 3.1415926536})
+			},
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeF32},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF32Const, Data: u64.LeBytes(api.EncodeF32(3.1415926536))},
+					},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "math/pi", Type: wasm.ExternTypeGlobal, Index: 0},
+				},
+			},
+		},
 		{
 			name: "ExportGlobalF64",
 			input: func(r Runtime) ModuleBuilder {
@@ -337,6 +429,23 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ExportGlobalsF64",
+			input: func(r Runtime) ModuleBuilder {
+				return r.NewModuleBuilder("").ExportGlobalsF64(map[string]float64{"math/pi": math.Pi})
+			},
+			expected: &wasm.Module{
+				GlobalSection: []*wasm.Global{
+					{
+						Type: &wasm.GlobalType{ValType: wasm.ValueTypeF64},
+						Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeF64Const, Data: u64.LeBytes(api.EncodeF64(math.Pi))},
+					},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "math/pi", Type: wasm.ExternTypeGlobal, Index: 0},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -359,6 +468,43 @@ func TestNewModuleBuilder_Build(t *testing.T) {
 	}
 }
 
+// TestNewModuleBuilder_ExportGlobalsI32 ensures a block of globals can be read by a guest which imports them.
+func TestNewModuleBuilder_ExportGlobalsI32(t *testing.T) {
+	r := NewRuntime()
+	nameToValue := map[string]int32{"a": 1, "b": -2, "c": math.MaxInt32}
+
+	env, err := r.NewModuleBuilder("env").ExportGlobalsI32(nameToValue).Instantiate(testCtx)
+	require.NoError(t, err)
+	defer env.Close(testCtx)
+
+	// Define a guest that imports each global and exports a function to read it.
+	names := []string{"a", "b", "c"}
+	guest := &wasm.Module{TypeSection: []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeI32}}}}
+	for i, name := range names {
+		guest.ImportSection = append(guest.ImportSection, &wasm.Import{
+			Type: wasm.ExternTypeGlobal, Module: "env", Name: name,
+			DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+		})
+		guest.FunctionSection = append(guest.FunctionSection, 0)
+		guest.CodeSection = append(guest.CodeSection, &wasm.Code{
+			Body: []byte{wasm.OpcodeGlobalGet, byte(i), wasm.OpcodeEnd},
+		})
+		guest.ExportSection = append(guest.ExportSection, &wasm.Export{
+			Type: wasm.ExternTypeFunc, Name: "get_" + name, Index: wasm.Index(i),
+		})
+	}
+
+	mod, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(guest))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	for _, name := range names {
+		results, err := mod.ExportedFunction("get_" + name).Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, nameToValue[name], int32(results[0]))
+	}
+}
+
 // TestNewModuleBuilder_Build_Errors only covers a few scenarios to avoid duplicating tests in internal/wasm/host_test.go
 func TestNewModuleBuilder_Build_Errors(t *testing.T) {
 	tests := []struct {
@@ -384,6 +530,43 @@ func TestNewModuleBuilder_Build_Errors(t *testing.T) {
 			},
 			expectedErr: "memory[memory] capacity 1 pages (64 Ki) less than minimum 2 pages (128 Ki)",
 		},
+		{
+			name: "multiple memories", // only one test to avoid duplicating tests in host_test.go
+			input: func(cfg RuntimeConfig) ModuleBuilder {
+				return NewRuntimeWithConfig(cfg).NewModuleBuilder("").
+					ExportMemory("a", 1).
+					ExportMemory("b", 1)
+			},
+			expectedErr: "only one memory is allowed, but configured: a, b",
+		},
+		{
+			name: "strict exports duplicate func",
+			input: func(cfg RuntimeConfig) ModuleBuilder {
+				return NewRuntimeWithConfig(cfg).NewModuleBuilder("").WithStrictExports(true).
+					ExportFunction("fn", func() uint32 { return 1 }).
+					ExportFunction("fn", func() uint32 { return 2 })
+			},
+			expectedErr: "func[fn] is exported more than once",
+		},
+		{
+			name: "strict exports set after duplicate global",
+			input: func(cfg RuntimeConfig) ModuleBuilder {
+				return NewRuntimeWithConfig(cfg).NewModuleBuilder("").
+					ExportGlobalI32("g", 1).
+					ExportGlobalI64("g", 2).
+					WithStrictExports(true)
+			},
+			expectedErr: "global[g] is exported more than once",
+		},
+		{
+			name: "strict exports duplicate memory",
+			input: func(cfg RuntimeConfig) ModuleBuilder {
+				return NewRuntimeWithConfig(cfg).NewModuleBuilder("").WithStrictExports(true).
+					ExportMemory("memory", 1).
+					ExportMemoryWithMax("memory", 1, 1)
+			},
+			expectedErr: "memory[memory] is exported more than once",
+		},
 	}
 
 	for _, tt := range tests {
@@ -396,6 +579,30 @@ func TestNewModuleBuilder_Build_Errors(t *testing.T) {
 	}
 }
 
+func TestNewModuleBuilder_ExportFunction_Duplicate(t *testing.T) {
+	for _, strict := range []bool{false, true} {
+		strict := strict
+
+		t.Run(fmt.Sprintf("strict=%v", strict), func(t *testing.T) {
+			b := NewRuntime().NewModuleBuilder("env").WithStrictExports(strict).
+				ExportFunction("fn", func() uint32 { return 1 }).
+				ExportFunction("fn", func() uint32 { return 2 })
+			if strict {
+				_, err := b.Instantiate(testCtx)
+				require.EqualError(t, err, "func[fn] is exported more than once")
+				return
+			}
+
+			// Last wins by default.
+			mod, err := b.Instantiate(testCtx)
+			require.NoError(t, err)
+			results, err := mod.ExportedFunction("fn").Call(testCtx)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{2}, results)
+		})
+	}
+}
+
 // TestNewModuleBuilder_Instantiate ensures Runtime.InstantiateModule is called on success.
 func TestNewModuleBuilder_Instantiate(t *testing.T) {
 	r := NewRuntime()
@@ -416,6 +623,56 @@ func TestNewModuleBuilder_Instantiate_Errors(t *testing.T) {
 	require.EqualError(t, err, "module env has already been instantiated")
 }
 
+// TestNewModuleBuilder_Reactor ensures a built module stays resident, so its exports can be called directly.
+func TestNewModuleBuilder_Reactor(t *testing.T) {
+	r := NewRuntime()
+
+	var calls uint32
+	compiled, err := r.NewModuleBuilder("math").
+		ExportFunction("add", func(x, y uint32) uint32 {
+			calls++
+			return x + y
+		}).Build(testCtx)
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	m, err := r.InstantiateModuleWithConfig(testCtx, compiled, NewModuleConfig())
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+
+	// Nothing is called on instantiation, as there is no start function.
+	require.Zero(t, calls)
+
+	add := m.ExportedFunction("add")
+	for i := uint64(1); i <= 3; i++ {
+		results, err := add.Call(testCtx, i, i)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{i * 2}, results)
+	}
+	require.Equal(t, uint32(3), calls)
+}
+
+// TestNewModuleBuilder_Start ensures a function exported as "_start" is called like any module's, unless skipped.
+func TestNewModuleBuilder_Start(t *testing.T) {
+	r := NewRuntime()
+
+	var starts uint32
+	compiled, err := r.NewModuleBuilder("math").
+		ExportFunction("_start", func() { starts++ }).Build(testCtx)
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	m, err := r.InstantiateModuleWithConfig(testCtx, compiled, NewModuleConfig().WithName("started"))
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+	require.Equal(t, uint32(1), starts)
+
+	m, err = r.InstantiateModuleWithConfig(testCtx, compiled, NewModuleConfig().WithName("skipped").WithStartFunctions())
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+	require.Equal(t, uint32(1), starts)
+}
+
 // requireHostModuleEquals is redefined from internal/wasm/host_test.go to avoid an import cycle extracting it.
 func requireHostModuleEquals(t *testing.T, expected, actual *wasm.Module) {
 	// `require.Equal(t, expected, actual)` fails reflect pointers don't match, so brute compare:
@@ -0,0 +1,118 @@
+package wazero
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// cacheMagic and cacheVersion begin data returned by SerializeCompiledCode.
+var (
+	cacheMagic   = []byte("wazc")
+	cacheVersion = byte(2)
+)
+
+// SerializeCompiledCode returns the code compiled for the module, so that CompileModuleFromCache can restore it in
+// another Runtime, possibly in another process, without compiling the module again.
+//
+// Ex. To save the compiled code of a module to a file:
+//	compiled, err := r.CompileModule(ctx, source)
+//	--snip--
+//	cache, err := wazero.SerializeCompiledCode(compiled)
+//	--snip--
+//	err = os.WriteFile("module.cache", cache, 0o600)
+//
+// This errs unless the code was compiled by a Runtime configured with NewRuntimeConfigInterpreter. The interpreter
+// compiles to bytecode independent of the CPU architecture, so the result can be restored on any host. JIT compiled
+// code is not serializable, as it is native to the host it was compiled on.
+//
+// Note: Modules built with NewModuleBuilder are not serializable, as their functions are defined in Go.
+func SerializeCompiledCode(compiled CompiledCode) ([]byte, error) {
+	c, ok := compiled.(*compiledCode)
+	if !ok {
+		return nil, fmt.Errorf("unsupported CompiledCode implementation: %#v", compiled)
+	}
+	cache, ok := c.compiledEngine.(wasm.CodeCache)
+	if !ok {
+		return nil, errors.New("compiled code is not serializable: use NewRuntimeConfigInterpreter")
+	}
+	data, err := cache.SerializeCode(c.module)
+	if err != nil {
+		return nil, fmt.Errorf("compiled code is not serializable: %w", err)
+	}
+
+	format := cache.CodeFormat()
+	ret := append([]byte{}, cacheMagic...)
+	ret = append(ret, cacheVersion, byte(len(format)))
+	ret = append(ret, format...)
+	ret = append(ret, c.module.ID[:]...)
+	return append(ret, data...), nil
+}
+
+// CompileModuleFromCache is like Runtime.CompileModule, except it restores the compiled code of the source from the
+// result of SerializeCompiledCode, instead of compiling it.
+//
+// This errs if the cache wasn't serialized from the same source, or by an engine other than the one of this Runtime.
+// Ex. A cache serialized by a Runtime configured with NewRuntimeConfigInterpreter can't be used by one configured
+// with NewRuntimeConfigJIT.
+//
+// Note: The source is decoded and validated as usual, but the cache is trusted to be the result of
+// SerializeCompiledCode. Don't restore caches from untrusted sources.
+func CompileModuleFromCache(ctx context.Context, r Runtime, source, cache []byte) (CompiledCode, error) {
+	rt, ok := r.(*runtime)
+	if !ok {
+		return nil, fmt.Errorf("unsupported Runtime implementation: %#v", r)
+	}
+
+	format, id, data, err := decodeCacheHeader(cache)
+	if err != nil {
+		return nil, err
+	}
+	engine, ok := rt.store.Engine.(wasm.CodeCache)
+	if !ok || engine.CodeFormat() != format {
+		return nil, fmt.Errorf("cache was compiled by the %s engine, which this runtime doesn't use", format)
+	}
+
+	internal, _, err := rt.decodeModule(source, false)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(internal.ID[:], id) {
+		return nil, errors.New("cache was compiled from a different source")
+	}
+
+	if err = engine.DeserializeCode(internal, data); err != nil {
+		return nil, err
+	}
+	return &compiledCode{
+		module:            internal,
+		compiledEngine:    rt.store.Engine,
+		usesFloatingPoint: internal.UsesFloatingPoint(),
+	}, nil
+}
+
+// decodeCacheHeader returns the fields written by SerializeCompiledCode before the engine data.
+func decodeCacheHeader(cache []byte) (format string, id, data []byte, err error) {
+	errInvalid := errors.New("invalid cache")
+	if !bytes.HasPrefix(cache, cacheMagic) {
+		return "", nil, nil, errInvalid
+	}
+	cache = cache[len(cacheMagic):]
+	if len(cache) > 0 && cache[0] != cacheVersion {
+		return "", nil, nil, fmt.Errorf("unsupported cache version: %d", cache[0])
+	}
+	if len(cache) < 2 {
+		return "", nil, nil, errInvalid
+	}
+	formatLen := int(cache[1])
+	cache = cache[2:]
+	if len(cache) < formatLen+len(wasm.ModuleID{}) {
+		return "", nil, nil, errInvalid
+	}
+	format, cache = string(cache[:formatLen]), cache[formatLen:]
+	id, data = cache[:len(wasm.ModuleID{})], cache[len(wasm.ModuleID{}):]
+	return
+}
@@ -0,0 +1,220 @@
+package wazero
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// facWasm exports "fac", which recursively calculates the factorial of its i64 param.
+var facWasm = binary.EncodeModule(&wasm.Module{
+	TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI64}, Results: []wasm.ValueType{wasm.ValueTypeI64}}},
+	FunctionSection: []wasm.Index{0},
+	CodeSection: []*wasm.Code{{Body: []byte{
+		wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Eqz,
+		wasm.OpcodeIf, wasm.ValueTypeI64,
+		wasm.OpcodeI64Const, 1,
+		wasm.OpcodeElse,
+		wasm.OpcodeLocalGet, 0,
+		wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Const, 1, wasm.OpcodeI64Sub,
+		wasm.OpcodeCall, 0,
+		wasm.OpcodeI64Mul,
+		wasm.OpcodeEnd,
+		wasm.OpcodeEnd,
+	}}},
+	ExportSection: []*wasm.Export{{Name: "fac", Type: wasm.ExternTypeFunc, Index: 0}},
+})
+
+func TestCompileModuleFromCache(t *testing.T) {
+	compiled, err := NewRuntimeWithConfig(NewRuntimeConfigInterpreter()).CompileModule(testCtx, facWasm)
+	require.NoError(t, err)
+
+	cache, err := SerializeCompiledCode(compiled)
+	require.NoError(t, err)
+
+	// Restore into a new runtime, as if on another host, which never compiled the source.
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter())
+	restored, err := CompileModuleFromCache(testCtx, r, facWasm, cache)
+	require.NoError(t, err)
+	require.Equal(t, compiled.ID(), restored.ID())
+
+	mod, err := r.InstantiateModule(testCtx, restored)
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	results, err := mod.ExportedFunction("fac").Call(testCtx, 5)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{120}, results)
+}
+
+// TestCompileModuleFromCache_UsesFloatingPoint ensures fields computed during compilation are also set on restore.
+func TestCompileModuleFromCache_UsesFloatingPoint(t *testing.T) {
+	floatWasm := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Results: []wasm.ValueType{wasm.ValueTypeF32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeF32Const, 0, 0, 0, 0, wasm.OpcodeEnd}}},
+	})
+
+	for _, source := range [][]byte{facWasm, floatWasm} {
+		compiled, err := NewRuntimeWithConfig(NewRuntimeConfigInterpreter()).CompileModule(testCtx, source)
+		require.NoError(t, err)
+		cache, err := SerializeCompiledCode(compiled)
+		require.NoError(t, err)
+
+		restored, err := CompileModuleFromCache(testCtx, NewRuntimeWithConfig(NewRuntimeConfigInterpreter()), source, cache)
+		require.NoError(t, err)
+		require.Equal(t, compiled.UsesFloatingPoint(), restored.UsesFloatingPoint())
+	}
+}
+
+func TestCompileModuleFromCache_Errors(t *testing.T) {
+	compiled, err := NewRuntimeWithConfig(NewRuntimeConfigInterpreter()).CompileModule(testCtx, facWasm)
+	require.NoError(t, err)
+	cache, err := SerializeCompiledCode(compiled)
+	require.NoError(t, err)
+
+	otherWasm := binary.EncodeModule(&wasm.Module{})
+
+	tests := []struct {
+		name          string
+		config        RuntimeConfig
+		source, cache []byte
+		expectedErr   string
+	}{
+		{
+			name:        "invalid cache",
+			config:      NewRuntimeConfigInterpreter(),
+			source:      facWasm,
+			cache:       facWasm,
+			expectedErr: "invalid cache",
+		},
+		{
+			name:        "unsupported version",
+			config:      NewRuntimeConfigInterpreter(),
+			source:      facWasm,
+			cache:       append(append([]byte{}, cacheMagic...), 0),
+			expectedErr: "unsupported cache version: 0",
+		},
+		{
+			name:        "truncated",
+			config:      NewRuntimeConfigInterpreter(),
+			source:      facWasm,
+			cache:       cache[:len(cache)-1],
+			expectedErr: "invalid code: unexpected end of data",
+		},
+		{
+			name:        "different source",
+			config:      NewRuntimeConfigInterpreter(),
+			source:      otherWasm,
+			cache:       cache,
+			expectedErr: "cache was compiled from a different source",
+		},
+	}
+	if JITSupported {
+		tests = append(tests, struct {
+			name          string
+			config        RuntimeConfig
+			source, cache []byte
+			expectedErr   string
+		}{
+			name:        "jit",
+			config:      NewRuntimeConfigJIT(),
+			source:      facWasm,
+			cache:       cache,
+			expectedErr: "cache was compiled by the interpreter engine, which this runtime doesn't use",
+		})
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := CompileModuleFromCache(testCtx, NewRuntimeWithConfig(tc.config), tc.source, tc.cache)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// TestCompileModuleFromCache_CodeFormat ensures code isn't restored into a runtime which would compile it differently.
+func TestCompileModuleFromCache_CodeFormat(t *testing.T) {
+	strict := NewRuntimeConfigInterpreter()
+	lenient := strict.WithLenientFloatToInt(true)
+
+	tests := []struct {
+		name        string
+		from, to    RuntimeConfig
+		expectedErr string
+	}{
+		{
+			name:        "lenient to strict",
+			from:        lenient,
+			to:          strict,
+			expectedErr: "cache was compiled by the interpreter+lenient-f2i engine, which this runtime doesn't use",
+		},
+		{
+			name:        "strict to lenient",
+			from:        strict,
+			to:          lenient,
+			expectedErr: "cache was compiled by the interpreter engine, which this runtime doesn't use",
+		},
+		{
+			name: "lenient to lenient",
+			from: lenient,
+			to:   lenient,
+		},
+		{
+			name:        "labels to no labels",
+			from:        strict.WithLabelTracing(true),
+			to:          strict,
+			expectedErr: "cache was compiled by the interpreter+labels engine, which this runtime doesn't use",
+		},
+		{
+			name:        "no labels to labels",
+			from:        strict,
+			to:          strict.WithLabelTracing(true),
+			expectedErr: "cache was compiled by the interpreter engine, which this runtime doesn't use",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := NewRuntimeWithConfig(tc.from).CompileModule(testCtx, facWasm)
+			require.NoError(t, err)
+			cache, err := SerializeCompiledCode(compiled)
+			require.NoError(t, err)
+
+			_, err = CompileModuleFromCache(testCtx, NewRuntimeWithConfig(tc.to), facWasm, cache)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestSerializeCompiledCode_Errors(t *testing.T) {
+	t.Run("host module", func(t *testing.T) {
+		r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter())
+		compiled, err := r.NewModuleBuilder("env").ExportFunction("nop", func() {}).Build(testCtx)
+		require.NoError(t, err)
+
+		_, err = SerializeCompiledCode(compiled)
+		require.EqualError(t, err, "compiled code is not serializable: host functions are not serializable")
+	})
+
+	t.Run("jit", func(t *testing.T) {
+		if !JITSupported {
+			t.Skip("JIT is not supported on this platform")
+		}
+		compiled, err := NewRuntimeWithConfig(NewRuntimeConfigJIT()).CompileModule(testCtx, facWasm)
+		require.NoError(t, err)
+
+		_, err = SerializeCompiledCode(compiled)
+		require.EqualError(t, err, "compiled code is not serializable: use NewRuntimeConfigInterpreter")
+	})
+}
@@ -1,17 +1,26 @@
 package wazero
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"math"
+	"os"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasm/interpreter"
 	"github.com/tetratelabs/wazero/internal/wasm/jit"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
 )
 
 // RuntimeConfig controls runtime behavior, with the default implementation as NewRuntimeConfig
@@ -21,6 +30,65 @@ import (
 //
 // Note: RuntimeConfig is immutable. Each WithXXX function returns a new instance including the corresponding change.
 type RuntimeConfig interface {
+	// EngineKind returns the kind of engine modules are compiled and run with: EngineKindJIT or EngineKindInterpreter.
+	//
+	// Ex. To log which engine NewRuntimeConfig selected for this platform:
+	//	log.Printf("wazero engine: %s", wazero.NewRuntimeConfig().EngineKind())
+	EngineKind() string
+
+	// WithCallHook sets functions called before and after each api.Function Call made by the embedder, ex. to wrap
+	// each with tracing or metrics. Either function can be nil. This defaults to no hooks.
+	//
+	// * before - called before the function, returning the context to call it with. Ex. a context with a new span.
+	// * after - called after the function with the context returned by before and the error of the call, if any.
+	//
+	// Ex. To time each call:
+	//	rConfig = wazero.NewRuntimeConfig().WithCallHook(
+	//		func(ctx context.Context, mod api.Module, fn api.FunctionDefinition) context.Context {
+	//			return context.WithValue(ctx, startKey{}, time.Now())
+	//		},
+	//		func(ctx context.Context, mod api.Module, fn api.FunctionDefinition, err error) {
+	//			log.Printf("%s.%s took %s", mod.Name(), fn.Name(), time.Since(ctx.Value(startKey{}).(time.Time)))
+	//		})
+	//
+	// Note: To keep overhead low, only top-level calls are hooked: neither calls between guest functions, nor any
+	// api.Function Call made during another, such as by a host function, are.
+	WithCallHook(
+		before func(ctx context.Context, mod api.Module, fn api.FunctionDefinition) context.Context,
+		after func(ctx context.Context, mod api.Module, fn api.FunctionDefinition, err error),
+	) RuntimeConfig
+
+	// WithDefaultStartFunctions configures the functions to call after a module is instantiated, unless its
+	// ModuleConfig.WithStartFunctions is set. Defaults to "_start".
+	//
+	// Ex. To initialize reactors without configuring each instantiation:
+	//	rConfig = wazero.NewRuntimeConfig().WithDefaultStartFunctions("_initialize")
+	//	--snip--
+	//	mod, err := r.InstantiateModule(ctx, compiled) // calls "_initialize"
+	//
+	// Note: No functions are called when this is set without any, except "_initialize" of a WASI reactor whose
+	// ModuleConfig.WithStartFunctions isn't set.
+	// See ModuleConfig.WithStartFunctions
+	WithDefaultStartFunctions(...string) RuntimeConfig
+
+	// WithDisallowMemoryImport rejects any module that imports a memory during Runtime.CompileModule. This defaults to
+	// false, as importing a memory is part of WebAssembly 1.0 (20191205).
+	//
+	// This is useful to enforce a policy where modules define their own memory, so that memory of the host or another
+	// module is never shared unexpectedly. Other imports, such as functions, globals and tables, are still allowed.
+	// The error returned names the memory import, so the offending module can be identified.
+	//
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#import-section%E2%91%A0
+	WithDisallowMemoryImport(bool) RuntimeConfig
+
+	// WithDisallowStartSection rejects any module that declares a start section during Runtime.CompileModule. This
+	// defaults to false, as the start section is part of WebAssembly 1.0 (20191205).
+	//
+	// This is useful to enforce a policy where modules are pure libraries, without implicit initialization.
+	// The error returned names the start function index, so the offending function can be identified.
+	//
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#start-section%E2%91%A0
+	WithDisallowStartSection(bool) RuntimeConfig
 
 	// WithFeatureBulkMemoryOperations adds instructions modify ranges of memory or table entries
 	// ("bulk-memory-operations"). This defaults to false as the feature was not finished in WebAssembly 1.0.
@@ -105,6 +173,106 @@ type RuntimeConfig interface {
 	// See https://github.com/WebAssembly/spec/blob/main/proposals/sign-extension-ops/Overview.md
 	WithFeatureSignExtensionOps(bool) RuntimeConfig
 
+	// WithFeatureThreads enables shared memory ("threads"). This defaults to false as the feature was not in
+	// WebAssembly 1.0.
+	//
+	// Here are the notable effects:
+	// * Memories can be declared shared, which requires a max. A shared memory is allocated at its max, so its buffer
+	//   never moves, and api.Memory functions can be called concurrently with growing the memory.
+	//
+	// Note: Shared memory is not goroutine-safe, as atomic instructions are not yet supported, and guest code accesses
+	// the memory without locking. Functions using a shared memory must not be called concurrently.
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	WithFeatureThreads(bool) RuntimeConfig
+
+	// WithFeaturesFromEnv enables features named in the environment variable "WAZERO_FEATURES", in addition to those
+	// already enabled. This allows operators of CLI tools to enable features without code changes. Names are those of
+	// the corresponding WithFeatureXXX, separated by commas or '|'. When the variable is unset or empty, this returns
+	// the same configuration.
+	//
+	// Ex. To enable the features "multi-value" and "sign-extension-ops":
+	//	// WAZERO_FEATURES=multi-value,sign-extension-ops
+	//	rConfig, err := wazero.NewRuntimeConfig().WithFeaturesFromEnv()
+	//	if err != nil {
+	//		log.Fatal(err)
+	//	}
+	//
+	// Note: This errs on any unknown feature name, as ignoring it could hide a misconfiguration.
+	WithFeaturesFromEnv() (RuntimeConfig, error)
+
+	// WithLabelTracing compiles functions with their control flow labels, so that an experimental.LabelListener in the
+	// context.Context of a call is notified as the function reaches them. This defaults to false, as labels are
+	// otherwise no-ops which slow down execution.
+	//
+	// Ex. To trace the labels reached by a call:
+	//	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithLabelTracing(true))
+	//	--snip--
+	//	results, err := fn.Call(context.WithValue(ctx, experimental.LabelListenerKey{}, tracer))
+	//
+	// Note: This is interpreter-only for now! Other engines never notify an experimental.LabelListener.
+	WithLabelTracing(bool) RuntimeConfig
+
+	// WithLenientFloatToInt makes the trapping float-to-int conversion instructions, such as `i32.trunc_f32_s`,
+	// saturate instead of trapping, as if the module used their non-trapping variants, such as `i32.trunc_sat_f32_s`.
+	// This defaults to false.
+	//
+	// When enabled, NaN converts to zero, and out-of-range values clamp to the minimum or maximum of the integer type,
+	// instead of the call failing with "invalid conversion to integer" or "integer overflow".
+	//
+	// Notes:
+	// * This is NOT conformant to the WebAssembly specification. Only enable it for leniency when running modules
+	//   which otherwise trap, and never when the trap is significant, ex. in spec tests.
+	// * This applies regardless of WithFeatureNonTrappingFloatToIntConversion, which only allows the
+	//   non-trapping instructions.
+	//
+	// See https://github.com/WebAssembly/spec/blob/main/proposals/nontrapping-float-to-int-conversion/Overview.md
+	WithLenientFloatToInt(bool) RuntimeConfig
+
+	// WithMaxBlockNesting limits how deeply a function body can nest `block`, `loop` and `if` instructions. This
+	// defaults to zero, which means no limit.
+	//
+	// Ex. To reject functions nesting blocks deeper than 1000:
+	//	rConfig = wazero.NewRuntimeConfig().WithMaxBlockNesting(1000)
+	//
+	// This is useful when compiling untrusted modules, as a body of millions of nested blocks otherwise exhausts memory
+	// during compilation. Runtime.CompileModule errs when a function exceeds the limit, before validating it.
+	WithMaxBlockNesting(int) RuntimeConfig
+
+	// WithMaxFunctionTypes lowers the maximum count of distinct function types in the runtime from 134217728 (2^27) to
+	// the input. This bounds the memory used to assign each type an ID, which indirect function calls check.
+	//
+	// Notes:
+	// * Function types are shared by all modules in the runtime, including host modules. A module instantiated when
+	//   the count of types would exceed this fails (Runtime.InstantiateModule) with "too many function types".
+	// * Identical types, such as `(func (param i32))` in two modules, only count once.
+	// * This can't raise the limit above 2^27, so larger values are lowered to it.
+	WithMaxFunctionTypes(uint32) RuntimeConfig
+
+	// WithMaxModuleSize limits the size in bytes of the source Runtime.CompileModule accepts. This defaults to zero,
+	// which means no limit.
+	//
+	// Ex. To reject modules larger than 10MiB:
+	//	rConfig = wazero.NewRuntimeConfig().WithMaxModuleSize(10 << 20)
+	//
+	// This is useful when compiling untrusted modules, to bound the work done decoding them. Regardless of this setting,
+	// the decoder rejects a section, or a vector count which sizes an allocation, exceeding the remaining input before
+	// allocating for it. Locals can't be bounded by the input, as a few bytes declare many, so a function with more
+	// than 50000 locals is rejected.
+	WithMaxModuleSize(bytes int) RuntimeConfig
+
+	// WithMaxResults limits how many results a function can return. This defaults to zero, which means no limit.
+	//
+	// Ex. To reject modules with a function returning more than 16 values:
+	//	rConfig = wazero.NewRuntimeConfig().WithFeatureMultiValue(true).WithMaxResults(16)
+	//
+	// This is useful when compiling untrusted modules, as a call allocates a buffer for the results of the function.
+	// Runtime.CompileModule errs when any function, including an imported one, exceeds the limit.
+	//
+	// Notes:
+	// * Without WithFeatureMultiValue, functions return at most one result, so the limit only matters with multi-value.
+	// * Functions defined in Go with NewModuleBuilder aren't checked, as their results are known by the host.
+	WithMaxResults(int) RuntimeConfig
+
 	// WithMemoryCapacityPages is a function that determines memory capacity in pages (65536 bytes per page). The input
 	// are the min and possibly nil max defined by the module, and the default is to return the min.
 	//
@@ -118,8 +286,24 @@ type RuntimeConfig interface {
 	//
 	// This function is used at compile time (ModuleBuilder.Build or Runtime.CompileModule). Compile will err if the
 	// function returns a value lower than minPages or greater than WithMemoryLimitPages.
+	//
+	// It is also used when `memory.grow` exceeds the capacity, which reallocates the memory. In this case, minPages is
+	// the new size, and maxPages is the same as at compile time. As the grow already succeeded, results lower than the
+	// new size are clamped up to it, and results greater than the max are clamped down to it.
 	WithMemoryCapacityPages(func(minPages uint32, maxPages *uint32) uint32) RuntimeConfig
 
+	// WithMemoryGrowthListener sets a function called after each `memory.grow` instruction, or nil to disable. This
+	// defaults to nil. This is observe-only, ex. to record growth history for capacity planning.
+	//
+	// The function receives the index of the memory, the size in pages before growth and the requested delta in pages.
+	// ok is false when the growth failed, ex. exceeding the maximum, in which case the size is unchanged.
+	//
+	// Notes:
+	// * The function is called synchronously by the guest, so it should return quickly.
+	// * The memory index is always zero, as a module has at most one memory.
+	// * Tables aren't observed, as `table.grow` is not yet supported by any engine.
+	WithMemoryGrowthListener(func(memoryIndex, before, delta uint32, ok bool)) RuntimeConfig
+
 	// WithMemoryLimitPages limits the maximum number of pages a module can define from 65536 pages (4GiB) to the input.
 	//
 	// Notes:
@@ -132,6 +316,99 @@ type RuntimeConfig interface {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-types%E2%91%A0
 	WithMemoryLimitPages(uint32) RuntimeConfig
 
+	// WithMemoryWriteLog sets a function called after each write to memory by a guest instruction, such as
+	// `i32.store`, or nil to disable. This defaults to nil. This is useful for record/replay debugging, as logged
+	// writes can be replayed or compared between executions.
+	//
+	// The function receives the offset of the write and the bytes written. Bulk operations (`memory.fill`,
+	// `memory.copy` and `memory.init`) are logged as a single range, instead of one call per byte. Writes that trap
+	// and zero-length bulk operations are not logged.
+	//
+	// Notes:
+	// * This is heavyweight: every store instruction calls the function, which can slow memory-intensive code
+	//   significantly. Only enable it while debugging.
+	// * The data is a view of the memory, so it is only valid during the call. Copy it to retain the bytes.
+	// * Writes by the host, such as api.Memory Write, are not logged.
+	// * This is only supported by NewRuntimeConfigInterpreter. Other engines ignore this setting.
+	WithMemoryWriteLog(func(offset uint32, data []byte)) RuntimeConfig
+
+	// WithNullFuncrefHandler sets a function called when `call_indirect` references a null (uninitialized) table
+	// element, instead of trapping with "invalid table access", or nil to disable. This defaults to nil.
+	//
+	// The function receives the index of the table and the element. When it returns nil, the call completes as a
+	// no-op, with zero values of the results of the expected function type. Otherwise, the call traps with the error.
+	//
+	// Ex. To ignore calls to null elements of table 0:
+	//	c = c.WithNullFuncrefHandler(func(tableIndex, offset uint32) error {
+	//		if tableIndex == 0 {
+	//			return nil
+	//		}
+	//		return fmt.Errorf("null element %d of table %d", offset, tableIndex)
+	//	})
+	//
+	// Notes:
+	// * This is NOT conformant to the WebAssembly specification, so only enable it for leniency.
+	// * Elements out of the bounds of the table still trap, as they are not null.
+	// * This is only supported by NewRuntimeConfigInterpreter. Other engines ignore this setting.
+	WithNullFuncrefHandler(func(tableIndex, offset uint32) error) RuntimeConfig
+
+	// WithOptimizeBoundsChecks omits the bounds check of a memory load or store when its address is proven within the
+	// minimum size of the memory. This defaults to false.
+	//
+	// An access is proven when its address is a constant, ex. `(i32.load offset=8 (i32.const 16))`, and its end is not
+	// beyond the minimum pages of the memory. Memory never shrinks, and an imported memory must have at least the
+	// minimum pages the module declares, so such an access can never be out of bounds. Other accesses are still checked,
+	// and trap as usual when out of bounds.
+	//
+	// Note: This is only supported by NewRuntimeConfigJIT. Other engines ignore this setting.
+	WithOptimizeBoundsChecks(bool) RuntimeConfig
+
+	// WithPerModuleTypeIDs assigns the function types of each module IDs which are only unique within that module,
+	// instead of the whole runtime. This defaults to false, which shares IDs between modules.
+	//
+	// `call_indirect` checks the type of the function it calls by its ID. When false, identical types, such as
+	// `(func (param i32))` in two modules, have the same ID, and the IDs of all modules are retained by the runtime.
+	// When true, a module's IDs can't collide with another's, and the limit of WithMaxFunctionTypes applies to each
+	// module instead of the runtime.
+	//
+	// Notes:
+	// * A `call_indirect` of a function from another module, ex. via a shared table, still type-checks correctly: As
+	//   IDs of different modules aren't comparable, their signatures are compared instead, which is slower.
+	// * This is only supported by NewRuntimeConfigInterpreter. Other engines ignore this setting.
+	WithPerModuleTypeIDs(bool) RuntimeConfig
+
+	// WithResultValidation checks that the results of each exported function call match its signature, before they
+	// are returned. This defaults to false as it adds overhead to every call, and a mismatch indicates an engine bug.
+	//
+	// When enabled, Function.Call returns an error instead of results if the count of results differs from the
+	// signature, or a result is not a valid value of its declared type (Ex. an i32 with non-zero upper bits).
+	//
+	// Note: This is only supported by NewRuntimeConfigInterpreter. Other engines ignore this setting.
+	WithResultValidation(bool) RuntimeConfig
+
+	// WithTableSizeLimit limits the maximum count of elements a table defined by a module can have. By default, only
+	// wazero's own limit of 134217728 (2^27) elements applies.
+	//
+	// Notes:
+	// * If a module defines no table max value, Runtime.CompileModule sets max to the limit.
+	// * If a module defines a table min or max larger than this limit, it will fail to compile (Runtime.CompileModule).
+	// * As the max is set, tables can't grow beyond this limit.
+	// * Tables imported by a module are limited by the module which defines them.
+	//
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#table-types%E2%91%A0
+	WithTableSizeLimit(maxElements uint32) RuntimeConfig
+
+	// WithValidationWarnings sets a function called with each non-fatal problem found while compiling a module
+	// (Runtime.CompileModule), or nil to disable. This defaults to nil.
+	//
+	// Warnings are only reported for modules which are otherwise valid, and never change whether compilation
+	// succeeds. Ex. "custom section \"producers\" is unused", or a memory access whose alignment hint is less than the
+	// natural alignment of its type.
+	//
+	// Note: An alignment hint larger than the natural alignment is invalid per the specification, so fails compilation
+	// instead of being a warning.
+	WithValidationWarnings(func(warning string)) RuntimeConfig
+
 	// WithWasmCore1 enables features included in the WebAssembly Core Specification 1.0. Selecting this
 	// overwrites any currently accumulated features with only those included in this W3C recommendation.
 	//
@@ -141,6 +418,9 @@ type RuntimeConfig interface {
 	// also enable or disable individual features via `WithXXX` methods. Ex.
 	//	rConfig = wazero.NewRuntimeConfig().WithWasmCore1().WithFeatureMutableGlobal(false)
 	//
+	// Note: Building with the tag "wazero_core1" restricts every runtime to these features, regardless of
+	// configuration. This removes branches for other features, in exchange for failing modules that use them.
+	//
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/
 	WithWasmCore1() RuntimeConfig
 
@@ -156,11 +436,37 @@ type RuntimeConfig interface {
 	WithWasmCore2() RuntimeConfig
 }
 
+// EngineKindJIT is the EngineKind of NewRuntimeConfigJIT.
+const EngineKindJIT = "jit"
+
+// EngineKindInterpreter is the EngineKind of NewRuntimeConfigInterpreter.
+const EngineKindInterpreter = "interpreter"
+
 type runtimeConfig struct {
-	enabledFeatures     wasm.Features
-	newEngine           func(wasm.Features) wasm.Engine
-	memoryLimitPages    uint32
-	memoryCapacityPages func(minPages uint32, maxPages *uint32) uint32
+	enabledFeatures      wasm.Features
+	engineKind           string
+	newEngine            func(wasm.Features) wasm.Engine
+	memoryLimitPages     uint32
+	memoryCapacityPages  func(minPages uint32, maxPages *uint32) uint32
+	maxModuleSize        int
+	maxBlockNesting      int
+	maxFunctionTypes     uint32
+	maxResults           int
+	tableSizeLimit       *uint32
+	disallowStartSection bool
+	disallowMemoryImport bool
+	// defaultStartFunctions holds the latest state of WithDefaultStartFunctions, or nil if never set.
+	defaultStartFunctions []string
+	validateResults       bool
+	labelTracing          bool
+	lenientFloatToInt     bool
+	optimizeBoundsChecks  bool
+	perModuleTypeIDs      bool
+	memoryWriteLog        func(offset uint32, data []byte)
+	memoryGrowthListener  func(memoryIndex, before, delta uint32, ok bool)
+	nullFuncrefHandler    func(tableIndex, offset uint32) error
+	callHooks             *wasm.CallHooks
+	validationWarnings    func(warning string)
 }
 
 // engineLessConfig helps avoid copy/pasting the wrong defaults.
@@ -168,25 +474,75 @@ var engineLessConfig = &runtimeConfig{
 	enabledFeatures:     wasm.Features20191205,
 	memoryLimitPages:    wasm.MemoryLimitPages,
 	memoryCapacityPages: func(minPages uint32, maxPages *uint32) uint32 { return minPages },
+	maxFunctionTypes:    wasm.MaximumFunctionTypes,
 }
 
 // NewRuntimeConfigJIT compiles WebAssembly modules into runtime.GOARCH-specific assembly for optimal performance.
 //
 // Note: This panics at runtime the runtime.GOOS or runtime.GOARCH does not support JIT. Use NewRuntimeConfig to safely
 // detect and fallback to NewRuntimeConfigInterpreter if needed.
+// Note: Calls ignore when their context.Context is done, ex. a deadline, so a guest which loops infinitely never
+// returns. Use NewRuntimeConfigInterpreter to bound calls, including start functions, by a deadline.
 func NewRuntimeConfigJIT() RuntimeConfig {
 	ret := *engineLessConfig // copy
+	ret.engineKind = EngineKindJIT
 	ret.newEngine = jit.NewEngine
 	return &ret
 }
 
 // NewRuntimeConfigInterpreter interprets WebAssembly modules instead of compiling them into assembly.
+//
+// Calls stop with the error of their context.Context when it is done, ex. context.DeadlineExceeded. This includes the
+// start functions of Runtime.InstantiateModule, so a deadline bounds instantiation even if a start function loops or
+// recurses infinitely. This is checked on function entry and backward branches, so a host function or a single long
+// instruction, ex. memory.fill, isn't interrupted. Unlike this, NewRuntimeConfigJIT ignores the context.
 func NewRuntimeConfigInterpreter() RuntimeConfig {
 	ret := *engineLessConfig // copy
+	ret.engineKind = EngineKindInterpreter
 	ret.newEngine = interpreter.NewEngine
 	return &ret
 }
 
+// EngineKind implements RuntimeConfig.EngineKind
+func (c *runtimeConfig) EngineKind() string {
+	return c.engineKind
+}
+
+// WithCallHook implements RuntimeConfig.WithCallHook
+func (c *runtimeConfig) WithCallHook(
+	before func(ctx context.Context, mod api.Module, fn api.FunctionDefinition) context.Context,
+	after func(ctx context.Context, mod api.Module, fn api.FunctionDefinition, err error),
+) RuntimeConfig {
+	ret := *c // copy
+	if before == nil && after == nil {
+		ret.callHooks = nil
+	} else {
+		ret.callHooks = &wasm.CallHooks{Before: before, After: after}
+	}
+	return &ret
+}
+
+// WithDefaultStartFunctions implements RuntimeConfig.WithDefaultStartFunctions
+func (c *runtimeConfig) WithDefaultStartFunctions(startFunctions ...string) RuntimeConfig {
+	ret := *c                                                         // copy
+	ret.defaultStartFunctions = append([]string{}, startFunctions...) // non-nil, even if empty
+	return &ret
+}
+
+// WithDisallowMemoryImport implements RuntimeConfig.WithDisallowMemoryImport
+func (c *runtimeConfig) WithDisallowMemoryImport(disallowMemoryImport bool) RuntimeConfig {
+	ret := *c // copy
+	ret.disallowMemoryImport = disallowMemoryImport
+	return &ret
+}
+
+// WithDisallowStartSection implements RuntimeConfig.WithDisallowStartSection
+func (c *runtimeConfig) WithDisallowStartSection(disallowStartSection bool) RuntimeConfig {
+	ret := *c // copy
+	ret.disallowStartSection = disallowStartSection
+	return &ret
+}
+
 // WithFeatureBulkMemoryOperations implements RuntimeConfig.WithFeatureBulkMemoryOperations
 func (c *runtimeConfig) WithFeatureBulkMemoryOperations(enabled bool) RuntimeConfig {
 	ret := *c // copy
@@ -233,6 +589,76 @@ func (c *runtimeConfig) WithFeatureSignExtensionOps(enabled bool) RuntimeConfig
 	return &ret
 }
 
+// WithFeatureThreads implements RuntimeConfig.WithFeatureThreads
+func (c *runtimeConfig) WithFeatureThreads(enabled bool) RuntimeConfig {
+	ret := *c // copy
+	ret.enabledFeatures = ret.enabledFeatures.Set(wasm.FeatureThreads, enabled)
+	return &ret
+}
+
+// featuresEnv is the environment variable read by RuntimeConfig.WithFeaturesFromEnv
+const featuresEnv = "WAZERO_FEATURES"
+
+// WithFeaturesFromEnv implements RuntimeConfig.WithFeaturesFromEnv
+func (c *runtimeConfig) WithFeaturesFromEnv() (RuntimeConfig, error) {
+	features, err := wasm.ParseFeatures(os.Getenv(featuresEnv))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", featuresEnv, err)
+	}
+	ret := *c // copy
+	ret.enabledFeatures |= features
+	// bulk-memory-operations proposal is mutually-dependant with reference-types proposal.
+	if features.Get(wasm.FeatureBulkMemoryOperations) || features.Get(wasm.FeatureReferenceTypes) {
+		ret.enabledFeatures |= wasm.FeatureBulkMemoryOperations | wasm.FeatureReferenceTypes
+	}
+	return &ret, nil
+}
+
+// WithLabelTracing implements RuntimeConfig.WithLabelTracing
+func (c *runtimeConfig) WithLabelTracing(labelTracing bool) RuntimeConfig {
+	ret := *c // copy
+	ret.labelTracing = labelTracing
+	return &ret
+}
+
+// WithLenientFloatToInt implements RuntimeConfig.WithLenientFloatToInt
+func (c *runtimeConfig) WithLenientFloatToInt(lenientFloatToInt bool) RuntimeConfig {
+	ret := *c // copy
+	ret.lenientFloatToInt = lenientFloatToInt
+	return &ret
+}
+
+// WithMaxBlockNesting implements RuntimeConfig.WithMaxBlockNesting
+func (c *runtimeConfig) WithMaxBlockNesting(maxBlockNesting int) RuntimeConfig {
+	ret := *c // copy
+	ret.maxBlockNesting = maxBlockNesting
+	return &ret
+}
+
+// WithMaxFunctionTypes implements RuntimeConfig.WithMaxFunctionTypes
+func (c *runtimeConfig) WithMaxFunctionTypes(maxFunctionTypes uint32) RuntimeConfig {
+	ret := *c // copy
+	if maxFunctionTypes > wasm.MaximumFunctionTypes {
+		maxFunctionTypes = wasm.MaximumFunctionTypes
+	}
+	ret.maxFunctionTypes = maxFunctionTypes
+	return &ret
+}
+
+// WithMaxModuleSize implements RuntimeConfig.WithMaxModuleSize
+func (c *runtimeConfig) WithMaxModuleSize(bytes int) RuntimeConfig {
+	ret := *c // copy
+	ret.maxModuleSize = bytes
+	return &ret
+}
+
+// WithMaxResults implements RuntimeConfig.WithMaxResults
+func (c *runtimeConfig) WithMaxResults(maxResults int) RuntimeConfig {
+	ret := *c // copy
+	ret.maxResults = maxResults
+	return &ret
+}
+
 // WithMemoryCapacityPages implements RuntimeConfig.WithMemoryCapacityPages
 func (c *runtimeConfig) WithMemoryCapacityPages(maxCapacityPages func(minPages uint32, maxPages *uint32) uint32) RuntimeConfig {
 	if maxCapacityPages == nil {
@@ -243,6 +669,13 @@ func (c *runtimeConfig) WithMemoryCapacityPages(maxCapacityPages func(minPages u
 	return &ret
 }
 
+// WithMemoryGrowthListener implements RuntimeConfig.WithMemoryGrowthListener
+func (c *runtimeConfig) WithMemoryGrowthListener(memoryGrowthListener func(memoryIndex, before, delta uint32, ok bool)) RuntimeConfig {
+	ret := *c // copy
+	ret.memoryGrowthListener = memoryGrowthListener
+	return &ret
+}
+
 // WithMemoryLimitPages implements RuntimeConfig.WithMemoryLimitPages
 func (c *runtimeConfig) WithMemoryLimitPages(memoryLimitPages uint32) RuntimeConfig {
 	ret := *c // copy
@@ -250,6 +683,55 @@ func (c *runtimeConfig) WithMemoryLimitPages(memoryLimitPages uint32) RuntimeCon
 	return &ret
 }
 
+// WithMemoryWriteLog implements RuntimeConfig.WithMemoryWriteLog
+func (c *runtimeConfig) WithMemoryWriteLog(memoryWriteLog func(offset uint32, data []byte)) RuntimeConfig {
+	ret := *c // copy
+	ret.memoryWriteLog = memoryWriteLog
+	return &ret
+}
+
+// WithNullFuncrefHandler implements RuntimeConfig.WithNullFuncrefHandler
+func (c *runtimeConfig) WithNullFuncrefHandler(nullFuncrefHandler func(tableIndex, offset uint32) error) RuntimeConfig {
+	ret := *c // copy
+	ret.nullFuncrefHandler = nullFuncrefHandler
+	return &ret
+}
+
+// WithOptimizeBoundsChecks implements RuntimeConfig.WithOptimizeBoundsChecks
+func (c *runtimeConfig) WithOptimizeBoundsChecks(optimizeBoundsChecks bool) RuntimeConfig {
+	ret := *c // copy
+	ret.optimizeBoundsChecks = optimizeBoundsChecks
+	return &ret
+}
+
+// WithPerModuleTypeIDs implements RuntimeConfig.WithPerModuleTypeIDs
+func (c *runtimeConfig) WithPerModuleTypeIDs(perModuleTypeIDs bool) RuntimeConfig {
+	ret := *c // copy
+	ret.perModuleTypeIDs = perModuleTypeIDs
+	return &ret
+}
+
+// WithResultValidation implements RuntimeConfig.WithResultValidation
+func (c *runtimeConfig) WithResultValidation(validateResults bool) RuntimeConfig {
+	ret := *c // copy
+	ret.validateResults = validateResults
+	return &ret
+}
+
+// WithTableSizeLimit implements RuntimeConfig.WithTableSizeLimit
+func (c *runtimeConfig) WithTableSizeLimit(maxElements uint32) RuntimeConfig {
+	ret := *c // copy
+	ret.tableSizeLimit = &maxElements
+	return &ret
+}
+
+// WithValidationWarnings implements RuntimeConfig.WithValidationWarnings
+func (c *runtimeConfig) WithValidationWarnings(validationWarnings func(warning string)) RuntimeConfig {
+	ret := *c // copy
+	ret.validationWarnings = validationWarnings
+	return &ret
+}
+
 // WithWasmCore1 implements RuntimeConfig.WithWasmCore1
 func (c *runtimeConfig) WithWasmCore1() RuntimeConfig {
 	ret := *c // copy
@@ -271,16 +753,501 @@ func (c *runtimeConfig) WithWasmCore2() RuntimeConfig {
 // the name "Module" for both before and after instantiation as the name conflation has caused confusion.
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#semantic-phases%E2%91%A0
 type CompiledCode interface {
+	// ID returns the hex-encoded SHA-256 checksum of the source passed to Runtime.CompileModule. This identifies the
+	// module regardless of the names it is instantiated with, so is stable across re-instantiation and processes.
+	//
+	// Ex. Two compilations of the same source have the same ID, while any difference in the source changes it.
+	//
+	// Note: Modules defined by ModuleBuilder have no source, so their ID is only stable within the current process.
+	ID() string
+
+	// WASIModuleKind returns whether this is a WASI command or reactor, based on the functions it exports.
+	//
+	// Ex. A command is instantiated to run "_start" once, while a reactor stays resident after "_initialize":
+	//	switch compiled.WASIModuleKind() {
+	//	case wazero.WASIModuleKindCommand:
+	//		mod, err = r.InstantiateModule(ctx, compiled) // calls "_start" by default
+	//	case wazero.WASIModuleKindReactor:
+	//		mod, err = r.InstantiateModuleWithConfig(ctx, compiled, config.WithStartFunctions("_initialize"))
+	//	}
+	//
+	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/design/application-abi.md#current-unstable-abi
+	WASIModuleKind() WASIModuleKind
+
+	// FunctionBody returns a copy of the body of the function at the index in the function index namespace, which
+	// begins with imported functions. This is the code of the function in the WebAssembly binary format, excluding its
+	// local declarations, and ends with the `end` instruction.
+	//
+	// This returns false if there's no function at the index, or it has no body: an imported function or a host
+	// function defined by ModuleBuilder.
+	//
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-code
+	FunctionBody(index uint32) ([]byte, bool)
+
+	// FunctionInstructionCount returns the count of instructions of the function at the index in the function index
+	// namespace, as lowered to wazero's intermediate representation. This is a measure of the size of a function which
+	// is independent of its encoding, ex. for complexity metrics or billing.
+	//
+	// This returns zero if there's no function at the index, or it has no body: an imported function or a host
+	// function defined by ModuleBuilder.
+	FunctionInstructionCount(index uint32) int
+
+	// DylinkInfo returns the requirements of a module which supports dynamic linking, decoded from its "dylink.0"
+	// custom section, or nil if it has none.
+	//
+	// Ex. A host linker reserves memory and table regions for a side module before instantiating it:
+	//	if info := compiled.DylinkInfo(); info != nil {
+	//		memoryBase := alignUp(heapEnd, 1<<info.MemoryAlignment)
+	//		heapEnd = memoryBase + info.MemorySize
+	//		--snip--
+	//	}
+	//
+	// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md#the-dylink0-section
+	DylinkInfo() *DylinkInfo
+
+	// ImportedGlobals returns the globals the module imports, in order, or an empty slice if it imports none. This
+	// allows verifying the host provides compatible globals before instantiating the module.
+	//
+	// Ex. To list the global imports:
+	//	for _, g := range compiled.ImportedGlobals() {
+	//		fmt.Printf("%s.%s %s mutable=%t\n", g.Module, g.Name, api.ValueTypeName(g.ValType), g.Mutable)
+	//	}
+	ImportedGlobals() []ImportedGlobal
+
+	// DuplicateImports returns each module and name the module imports more than once, in order of the first such
+	// import, or an empty slice if there are none. This helps clean up the output of toolchains.
+	//
+	// Ex. To report duplicates which can't be satisfied:
+	//	for _, d := range compiled.DuplicateImports() {
+	//		if d.Conflicting {
+	//			fmt.Printf("%s.%s is imported as different types at %v\n", d.Module, d.Name, d.Indexes)
+	//		}
+	//	}
+	//
+	// Note: Duplicate imports are valid per the specification, including of different kinds, ex. a function and a
+	// global. However, an import resolves to a single export, so conflicting duplicates fail to instantiate.
+	DuplicateImports() []DuplicateImport
+
+	// ElementSegments returns each element segment of the module, in order, or an empty slice if it has none. This
+	// allows tools to statically analyze which functions `call_indirect` can reach through each table.
+	//
+	// Ex. To list the functions an active segment places in its table:
+	//	for _, e := range compiled.ElementSegments() {
+	//		if e.Mode != "active" || e.OffsetGlobal != nil {
+	//			continue
+	//		}
+	//		for i, fn := range e.Init {
+	//			if fn != nil {
+	//				fmt.Printf("table[%d][%d] = function[%d]\n", e.TableIndex, e.Offset+uint32(i), *fn)
+	//			}
+	//		}
+	//	}
+	//
+	// Note: Segments encoded as function indexes and as `ref.func` or `ref.null` expressions (reference-types) are
+	// reported the same way, as each element is either a function index or null.
+	ElementSegments() []ElementSegment
+
+	// Types returns a copy of each function type the module declares in its type section, in order, or an empty slice
+	// if it declares none. Unlike the signatures of exported functions, this includes types only used by imports,
+	// non-exported functions or `call_indirect`, so is useful for tools that generate bindings.
+	//
+	// Ex. To print the signature of each type index:
+	//	for i, ft := range compiled.Types() {
+	//		fmt.Printf("type[%d] %v -> %v\n", i, ft.Params, ft.Results)
+	//	}
+	//
+	// Note: Type indexes are those of the module, so may differ from the type IDs shared by modules in the runtime.
+	Types() []*FunctionType
+
+	// UsesFloatingPoint returns true if the module has any f32 or f64 value types, or any instructions which operate on
+	// floats. This is computed during compilation, so is cheap to call, ex. to route modules to a runtime which only
+	// accepts deterministic integer code.
+	//
+	// Note: This is true for a module with floats in a signature, even if it has no float arithmetic.
+	UsesFloatingPoint() bool
+
+	// AssertExports returns an error if the exports of the module don't match the expected interface, keyed by export
+	// name. This allows contract testing between a guest and its host before instantiating the module.
+	//
+	// Ex. Require the module to export exactly "add" (i32, i32) -> i32, and a memory named "memory":
+	//	i32 := api.ValueTypeI32
+	//	err := compiled.AssertExports(map[string]*wazero.ExportSignature{
+	//		"add":    {Params: []api.ValueType{i32, i32}, Results: []api.ValueType{i32}},
+	//		"memory": {Kind: "memory"},
+	//	})
+	//
+	// The error reports each missing, extra or mismatched export. Function exports must match exactly, so any that are
+	// not expected are extra. Memory, global and table exports are only checked when expected, so are optional. A nil
+	// ExportSignature is also reported, as it doesn't say what to expect.
+	AssertExports(expected map[string]*ExportSignature) error
+
+	// InstanceCount returns the count of modules instantiated from this which are not yet closed.
+	//
+	// Instances share the code compiled from this, instead of compiling it again, so closing one doesn't affect the
+	// others. Ex. Instantiate several modules with different names, and close them independently:
+	//	m1, _ := r.InstantiateModuleWithConfig(ctx, compiled, wazero.NewModuleConfig().WithName("m1"))
+	//	m2, _ := r.InstantiateModuleWithConfig(ctx, compiled, wazero.NewModuleConfig().WithName("m2"))
+	//	m1.Close(ctx) // compiled.InstanceCount() == 1, and m2 still runs.
+	InstanceCount() int
+
 	// Close releases all the allocated resources for this CompiledCode.
 	//
 	// Note: It is safe to call Close while having outstanding calls from Modules instantiated from this CompiledCode.
 	Close(context.Context) error
 }
 
+// WASIModuleKind is the kind of a WASI module. See CompiledCode.WASIModuleKind
+type WASIModuleKind byte
+
+const (
+	// WASIModuleKindUnknown is a module which exports neither "_start" nor "_initialize", such as a library or a
+	// module which doesn't use WASI.
+	WASIModuleKindUnknown WASIModuleKind = iota
+	// WASIModuleKindCommand is a module which exports "_start", which runs once and possibly exits.
+	WASIModuleKindCommand
+	// WASIModuleKindReactor is a module which exports "_initialize", which stays resident after it is called.
+	WASIModuleKindReactor
+	// WASIModuleKindAmbiguous is a module which exports both "_start" and "_initialize", so is neither a valid command
+	// nor a valid reactor.
+	WASIModuleKindAmbiguous
+)
+
+// String returns a name of the kind, ex. "command".
+func (k WASIModuleKind) String() string {
+	switch k {
+	case WASIModuleKindCommand:
+		return "command"
+	case WASIModuleKindReactor:
+		return "reactor"
+	case WASIModuleKindAmbiguous:
+		return "ambiguous"
+	}
+	return "unknown"
+}
+
+// DylinkInfo is the "dylink.0" custom section of a module. See CompiledCode.DylinkInfo
+type DylinkInfo struct {
+	// MemorySize is the size in bytes of the static data of the module, which the linker reserves in memory.
+	MemorySize uint32
+	// MemoryAlignment is the alignment of MemorySize, as a power of two. Ex. 2 means 4-byte aligned.
+	MemoryAlignment uint32
+	// TableSize is the count of table elements the linker reserves for the module.
+	TableSize uint32
+	// TableAlignment is the alignment of TableSize, as a power of two.
+	TableAlignment uint32
+	// Needed are the names of the shared libraries the module depends on, in order.
+	Needed []string
+}
+
+// ImportedGlobal is a global import of a module. See CompiledCode.ImportedGlobals
+type ImportedGlobal struct {
+	// Module is the name of the module the global is imported from.
+	Module string
+	// Name is the name of the global in that module.
+	Name string
+	// ValType is the type of the value of the global.
+	ValType api.ValueType
+	// Mutable is true if the global must be mutable. Otherwise, it must be immutable.
+	Mutable bool
+}
+
+// DuplicateImport is a module and name imported more than once. See CompiledCode.DuplicateImports
+type DuplicateImport struct {
+	// Module is the name of the module imported from.
+	Module string
+	// Name is the name imported from that module.
+	Name string
+	// Indexes are the positions of each import of the name, among all imports of the module, in order.
+	Indexes []uint32
+	// Conflicting is true if the imports differ in kind or type. Otherwise, they are identical, so only redundant.
+	Conflicting bool
+}
+
+// ElementSegment is an element segment of a module. See CompiledCode.ElementSegments
+type ElementSegment struct {
+	// Mode is "active", "passive" or "declarative". Only active segments initialize a table on instantiation.
+	Mode string
+	// Type is the reference type of the elements: "funcref" or "externref".
+	Type string
+	// TableIndex is the table an active segment initializes, or zero otherwise.
+	TableIndex uint32
+	// Offset is the position in the table of the first element of an active segment, if constant. Otherwise, zero.
+	Offset uint32
+	// OffsetGlobal is the index of the global, ex. an imported one, whose value is the Offset of an active segment, or
+	// nil if the offset is constant or the segment isn't active.
+	OffsetGlobal *uint32
+	// Init are the function index of each element, or nil for a null reference.
+	Init []*uint32
+}
+
+// FunctionType is a function signature declared by a module. See CompiledCode.Types
+type FunctionType struct {
+	// Params are the parameter types of the function.
+	Params []api.ValueType
+	// Results are the result types of the function.
+	Results []api.ValueType
+}
+
+// ExportSignature is an expected export of a module. See CompiledCode.AssertExports
+type ExportSignature struct {
+	// Kind is "func", "memory", "global" or "table". Empty defaults to "func".
+	Kind string
+	// Params are the parameter types of a function.
+	Params []api.ValueType
+	// Results are the result types of a function.
+	Results []api.ValueType
+	// ValType is the type of the value of a global.
+	ValType api.ValueType
+	// Mutable is true if a global must be mutable. Otherwise, it must be immutable.
+	Mutable bool
+}
+
 type compiledCode struct {
 	module *wasm.Module
 	// compiledEngine holds an engine on which `module` is compiled.
 	compiledEngine wasm.Engine
+
+	// instructionCounts are lazily initialized by FunctionInstructionCount, guarded by instructionCountsOnce.
+	instructionCounts     []int
+	instructionCountsOnce sync.Once
+
+	// usesFloatingPoint is the result of UsesFloatingPoint, computed during compilation.
+	usesFloatingPoint bool
+
+	// instances is the count of modules instantiated from this and not yet closed. Only accessed atomically.
+	instances int64
+}
+
+// ID implements CompiledCode.ID
+func (c *compiledCode) ID() string {
+	return hex.EncodeToString(c.module.ID[:])
+}
+
+// WASIModuleKind implements CompiledCode.WASIModuleKind
+func (c *compiledCode) WASIModuleKind() WASIModuleKind {
+	var hasStart, hasInitialize bool
+	for _, e := range c.module.ExportSection {
+		if e.Type != wasm.ExternTypeFunc {
+			continue
+		}
+		switch e.Name {
+		case "_start":
+			hasStart = true
+		case "_initialize":
+			hasInitialize = true
+		}
+	}
+	switch {
+	case hasStart && hasInitialize:
+		return WASIModuleKindAmbiguous
+	case hasStart:
+		return WASIModuleKindCommand
+	case hasInitialize:
+		return WASIModuleKindReactor
+	}
+	return WASIModuleKindUnknown
+}
+
+// FunctionBody implements CompiledCode.FunctionBody
+func (c *compiledCode) FunctionBody(index uint32) ([]byte, bool) {
+	importCount := c.module.ImportFuncCount()
+	if index < importCount || index-importCount >= uint32(len(c.module.CodeSection)) {
+		return nil, false
+	}
+	return append([]byte{}, c.module.CodeSection[index-importCount].Body...), true
+}
+
+// FunctionInstructionCount implements CompiledCode.FunctionInstructionCount
+func (c *compiledCode) FunctionInstructionCount(index uint32) int {
+	c.instructionCountsOnce.Do(func() {
+		if c.module.IsHostModule() {
+			return
+		}
+		// The module was already validated with the features of the runtime, so enabling all of them changes nothing.
+		irs, err := wazeroir.CompileFunctions(context.Background(), wasm.Features20220419, c.module)
+		if err != nil {
+			return // unexpected as the module was already compiled.
+		}
+		c.instructionCounts = make([]int, len(irs))
+		for i, ir := range irs {
+			c.instructionCounts[i] = len(ir.Operations)
+		}
+	})
+
+	importCount := c.module.ImportFuncCount()
+	if index < importCount || index-importCount >= uint32(len(c.instructionCounts)) {
+		return 0
+	}
+	return c.instructionCounts[index-importCount]
+}
+
+// DylinkInfo implements CompiledCode.DylinkInfo
+func (c *compiledCode) DylinkInfo() *DylinkInfo {
+	d := c.module.DylinkSection
+	if d == nil {
+		return nil
+	}
+	return &DylinkInfo{
+		MemorySize:      d.MemorySize,
+		MemoryAlignment: d.MemoryAlignment,
+		TableSize:       d.TableSize,
+		TableAlignment:  d.TableAlignment,
+		Needed:          append([]string{}, d.Needed...),
+	}
+}
+
+// ImportedGlobals implements CompiledCode.ImportedGlobals
+func (c *compiledCode) ImportedGlobals() []ImportedGlobal {
+	ret := []ImportedGlobal{}
+	for _, imp := range c.module.ImportSection {
+		if imp.Type != wasm.ExternTypeGlobal {
+			continue
+		}
+		ret = append(ret, ImportedGlobal{
+			Module:  imp.Module,
+			Name:    imp.Name,
+			ValType: imp.DescGlobal.ValType,
+			Mutable: imp.DescGlobal.Mutable,
+		})
+	}
+	return ret
+}
+
+// DuplicateImports implements CompiledCode.DuplicateImports
+func (c *compiledCode) DuplicateImports() []DuplicateImport {
+	ret := []DuplicateImport{}
+	for _, d := range c.module.DuplicateImports() {
+		ret = append(ret, DuplicateImport{
+			Module:      d.Module,
+			Name:        d.Name,
+			Indexes:     d.Indices,
+			Conflicting: d.Conflicting,
+		})
+	}
+	return ret
+}
+
+// ElementSegments implements CompiledCode.ElementSegments
+func (c *compiledCode) ElementSegments() []ElementSegment {
+	ret := make([]ElementSegment, 0, len(c.module.ElementSection))
+	for _, e := range c.module.ElementSection {
+		segment := ElementSegment{Type: wasm.RefTypeName(e.Type), Init: make([]*uint32, 0, len(e.Init))}
+		switch e.Mode {
+		case wasm.ElementModeActive:
+			segment.Mode = "active"
+			segment.TableIndex = e.TableIndex
+			// The offset was validated on compilation, so is either i32.const or global.get.
+			if e.OffsetExpr.Opcode == wasm.OpcodeGlobalGet {
+				global, _, _ := leb128.DecodeUint32(bytes.NewReader(e.OffsetExpr.Data))
+				segment.OffsetGlobal = &global
+			} else {
+				offset, _, _ := leb128.DecodeInt32(bytes.NewReader(e.OffsetExpr.Data))
+				segment.Offset = uint32(offset)
+			}
+		case wasm.ElementModePassive:
+			segment.Mode = "passive"
+		case wasm.ElementModeDeclarative:
+			segment.Mode = "declarative"
+		}
+		for _, idx := range e.Init {
+			if idx == nil {
+				segment.Init = append(segment.Init, nil)
+			} else {
+				fn := *idx // copy, so the caller can't change the module.
+				segment.Init = append(segment.Init, &fn)
+			}
+		}
+		ret = append(ret, segment)
+	}
+	return ret
+}
+
+// Types implements CompiledCode.Types
+func (c *compiledCode) Types() []*FunctionType {
+	ret := make([]*FunctionType, 0, len(c.module.TypeSection))
+	for _, ft := range c.module.TypeSection {
+		ret = append(ret, &FunctionType{
+			Params:  append([]api.ValueType{}, ft.Params...),
+			Results: append([]api.ValueType{}, ft.Results...),
+		})
+	}
+	return ret
+}
+
+// UsesFloatingPoint implements CompiledCode.UsesFloatingPoint
+func (c *compiledCode) UsesFloatingPoint() bool {
+	return c.usesFloatingPoint
+}
+
+// InstanceCount implements CompiledCode.InstanceCount
+func (c *compiledCode) InstanceCount() int {
+	return int(atomic.LoadInt64(&c.instances))
+}
+
+// AssertExports implements CompiledCode.AssertExports
+func (c *compiledCode) AssertExports(expected map[string]*ExportSignature) error {
+	functions, globals, _, _, err := c.module.AllDeclarations()
+	if err != nil {
+		return err // unexpected as the module was already validated.
+	}
+
+	var problems []string
+	exported := map[string]struct{}{}
+	for _, e := range c.module.ExportSection {
+		exported[e.Name] = struct{}{}
+		kind := wasm.ExternTypeName(e.Type)
+		sig, ok := expected[e.Name]
+		if !ok {
+			if e.Type == wasm.ExternTypeFunc {
+				problems = append(problems, fmt.Sprintf("unexpected export %s[%s]", kind, e.Name))
+			}
+			continue
+		} else if sig == nil {
+			continue // reported below
+		}
+
+		expectedKind := sig.Kind
+		if expectedKind == "" {
+			expectedKind = wasm.ExternTypeFuncName
+		}
+		if kind != expectedKind {
+			problems = append(problems, fmt.Sprintf("export %s[%s] is not a %s", kind, e.Name, expectedKind))
+			continue
+		}
+
+		switch e.Type {
+		case wasm.ExternTypeFunc:
+			ft := c.module.TypeSection[functions[e.Index]]
+			if !ft.EqualsSignature(sig.Params, sig.Results) {
+				problems = append(problems, fmt.Sprintf("export func[%s] signature mismatch: %s != %s",
+					e.Name, ft, &wasm.FunctionType{Params: sig.Params, Results: sig.Results}))
+			}
+		case wasm.ExternTypeGlobal:
+			gt := globals[e.Index]
+			if gt.ValType != sig.ValType || gt.Mutable != sig.Mutable {
+				problems = append(problems, fmt.Sprintf("export global[%s] type mismatch: %s mutable=%t != %s mutable=%t",
+					e.Name, wasm.ValueTypeName(gt.ValType), gt.Mutable, wasm.ValueTypeName(sig.ValType), sig.Mutable))
+			}
+		}
+	}
+
+	for name, sig := range expected {
+		if sig == nil {
+			problems = append(problems, fmt.Sprintf("expected export[%s] is nil", name))
+		} else if _, ok := exported[name]; !ok {
+			kind := sig.Kind
+			if kind == "" {
+				kind = wasm.ExternTypeFuncName
+			}
+			problems = append(problems, fmt.Sprintf("missing export %s[%s]", kind, name))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	sort.Strings(problems)
+	return fmt.Errorf("exports don't match:\n\t%s", strings.Join(problems, "\n\t"))
 }
 
 // Close implements CompiledCode.Close
@@ -335,6 +1302,24 @@ type ModuleConfig interface {
 	// See https://en.wikipedia.org/wiki/Null-terminated_string
 	WithEnv(key, value string) ModuleConfig
 
+	// WithExitCodeAsError configures whether a call during which the module exits, ex. via "proc_exit" in
+	// "wasi_snapshot_preview1", fails with a sys.ExitError.
+	//
+	// When never set, any exit fails the call with a sys.ExitError, including exit(0). This is for compatibility, so
+	// use errors.As to tell a sys.ExitError with ExitCode zero from a failure.
+	//
+	// Once set, exit(0) is a normal termination, whether true or false. When true, only a non-zero exit code fails the
+	// call with a sys.ExitError. When false, any exit is a normal termination. A call which exits normally returns nil
+	// results and a nil error, even if the function has results, as the exit skipped them.
+	//
+	// Ex. To treat only a non-zero exit code as an error:
+	//	config := wazero.NewModuleConfig().WithExitCodeAsError(true)
+	//	_, err := r.InstantiateModuleWithConfig(ctx, compiled, config) // nil on exit(0), sys.ExitError on exit(1)
+	//
+	// Note: This only affects the call during which the module exits. Calling a function of a module that already
+	// exited fails with a sys.ExitError regardless.
+	WithExitCodeAsError(bool) ModuleConfig
+
 	// WithFS assigns the file system to use for any paths beginning at "/". Defaults to not found.
 	//
 	// Ex. This sets a read-only, embedded file-system to serve files under the root ("/") and working (".") directories:
@@ -348,7 +1333,10 @@ type ModuleConfig interface {
 	//	// "index.html" is accessible as both "/index.html" and "./index.html" because we didn't use WithWorkDirFS.
 	//	config := wazero.NewModuleConfig().WithFS(rooted)
 	//
-	// Note: This sets WithWorkDirFS to the same file-system unless already set.
+	// Notes:
+	// * This sets WithWorkDirFS to the same file-system unless already set.
+	// * The guest can only change the file-system if it implements experimental.WritableFS, such as
+	//   experimental.MemFS. Otherwise, it is read-only.
 	WithFS(fs.FS) ModuleConfig
 
 	// WithImport replaces a specific import module and name with a new one. This allows you to break up a monolithic
@@ -373,9 +1361,28 @@ type ModuleConfig interface {
 	//	(import "wasm" "wasm_increment" (func $wasm_increment (result i32)))
 	//	(import "wasm" "wasm_decrement" (func $wasm_decrement (result i32)))
 	//
-	// Note: Any WithImport instructions happen in order, after any WithImportModule instructions.
+	// Notes:
+	// * Any WithImport instructions happen in order, after any WithImportModule instructions.
+	// * Instantiation fails if an import is replaced with one which is also replaced, ex. "a.f" with "b.f" and "b.f"
+	//   with "c.f", as the order of replacement is undefined. Replace "a.f" with "c.f" instead.
 	WithImport(oldModule, oldName, newModule, newName string) ModuleConfig
 
+	// WithImportMemoryLimits overrides the minimum and maximum pages a memory import declares, when resolving it on
+	// instantiation. This allows tightening the limits a module accepts from the module that exports the memory.
+	//
+	// For example, if a module was compiled to import a memory of any size:
+	//	(import "env" "memory" (memory 1))
+	//
+	// Use this function to only accept a memory which can't grow larger than 16 pages:
+	//	config.WithImportMemoryLimits("env", "memory", 1, 16)
+	//
+	// Notes:
+	// * The module and name are matched after any WithImportModule or WithImport replacements.
+	// * Instantiation fails if the overridden limits are incompatible with the exported memory, just like the declared
+	//   ones. Ex. if the exported memory can grow beyond max.
+	// * Instantiation also fails if min is less than the declared minimum, as code may be compiled to rely on it.
+	WithImportMemoryLimits(module, name string, min, max uint32) ModuleConfig
+
 	// WithImportModule replaces every import with oldModule with newModule. This is helpful for modules who have
 	// transitioned to a stable status since the underlying wasm was compiled.
 	//
@@ -386,9 +1393,31 @@ type ModuleConfig interface {
 	//	config.WithImportModule("wasi_unstable", wasi.ModuleSnapshotPreview1)
 	//
 	// See WithImport for a comprehensive example.
-	// Note: Any WithImportModule instructions happen in order, before any WithImport instructions.
+	//
+	// Notes:
+	// * Any WithImportModule instructions happen in order, before any WithImport instructions.
+	// * Instantiation fails if an import module is replaced with one which is also replaced, ex. "a" with "b" and "b"
+	//   with "c", as the order of replacement is undefined. Replace "a" with "c" instead.
 	WithImportModule(oldModule, newModule string) ModuleConfig
 
+	// WithLazyImport declares a function import which doesn't need to exist when the module is instantiated. Instead,
+	// the import is bound later, via BindImport. This allows a plugin loader to instantiate a module before the module
+	// that implements one of its imports.
+	//
+	// For example, if a module imports a function from a module that doesn't exist yet:
+	//	(import "plugin" "run" (func $run (param i32) (result i32)))
+	//
+	// Declare the import as lazy, then bind it once the implementation exists:
+	//	mod, _ := r.InstantiateModuleWithConfig(ctx, compiled, config.WithLazyImport("plugin", "run"))
+	//	plugin, _ := r.InstantiateModuleFromCode(ctx, pluginSource)
+	//	_ = wazero.BindImport(mod, "plugin", "run", plugin.ExportedFunction("run"))
+	//
+	// Notes:
+	// * The module and name are matched after any WithImportModule or WithImport replacements.
+	// * Calling the import before it is bound fails with an "import not yet bound" error.
+	// * Only function imports can be lazy. This is ignored for other types of imports.
+	WithLazyImport(module, name string) ModuleConfig
+
 	// WithName configures the module name. Defaults to what was decoded from the module source.
 	//
 	// If the source was in WebAssembly 1.0 Binary Format, this defaults to what was decoded from the custom name
@@ -401,9 +1430,52 @@ type ModuleConfig interface {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#modules%E2%91%A0%E2%91%A2
 	WithName(string) ModuleConfig
 
-	// WithStartFunctions configures the functions to call after the module is instantiated. Defaults to "_start".
+	// WithNoFSErrno configures the errno WASI functions such as "path_open" return when no file system is configured
+	// via WithFS or WithWorkDirFS. Defaults to EBADF (8), as there is no directory file descriptor to open paths from.
+	//
+	// Ex. To return EACCES, so that guests can't tell whether a path exists:
+	//	config = config.WithNoFSErrno(wasi.ErrnoAcces)
+	//
+	// Note: This has no effect when a file system is configured. Ex. Opening a missing file returns ENOENT.
+	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-errno-enumu16
+	WithNoFSErrno(errno uint32) ModuleConfig
+
+	// WithPostStartHook sets a function called with the module after its start functions succeed, including the start
+	// section and WithStartFunctions. Defaults to nil.
+	//
+	// If hook errs, the module is closed and instantiation fails with its error, so the name can be reused.
+	//
+	// Note: hook isn't called if a start function fails or exits. See WithPreStartHook
+	WithPostStartHook(hook func(ctx context.Context, mod api.Module) error) ModuleConfig
+
+	// WithPreStartHook sets a function called with the module after its memory and tables are initialized, but before
+	// any start function, including the start section. Defaults to nil.
+	//
+	// This allows general setup, such as writing to memory or setting globals, which start functions can observe.
+	//
+	// Ex. To write input to memory the start function reads:
+	//	config = config.WithPreStartHook(func(ctx context.Context, mod api.Module) error {
+	//		if !mod.Memory().Write(ctx, 0, input) {
+	//			return errors.New("input out of range")
+	//		}
+	//		return nil
+	//	})
+	//
+	// If hook errs, instantiation fails with its error, so the name can be reused.
+	//
+	// Note: Calls to exported functions from hook work, but precede the start functions.
+	WithPreStartHook(hook func(ctx context.Context, mod api.Module) error) ModuleConfig
+
+	// WithStartFunctions configures the functions to call after the module is instantiated. Defaults to "_start", or
+	// the functions set by RuntimeConfig.WithDefaultStartFunctions.
+	//
+	// When this isn't set and the module is a WASI reactor (CompiledCode.WASIModuleKind), "_initialize" is called
+	// before the default functions. Setting this opts out of that, so include "_initialize" to keep calling it. Once
+	// called, the reactor stays resident: its exports can be called any number of times, except "_initialize", which
+	// errs if called again. When not included, the host is responsible for calling "_initialize" once.
 	//
 	// Note: If any function doesn't exist, it is skipped. However, all functions that do exist are called in order.
+	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/design/application-abi.md#current-unstable-abi
 	WithStartFunctions(...string) ModuleConfig
 
 	// WithStderr configures where standard error (file descriptor 2) is written. Defaults to io.Discard.
@@ -416,6 +1488,16 @@ type ModuleConfig interface {
 	// See https://linux.die.net/man/3/stderr
 	WithStderr(io.Writer) ModuleConfig
 
+	// WithStderrRingBuffer retains the last size bytes written to standard error in memory, in addition to writing
+	// them to the writer configured by WithStderr. Defaults to zero, which retains nothing.
+	//
+	// This is useful for crash diagnostics, ex. to include recent errors when a function traps, without retaining all
+	// output. The retained bytes are read via RecentStderr.
+	//
+	// Note: When more than size bytes were written, only the most recent are retained. These are raw bytes, so may
+	// begin in the middle of a multi-byte UTF-8 character.
+	WithStderrRingBuffer(size int) ModuleConfig
+
 	// WithStdin configures where standard input (file descriptor 0) is read. Defaults to return io.EOF.
 	//
 	// This reader is most commonly used by the functions like "fd_read" in "wasi_snapshot_preview1" although it could
@@ -436,6 +1518,28 @@ type ModuleConfig interface {
 	// See https://linux.die.net/man/3/stdout
 	WithStdout(io.Writer) ModuleConfig
 
+	// WithStdoutRingBuffer retains the last size bytes written to standard output in memory, in addition to writing
+	// them to the writer configured by WithStdout. Defaults to zero, which retains nothing.
+	//
+	// The retained bytes are read via RecentStdout. See WithStderrRingBuffer for notes.
+	WithStdoutRingBuffer(size int) ModuleConfig
+
+	// WithTrapSink sets a function called with each trap in the instantiated module, such as executing `unreachable`
+	// or dividing an integer by zero. Defaults to nil, which reports nothing.
+	//
+	// Ex. To log traps in a structured way, instead of at each call site:
+	//	config = config.WithTrapSink(func(trap wazero.TrapInfo) {
+	//		logger.Error("trap", "module", trap.Module, "function", trap.Function, "reason", trap.Reason)
+	//	})
+	//
+	// Traps are reported for each call to an exported function of this module, including the start function and any
+	// WithStartFunctions, before the call returns its error. Each module has its own sink, so modules instantiated with
+	// different configs don't receive each other's traps.
+	//
+	// Note: Only traps are reported, not other errors such as a sys.ExitError or a panic in a host function.
+	// Note: The sink is called synchronously by the goroutine that made the call, so should not block.
+	WithTrapSink(func(TrapInfo)) ModuleConfig
+
 	// WithWorkDirFS indicates the file system to use for any paths beginning at "./". Defaults to the same as WithFS.
 	//
 	// Ex. This sets a read-only, embedded file-system as the root ("/"), and a mutable one as the working directory ("."):
@@ -449,15 +1553,45 @@ type ModuleConfig interface {
 	// Note: os.DirFS documentation includes important notes about isolation, which also applies to fs.Sub. As of Go 1.18,
 	// the built-in file-systems are not jailed (chroot). See https://github.com/golang/go/issues/42322
 	WithWorkDirFS(fs.FS) ModuleConfig
+
+	// WithWriter configures where writes to the file descriptor fd, such as "fd_write" in "wasi_snapshot_preview1", go.
+	// Defaults to none, so writes to a file descriptor which isn't an opened file fail with EBADF.
+	//
+	// Ex. To redirect a guest which logs to file descriptor 3:
+	//	config = config.WithWriter(3, logWriter)
+	//
+	// Instantiation errs if fd is 0, 1 or 2, as those are configured by WithStdin, WithStdout and WithStderr, or if fd is
+	// a pre-opened file descriptor, such as one of WithFS.
+	//
+	// Note: The caller is responsible to close any io.Writer they supply: It is not closed on api.Module Close.
+	WithWriter(fd uint32, w io.Writer) ModuleConfig
+}
+
+// TrapInfo describes a trap. See ModuleConfig.WithTrapSink
+type TrapInfo struct {
+	// Module is the name of the module the trap occurred in.
+	Module string
+	// Function is the function which was called, as "module.function", or "module.[index]" if it has no name.
+	//
+	// Note: The function which trapped may be a different one called by this. The wasm stack trace in Err includes
+	// every function, as the source location isn't yet available.
+	Function string
+	// Reason describes the trap, ex. "integer divide by zero".
+	Reason string
+	// Err is the error the call failed with, which includes the wasm stack trace.
+	Err error
 }
 
 type moduleConfig struct {
-	name           string
+	name string
+	// startFunctions holds the latest state of WithStartFunctions, or nil if never set.
 	startFunctions []string
 	stdin          io.Reader
 	stdout         io.Writer
 	stderr         io.Writer
-	args           []string
+	// stdoutRingSize and stderrRingSize are the bytes to retain of each, or zero for none.
+	stdoutRingSize, stderrRingSize int
+	args                           []string
 	// environ is pair-indexed to retain order similar to os.Environ.
 	environ []string
 	// environKeys allow overwriting of existing values.
@@ -474,15 +1608,32 @@ type moduleConfig struct {
 	replacedImports map[string][2]string
 	// replacedImportModules holds the latest state of WithImportModule
 	replacedImportModules map[string]string
+	// exitCodeAsError holds the latest state of WithExitCodeAsError, or nil if never set.
+	exitCodeAsError *bool
+	// noFSErrno holds the latest state of WithNoFSErrno, or nil if never set.
+	noFSErrno *uint32
+	// lazyImports holds the latest state of WithLazyImport
+	// Note: Key is NUL delimited as import module and name can both include any UTF-8 characters.
+	lazyImports map[string]struct{}
+	// importMemoryLimits holds the latest state of WithImportMemoryLimits, as min and max pages.
+	// Note: Key is NUL delimited as import module and name can both include any UTF-8 characters.
+	importMemoryLimits map[string][2]uint32
+	// trapSink holds the latest state of WithTrapSink
+	trapSink func(TrapInfo)
+	// writers holds the latest state of WithWriter
+	writers map[uint32]io.Writer
+	// preStartHook holds the latest state of WithPreStartHook
+	preStartHook func(ctx context.Context, mod api.Module) error
+	// postStartHook holds the latest state of WithPostStartHook
+	postStartHook func(ctx context.Context, mod api.Module) error
 }
 
 func NewModuleConfig() ModuleConfig {
 	return &moduleConfig{
-		startFunctions: []string{"_start"},
-		environKeys:    map[string]int{},
-		preopenFD:      uint32(3), // after stdin/stdout/stderr
-		preopens:       map[uint32]*wasm.FileEntry{},
-		preopenPaths:   map[string]uint32{},
+		environKeys:  map[string]int{},
+		preopenFD:    uint32(3), // after stdin/stdout/stderr
+		preopens:     map[uint32]*wasm.FileEntry{},
+		preopenPaths: map[string]uint32{},
 	}
 }
 
@@ -506,6 +1657,13 @@ func (c *moduleConfig) WithEnv(key, value string) ModuleConfig {
 	return &ret
 }
 
+// WithExitCodeAsError implements ModuleConfig.WithExitCodeAsError
+func (c *moduleConfig) WithExitCodeAsError(exitCodeAsError bool) ModuleConfig {
+	ret := *c // copy
+	ret.exitCodeAsError = &exitCodeAsError
+	return &ret
+}
+
 // WithFS implements ModuleConfig.WithFS
 func (c *moduleConfig) WithFS(fs fs.FS) ModuleConfig {
 	ret := *c // copy
@@ -527,6 +1685,17 @@ func (c *moduleConfig) WithImport(oldModule, oldName, newModule, newName string)
 	return &ret
 }
 
+// WithImportMemoryLimits implements ModuleConfig.WithImportMemoryLimits
+func (c *moduleConfig) WithImportMemoryLimits(module, name string, min, max uint32) ModuleConfig {
+	ret := *c // copy
+	ret.importMemoryLimits = make(map[string][2]uint32, len(c.importMemoryLimits)+1)
+	for k, v := range c.importMemoryLimits {
+		ret.importMemoryLimits[k] = v
+	}
+	ret.importMemoryLimits[module+"\x00"+name] = [2]uint32{min, max} // delimit with NUL as module and name can be any UTF-8 characters.
+	return &ret
+}
+
 // WithImportModule implements ModuleConfig.WithImportModule
 func (c *moduleConfig) WithImportModule(oldModule, newModule string) ModuleConfig {
 	ret := *c // copy
@@ -537,6 +1706,17 @@ func (c *moduleConfig) WithImportModule(oldModule, newModule string) ModuleConfi
 	return &ret
 }
 
+// WithLazyImport implements ModuleConfig.WithLazyImport
+func (c *moduleConfig) WithLazyImport(module, name string) ModuleConfig {
+	ret := *c // copy
+	ret.lazyImports = make(map[string]struct{}, len(c.lazyImports)+1)
+	for k := range c.lazyImports {
+		ret.lazyImports[k] = struct{}{}
+	}
+	ret.lazyImports[module+"\x00"+name] = struct{}{} // delimit with NUL as module and name can be any UTF-8 characters.
+	return &ret
+}
+
 // WithName implements ModuleConfig.WithName
 func (c *moduleConfig) WithName(name string) ModuleConfig {
 	ret := *c // copy
@@ -544,10 +1724,31 @@ func (c *moduleConfig) WithName(name string) ModuleConfig {
 	return &ret
 }
 
+// WithNoFSErrno implements ModuleConfig.WithNoFSErrno
+func (c *moduleConfig) WithNoFSErrno(errno uint32) ModuleConfig {
+	ret := *c // copy
+	ret.noFSErrno = &errno
+	return &ret
+}
+
+// WithPostStartHook implements ModuleConfig.WithPostStartHook
+func (c *moduleConfig) WithPostStartHook(hook func(ctx context.Context, mod api.Module) error) ModuleConfig {
+	ret := *c // copy
+	ret.postStartHook = hook
+	return &ret
+}
+
+// WithPreStartHook implements ModuleConfig.WithPreStartHook
+func (c *moduleConfig) WithPreStartHook(hook func(ctx context.Context, mod api.Module) error) ModuleConfig {
+	ret := *c // copy
+	ret.preStartHook = hook
+	return &ret
+}
+
 // WithStartFunctions implements ModuleConfig.WithStartFunctions
 func (c *moduleConfig) WithStartFunctions(startFunctions ...string) ModuleConfig {
-	ret := *c // copy
-	ret.startFunctions = startFunctions
+	ret := *c                                                  // copy
+	ret.startFunctions = append([]string{}, startFunctions...) // non-nil, even if empty
 	return &ret
 }
 
@@ -558,6 +1759,13 @@ func (c *moduleConfig) WithStderr(stderr io.Writer) ModuleConfig {
 	return &ret
 }
 
+// WithStderrRingBuffer implements ModuleConfig.WithStderrRingBuffer
+func (c *moduleConfig) WithStderrRingBuffer(size int) ModuleConfig {
+	ret := *c // copy
+	ret.stderrRingSize = size
+	return &ret
+}
+
 // WithStdin implements ModuleConfig.WithStdin
 func (c *moduleConfig) WithStdin(stdin io.Reader) ModuleConfig {
 	ret := *c // copy
@@ -572,6 +1780,20 @@ func (c *moduleConfig) WithStdout(stdout io.Writer) ModuleConfig {
 	return &ret
 }
 
+// WithStdoutRingBuffer implements ModuleConfig.WithStdoutRingBuffer
+func (c *moduleConfig) WithStdoutRingBuffer(size int) ModuleConfig {
+	ret := *c // copy
+	ret.stdoutRingSize = size
+	return &ret
+}
+
+// WithTrapSink implements ModuleConfig.WithTrapSink
+func (c *moduleConfig) WithTrapSink(trapSink func(TrapInfo)) ModuleConfig {
+	ret := *c // copy
+	ret.trapSink = trapSink
+	return &ret
+}
+
 // WithWorkDirFS implements ModuleConfig.WithWorkDirFS
 func (c *moduleConfig) WithWorkDirFS(fs fs.FS) ModuleConfig {
 	ret := *c // copy
@@ -579,8 +1801,30 @@ func (c *moduleConfig) WithWorkDirFS(fs fs.FS) ModuleConfig {
 	return &ret
 }
 
+// WithWriter implements ModuleConfig.WithWriter
+func (c *moduleConfig) WithWriter(fd uint32, w io.Writer) ModuleConfig {
+	ret := *c // copy
+	ret.writers = make(map[uint32]io.Writer, len(c.writers)+1)
+	for k, v := range c.writers {
+		ret.writers[k] = v
+	}
+	ret.writers[fd] = w
+	return &ret
+}
+
 // setFS maps a path to a file-system. This is only used for base paths: "/" and ".".
 func (c *moduleConfig) setFS(path string, fs fs.FS) {
+	// Copy the maps, as they are shared with the config this was copied from.
+	preopens := make(map[uint32]*wasm.FileEntry, len(c.preopens)+1)
+	for fd, entry := range c.preopens {
+		preopens[fd] = entry
+	}
+	preopenPaths := make(map[string]uint32, len(c.preopenPaths)+1)
+	for p, fd := range c.preopenPaths {
+		preopenPaths[p] = fd
+	}
+	c.preopens, c.preopenPaths = preopens, preopenPaths
+
 	// Check to see if this key already exists and update it.
 	entry := &wasm.FileEntry{Path: path, FS: fs}
 	if fd, ok := c.preopenPaths[path]; ok {
@@ -614,7 +1858,10 @@ func (c *moduleConfig) toSysContext() (sys *wasm.SysContext, err error) {
 	// Ensure no-one set a nil FD. We do this here instead of at the call site to allow chaining as nil is unexpected.
 	rootFD := uint32(0) // zero is invalid
 	setWorkDirFS := false
-	preopens := c.preopens
+	preopens := make(map[uint32]*wasm.FileEntry, len(c.preopens)+1) // copy, as the SysContext adds opened files.
+	for fd, entry := range c.preopens {
+		preopens[fd] = entry
+	}
 	for fd, entry := range preopens {
 		if entry.FS == nil {
 			err = fmt.Errorf("FS for %s is nil", entry.Path)
@@ -631,12 +1878,38 @@ func (c *moduleConfig) toSysContext() (sys *wasm.SysContext, err error) {
 		preopens[c.preopenFD] = &wasm.FileEntry{Path: ".", FS: preopens[rootFD].FS}
 	}
 
-	return wasm.NewSysContext(math.MaxUint32, c.args, environ, c.stdin, c.stdout, c.stderr, preopens)
+	stdout, stderr := c.stdout, c.stderr
+	if c.stdoutRingSize > 0 {
+		stdout = wasm.NewRingBuffer(stdout, c.stdoutRingSize)
+	}
+	if c.stderrRingSize > 0 {
+		stderr = wasm.NewRingBuffer(stderr, c.stderrRingSize)
+	}
+
+	if sys, err = wasm.NewSysContext(math.MaxUint32, c.args, environ, c.stdin, stdout, stderr, preopens); err != nil {
+		return
+	}
+	if c.noFSErrno != nil && len(preopens) == 0 {
+		sys.SetNoFSErrno(*c.noFSErrno)
+	}
+	for fd, w := range c.writers {
+		if fd <= 2 {
+			return nil, fmt.Errorf("writer for fd %d is invalid: fd 0, 1 and 2 are stdin, stdout and stderr", fd)
+		} else if _, ok := preopens[fd]; ok {
+			return nil, fmt.Errorf("writer for fd %d is invalid: fd is pre-opened", fd)
+		} else if w == nil {
+			return nil, fmt.Errorf("writer for fd %d is nil", fd)
+		}
+		sys.SetWriter(fd, w)
+	}
+	return
 }
 
-func (c *moduleConfig) replaceImports(module *wasm.Module) *wasm.Module {
+// replaceImports returns a copy of the module with imports replaced per WithImportModule and WithImport, or the module
+// itself if none are. This errs if the result of a replacement is itself replaced, as which applies first is undefined.
+func (c *moduleConfig) replaceImports(module *wasm.Module) (*wasm.Module, error) {
 	if (c.replacedImportModules == nil && c.replacedImports == nil) || module.ImportSection == nil {
-		return module
+		return module, nil
 	}
 
 	changed := false
@@ -645,41 +1918,86 @@ func (c *moduleConfig) replaceImports(module *wasm.Module) *wasm.Module {
 	replacedImports := make([]*wasm.Import, len(module.ImportSection))
 	copy(replacedImports, module.ImportSection)
 
-	// First, replace any import.Module
-	for oldModule, newModule := range c.replacedImportModules {
-		for i, imp := range replacedImports {
-			if imp.Module == oldModule {
-				changed = true
-				cp := *imp // shallow copy
-				cp.Module = newModule
-				replacedImports[i] = &cp
-			} else {
-				replacedImports[i] = imp
+	for i, imp := range module.ImportSection {
+		importModule, importName := imp.Module, imp.Name
+
+		// First, replace any import.Module
+		if newModule, ok := c.replacedImportModules[importModule]; ok {
+			if next, ok := c.replacedImportModules[newModule]; ok && newModule != importModule {
+				return nil, errorAmbiguousImport(imp, i, "WithImportModule",
+					importModule, newModule, next)
 			}
+			importModule = newModule
 		}
-	}
 
-	// Now, replace any import.Module+import.Name
-	for oldImport, newImport := range c.replacedImports {
-		for i, imp := range replacedImports {
-			nulIdx := strings.IndexByte(oldImport, 0)
-			oldModule := oldImport[0:nulIdx]
-			oldName := oldImport[nulIdx+1:]
-			if imp.Module == oldModule && imp.Name == oldName {
-				changed = true
-				cp := *imp // shallow copy
-				cp.Module = newImport[0]
-				cp.Name = newImport[1]
-				replacedImports[i] = &cp
-			} else {
-				replacedImports[i] = imp
+		// Now, replace any import.Module+import.Name
+		if newImport, ok := c.replacedImports[importModule+"\x00"+importName]; ok {
+			if next, ok := c.replacedImports[newImport[0]+"\x00"+newImport[1]]; ok && newImport != [2]string{importModule, importName} {
+				return nil, errorAmbiguousImport(imp, i, "WithImport",
+					importModule+"."+importName, newImport[0]+"."+newImport[1], next[0]+"."+next[1])
 			}
+			importModule, importName = newImport[0], newImport[1]
+		}
+
+		if importModule != imp.Module || importName != imp.Name {
+			changed = true
+			cp := *imp // shallow copy
+			cp.Module = importModule
+			cp.Name = importName
+			replacedImports[i] = &cp
 		}
 	}
 
 	if !changed {
-		return module
+		return module, nil
 	}
 	ret.ImportSection = replacedImports
-	return &ret
+	return &ret, nil
+}
+
+func errorAmbiguousImport(i *wasm.Import, idx int, option, old, replaced, next string) error {
+	return fmt.Errorf("import[%d] %s[%s.%s]: ambiguous %s: %s is replaced with %s, which is also replaced with %s",
+		idx, wasm.ExternTypeName(i.Type), i.Module, i.Name, option, old, replaced, next)
+}
+
+// overrideImportMemoryLimits returns a copy of the module with the limits of any memory import replaced per
+// WithImportMemoryLimits, or the module itself if there are none.
+func (c *moduleConfig) overrideImportMemoryLimits(module *wasm.Module) (*wasm.Module, error) {
+	if c.importMemoryLimits == nil {
+		return module, nil
+	}
+
+	var ret *wasm.Module
+	for idx, imp := range module.ImportSection {
+		if imp.Type != wasm.ExternTypeMemory {
+			continue
+		}
+		limits, ok := c.importMemoryLimits[imp.Module+"\x00"+imp.Name]
+		if !ok {
+			continue
+		}
+
+		min, max := limits[0], limits[1]
+		if min > max {
+			return nil, fmt.Errorf("import[%d] memory[%s.%s]: minimum size override %d > maximum %d", idx, imp.Module, imp.Name, min, max)
+		} else if min < imp.DescMem.Min {
+			return nil, fmt.Errorf("import[%d] memory[%s.%s]: minimum size override %d < declared %d", idx, imp.Module, imp.Name, min, imp.DescMem.Min)
+		}
+
+		if ret == nil {
+			cp := *module // shallow copy
+			cp.ImportSection = append([]*wasm.Import{}, module.ImportSection...)
+			ret = &cp
+		}
+		mem := *imp.DescMem // shallow copy
+		mem.Min, mem.Max, mem.IsMaxEncoded = min, max, true
+		cp := *imp // shallow copy
+		cp.DescMem = &mem
+		ret.ImportSection[idx] = &cp
+	}
+
+	if ret == nil {
+		return module, nil
+	}
+	return ret, nil
 }
@@ -5,6 +5,8 @@ package wazero
 const JITSupported = true
 
 // NewRuntimeConfig returns NewRuntimeConfigJIT
+//
+// Note: Calls ignore when their context.Context is done, ex. a deadline. See NewRuntimeConfigInterpreter
 func NewRuntimeConfig() RuntimeConfig {
 	return NewRuntimeConfigJIT()
 }
@@ -1,17 +1,24 @@
 package wazero
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"math"
+	"strings"
 	"testing"
 	"testing/fstest"
 
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
+	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
 )
 
 func TestRuntimeConfig(t *testing.T) {
+	ten := uint32(10)
 	tests := []struct {
 		name     string
 		with     func(RuntimeConfig) RuntimeConfig
@@ -26,6 +33,141 @@ func TestRuntimeConfig(t *testing.T) {
 				memoryLimitPages: 1,
 			},
 		},
+		{
+			name: "WithLabelTracing",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithLabelTracing(true)
+			},
+			expected: &runtimeConfig{
+				labelTracing: true,
+			},
+		},
+		{
+			name: "WithLenientFloatToInt",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithLenientFloatToInt(true)
+			},
+			expected: &runtimeConfig{
+				lenientFloatToInt: true,
+			},
+		},
+		{
+			name: "WithOptimizeBoundsChecks",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithOptimizeBoundsChecks(true)
+			},
+			expected: &runtimeConfig{
+				optimizeBoundsChecks: true,
+			},
+		},
+		{
+			name: "WithPerModuleTypeIDs",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithPerModuleTypeIDs(true)
+			},
+			expected: &runtimeConfig{
+				perModuleTypeIDs: true,
+			},
+		},
+		{
+			name: "WithMaxBlockNesting",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMaxBlockNesting(1000)
+			},
+			expected: &runtimeConfig{
+				maxBlockNesting: 1000,
+			},
+		},
+		{
+			name: "WithMaxFunctionTypes",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMaxFunctionTypes(2)
+			},
+			expected: &runtimeConfig{
+				maxFunctionTypes: 2,
+			},
+		},
+		{
+			name: "WithMaxFunctionTypes above maximum",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMaxFunctionTypes(math.MaxUint32)
+			},
+			expected: &runtimeConfig{
+				maxFunctionTypes: wasm.MaximumFunctionTypes,
+			},
+		},
+		{
+			name: "WithMaxModuleSize",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMaxModuleSize(1024)
+			},
+			expected: &runtimeConfig{
+				maxModuleSize: 1024,
+			},
+		},
+		{
+			name: "WithMaxResults",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithMaxResults(16)
+			},
+			expected: &runtimeConfig{
+				maxResults: 16,
+			},
+		},
+		{
+			name: "WithTableSizeLimit",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithTableSizeLimit(10)
+			},
+			expected: &runtimeConfig{
+				tableSizeLimit: &ten,
+			},
+		},
+		{
+			name: "WithDefaultStartFunctions",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDefaultStartFunctions("_initialize")
+			},
+			expected: &runtimeConfig{
+				defaultStartFunctions: []string{"_initialize"},
+			},
+		},
+		{
+			name: "WithDefaultStartFunctions none",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDefaultStartFunctions()
+			},
+			expected: &runtimeConfig{
+				defaultStartFunctions: []string{},
+			},
+		},
+		{
+			name: "WithDisallowMemoryImport",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDisallowMemoryImport(true)
+			},
+			expected: &runtimeConfig{
+				disallowMemoryImport: true,
+			},
+		},
+		{
+			name: "WithDisallowStartSection",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithDisallowStartSection(true)
+			},
+			expected: &runtimeConfig{
+				disallowStartSection: true,
+			},
+		},
+		{
+			name: "WithResultValidation",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithResultValidation(true)
+			},
+			expected: &runtimeConfig{
+				validateResults: true,
+			},
+		},
 		{
 			name: "bulk-memory-operations",
 			with: func(c RuntimeConfig) RuntimeConfig {
@@ -71,6 +213,15 @@ func TestRuntimeConfig(t *testing.T) {
 				enabledFeatures: wasm.FeatureSignExtensionOps,
 			},
 		},
+		{
+			name: "threads",
+			with: func(c RuntimeConfig) RuntimeConfig {
+				return c.WithFeatureThreads(true)
+			},
+			expected: &runtimeConfig{
+				enabledFeatures: wasm.FeatureThreads,
+			},
+		},
 		{
 			name: "REC-wasm-core-1-20191205",
 			with: func(c RuntimeConfig) RuntimeConfig {
@@ -131,6 +282,9 @@ func TestRuntimeConfig(t *testing.T) {
 }
 
 func TestRuntimeConfig_FeatureToggle(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name          string
 		feature       wasm.Features
@@ -177,6 +331,14 @@ func TestRuntimeConfig_FeatureToggle(t *testing.T) {
 				return c.WithFeatureSignExtensionOps(v)
 			},
 		},
+		{
+			name:          "threads",
+			feature:       wasm.FeatureThreads,
+			expectDefault: false,
+			setFeature: func(c RuntimeConfig, v bool) RuntimeConfig {
+				return c.WithFeatureThreads(v)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -201,6 +363,51 @@ func TestRuntimeConfig_FeatureToggle(t *testing.T) {
 	}
 }
 
+func TestRuntimeConfig_WithFeaturesFromEnv(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	tests := []struct {
+		name     string
+		env      string
+		expected wasm.Features
+	}{
+		{
+			name:     "empty",
+			expected: wasm.Features20191205,
+		},
+		{
+			name:     "features",
+			env:      "multi-value,sign-extension-ops",
+			expected: wasm.Features20191205 | wasm.FeatureMultiValue | wasm.FeatureSignExtensionOps,
+		},
+		{
+			name:     "reference-types enables bulk-memory-operations",
+			env:      "reference-types",
+			expected: wasm.Features20191205 | wasm.FeatureReferenceTypes | wasm.FeatureBulkMemoryOperations,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv("WAZERO_FEATURES", tc.env)
+
+			c, err := NewRuntimeConfig().WithFeaturesFromEnv()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, c.(*runtimeConfig).enabledFeatures)
+		})
+	}
+
+	t.Run("unknown feature", func(t *testing.T) {
+		t.Setenv("WAZERO_FEATURES", "multi-value,simd")
+
+		_, err := NewRuntimeConfig().WithFeaturesFromEnv()
+		require.EqualError(t, err, `invalid WAZERO_FEATURES: unknown feature: "simd"`)
+	})
+}
+
 func TestModuleConfig(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -341,6 +548,26 @@ func TestModuleConfig(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "WithImportMemoryLimits",
+			with: func(c ModuleConfig) ModuleConfig {
+				return c.WithImportMemoryLimits("env", "memory", 1, 2).
+					WithImportMemoryLimits("env", "memory", 1, 4)
+			},
+			expected: &moduleConfig{
+				importMemoryLimits: map[string][2]uint32{"env\x00memory": {1, 4}},
+			},
+		},
+		{
+			name: "WithLazyImport",
+			with: func(c ModuleConfig) ModuleConfig {
+				return c.WithLazyImport("plugin", "run").
+					WithLazyImport("plugin", "stop")
+			},
+			expected: &moduleConfig{
+				lazyImports: map[string]struct{}{"plugin\x00run": {}, "plugin\x00stop": {}},
+			},
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -572,7 +799,8 @@ func TestModuleConfig_replaceImports(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			actual := tc.config.(*moduleConfig).replaceImports(tc.input)
+			actual, err := tc.config.(*moduleConfig).replaceImports(tc.input)
+			require.NoError(t, err)
 			if tc.expectSame {
 				require.Same(t, tc.input, actual)
 			} else {
@@ -583,6 +811,81 @@ func TestModuleConfig_replaceImports(t *testing.T) {
 	}
 }
 
+func TestModuleConfig_replaceImports_Ambiguous(t *testing.T) {
+	imports := func(names ...string) *wasm.Module {
+		m := &wasm.Module{}
+		for _, n := range names {
+			dot := strings.IndexByte(n, '.')
+			m.ImportSection = append(m.ImportSection, &wasm.Import{Type: wasm.ExternTypeFunc, Module: n[:dot], Name: n[dot+1:]})
+		}
+		return m
+	}
+
+	tests := []struct {
+		name        string
+		config      ModuleConfig
+		input       *wasm.Module
+		expected    []string
+		expectedErr string
+	}{
+		{
+			name:        "WithImportModule chained",
+			config:      NewModuleConfig().WithImportModule("a", "b").WithImportModule("b", "c"),
+			input:       imports("a.f"),
+			expectedErr: "import[0] func[a.f]: ambiguous WithImportModule: a is replaced with b, which is also replaced with c",
+		},
+		{
+			name:        "WithImport chained",
+			config:      NewModuleConfig().WithImport("a", "f", "b", "f").WithImport("b", "f", "c", "f"),
+			input:       imports("x.y", "a.f"),
+			expectedErr: "import[1] func[a.f]: ambiguous WithImport: a.f is replaced with b.f, which is also replaced with c.f",
+		},
+		{
+			name:     "WithImportModule chained, but not imported",
+			config:   NewModuleConfig().WithImportModule("a", "b").WithImportModule("b", "c"),
+			input:    imports("b.f"),
+			expected: []string{"c.f"},
+		},
+		{
+			name:     "same name in different modules",
+			config:   NewModuleConfig().WithImportModule("a", "b"),
+			input:    imports("a.f", "b.f"),
+			expected: []string{"b.f", "b.f"},
+		},
+		{
+			name:     "WithImportModule then WithImport",
+			config:   NewModuleConfig().WithImportModule("a", "b").WithImport("b", "f", "c", "f"),
+			input:    imports("a.f"),
+			expected: []string{"c.f"},
+		},
+		{
+			name:     "replaced with itself",
+			config:   NewModuleConfig().WithImportModule("a", "a").WithImport("a", "f", "a", "f"),
+			input:    imports("a.f"),
+			expected: []string{"a.f"},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := tc.config.(*moduleConfig).replaceImports(tc.input)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var names []string
+			for _, imp := range actual.ImportSection {
+				names = append(names, imp.Module+"."+imp.Name)
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+}
+
 func TestModuleConfig_toSysContext(t *testing.T) {
 	testFS := fstest.MapFS{}
 	testFS2 := fstest.MapFS{}
@@ -801,6 +1104,52 @@ func TestModuleConfig_toSysContext(t *testing.T) {
 	}
 }
 
+// TestModuleConfig_toSysContext_PreopensNotShared ensures configs derived from another don't change its pre-opens.
+func TestModuleConfig_toSysContext_PreopensNotShared(t *testing.T) {
+	testFS, testFS2 := fstest.MapFS{"a": {}}, fstest.MapFS{"b": {}}
+
+	root := NewModuleConfig().WithFS(testFS)
+	_ = root.WithWorkDirFS(testFS2)
+
+	for i := 0; i < 2; i++ { // toSysContext doesn't change the config either.
+		sys, err := root.(*moduleConfig).toSysContext()
+		require.NoError(t, err)
+		workDir, ok := sys.OpenedFile(4)
+		require.True(t, ok)
+		require.Equal(t, &wasm.FileEntry{Path: ".", FS: testFS}, workDir)
+		_, ok = sys.OpenedFile(5)
+		require.False(t, ok)
+	}
+}
+
+func TestModuleConfig_toSysContext_RingBuffer(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	sys, err := NewModuleConfig().
+		WithStdout(&stdout).WithStdoutRingBuffer(4).
+		WithStderr(&stderr).WithStderrRingBuffer(8).(*moduleConfig).toSysContext()
+	require.NoError(t, err)
+
+	_, err = sys.Stdout().Write([]byte("hello world"))
+	require.NoError(t, err)
+	_, err = sys.Stderr().Write([]byte("warning: "))
+	require.NoError(t, err)
+	_, err = sys.Stderr().Write([]byte("disk full"))
+	require.NoError(t, err)
+
+	// The configured writers receive everything, but only the tail is retained.
+	require.Equal(t, "hello world", stdout.String())
+	require.Equal(t, "warning: disk full", stderr.String())
+	require.Equal(t, []byte("orld"), sys.RecentStdout())
+	require.Equal(t, []byte("isk full"), sys.RecentStderr())
+
+	t.Run("not configured", func(t *testing.T) {
+		sys, err := NewModuleConfig().(*moduleConfig).toSysContext()
+		require.NoError(t, err)
+		require.Nil(t, sys.RecentStdout())
+		require.Nil(t, sys.RecentStderr())
+	})
+}
+
 func TestModuleConfig_toSysContext_Errors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -842,6 +1191,21 @@ func TestModuleConfig_toSysContext_Errors(t *testing.T) {
 			input:       NewModuleConfig().WithWorkDirFS(nil),
 			expectedErr: "FS for . is nil",
 		},
+		{
+			name:        "WithWriter - stdout",
+			input:       NewModuleConfig().WithWriter(1, io.Discard),
+			expectedErr: "writer for fd 1 is invalid: fd 0, 1 and 2 are stdin, stdout and stderr",
+		},
+		{
+			name:        "WithWriter - pre-opened",
+			input:       NewModuleConfig().WithFS(fstest.MapFS{}).WithWriter(3, io.Discard),
+			expectedErr: "writer for fd 3 is invalid: fd is pre-opened",
+		},
+		{
+			name:        "WithWriter - nil",
+			input:       NewModuleConfig().WithWriter(3, nil),
+			expectedErr: "writer for fd 3 is nil",
+		},
 	}
 	for _, tt := range tests {
 		tc := tt
@@ -883,3 +1247,457 @@ func TestCompiledCode_Close(t *testing.T) {
 		require.Zero(t, len(e.cachedModules))
 	}
 }
+
+func TestCompiledCode_ID(t *testing.T) {
+	r := NewRuntime()
+	source := []byte(`(module (func $f) (export "a" (func $f)))`)
+
+	compile := func(source []byte) CompiledCode {
+		compiled, err := r.CompileModule(testCtx, source)
+		require.NoError(t, err)
+		t.Cleanup(func() { compiled.Close(testCtx) })
+		return compiled
+	}
+
+	c1, c2 := compile(source), compile(source)
+	require.Equal(t, c1.ID(), c2.ID())
+	require.Equal(t, 64, len(c1.ID())) // hex-encoded SHA-256
+
+	// Only the export name differs by one byte.
+	modified := compile([]byte(`(module (func $f) (export "b" (func $f)))`))
+	require.NotEqual(t, c1.ID(), modified.ID())
+}
+
+func TestCompiledCode_WASIModuleKind(t *testing.T) {
+	tests := []struct {
+		name     string
+		source   string
+		expected WASIModuleKind
+	}{
+		{
+			name:     "command",
+			source:   `(module (func $main) (export "_start" (func $main)))`,
+			expected: WASIModuleKindCommand,
+		},
+		{
+			name:     "reactor",
+			source:   `(module (func $init) (export "_initialize" (func $init)))`,
+			expected: WASIModuleKindReactor,
+		},
+		{
+			name:     "neither",
+			source:   `(module (func $f) (export "f" (func $f)))`,
+			expected: WASIModuleKindUnknown,
+		},
+		{
+			name:     "neither - not a function",
+			source:   `(module (memory 1) (export "_start" (memory 0)))`,
+			expected: WASIModuleKindUnknown,
+		},
+		{
+			name:     "both",
+			source:   `(module (func $f) (export "_start" (func $f)) (export "_initialize" (func $f)))`,
+			expected: WASIModuleKindAmbiguous,
+		},
+	}
+
+	r := NewRuntime()
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := r.CompileModule(testCtx, []byte(tc.source))
+			require.NoError(t, err)
+			defer compiled.Close(testCtx)
+			require.Equal(t, tc.expected, compiled.WASIModuleKind())
+		})
+	}
+}
+
+func TestWASIModuleKind_String(t *testing.T) {
+	require.Equal(t, "unknown", WASIModuleKindUnknown.String())
+	require.Equal(t, "command", WASIModuleKindCommand.String())
+	require.Equal(t, "reactor", WASIModuleKindReactor.String())
+	require.Equal(t, "ambiguous", WASIModuleKindAmbiguous.String())
+}
+
+func TestCompiledCode_FunctionBody(t *testing.T) {
+	r := NewRuntime()
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+	(import "env" "f" (func $env.f))
+	(func $one (result i32) i32.const 1)
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	body, ok := compiled.FunctionBody(1)
+	require.True(t, ok)
+	require.Equal(t, []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeEnd}, body)
+
+	// The result is a copy, so changing it doesn't affect the compiled code.
+	body[1] = 2
+	body, _ = compiled.FunctionBody(1)
+	require.Equal(t, []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeEnd}, body)
+
+	_, ok = compiled.FunctionBody(0) // imported
+	require.False(t, ok)
+
+	_, ok = compiled.FunctionBody(2) // out of range
+	require.False(t, ok)
+
+	host, err := r.NewModuleBuilder("env").ExportFunction("f", func() {}).Build(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	_, ok = host.FunctionBody(0)
+	require.False(t, ok)
+}
+
+func TestCompiledCode_FunctionInstructionCount(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithFeatureSignExtensionOps(true))
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+	(import "env" "f" (func $env.f))
+	(func (param i32) (result i32) local.get 0 i32.extend8_s)
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	// pick, sign-extend, drop and return. See wazeroir.TestCompile_SignExtensionOps
+	require.Equal(t, 4, compiled.FunctionInstructionCount(1))
+
+	require.Zero(t, compiled.FunctionInstructionCount(0)) // imported
+	require.Zero(t, compiled.FunctionInstructionCount(2)) // out of range
+
+	host, err := r.NewModuleBuilder("env").ExportFunction("f", func() {}).Build(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	require.Zero(t, host.FunctionInstructionCount(0))
+}
+
+func TestCompiledCode_ImportedGlobals(t *testing.T) {
+	r := NewRuntime()
+
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{}},
+		ImportSection: []*wasm.Import{
+			{Type: wasm.ExternTypeFunc, Module: "env", Name: "f", DescFunc: 0},
+			{Type: wasm.ExternTypeGlobal, Module: "env", Name: "counter", DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeI32, Mutable: true}},
+			{Type: wasm.ExternTypeGlobal, Module: "env", Name: "pi", DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeF64}},
+		},
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	require.Equal(t, []ImportedGlobal{
+		{Module: "env", Name: "counter", ValType: api.ValueTypeI32, Mutable: true},
+		{Module: "env", Name: "pi", ValType: api.ValueTypeF64},
+	}, compiled.ImportedGlobals())
+
+	none, err := r.CompileModule(testCtx, []byte(`(module (import "env" "f" (func $env.f)))`))
+	require.NoError(t, err)
+	defer none.Close(testCtx)
+
+	require.Equal(t, []ImportedGlobal{}, none.ImportedGlobals())
+}
+
+func TestCompiledCode_DuplicateImports(t *testing.T) {
+	r := NewRuntime()
+
+	i32 := &wasm.GlobalType{ValType: wasm.ValueTypeI32}
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{}, {Params: []wasm.ValueType{wasm.ValueTypeI32}}},
+		ImportSection: []*wasm.Import{
+			{Type: wasm.ExternTypeFunc, Module: "env", Name: "f", DescFunc: 0},
+			{Type: wasm.ExternTypeGlobal, Module: "env", Name: "g", DescGlobal: i32},
+			{Type: wasm.ExternTypeFunc, Module: "env", Name: "f", DescFunc: 0},   // identical to import[0]
+			{Type: wasm.ExternTypeFunc, Module: "env", Name: "g", DescFunc: 1},   // a function, unlike import[1]
+			{Type: wasm.ExternTypeFunc, Module: "other", Name: "f", DescFunc: 1}, // a different module
+		},
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	require.Equal(t, []DuplicateImport{
+		{Module: "env", Name: "f", Indexes: []uint32{0, 2}},
+		{Module: "env", Name: "g", Indexes: []uint32{1, 3}, Conflicting: true},
+	}, compiled.DuplicateImports())
+
+	none, err := r.CompileModule(testCtx, []byte(`(module (import "env" "f" (func $env.f)))`))
+	require.NoError(t, err)
+	defer none.Close(testCtx)
+
+	require.Equal(t, []DuplicateImport{}, none.DuplicateImports())
+}
+
+func TestCompiledCode_ElementSegments(t *testing.T) {
+	r := NewRuntime()
+
+	f0, f1 := uint32(0), uint32(1)
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}, {Body: []byte{wasm.OpcodeEnd}}},
+		TableSection:    []*wasm.Table{{Min: 4, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{
+			{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: leb128.EncodeInt32(2)},
+				Init:       []*wasm.Index{&f1, &f0},
+				Type:       wasm.RefTypeFuncref,
+			},
+		},
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	require.Equal(t, []ElementSegment{
+		{Mode: "active", Type: "funcref", Offset: 2, Init: []*uint32{&f1, &f0}},
+	}, compiled.ElementSegments())
+
+	t.Run("expressions", func(t *testing.T) {
+		// The binary encoder only supports active segments of function indexes, so this uses the decoded form of
+		// segments of `ref.func` and `ref.null` expressions.
+		global := uint32(0)
+		code := &compiledCode{module: &wasm.Module{ElementSection: []*wasm.ElementSegment{
+			{
+				OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeGlobalGet, Data: leb128.EncodeUint32(global)},
+				TableIndex: 1,
+				Init:       []*wasm.Index{nil, &f1},
+				Type:       wasm.RefTypeFuncref,
+			},
+			{Init: []*wasm.Index{&f0}, Type: wasm.RefTypeFuncref, Mode: wasm.ElementModePassive},
+			{Init: []*wasm.Index{nil}, Type: wasm.RefTypeExternref, Mode: wasm.ElementModeDeclarative},
+		}}}
+
+		require.Equal(t, []ElementSegment{
+			{Mode: "active", Type: "funcref", TableIndex: 1, OffsetGlobal: &global, Init: []*uint32{nil, &f1}},
+			{Mode: "passive", Type: "funcref", Init: []*uint32{&f0}},
+			{Mode: "declarative", Type: "externref", Init: []*uint32{nil}},
+		}, code.ElementSegments())
+	})
+
+	none, err := r.CompileModule(testCtx, []byte(`(module)`))
+	require.NoError(t, err)
+	defer none.Close(testCtx)
+
+	require.Equal(t, []ElementSegment{}, none.ElementSegments())
+}
+
+func TestCompiledCode_Types(t *testing.T) {
+	r := NewRuntime()
+
+	i32, i64, f64 := api.ValueTypeI32, api.ValueTypeI64, api.ValueTypeF64
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}},
+			{},
+			{Params: []wasm.ValueType{f64}},
+			{Results: []wasm.ValueType{i64}}, // only used by an import
+		},
+		ImportSection:   []*wasm.Import{{Type: wasm.ExternTypeFunc, Module: "env", Name: "now", DescFunc: 3}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	types := compiled.Types()
+	require.Equal(t, []*FunctionType{
+		{Params: []api.ValueType{i32, i32}, Results: []api.ValueType{i32}},
+		{Params: []api.ValueType{}, Results: []api.ValueType{}},
+		{Params: []api.ValueType{f64}, Results: []api.ValueType{}},
+		{Params: []api.ValueType{}, Results: []api.ValueType{i64}},
+	}, types)
+
+	// The result is a copy, so changing it doesn't affect the module.
+	types[0].Params[0] = i64
+	require.Equal(t, i32, compiled.Types()[0].Params[0])
+
+	empty, err := r.CompileModule(testCtx, []byte(`(module)`))
+	require.NoError(t, err)
+	defer empty.Close(testCtx)
+
+	require.Equal(t, []*FunctionType{}, empty.Types())
+}
+
+func TestRuntimeConfig_EngineKind(t *testing.T) {
+	require.Equal(t, EngineKindInterpreter, NewRuntimeConfigInterpreter().EngineKind())
+	// Other settings don't change the kind.
+	require.Equal(t, EngineKindInterpreter, NewRuntimeConfigInterpreter().WithWasmCore2().EngineKind())
+
+	if JITSupported {
+		require.Equal(t, EngineKindJIT, NewRuntimeConfigJIT().EngineKind())
+		require.Equal(t, EngineKindJIT, NewRuntimeConfig().EngineKind())
+	} else {
+		require.Equal(t, EngineKindInterpreter, NewRuntimeConfig().EngineKind())
+	}
+}
+
+func TestCompiledCode_UsesFloatingPoint(t *testing.T) {
+	r := NewRuntime()
+
+	tests := []struct {
+		name     string
+		source   string
+		expected bool
+	}{
+		{
+			name:   "integers only",
+			source: `(module (func (param i32 i32) (result i32) local.get 0 local.get 1 i32.add))`,
+		},
+		{
+			name:     "float signature without float arithmetic",
+			source:   `(module (import "env" "log" (func $log (param f64))) (func $run (param f64) local.get 0 call $log))`,
+			expected: true,
+		},
+		{
+			name:     "float arithmetic",
+			source:   `(module (func (result f32) f32.const 1))`,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			compiled, err := r.CompileModule(testCtx, []byte(tc.source))
+			require.NoError(t, err)
+			defer compiled.Close(testCtx)
+
+			require.Equal(t, tc.expected, compiled.UsesFloatingPoint())
+		})
+	}
+}
+
+func TestCompiledCode_AssertExports(t *testing.T) {
+	r := NewRuntime()
+
+	i32, i64 := api.ValueTypeI32, api.ValueTypeI64
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32, i32}, Results: []wasm.ValueType{i32}}, {}},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeEnd}},
+		},
+		MemorySection: &wasm.Memory{Min: 1},
+		GlobalSection: []*wasm.Global{
+			{Type: &wasm.GlobalType{ValType: i64}, Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI64Const, Data: []byte{0}}},
+		},
+		ExportSection: []*wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "add", Index: 0},
+			{Type: wasm.ExternTypeFunc, Name: "noop", Index: 1},
+			{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0},
+			{Type: wasm.ExternTypeGlobal, Name: "zero", Index: 0},
+		},
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	add := &ExportSignature{Params: []api.ValueType{i32, i32}, Results: []api.ValueType{i32}}
+	tests := []struct {
+		name        string
+		expected    map[string]*ExportSignature
+		expectedErr string
+	}{
+		{
+			name: "matches",
+			expected: map[string]*ExportSignature{
+				"add":    add,
+				"noop":   {Kind: "func"},
+				"memory": {Kind: "memory"},
+				"zero":   {Kind: "global", ValType: i64},
+			},
+		},
+		{
+			name:     "matches functions only",
+			expected: map[string]*ExportSignature{"add": add, "noop": {}},
+		},
+		{
+			name: "nil signature",
+			expected: map[string]*ExportSignature{
+				"add":     nil,
+				"noop":    {},
+				"missing": nil,
+			},
+			expectedErr: `exports don't match:
+	expected export[add] is nil
+	expected export[missing] is nil`,
+		},
+		{
+			name: "signature mismatch",
+			expected: map[string]*ExportSignature{
+				"add":  {Params: []api.ValueType{i32}, Results: []api.ValueType{i32}},
+				"noop": {},
+			},
+			expectedErr: `exports don't match:
+	export func[add] signature mismatch: i32i32_i32 != i32_i32`,
+		},
+		{
+			name: "global mismatch",
+			expected: map[string]*ExportSignature{
+				"add":  add,
+				"noop": {},
+				"zero": {Kind: "global", ValType: i32, Mutable: true},
+			},
+			expectedErr: `exports don't match:
+	export global[zero] type mismatch: i64 mutable=false != i32 mutable=true`,
+		},
+		{
+			name: "missing, extra and wrong kind",
+			expected: map[string]*ExportSignature{
+				"add":    add,
+				"sub":    add,
+				"memory": {Kind: "global", ValType: i32},
+			},
+			expectedErr: `exports don't match:
+	export memory[memory] is not a global
+	missing export func[sub]
+	unexpected export func[noop]`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := compiled.AssertExports(tc.expected)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestCompiledCode_DylinkInfo(t *testing.T) {
+	r := NewRuntime()
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+	require.Nil(t, compiled.DylinkInfo())
+
+	source := append(binary.EncodeModule(&wasm.Module{}),
+		wasm.SectionIDCustom, 0x18, // 24 bytes in this section
+		0x08, 'd', 'y', 'l', 'i', 'n', 'k', '.', '0',
+		1, 5, 0x80, 0x08, 2, 4, 0, // memory info: size 1024, alignment 2, table size 4, alignment 0
+		2, 6, 1, 4, 'l', 'i', 'b', 'c', // needed: libc
+	)
+	compiled, err = r.CompileModule(testCtx, source)
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+	require.Equal(t, &DylinkInfo{
+		MemorySize:      1024,
+		MemoryAlignment: 2,
+		TableSize:       4,
+		Needed:          []string{"libc"},
+	}, compiled.DylinkInfo())
+}
@@ -0,0 +1,35 @@
+//go:build wazero_core1
+
+package wazero
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// TestRuntime_Core1Only ensures the "wazero_core1" build tag runs WebAssembly 1.0 (20191205) modules, and fails
+// clearly on any other feature, even if enabled in RuntimeConfig.
+func TestRuntime_Core1Only(t *testing.T) {
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithWasmCore2())
+
+	t.Run("core-1 runs", func(t *testing.T) {
+		m, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $core1
+  (func $add (param i32 i32) (result i32) local.get 0 local.get 1 i32.add)
+  (export "add" (func $add))
+)`))
+		require.NoError(t, err)
+		defer m.Close(testCtx)
+
+		results, err := m.ExportedFunction("add").Call(testCtx, 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3}, results)
+	})
+
+	t.Run("other features err", func(t *testing.T) {
+		_, err := r.CompileModule(testCtx, []byte(`(module $sign_extension
+  (func (param i32) (result i32) local.get 0 i32.extend8_s)
+)`))
+		require.EqualError(t, err, `2:46: i32.extend8_s invalid as feature "sign-extension-ops" is disabled in module.func[0]`)
+	})
+}
@@ -1,3 +1,5 @@
+//go:build !wazero_core1
+
 package multiple_results
 
 import (
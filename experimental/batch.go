@@ -0,0 +1,45 @@
+package experimental
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// BatchFunction returns a host function for wazero.ModuleBuilder ExportFunction, which calls fn for each element of a
+// batch the guest accumulated in its memory. This amortizes the cost of crossing between the guest and the host, which
+// dominates when a guest makes many tiny host calls, ex. a callback per element of an array.
+//
+// The host function has the signature (param $offset i32) (param $count i32): the guest writes count elements of
+// elementSize bytes each, back to back from offset, then makes one call to process them all.
+//
+// Ex. To sum int32 elements, instead of exporting a function called per element:
+//	sum := experimental.BatchFunction(4, func(ctx context.Context, m api.Module, element []byte) {
+//		total += int32(binary.LittleEndian.Uint32(element))
+//	})
+//	env, err := r.NewModuleBuilder("env").ExportFunction("sum", sum).Instantiate(ctx)
+//
+// Notes:
+// * A batch of zero elements returns without reading memory, so its offset can be anything.
+// * The call traps if the batch is out of range of the memory, before fn is called for any element.
+// * element is a view of the memory, so is only valid until fn returns. Copy it to retain it.
+func BatchFunction(elementSize uint32, fn func(ctx context.Context, m api.Module, element []byte)) interface{} {
+	return func(ctx context.Context, m api.Module, offset, count uint32) {
+		if count == 0 {
+			return
+		}
+		size := uint64(count) * uint64(elementSize)
+		var batch []byte
+		var ok bool
+		if size <= uint64(^uint32(0)) {
+			batch, ok = ReadAlias(ctx, m.Memory(), offset, uint32(size))
+		}
+		if !ok {
+			panic(fmt.Errorf("batch of %d elements at offset %d is out of memory range", count, offset))
+		}
+		for i := uint32(0); i < count; i++ {
+			fn(ctx, m, batch[i*elementSize:(i+1)*elementSize])
+		}
+	}
+}
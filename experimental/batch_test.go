@@ -0,0 +1,138 @@
+package experimental_test
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	wasmbinary "github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// batchWasm exports functions which add 1 to $n to a total kept by the host. "per_element" calls env.add for each
+// value, while "batched" writes them to memory as int32s and calls env.add_batch once.
+var batchWasm = func() []byte {
+	i32 := wasm.ValueTypeI32
+	return wasmbinary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32, i32}},
+		},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "add", Type: wasm.ExternTypeFunc, DescFunc: 0},
+			{Module: "env", Name: "add_batch", Type: wasm.ExternTypeFunc, DescFunc: 1},
+		},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			// (block (loop (br_if 1 (i32.eqz (local.get 0)))
+			//   (call $add (local.get 0))
+			//   (local.set 0 (i32.sub (local.get 0) (i32.const 1)))
+			//   (br 0)))
+			{Body: []byte{
+				wasm.OpcodeBlock, 0x40, wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Eqz, wasm.OpcodeBrIf, 1,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalSet, 0,
+				wasm.OpcodeBr, 0,
+				wasm.OpcodeEnd, wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			// (local.set 1 (local.get 0))
+			// (block (loop (br_if 1 (i32.eqz (local.get 1)))
+			//   (local.set 1 (i32.sub (local.get 1) (i32.const 1)))
+			//   (i32.store (i32.shl (local.get 1) (i32.const 2)) (i32.add (local.get 1) (i32.const 1)))
+			//   (br 0)))
+			// (call $add_batch (i32.const 0) (local.get 0))
+			{LocalTypes: []wasm.ValueType{i32}, Body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalSet, 1,
+				wasm.OpcodeBlock, 0x40, wasm.OpcodeLoop, 0x40,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Eqz, wasm.OpcodeBrIf, 1,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalSet, 1,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 2, wasm.OpcodeI32Shl,
+				wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add,
+				wasm.OpcodeI32Store, 2, 0,
+				wasm.OpcodeBr, 0,
+				wasm.OpcodeEnd, wasm.OpcodeEnd,
+				wasm.OpcodeI32Const, 0, wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 1,
+				wasm.OpcodeEnd,
+			}},
+		},
+		MemorySection: &wasm.Memory{Min: 1},
+		ExportSection: []*wasm.Export{
+			{Name: "per_element", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "batched", Type: wasm.ExternTypeFunc, Index: 3},
+		},
+	})
+}()
+
+// instantiateBatch returns the module of batchWasm, and the total its host functions add to.
+func instantiateBatch(ctx context.Context, tb testing.TB, r wazero.Runtime) (api.Module, *int64) {
+	var total int64
+	add := func(v uint32) { total += int64(v) }
+	addBatch := experimental.BatchFunction(4, func(_ context.Context, _ api.Module, element []byte) {
+		total += int64(binary.LittleEndian.Uint32(element))
+	})
+
+	_, err := r.NewModuleBuilder("env").
+		ExportFunction("add", add).
+		ExportFunction("add_batch", addBatch).
+		Instantiate(ctx)
+	require.NoError(tb, err)
+	mod, err := r.InstantiateModuleFromCode(ctx, batchWasm)
+	require.NoError(tb, err)
+	return mod, &total
+}
+
+func TestBatchFunction(t *testing.T) {
+	ctx := context.Background()
+	mod, total := instantiateBatch(ctx, t, wazero.NewRuntime())
+
+	for _, fn := range []string{"per_element", "batched"} {
+		*total = 0
+		_, err := mod.ExportedFunction(fn).Call(ctx, 100)
+		require.NoError(t, err)
+		require.Equal(t, int64(5050), *total, fn)
+	}
+
+	t.Run("zero count doesn't read memory", func(t *testing.T) {
+		called := false
+		fn := experimental.BatchFunction(4, func(context.Context, api.Module, []byte) { called = true })
+		// The offset is out of range, so would trap if read.
+		fn.(func(context.Context, api.Module, uint32, uint32))(ctx, mod, 0xffff_ffff, 0)
+		require.False(t, called)
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		fn := experimental.BatchFunction(4, func(context.Context, api.Module, []byte) { t.Fatal("unexpected call") })
+		batch := fn.(func(context.Context, api.Module, uint32, uint32))
+
+		err := require.CapturePanic(func() { batch(ctx, mod, mod.Memory().Size(ctx)-4, 2) })
+		require.EqualError(t, err, "batch of 2 elements at offset 65532 is out of memory range")
+
+		err = require.CapturePanic(func() { batch(ctx, mod, 0, 0x8000_0000) })
+		require.EqualError(t, err, "batch of 2147483648 elements at offset 0 is out of memory range")
+	})
+}
+
+// BenchmarkBatchFunction compares the cost of calling a host function per element with calling it once per batch.
+func BenchmarkBatchFunction(b *testing.B) {
+	ctx := context.Background()
+	const elements = 1000
+
+	for _, fn := range []string{"per_element", "batched"} {
+		b.Run(fn, func(b *testing.B) {
+			mod, _ := instantiateBatch(ctx, b, wazero.NewRuntime())
+			f := mod.ExportedFunction(fn)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := f.Call(ctx, elements); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package experimental
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// ParamBounds is the inclusive range of values a host function accepts for an integer parameter.
+// See WithParamBounds
+type ParamBounds struct {
+	Min, Max int64
+}
+
+// WithParamBounds returns a host function for wazero.ModuleBuilder ExportFunction, which traps when the guest passes
+// an integer parameter of fn outside its bounds. bounds is keyed by the index of the parameter in fn.
+//
+// Ex. Given a host function which only accepts a file descriptor in [0, 1024), the guest can't pass -1:
+//	fn, err := experimental.WithParamBounds(func(fd int32) uint32 { --snip-- },
+//		map[int]experimental.ParamBounds{0: {Min: 0, Max: 1023}})
+//	if err != nil {
+//		return err
+//	}
+//	_, err = r.NewModuleBuilder("env").ExportFunction("close", fn).Instantiate(ctx)
+//
+// Parameters are compared as the Go type of fn, so an unsigned parameter is never below zero.
+//
+// Note: Validation is opt-in per function, as it calls fn via reflection, which adds overhead to each call.
+func WithParamBounds(fn interface{}, bounds map[int]ParamBounds) (interface{}, error) {
+	fnV := reflect.ValueOf(fn)
+	fnT := fnV.Type()
+	if fnT.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%T is not a function", fn)
+	}
+
+	for i, b := range bounds {
+		if i < 0 || i >= fnT.NumIn() {
+			return nil, fmt.Errorf("param[%d] is out of range: %s has %d params", i, fnT, fnT.NumIn())
+		} else if b.Min > b.Max {
+			return nil, fmt.Errorf("param[%d] has min %d > max %d", i, b.Min, b.Max)
+		}
+		switch fnT.In(i).Kind() {
+		case reflect.Int32, reflect.Int64, reflect.Uint32, reflect.Uint64:
+		default:
+			return nil, fmt.Errorf("param[%d] is a %s, not an integer", i, fnT.In(i))
+		}
+	}
+
+	return reflect.MakeFunc(fnT, func(args []reflect.Value) []reflect.Value {
+		for i, b := range bounds {
+			checkParamBounds(i, args[i], b)
+		}
+		return fnV.Call(args)
+	}).Interface(), nil
+}
+
+// checkParamBounds traps unless the integer param is within the bounds.
+func checkParamBounds(i int, v reflect.Value, b ParamBounds) {
+	switch v.Kind() {
+	case reflect.Int32, reflect.Int64:
+		if n := v.Int(); n < b.Min || n > b.Max {
+			panic(wasmruntime.New(fmt.Sprintf("param[%d] is out of bounds: %d not in [%d, %d]", i, n, b.Min, b.Max)))
+		}
+	default:
+		// An unsigned value is never negative, so a negative Min admits zero, and a negative Max nothing.
+		if n := v.Uint(); (b.Min > 0 && n < uint64(b.Min)) || b.Max < 0 || n > uint64(b.Max) {
+			panic(wasmruntime.New(fmt.Sprintf("param[%d] is out of bounds: %d not in [%d, %d]", i, n, b.Min, b.Max)))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestWithParamBounds(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	// scale only accepts a signed factor in [-10, 10] and an unsigned count up to 100.
+	scale, err := experimental.WithParamBounds(func(_ context.Context, factor int32, count uint64) int64 {
+		return int64(factor) * int64(count)
+	}, map[int]experimental.ParamBounds{1: {Min: -10, Max: 10}, 2: {Min: 0, Max: 100}})
+	require.NoError(t, err)
+
+	host, err := r.NewModuleBuilder("env").ExportFunction("scale", scale).Instantiate(ctx)
+	require.NoError(t, err)
+	defer host.Close(ctx)
+
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(`(module
+  (import "env" "scale" (func $scale (param i32 i64) (result i64)))
+  (func $run (param i32 i64) (result i64) local.get 0 local.get 1 call $scale)
+  (export "run" (func $run))
+)`))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	tests := []struct {
+		name          string
+		factor, count uint64
+		expected      int64
+		expectedErr   string
+	}{
+		{name: "within bounds", factor: 3, count: 100, expected: 300},
+		{name: "negative within bounds", factor: uint64(uint32(0xfffffff6)), count: 2, expected: -20}, // -10
+		{
+			name: "signed below min", factor: uint64(uint32(0xfffffff5)), count: 2, // -11
+			expectedErr: `wasm error: param[1] is out of bounds: -11 not in [-10, 10]
+wasm stack trace:
+	env.scale(i32,i64) i64
+	.run(i32,i64) i64`,
+		},
+		{
+			name: "unsigned above max", factor: 1, count: 0xffffffffffffffff,
+			expectedErr: `wasm error: param[2] is out of bounds: 18446744073709551615 not in [0, 100]
+wasm stack trace:
+	env.scale(i32,i64) i64
+	.run(i32,i64) i64`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := mod.ExportedFunction("run").Call(ctx, tc.factor, tc.count)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, int64(results[0]))
+			}
+		})
+	}
+}
+
+func TestWithParamBounds_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		fn          interface{}
+		bounds      map[int]experimental.ParamBounds
+		expectedErr string
+	}{
+		{
+			name:        "not a function",
+			fn:          experimental.ParamBounds{},
+			expectedErr: "experimental.ParamBounds is not a function",
+		},
+		{
+			name:        "param out of range",
+			fn:          func(uint32) {},
+			bounds:      map[int]experimental.ParamBounds{1: {}},
+			expectedErr: "param[1] is out of range: func(uint32) has 1 params",
+		},
+		{
+			name:        "min over max",
+			fn:          func(uint32) {},
+			bounds:      map[int]experimental.ParamBounds{0: {Min: 2, Max: 1}},
+			expectedErr: "param[0] has min 2 > max 1",
+		},
+		{
+			name:        "not an integer",
+			fn:          func(context.Context, float32) {},
+			bounds:      map[int]experimental.ParamBounds{1: {}},
+			expectedErr: "param[1] is a float32, not an integer",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := experimental.WithParamBounds(tc.fn, tc.bounds)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
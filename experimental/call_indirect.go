@@ -0,0 +1,38 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// CallIndirectInterceptorKey is a context.Context Value key. Its associated value should be a CallIndirectInterceptor.
+//
+// Note: This is interpreter-only for now!
+type CallIndirectInterceptorKey struct{}
+
+// CallIndirectInterceptor is notified of each call_indirect instruction before its table lookup. This allows a host to
+// implement virtual tables, ex. dispatching some table elements to Go code instead of the functions they reference.
+//
+// Ex. To route the element at index 1 of any table to Go:
+//	type router struct{}
+//
+//	func (router) InterceptCallIndirect(ctx context.Context, m api.Module, tableIndex, typeIndex, elementIndex uint32, params []uint64) ([]uint64, bool) {
+//		if elementIndex != 1 {
+//			return nil, false // look up the table element as usual.
+//		}
+//		return []uint64{params[0] * 2}, true
+//	}
+//	--snip--
+//	ctx = context.WithValue(ctx, experimental.CallIndirectInterceptorKey{}, router{})
+//	results, err := fn.Call(ctx)
+type CallIndirectInterceptor interface {
+	// InterceptCallIndirect is called with the table index and type index of the instruction, the index of the table
+	// element the guest calls, and the params of the call. m is the module executing the instruction.
+	//
+	// When handled is false, the call proceeds as usual. Otherwise, the table isn't accessed, and results are those of
+	// the call. These must be valid for the function type at typeIndex, or the call traps. Ex. when the count differs.
+	//
+	// Note: The element index is not checked against the table size, so a host can also handle indexes out of bounds.
+	InterceptCallIndirect(ctx context.Context, m api.Module, tableIndex, typeIndex, elementIndex uint32, params []uint64) (results []uint64, handled bool)
+}
@@ -0,0 +1,115 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// dispatchWasm exports "dispatch", which calls the element of the table at its second param with its first. The table
+// has 4 elements, but only the first is initialized, to a function that increments its param.
+var dispatchWasm = func() []byte {
+	zero := wasm.Index(0)
+	return binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+			{Params: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+		},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1,
+				wasm.OpcodeCallIndirect, 0, 0, // type index and table index
+				wasm.OpcodeEnd,
+			}},
+		},
+		TableSection: []*wasm.Table{{Min: 4, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&zero},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{{Name: "dispatch", Type: wasm.ExternTypeFunc, Index: 1}},
+	})
+}()
+
+// vtable handles call_indirect in Go, depending on the table element: 1 doubles, 2 squares and 3 returns no result,
+// which is invalid for the type. Element 0 is left to the guest.
+type vtable struct {
+	calls []uint32
+}
+
+func (v *vtable) InterceptCallIndirect(_ context.Context, _ api.Module, tableIndex, typeIndex, elementIndex uint32, params []uint64) ([]uint64, bool) {
+	v.calls = append(v.calls, elementIndex)
+	if tableIndex != 0 || typeIndex != 0 {
+		panic("unexpected call_indirect")
+	}
+	switch elementIndex {
+	case 1:
+		return []uint64{params[0] * 2}, true
+	case 2:
+		return []uint64{params[0] * params[0]}, true
+	case 3:
+		return nil, true
+	}
+	return nil, false
+}
+
+func TestCallIndirectInterceptor(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+
+	mod, err := r.InstantiateModuleFromCode(ctx, dispatchWasm)
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	v := &vtable{}
+	interceptCtx := context.WithValue(ctx, experimental.CallIndirectInterceptorKey{}, v)
+	dispatch := mod.ExportedFunction("dispatch")
+
+	tests := []struct {
+		name           string
+		param, element uint64
+		expected       uint64
+		expectedErr    string
+	}{
+		{name: "not handled", param: 3, element: 0, expected: 4},
+		{name: "double", param: 3, element: 1, expected: 6},
+		{name: "square", param: 3, element: 2, expected: 9},
+		{
+			name: "invalid results", param: 3, element: 3,
+			expectedErr: `wasm error: indirect call type mismatch
+wasm stack trace:
+	.[1](i32,i32) i32`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			results, err := dispatch.Call(interceptCtx, tc.param, tc.element)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, []uint64{tc.expected}, results)
+			}
+		})
+	}
+	require.Equal(t, []uint32{0, 1, 2, 3}, v.calls)
+
+	t.Run("table is used without an interceptor", func(t *testing.T) {
+		_, err := dispatch.Call(ctx, 3, 1)
+		require.EqualError(t, err, `wasm error: invalid table access
+wasm stack trace:
+	.[1](i32,i32) i32`)
+	})
+}
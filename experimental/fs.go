@@ -0,0 +1,29 @@
+package experimental
+
+import (
+	"io/fs"
+)
+
+// WritableFS is a file system that guests can change via WASI, such as by opening a file with `O_CREAT` or renaming
+// it. Assign one with wazero.ModuleConfig WithFS or WithWorkDirFS. A file system that doesn't implement this is
+// read-only to the guest. See MemFS for an in-memory implementation.
+//
+// Names are slash-separated paths as defined by fs.ValidPath, so can't escape the root of the file system.
+//
+// Note: Files opened for write must implement io.Writer, and are otherwise read-only.
+type WritableFS interface {
+	fs.StatFS
+
+	// OpenFile is like os.OpenFile. The flag is a combination of os.O_* values, such as os.O_RDWR|os.O_CREATE, and
+	// perm are the permissions of a file created by it.
+	OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error)
+
+	// Mkdir is like os.Mkdir. This errs with fs.ErrExist if a file or directory already has the name.
+	Mkdir(name string, perm fs.FileMode) error
+
+	// Rename is like os.Rename, replacing any file already named newName.
+	Rename(oldName, newName string) error
+
+	// Remove is like os.Remove, removing a file or an empty directory.
+	Remove(name string) error
+}
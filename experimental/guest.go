@@ -0,0 +1,111 @@
+package experimental
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// WriteToGuest copies data into the memory of the module, at an offset reserved by calling its allocation function.
+// This avoids the guest looping over a host function to read large inputs chunk by chunk.
+//
+// * allocFnName - the name of an exported function with the signature (i32) -> i32, which reserves the input count
+//   of bytes and returns their offset. Ex. "malloc"
+// * data - the bytes to write to the reserved offset.
+//
+// The resulting offset and length are typically passed to a guest function which processes the input.
+//
+// Ex. Pass a large input to an exported function "process"
+//	offset, length, err := experimental.WriteToGuest(ctx, mod, "malloc", input)
+//	if err != nil {
+//		return err
+//	}
+//	_, err = mod.ExportedFunction("process").Call(ctx, uint64(offset), uint64(length))
+//
+// Note: Allocation failure is assumed when the allocation function returns zero, which is the case for C's malloc.
+// Note: The caller is responsible for freeing the memory, if the guest allocator requires it.
+func WriteToGuest(ctx context.Context, mod api.Module, allocFnName string, data []byte) (offset, length uint32, err error) {
+	mem := mod.Memory()
+	if mem == nil {
+		return 0, 0, errors.New("module has no memory")
+	}
+
+	alloc := mod.ExportedFunction(allocFnName)
+	if alloc == nil {
+		return 0, 0, fmt.Errorf("%s is not exported", allocFnName)
+	}
+	if params, results := alloc.ParamTypes(), alloc.ResultTypes(); len(params) != 1 || params[0] != api.ValueTypeI32 ||
+		len(results) != 1 || results[0] != api.ValueTypeI32 {
+		return 0, 0, fmt.Errorf("%s must have the signature (i32) -> i32", allocFnName)
+	}
+
+	length = uint32(len(data))
+	if int(length) != len(data) {
+		return 0, 0, fmt.Errorf("data length %d overflows uint32", len(data))
+	}
+
+	results, err := alloc.Call(ctx, uint64(length))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s(%d) failed: %w", allocFnName, length, err)
+	}
+	if offset = uint32(results[0]); offset == 0 {
+		return 0, 0, fmt.Errorf("%s(%d) failed to allocate", allocFnName, length)
+	}
+
+	if !mem.Write(ctx, offset, data) {
+		return 0, 0, fmt.Errorf("%s(%d) returned offset %d, which is out of range of memory size %d",
+			allocFnName, length, offset, mem.Size(ctx))
+	}
+	return offset, length, nil
+}
+
+// copyChunkSize is the maximum count of bytes CopyGuestMemoryTo passes to each io.Writer Write.
+const copyChunkSize = 32 * 1024
+
+// CopyGuestMemoryTo writes length bytes of the module's memory, starting at ptr, to the writer. This avoids copying
+// the whole range first, ex. when a guest function returns a (ptr, length) pair describing a large result.
+//
+// Ex. Stream the result of an exported function "process" to standard output
+//	results, err := mod.ExportedFunction("process").Call(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	_, err = experimental.CopyGuestMemoryTo(ctx, mod, os.Stdout, uint32(results[0]), uint32(results[1]))
+//
+// This returns the count of bytes written, and errs without writing any if the range is out of memory bounds. Like
+// io.Copy, this errs with io.ErrShortWrite if the writer writes less than a chunk without returning an error.
+//
+// Note: Bytes are written in chunks directly from the memory, so the writer must not retain them, nor call the guest.
+func CopyGuestMemoryTo(ctx context.Context, mod api.Module, w io.Writer, ptr, length uint32) (int, error) {
+	mem := mod.Memory()
+	if mem == nil {
+		return 0, errors.New("module has no memory")
+	}
+
+	buf, ok := ReadAlias(ctx, mem, ptr, length)
+	if !ok {
+		if buf, ok = mem.Read(ctx, ptr, length); !ok {
+			return 0, fmt.Errorf("ptr %d + length %d is out of range of memory size %d", ptr, length, mem.Size(ctx))
+		}
+	}
+
+	var written int
+	for len(buf) > 0 {
+		chunk := buf
+		if len(chunk) > copyChunkSize {
+			chunk = chunk[:copyChunkSize]
+		}
+		n, err := w.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		} else if n < len(chunk) { // per io.Writer, this should have erred.
+			return written, io.ErrShortWrite
+		}
+		buf = buf[len(chunk):]
+	}
+	return written, nil
+}
@@ -0,0 +1,210 @@
+package experimental_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// bumpAllocatorWasm is a guest which exports "malloc" as a bump allocator: offset zero holds the count of bytes
+// allocated so far, and allocations begin after that at offset 8.
+var bumpAllocatorWasm = []byte(`(module
+  (func $malloc (param $size i32) (result i32)
+    i32.const 0 ;; address of the allocated count
+    i32.const 0 i32.load local.get 0 i32.add ;; allocated + size
+    i32.store
+    i32.const 0 i32.load local.get 0 i32.sub ;; the allocated count before this call
+    i32.const 8 i32.add ;; allocations begin after the count
+  )
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "malloc" (func $malloc))
+)`)
+
+// This shows how to pass a large input to a guest without it looping over a host function to read chunks.
+func Example_writeToGuest() {
+	ctx := context.Background()
+
+	r := wazero.NewRuntime()
+	mod, err := r.InstantiateModuleFromCode(ctx, bumpAllocatorWasm)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mod.Close(ctx)
+
+	for _, input := range []string{"hello", "wazero"} {
+		offset, length, err := experimental.WriteToGuest(ctx, mod, "malloc", []byte(input))
+		if err != nil {
+			log.Fatal(err)
+		}
+		bytes, _ := mod.Memory().Read(ctx, offset, length)
+		fmt.Println(offset, length, string(bytes))
+	}
+
+	// Output:
+	// 8 5 hello
+	// 13 6 wazero
+}
+
+func TestWriteToGuest_Errors(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	tests := []struct {
+		name, source, allocFnName, expectedErr string
+	}{
+		{
+			name:        "no memory",
+			source:      `(module)`,
+			allocFnName: "malloc",
+			expectedErr: "module has no memory",
+		},
+		{
+			name:        "not exported",
+			source:      `(module (memory 1))`,
+			allocFnName: "malloc",
+			expectedErr: "malloc is not exported",
+		},
+		{
+			name:        "wrong signature",
+			source:      `(module (func $malloc (param i64) (result i32) i32.const 8) (memory 1) (export "malloc" (func $malloc)))`,
+			allocFnName: "malloc",
+			expectedErr: "malloc must have the signature (i32) -> i32",
+		},
+		{
+			name:        "allocation failure",
+			source:      `(module (func $malloc (param i32) (result i32) i32.const 0) (memory 1) (export "malloc" (func $malloc)))`,
+			allocFnName: "malloc",
+			expectedErr: "malloc(5) failed to allocate",
+		},
+		{
+			name:        "allocation out of range",
+			source:      `(module (func $malloc (param i32) (result i32) i32.const 65535) (memory 1) (export "malloc" (func $malloc)))`,
+			allocFnName: "malloc",
+			expectedErr: "malloc(5) returned offset 65535, which is out of range of memory size 65536",
+		},
+		{
+			name:        "trap",
+			source:      `(module (func $malloc (param i32) (result i32) i32.const 65536 i32.load) (memory 1) (export "malloc" (func $malloc)))`,
+			allocFnName: "malloc",
+			expectedErr: `malloc(5) failed: wasm error: out of bounds memory access
+wasm stack trace:
+	trap.malloc(i32) i32`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := r.InstantiateModuleFromCodeWithConfig(ctx, []byte(tc.source), wazero.NewModuleConfig().WithName(tc.name))
+			require.NoError(t, err)
+			defer mod.Close(ctx)
+
+			_, _, err = experimental.WriteToGuest(ctx, mod, tc.allocFnName, []byte("hello"))
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// resultWasm exports "result", which returns the (ptr, length) of a 12 byte buffer at offset 16.
+const resultWasm = `(module
+  (memory 1)
+  (func $result (result i32 i32) i32.const 16 i32.const 12)
+  (export "result" (func $result))
+)`
+
+// chunkWriter records the size of each Write.
+type chunkWriter struct {
+	bytes.Buffer
+	sizes []int
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	w.sizes = append(w.sizes, len(p))
+	return w.Buffer.Write(p)
+}
+
+// shortWriter writes at most max bytes of each Write, without returning an error.
+type shortWriter struct {
+	bytes.Buffer
+	max int
+}
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	if len(p) > w.max {
+		p = p[:w.max]
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestCopyGuestMemoryTo(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfig().WithFeatureMultiValue(true))
+
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(resultWasm))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+	require.True(t, mod.Memory().Write(ctx, 16, []byte("hello wazero")))
+
+	t.Run("result buffer", func(t *testing.T) {
+		results, err := mod.ExportedFunction("result").Call(ctx)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		n, err := experimental.CopyGuestMemoryTo(ctx, mod, &buf, uint32(results[0]), uint32(results[1]))
+		require.NoError(t, err)
+		require.Equal(t, 12, n)
+		require.Equal(t, "hello wazero", buf.String())
+	})
+
+	t.Run("chunked", func(t *testing.T) {
+		w := &chunkWriter{}
+		n, err := experimental.CopyGuestMemoryTo(ctx, mod, w, 0, 65536)
+		require.NoError(t, err)
+		require.Equal(t, 65536, n)
+		require.Equal(t, []int{32768, 32768}, w.sizes)
+		require.Equal(t, "hello wazero", string(w.Bytes()[16:28]))
+	})
+
+	t.Run("short write", func(t *testing.T) {
+		w := &shortWriter{max: 5}
+		n, err := experimental.CopyGuestMemoryTo(ctx, mod, w, 16, 12)
+		require.Equal(t, io.ErrShortWrite, err)
+		require.Equal(t, 5, n)
+		require.Equal(t, "hello", w.String())
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		for _, tc := range []struct{ ptr, length uint32 }{
+			{ptr: 65530, length: 7},
+			{ptr: 65537, length: 0},
+			{ptr: 1, length: 0xffffffff},
+		} {
+			w := &chunkWriter{}
+			_, err := experimental.CopyGuestMemoryTo(ctx, mod, w, tc.ptr, tc.length)
+			require.EqualError(t, err, fmt.Sprintf("ptr %d + length %d is out of range of memory size 65536", tc.ptr, tc.length))
+			require.Zero(t, len(w.sizes))
+		}
+	})
+
+	t.Run("no memory", func(t *testing.T) {
+		noMem, err := r.InstantiateModuleFromCode(ctx, []byte(`(module $no_memory)`))
+		require.NoError(t, err)
+		defer noMem.Close(ctx)
+
+		_, err = experimental.CopyGuestMemoryTo(ctx, noMem, &bytes.Buffer{}, 0, 0)
+		require.EqualError(t, err, "module has no memory")
+	})
+}
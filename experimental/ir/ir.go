@@ -0,0 +1,85 @@
+// Package ir disassembles WebAssembly modules into wazeroir, the intermediate representation wazero's engines
+// execute or compile. This is separate from the experimental package, as it depends on internal packages which depend
+// on that.
+//
+// Note: All features here may be changed or deleted at any time, so use with caution!
+package ir
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+	"github.com/tetratelabs/wazero/internal/wasmdebug"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
+)
+
+// Disassemble returns the wazeroir operations of each function defined in the source, a module in the WebAssembly
+// binary format, as text. This is the intermediate representation the interpreter executes, and the JIT compiles to
+// native code, so it can help explain their behavior.
+//
+// features are the names of features to enable in addition to WebAssembly 1.0 (20191205), separated by '|' or ','.
+// Ex. "multi-value|bulk-memory-operations"
+//
+// Each function begins with its index and name, followed by its operations prefixed by their index. Labels and branch
+// targets render with their frame ID and kind. Ex. ".L2_cont" is the continuation of the control frame with ID 2.
+//
+// Ex. The module `(module (func $identity (param $x i32) (result i32) local.get 0))` disassembles to:
+//	func[0] .identity
+//	.entrypoint
+//	  0 	pick 0
+//	  1 	drop 1..1
+//	  2 	br .return
+//
+// Note: The text format is only meant to be read, and may change at any time.
+func Disassemble(ctx context.Context, source []byte, features string) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	enabledFeatures, err := wasm.ParseFeatures(features)
+	if err != nil {
+		return "", err
+	}
+	enabledFeatures |= wasm.Features20191205
+
+	m, err := binary.DecodeModule(source, enabledFeatures, wasm.MemoryLimitPages)
+	if err != nil {
+		return "", err
+	}
+	if err = m.Validate(enabledFeatures); err != nil {
+		return "", err
+	}
+
+	irs, err := wazeroir.CompileFunctions(ctx, enabledFeatures, m)
+	if err != nil {
+		return "", err
+	}
+
+	var moduleName string
+	var names wasm.NameMap
+	if m.NameSection != nil {
+		moduleName, names = m.NameSection.ModuleName, m.NameSection.FunctionNames
+	}
+	importCount := m.ImportFuncCount()
+
+	var builder strings.Builder
+	for i, ir := range irs {
+		idx := importCount + uint32(i)
+		var name string
+		for _, n := range names {
+			if n.Index == idx {
+				name = n.Name
+				break
+			}
+		}
+		if i > 0 {
+			builder.WriteByte('\n')
+		}
+		builder.WriteString(fmt.Sprintf("func[%d] %s\n", idx, wasmdebug.FuncName(moduleName, name, idx)))
+		builder.WriteString(wazeroir.FormatIndexed(ir.Operations))
+	}
+	return builder.String(), nil
+}
@@ -0,0 +1,63 @@
+package ir_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero/experimental/ir"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestDisassemble(t *testing.T) {
+	ctx := context.Background()
+
+	// The "identity" module, with a second function that branches, so its labels are rendered.
+	identity := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "f", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd}},
+			{Body: []byte{
+				wasm.OpcodeBlock, 0x40, wasm.OpcodeLocalGet, 0, wasm.OpcodeBrIf, 0, wasm.OpcodeEnd,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeEnd,
+			}},
+		},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "identity",
+			FunctionNames: wasm.NameMap{{Index: 1, Name: "identity"}},
+		},
+	})
+
+	text, err := ir.Disassemble(ctx, identity, "")
+	require.NoError(t, err)
+	require.Equal(t, `func[1] identity.identity
+.entrypoint
+  0 	pick 0
+  1 	drop 1..1
+  2 	br .return
+
+func[2] identity.[2]
+.entrypoint
+  0 	pick 0
+  1 	br_if .L2_cont, .L3
+  2 .L3:
+  3 	br .L2_cont
+  4 .L2_cont:
+  5 	pick 0
+  6 	drop 1..1
+  7 	br .return
+`, text)
+
+	t.Run("unknown feature", func(t *testing.T) {
+		_, err := ir.Disassemble(ctx, identity, "multi-value|tail-call")
+		require.EqualError(t, err, `unknown feature: "tail-call"`)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := ir.Disassemble(ctx, []byte("wasm"), "")
+		require.EqualError(t, err, "invalid magic number")
+	})
+}
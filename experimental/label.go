@@ -0,0 +1,65 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// LabelListenerKey is a context.Context Value key. Its associated value should be a LabelListener.
+//
+// The LabelListener is only notified by functions compiled by a wazero.Runtime configured with RuntimeConfig
+// WithLabelTracing, as labels are otherwise dropped during compilation.
+//
+// Note: This is interpreter-only for now!
+type LabelListenerKey struct{}
+
+// LabelKind is the kind of a label, which is a position in the control flow of a function. See LabelListener
+type LabelKind byte
+
+const (
+	// LabelKindHeader is the start of the body of a loop, or of the "then" of an if. This is also the instruction
+	// following a br_if which didn't branch.
+	LabelKindHeader LabelKind = iota
+	// LabelKindElse is the start of the "else" of an if, even when the if has no else instructions.
+	LabelKindElse
+	// LabelKindContinuation is the instruction following the end of a block or an if.
+	LabelKindContinuation
+)
+
+// String returns the name of the kind, ex. "header".
+func (k LabelKind) String() string {
+	switch k {
+	case LabelKindHeader:
+		return "header"
+	case LabelKindElse:
+		return "else"
+	case LabelKindContinuation:
+		return "continuation"
+	}
+	return "unknown"
+}
+
+// LabelListener is notified each time a function reaches a label, which allows tracing the control flow of a function
+// as it executes. This is heavyweight as it is called on every block boundary, so only enable it for debugging.
+//
+// Ex. To print each label reached in the call:
+//	type tracer struct{}
+//
+//	func (tracer) Label(ctx context.Context, m api.Module, funcIndex, frameID uint32, kind experimental.LabelKind) {
+//		fmt.Printf("func[%d] .L%d %s\n", funcIndex, frameID, kind)
+//	}
+//	--snip--
+//	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithLabelTracing(true))
+//	--snip--
+//	ctx = context.WithValue(ctx, experimental.LabelListenerKey{}, tracer{})
+//	results, err := fn.Call(ctx)
+type LabelListener interface {
+	// Label is called when the function at funcIndex of the module m reaches a label. frameID identifies the block, loop
+	// or if of the label, and is only unique within the function.
+	//
+	// The "then" or "else" of an if is always followed by its continuation, even when a branch or return skips it. In
+	// this case, the continuation is reported before the label the branch lands on, innermost first. Loops and blocks
+	// have no such pairing: a loop reports its header on each iteration, and a block only reports its continuation.
+	Label(ctx context.Context, m api.Module, funcIndex, frameID uint32, kind LabelKind)
+}
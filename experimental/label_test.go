@@ -0,0 +1,171 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// labelsWasm exports functions which branch on their param, which is 1 for "then" and 0 for "else".
+var labelsWasm = func() []byte {
+	i32 := wasm.ValueTypeI32
+	return binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0, 0, 0},
+		CodeSection: []*wasm.Code{
+			// The "param" example of if.wast, except the condition is the param:
+			//	(i32.const 1) (if (param i32) (result i32) (local.get 0)
+			//	  (then (i32.const 2) (i32.add))
+			//	  (else (i32.const -2) (i32.add)))
+			{Body: []byte{
+				wasm.OpcodeI32Const, 1, wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeIf, 0, // type index
+				wasm.OpcodeI32Const, 2, wasm.OpcodeI32Add,
+				wasm.OpcodeElse,
+				wasm.OpcodeI32Const, 0x7e /* -2 */, wasm.OpcodeI32Add,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			// (block (if (local.get 0) (then (br 1)))) (i32.const 1)
+			{Body: []byte{
+				wasm.OpcodeBlock, 0x40,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeIf, 0x40, wasm.OpcodeBr, 1, wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeEnd,
+			}},
+			// (if (local.get 0) (then (return (i32.const 2)))) (i32.const 1)
+			{Body: []byte{
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeIf, 0x40, wasm.OpcodeI32Const, 2, wasm.OpcodeReturn, wasm.OpcodeEnd,
+				wasm.OpcodeI32Const, 1,
+				wasm.OpcodeEnd,
+			}},
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "param", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "br", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "return", Type: wasm.ExternTypeFunc, Index: 2},
+		},
+	})
+}()
+
+type label struct {
+	frameID uint32
+	kind    experimental.LabelKind
+}
+
+// labelTracer records the labels of the function at funcIndex.
+type labelTracer struct {
+	funcIndex uint32
+	labels    []label
+}
+
+func (l *labelTracer) Label(_ context.Context, _ api.Module, funcIndex, frameID uint32, kind experimental.LabelKind) {
+	if funcIndex != l.funcIndex {
+		panic("unexpected function")
+	}
+	l.labels = append(l.labels, label{frameID, kind})
+}
+
+func TestLabelListener(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().
+		WithFeatureMultiValue(true).
+		WithLabelTracing(true))
+
+	mod, err := r.InstantiateModuleFromCode(ctx, labelsWasm)
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	header, els, continuation := experimental.LabelKindHeader, experimental.LabelKindElse, experimental.LabelKindContinuation
+	tests := []struct {
+		name      string
+		funcIndex uint32
+		param     uint64
+		expected  uint64
+		labels    []label
+	}{
+		{
+			name:     "param then",
+			param:    1,
+			expected: 3,
+			labels:   []label{{2, header}, {2, continuation}},
+		},
+		{
+			name:     "param else",
+			param:    0,
+			expected: uint64(uint32(0xffffffff)), // -1
+			labels:   []label{{2, els}, {2, continuation}},
+		},
+		{
+			name:      "br skips the continuation of the if",
+			funcIndex: 1,
+			param:     1,
+			expected:  1,
+			labels:    []label{{3, header}, {3, continuation}, {2, continuation}},
+		},
+		{
+			name:      "br not taken",
+			funcIndex: 1,
+			param:     0,
+			expected:  1,
+			labels:    []label{{3, els}, {3, continuation}, {2, continuation}},
+		},
+		{
+			name:      "return skips the continuation of the if",
+			funcIndex: 2,
+			param:     1,
+			expected:  2,
+			labels:    []label{{2, header}, {2, continuation}},
+		},
+		{
+			name:      "return not taken",
+			funcIndex: 2,
+			param:     0,
+			expected:  1,
+			labels:    []label{{2, els}, {2, continuation}},
+		},
+	}
+
+	names := []string{"param", "br", "return"}
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			tracer := &labelTracer{funcIndex: tc.funcIndex}
+			results, err := mod.ExportedFunction(names[tc.funcIndex]).
+				Call(context.WithValue(ctx, experimental.LabelListenerKey{}, tracer), tc.param)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{tc.expected}, results)
+			require.Equal(t, tc.labels, tracer.labels)
+		})
+	}
+}
+
+func TestLabelListener_WithoutLabelTracing(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter().WithFeatureMultiValue(true))
+
+	mod, err := r.InstantiateModuleFromCode(ctx, labelsWasm)
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	tracer := &labelTracer{}
+	results, err := mod.ExportedFunction("param").Call(context.WithValue(ctx, experimental.LabelListenerKey{}, tracer), 1)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{3}, results)
+	require.Zero(t, len(tracer.labels))
+}
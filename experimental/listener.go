@@ -45,25 +45,6 @@ type FunctionListener interface {
 // position to read from which might be subtle.
 
 // FunctionDefinition includes information about a function available pre-instantiation.
-type FunctionDefinition interface {
-	// ModuleName is the possibly empty name of the module defining this function.
-	ModuleName() string
-
-	// Index is the position in the module's function index namespace, imports first.
-	Index() uint32
-
-	// Name is the module-defined name of the function, which is not necessarily the same as its export name.
-	Name() string
-
-	// ExportNames include all exported names for the given function.
-	ExportNames() []string
-
-	// ParamTypes are the parameters of the function.
-	ParamTypes() []api.ValueType
-
-	// ParamNames are index-correlated with ParamTypes or nil if not available for one or more parameters.
-	ParamNames() []string
-
-	// ResultTypes are the results of the function.
-	ResultTypes() []api.ValueType
-}
+//
+// Note: This is an alias of api.FunctionDefinition, which api.Table also returns.
+type FunctionDefinition = api.FunctionDefinition
@@ -0,0 +1,356 @@
+package experimental
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// MemFS is a WritableFS held in memory, which is useful for tests or sandboxes where the guest needs to write files.
+// Ex.
+//
+//	memFS := experimental.NewMemFS()
+//	config := wazero.NewModuleConfig().WithFS(memFS)
+//
+// Notes:
+// * This is safe for concurrent use, including by different files open on the same name.
+// * Files are kept until removed, even after the guest that wrote them closes, so can be inspected afterwards via
+//   Open or fs.ReadFile.
+// * Permissions are recorded, but not enforced.
+type MemFS struct {
+	// mu guards all nodes and the offset of any open file.
+	mu   sync.Mutex
+	root *memNode
+}
+
+// memNode is a file, or a directory when children is non-nil.
+type memNode struct {
+	mode     fs.FileMode
+	modTime  time.Time
+	data     []byte
+	children map[string]*memNode
+}
+
+// NewMemFS returns an empty MemFS.
+func NewMemFS() *MemFS {
+	return &MemFS{root: &memNode{mode: fs.ModeDir | 0o755, modTime: time.Now(), children: map[string]*memNode{}}}
+}
+
+// Open implements fs.FS Open
+func (m *MemFS) Open(name string) (fs.File, error) {
+	return m.OpenFile(name, os.O_RDONLY, 0)
+}
+
+// OpenFile implements WritableFS.OpenFile
+func (m *MemFS) OpenFile(name string, flag int, perm fs.FileMode) (fs.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, n, err := m.lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+
+	writable := flag&(os.O_WRONLY|os.O_RDWR) != 0
+	switch {
+	case n == nil && flag&os.O_CREATE == 0:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	case n == nil:
+		n = &memNode{mode: perm & fs.ModePerm, modTime: time.Now()}
+		parent.children[base] = n
+	case flag&(os.O_CREATE|os.O_EXCL) == os.O_CREATE|os.O_EXCL:
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrExist}
+	case n.children != nil && (writable || flag&os.O_TRUNC != 0):
+		return nil, &fs.PathError{Op: "open", Path: name, Err: syscall.EISDIR}
+	case flag&os.O_TRUNC != 0:
+		n.data, n.modTime = nil, time.Now()
+	}
+	return &memFile{fs: m, node: n, name: path.Base(name), flag: flag}, nil
+}
+
+// Stat implements fs.StatFS Stat
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, base, n, err := m.lookup(name)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	}
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return n.stat(base), nil
+}
+
+// Mkdir implements WritableFS.Mkdir
+func (m *MemFS) Mkdir(name string, perm fs.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, n, err := m.lookup(name)
+	if err == nil && n != nil {
+		err = fs.ErrExist
+	}
+	if err != nil {
+		return &fs.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	parent.children[base] = &memNode{mode: fs.ModeDir | perm&fs.ModePerm, modTime: time.Now(), children: map[string]*memNode{}}
+	return nil
+}
+
+// Rename implements WritableFS.Rename
+func (m *MemFS) Rename(oldName, newName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	oldParent, oldBase, n, err := m.lookup(oldName)
+	if err == nil && n == nil {
+		err = fs.ErrNotExist
+	} else if err == nil && n == m.root {
+		err = fs.ErrInvalid
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+
+	newParent, newBase, existing, err := m.lookup(newName)
+	switch {
+	case err != nil:
+	case existing == n:
+		return nil // renaming to the same name is a no-op
+	case n.children != nil && strings.HasPrefix(newName, oldName+"/"):
+		err = fs.ErrInvalid // a directory can't be moved inside itself
+	case existing == nil:
+	case existing.children == nil && n.children != nil:
+		err = syscall.ENOTDIR
+	case existing.children != nil && n.children == nil:
+		err = syscall.EISDIR
+	case len(existing.children) > 0:
+		err = syscall.ENOTEMPTY
+	}
+	if err != nil {
+		return &os.LinkError{Op: "rename", Old: oldName, New: newName, Err: err}
+	}
+
+	delete(oldParent.children, oldBase)
+	newParent.children[newBase] = n
+	return nil
+}
+
+// Remove implements WritableFS.Remove
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	parent, base, n, err := m.lookup(name)
+	switch {
+	case err != nil:
+	case n == nil:
+		err = fs.ErrNotExist
+	case n == m.root:
+		err = fs.ErrInvalid
+	case len(n.children) > 0:
+		err = syscall.ENOTEMPTY
+	}
+	if err != nil {
+		return &fs.PathError{Op: "remove", Path: name, Err: err}
+	}
+	delete(parent.children, base)
+	return nil
+}
+
+// lookup returns the node of the name, or nil if only its parent directory exists, along with that parent and the
+// base name of the node. Invalid names err with fs.ErrInvalid, which prevents escaping the root.
+//
+// Note: The caller must hold the lock.
+func (m *MemFS) lookup(name string) (parent *memNode, base string, n *memNode, err error) {
+	if !fs.ValidPath(name) {
+		return nil, "", nil, fs.ErrInvalid
+	}
+	if name == "." {
+		return nil, ".", m.root, nil
+	}
+
+	n = m.root
+	for _, elem := range strings.Split(name, "/") {
+		if n == nil {
+			return nil, "", nil, fs.ErrNotExist
+		} else if n.children == nil {
+			return nil, "", nil, syscall.ENOTDIR
+		}
+		parent, base, n = n, elem, n.children[elem]
+	}
+	return
+}
+
+func (n *memNode) stat(name string) fs.FileInfo {
+	return &memFileInfo{name: name, size: int64(len(n.data)), mode: n.mode, modTime: n.modTime}
+}
+
+// memFile is an open file or directory of a MemFS.
+type memFile struct {
+	fs     *MemFS
+	node   *memNode
+	name   string
+	flag   int
+	offset int64
+	closed bool
+	// dirEntries are the remaining entries of a directory for ReadDir, populated on first use.
+	dirEntries []fs.DirEntry
+}
+
+// Stat implements fs.File Stat
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return nil, &fs.PathError{Op: "stat", Path: f.name, Err: fs.ErrClosed}
+	}
+	return f.node.stat(f.name), nil
+}
+
+// Read implements fs.File Read
+func (f *memFile) Read(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if err := f.check("read", os.O_RDONLY); err != nil {
+		return 0, err
+	}
+	if f.offset >= int64(len(f.node.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.node.data[f.offset:])
+	f.offset += int64(n)
+	return n, nil
+}
+
+// Write implements io.Writer
+func (f *memFile) Write(p []byte) (int, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if err := f.check("write", os.O_WRONLY); err != nil {
+		return 0, err
+	}
+	if f.flag&os.O_APPEND != 0 {
+		f.offset = int64(len(f.node.data))
+	}
+	if end := f.offset + int64(len(p)); end > int64(len(f.node.data)) {
+		// Append zeros, instead of copying to a new slice, so that repeated writes at the end amortize growth.
+		f.node.data = append(f.node.data, make([]byte, end-int64(len(f.node.data)))...)
+	}
+	n := copy(f.node.data[f.offset:], p)
+	f.offset += int64(n)
+	f.node.modTime = time.Now()
+	return n, nil
+}
+
+// Seek implements io.Seeker
+func (f *memFile) Seek(offset int64, whence int) (int64, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrClosed}
+	}
+	switch whence {
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += int64(len(f.node.data))
+	}
+	if offset < 0 {
+		return 0, &fs.PathError{Op: "seek", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+// ReadDir implements fs.ReadDirFile ReadDir
+func (f *memFile) ReadDir(count int) ([]fs.DirEntry, error) {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: fs.ErrClosed}
+	} else if f.node.children == nil {
+		return nil, &fs.PathError{Op: "readdir", Path: f.name, Err: syscall.ENOTDIR}
+	}
+
+	if f.dirEntries == nil {
+		names := make([]string, 0, len(f.node.children))
+		for name := range f.node.children {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		f.dirEntries = make([]fs.DirEntry, 0, len(names))
+		for _, name := range names {
+			f.dirEntries = append(f.dirEntries, fs.FileInfoToDirEntry(f.node.children[name].stat(name)))
+		}
+	}
+
+	entries := f.dirEntries
+	if count > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		} else if count < len(entries) {
+			entries = entries[:count]
+		}
+	}
+	f.dirEntries = f.dirEntries[len(entries):]
+	return entries, nil
+}
+
+// Close implements fs.File Close
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+
+	if f.closed {
+		return &fs.PathError{Op: "close", Path: f.name, Err: fs.ErrClosed}
+	}
+	f.closed = true
+	return nil
+}
+
+// check returns an error if the file can't be used for the operation, which requires the access mode os.O_RDONLY
+// (read) or os.O_WRONLY (write).
+//
+// Note: The caller must hold the lock.
+func (f *memFile) check(op string, access int) error {
+	if f.closed {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrClosed}
+	} else if f.node.children != nil {
+		return &fs.PathError{Op: op, Path: f.name, Err: syscall.EISDIR}
+	}
+
+	mode := f.flag & (os.O_RDONLY | os.O_WRONLY | os.O_RDWR)
+	if mode != os.O_RDWR && mode != access {
+		return &fs.PathError{Op: op, Path: f.name, Err: fs.ErrPermission}
+	}
+	return nil
+}
+
+// memFileInfo implements fs.FileInfo for a node of a MemFS.
+type memFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return i.size }
+func (i *memFileInfo) Mode() fs.FileMode  { return i.mode }
+func (i *memFileInfo) ModTime() time.Time { return i.modTime }
+func (i *memFileInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memFileInfo) Sys() interface{}   { return nil }
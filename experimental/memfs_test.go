@@ -0,0 +1,175 @@
+package experimental_test
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+var _ experimental.WritableFS = &experimental.MemFS{}
+
+func TestMemFS(t *testing.T) {
+	memFS := experimental.NewMemFS()
+	require.NoError(t, memFS.Mkdir("dir", 0o755))
+	writeFile(t, memFS, "dir/a.txt", "wazero")
+	writeFile(t, memFS, "b.txt", "")
+
+	require.NoError(t, fstest.TestFS(memFS, "dir", "dir/a.txt", "b.txt"))
+
+	// Append, then overwrite from the start.
+	f, err := memFS.OpenFile("dir/a.txt", os.O_WRONLY|os.O_APPEND, 0)
+	require.NoError(t, err)
+	_, err = f.(io.Writer).Write([]byte("!"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	requireFile(t, memFS, "dir/a.txt", "wazero!")
+
+	f, err = memFS.OpenFile("dir/a.txt", os.O_RDWR, 0)
+	require.NoError(t, err)
+	_, err = f.(io.Writer).Write([]byte("W"))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	requireFile(t, memFS, "dir/a.txt", "Wazero!")
+
+	// Rename into the root, replacing the existing file, then remove everything.
+	require.NoError(t, memFS.Rename("dir/a.txt", "b.txt"))
+	requireFile(t, memFS, "b.txt", "Wazero!")
+	require.NoError(t, memFS.Remove("b.txt"))
+	require.NoError(t, memFS.Remove("dir"))
+
+	entries, err := fs.ReadDir(memFS, ".")
+	require.NoError(t, err)
+	require.Zero(t, len(entries))
+}
+
+func TestMemFS_Errors(t *testing.T) {
+	memFS := experimental.NewMemFS()
+	require.NoError(t, memFS.Mkdir("dir", 0o755))
+	writeFile(t, memFS, "dir/a.txt", "wazero")
+
+	tests := []struct {
+		name        string
+		fn          func() error
+		expectedErr error
+	}{
+		{
+			name:        "open missing",
+			fn:          func() error { _, err := memFS.Open("b.txt"); return err },
+			expectedErr: fs.ErrNotExist,
+		},
+		{
+			name:        "open outside root",
+			fn:          func() error { _, err := memFS.Open("../dir"); return err },
+			expectedErr: fs.ErrInvalid,
+		},
+		{
+			name:        "open through file",
+			fn:          func() error { _, err := memFS.Open("dir/a.txt/b.txt"); return err },
+			expectedErr: syscall.ENOTDIR,
+		},
+		{
+			name: "create exclusive existing",
+			fn: func() error {
+				_, err := memFS.OpenFile("dir/a.txt", os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o644)
+				return err
+			},
+			expectedErr: fs.ErrExist,
+		},
+		{
+			name:        "open directory for write",
+			fn:          func() error { _, err := memFS.OpenFile("dir", os.O_RDWR, 0); return err },
+			expectedErr: syscall.EISDIR,
+		},
+		{
+			name: "write read-only",
+			fn: func() error {
+				f, err := memFS.Open("dir/a.txt")
+				require.NoError(t, err)
+				defer f.Close()
+				_, err = f.(io.Writer).Write([]byte("a"))
+				return err
+			},
+			expectedErr: fs.ErrPermission,
+		},
+		{
+			name:        "mkdir existing",
+			fn:          func() error { return memFS.Mkdir("dir", 0o755) },
+			expectedErr: fs.ErrExist,
+		},
+		{
+			name:        "remove non-empty directory",
+			fn:          func() error { return memFS.Remove("dir") },
+			expectedErr: syscall.ENOTEMPTY,
+		},
+		{
+			name:        "rename directory into itself",
+			fn:          func() error { return memFS.Rename("dir", "dir/sub") },
+			expectedErr: fs.ErrInvalid,
+		},
+		{
+			name:        "rename file over directory",
+			fn:          func() error { return memFS.Rename("dir/a.txt", "dir") },
+			expectedErr: syscall.EISDIR,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.ErrorIs(t, tc.fn(), tc.expectedErr)
+		})
+	}
+}
+
+// TestMemFS_Concurrent ensures files can be changed concurrently. Run with -race to detect unguarded state.
+func TestMemFS_Concurrent(t *testing.T) {
+	memFS := experimental.NewMemFS()
+	writeFile(t, memFS, "log.txt", "")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			name := fmt.Sprintf("%d.txt", i)
+			writeFile(t, memFS, name, name)
+			require.NoError(t, memFS.Rename(name, name+".bak"))
+
+			f, err := memFS.OpenFile("log.txt", os.O_WRONLY|os.O_APPEND, 0)
+			require.NoError(t, err)
+			_, err = f.(io.Writer).Write([]byte("."))
+			require.NoError(t, err)
+			require.NoError(t, f.Close())
+		}(i)
+	}
+	wg.Wait()
+
+	requireFile(t, memFS, "log.txt", "..........")
+	entries, err := fs.ReadDir(memFS, ".")
+	require.NoError(t, err)
+	require.Equal(t, 11, len(entries))
+}
+
+func writeFile(t *testing.T, memFS *experimental.MemFS, name, data string) {
+	f, err := memFS.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	require.NoError(t, err)
+	_, err = f.(io.Writer).Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+}
+
+func requireFile(t *testing.T, memFS *experimental.MemFS, name, expected string) {
+	data, err := fs.ReadFile(memFS, name)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(data))
+}
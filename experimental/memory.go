@@ -0,0 +1,27 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// aliasReader is implemented by api.Memory implementations that support ReadAlias.
+type aliasReader interface {
+	ReadAlias(ctx context.Context, offset, byteCount uint32) ([]byte, bool)
+}
+
+// ReadAlias is like api.Memory Read, except the result is a view of the memory instead of a copy. This avoids
+// allocating, and writing to the result changes the memory, ex. to read from an io.Reader directly into it.
+//
+// This returns false if out of range, or the memory doesn't support aliasing.
+//
+// Note: This is unsafe unless the result is discarded before the guest continues. For example, a memory.grow can
+// replace the underlying buffer, after which the result is a stale copy that no longer reflects, nor changes, the
+// memory. Use api.Memory Read to retain the bytes. A shared memory is the exception, as its buffer never moves.
+func ReadAlias(ctx context.Context, mem api.Memory, offset, byteCount uint32) ([]byte, bool) {
+	if r, ok := mem.(aliasReader); ok {
+		return r.ReadAlias(ctx, offset, byteCount)
+	}
+	return nil, false
+}
@@ -0,0 +1,56 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// growWasm exports "grow", which grows its memory by one page.
+const growWasm = `(module
+  (memory 1 2)
+  (func $grow (result i32) i32.const 1 memory.grow)
+  (export "grow" (func $grow))
+)`
+
+func TestReadAlias(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(growWasm))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	mem := mod.Memory()
+	require.True(t, mem.Write(ctx, 0, []byte("wazero")))
+
+	readCopy, ok := mem.Read(ctx, 0, 6)
+	require.True(t, ok)
+	alias, ok := experimental.ReadAlias(ctx, mem, 0, 6)
+	require.True(t, ok)
+
+	// Only the alias reflects changes to the memory.
+	require.True(t, mem.Write(ctx, 0, []byte("W")))
+	require.Equal(t, "wazero", string(readCopy))
+	require.Equal(t, "Wazero", string(alias))
+
+	// Growing the memory replaces its buffer, so the alias no longer reflects, nor changes, the memory.
+	_, err = mod.ExportedFunction("grow").Call(ctx)
+	require.NoError(t, err)
+	require.True(t, mem.Write(ctx, 0, []byte("WA")))
+	alias[2] = 'Z'
+
+	actual, ok := mem.Read(ctx, 0, 6)
+	require.True(t, ok)
+	require.Equal(t, "WAzero", string(actual))
+	require.Equal(t, "WaZero", string(alias)) // invalidated
+
+	// The copy is unaffected.
+	require.Equal(t, "wazero", string(readCopy))
+
+	_, ok = experimental.ReadAlias(ctx, mem, 2*65536-1, 2)
+	require.False(t, ok)
+}
@@ -0,0 +1,47 @@
+package experimental
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// MMIORegion handles the guest loads and stores within a range of memory registered with MapMMIO, instead of the
+// memory itself. This emulates memory-mapped registers of hardware.
+type MMIORegion interface {
+	// Load returns the little-endian value of the size bytes (1, 2, 4 or 8) at offset, which is relative to the start
+	// of the range. Bits beyond size bytes are ignored.
+	Load(ctx context.Context, offset, size uint32) uint64
+
+	// Store writes the little-endian value of the size bytes (1, 2, 4 or 8) at offset, which is relative to the start
+	// of the range. Bits beyond size bytes are zero.
+	Store(ctx context.Context, offset, size uint32, value uint64)
+}
+
+// mmioMapper is implemented by api.Memory implementations that support MapMMIO.
+type mmioMapper interface {
+	MapMMIO(offset, size uint32, region MMIORegion) error
+}
+
+// MapMMIO registers region to handle guest loads and stores of the size bytes at offset of the memory. The range can
+// exceed the current size of the memory, in which case accesses to it don't trap.
+//
+// A load or store which straddles the boundary of the range, ex. an i64.load of the 4 bytes before it and its first 4
+// bytes, isn't split: it traps with "memory access straddles an MMIO region".
+//
+// This errs if the range is empty, overlaps an existing range, or the memory doesn't support MMIO.
+//
+// Notes:
+// * This is interpreter-only for now! Other engines access the memory itself.
+// * Only guest loads and stores consult the region. Bulk memory operations, such as memory.fill, and api.Memory
+//   functions access the memory itself.
+// * This isn't safe to call concurrently with functions that use the memory, including on other goroutines, as guest
+//   loads and stores read the ranges without synchronization. Map ranges before calling any function, or from a host
+//   function called by the guest.
+func MapMMIO(mem api.Memory, offset, size uint32, region MMIORegion) error {
+	if m, ok := mem.(mmioMapper); ok {
+		return m.MapMMIO(offset, size, region)
+	}
+	return errors.New("memory doesn't support MMIO")
+}
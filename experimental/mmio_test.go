@@ -0,0 +1,169 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// mmioWasm exports functions to load and store at an offset of memory.
+var mmioWasm = func() []byte {
+	i32, i64 := wasm.ValueTypeI32, wasm.ValueTypeI64
+	// load returns the body of a load of the param, and store one of the second param at the first.
+	load := func(opcode wasm.Opcode) []byte {
+		return []byte{wasm.OpcodeLocalGet, 0, opcode, 0, 0, wasm.OpcodeEnd}
+	}
+	store := func(opcode wasm.Opcode) []byte {
+		return []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, opcode, 0, 0, wasm.OpcodeEnd}
+	}
+	return binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i64}},
+			{Params: []wasm.ValueType{i32, i32}},
+		},
+		FunctionSection: []wasm.Index{0, 0, 1, 2, 2},
+		CodeSection: []*wasm.Code{
+			{Body: load(wasm.OpcodeI32Load)},
+			{Body: load(wasm.OpcodeI32Load8U)},
+			{Body: load(wasm.OpcodeI64Load)},
+			{Body: store(wasm.OpcodeI32Store)},
+			{Body: store(wasm.OpcodeI32Store16)},
+		},
+		MemorySection: &wasm.Memory{Min: 1},
+		ExportSection: []*wasm.Export{
+			{Name: "load32", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "load8", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "load64", Type: wasm.ExternTypeFunc, Index: 2},
+			{Name: "store32", Type: wasm.ExternTypeFunc, Index: 3},
+			{Name: "store16", Type: wasm.ExternTypeFunc, Index: 4},
+		},
+	})
+}()
+
+// mmioAccess is a load or store of an mmioRegister.
+type mmioAccess struct {
+	store        bool
+	offset, size uint32
+	value        uint64
+}
+
+// mmioRegister is an 8-byte register which records each access.
+type mmioRegister struct {
+	value    uint64
+	accesses []mmioAccess
+}
+
+// Load implements experimental.MMIORegion
+func (r *mmioRegister) Load(_ context.Context, offset, size uint32) uint64 {
+	r.accesses = append(r.accesses, mmioAccess{offset: offset, size: size})
+	return r.value >> (offset * 8)
+}
+
+// Store implements experimental.MMIORegion
+func (r *mmioRegister) Store(_ context.Context, offset, size uint32, value uint64) {
+	r.accesses = append(r.accesses, mmioAccess{store: true, offset: offset, size: size, value: value})
+	r.value = value << (offset * 8)
+}
+
+func TestMapMMIO(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	mod, err := r.InstantiateModuleFromCode(ctx, mmioWasm)
+	require.NoError(t, err)
+
+	register := &mmioRegister{}
+	require.NoError(t, experimental.MapMMIO(mod.Memory(), 16, 8, register))
+
+	// Stores within the range are handled by the register, not the memory.
+	_, err = mod.ExportedFunction("store32").Call(ctx, 16, 0xcafe)
+	require.NoError(t, err)
+	_, err = mod.ExportedFunction("store16").Call(ctx, 20, 0x1_beef) // truncated to 16 bits
+	require.NoError(t, err)
+	buf, ok := mod.Memory().Read(ctx, 16, 8)
+	require.True(t, ok)
+	require.Equal(t, make([]byte, 8), buf)
+
+	results, err := mod.ExportedFunction("load32").Call(ctx, 20)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xbeef), results[0])
+	results, err = mod.ExportedFunction("load8").Call(ctx, 21)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0xbe), results[0])
+
+	require.Equal(t, []mmioAccess{
+		{store: true, offset: 0, size: 4, value: 0xcafe},
+		{store: true, offset: 4, size: 2, value: 0xbeef},
+		{offset: 4, size: 4},
+		{offset: 5, size: 1},
+	}, register.accesses)
+
+	// Accesses outside the range use the memory.
+	_, err = mod.ExportedFunction("store32").Call(ctx, 24, 1)
+	require.NoError(t, err)
+	results, err = mod.ExportedFunction("load32").Call(ctx, 24)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), results[0])
+	require.Equal(t, 4, len(register.accesses))
+
+	t.Run("straddles the range", func(t *testing.T) {
+		_, err = mod.ExportedFunction("load64").Call(ctx, 12)
+		require.Contains(t, err.Error(), "memory access straddles an MMIO region")
+
+		_, err = mod.ExportedFunction("store32").Call(ctx, 22, 1)
+		require.Contains(t, err.Error(), "memory access straddles an MMIO region")
+		require.Equal(t, 4, len(register.accesses))
+	})
+
+	t.Run("beyond the memory", func(t *testing.T) {
+		beyond := &mmioRegister{value: 42}
+		require.NoError(t, experimental.MapMMIO(mod.Memory(), mod.Memory().Size(ctx), 4, beyond))
+
+		results, err := mod.ExportedFunction("load32").Call(ctx, uint64(mod.Memory().Size(ctx)))
+		require.NoError(t, err)
+		require.Equal(t, uint64(42), results[0])
+	})
+}
+
+func TestMapMMIO_Errors(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	mod, err := r.InstantiateModuleFromCode(ctx, mmioWasm)
+	require.NoError(t, err)
+	mem := mod.Memory()
+	require.NoError(t, experimental.MapMMIO(mem, 16, 8, &mmioRegister{}))
+
+	tests := []struct {
+		name         string
+		offset, size uint32
+		expectedErr  string
+	}{
+		{name: "empty", offset: 0, size: 0, expectedErr: "MMIO range is empty"},
+		{name: "overlaps start", offset: 12, size: 8, expectedErr: "MMIO range [12, 20) overlaps an existing range"},
+		{name: "overlaps end", offset: 23, size: 8, expectedErr: "MMIO range [23, 31) overlaps an existing range"},
+		{name: "within", offset: 18, size: 2, expectedErr: "MMIO range [18, 20) overlaps an existing range"},
+		{name: "exceeds the address space", offset: 0xffff_fffc, size: 8, expectedErr: "MMIO range [4294967292, 4294967300) exceeds the address space"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.EqualError(t, experimental.MapMMIO(mem, tc.offset, tc.size, &mmioRegister{}), tc.expectedErr)
+		})
+	}
+
+	// Adjacent ranges don't overlap.
+	require.NoError(t, experimental.MapMMIO(mem, 8, 8, &mmioRegister{}))
+	require.NoError(t, experimental.MapMMIO(mem, 24, 8, &mmioRegister{}))
+
+	// The end of a range at the top of the address space doesn't wrap.
+	require.NoError(t, experimental.MapMMIO(mem, 0xffff_fff0, 16, &mmioRegister{}))
+	err = experimental.MapMMIO(mem, 0xffff_ff00, 0x100, &mmioRegister{})
+	require.EqualError(t, err, "MMIO range [4294967040, 4294967296) overlaps an existing range")
+}
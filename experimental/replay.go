@@ -0,0 +1,305 @@
+package experimental
+
+import (
+	"context"
+	crand "crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrReplayDiverged is wrapped by the error of a Replayer when the guest requests an input which isn't the next in its
+// ReplayLog, ex. it reads the clock when the recording read random bytes.
+var ErrReplayDiverged = errors.New("replay diverged")
+
+const (
+	replaySourceClock  = "clock"
+	replaySourceRandom = "random"
+	replaySourceStdin  = "stdin"
+	replaySourceFunc   = "func:"
+)
+
+// ReplayLog is the nondeterministic inputs of a guest run, in the order the guest requested them. See Recorder
+//
+// Note: This has only exported fields, so can be persisted with encoding/json.
+type ReplayLog struct {
+	Events []ReplayEvent
+}
+
+// ReplayEvent is an input of a guest run.
+type ReplayEvent struct {
+	// Source identifies the input: "clock", "random", "stdin", or "func:" and the name of a host function.
+	Source string
+	// Data are the bytes read from "random" or "stdin". Empty "stdin" data means it was at EOF.
+	Data []byte
+	// Values are the nanoseconds read from "clock", or the results of a host function.
+	Values []uint64
+}
+
+// String implements fmt.Stringer
+func (e *ReplayEvent) String() string {
+	switch e.Source {
+	case replaySourceRandom, replaySourceStdin:
+		return fmt.Sprintf("%s(%d bytes)", e.Source, len(e.Data))
+	}
+	return e.Source
+}
+
+// Recorder records the nondeterministic inputs of a guest run into a ReplayLog, so that a Replayer can reproduce it.
+// Recorded inputs are the clock and random bytes of Sys, bytes read from stdin, and results of host functions.
+//
+// Ex. To record a WASI command, assign the recorder to the context used to instantiate WASI, and wrap stdin:
+//	recorder := experimental.NewRecorder(nil)
+//	ctx = context.WithValue(ctx, experimental.SysKey{}, recorder)
+//	wm, err := wasi.InstantiateSnapshotPreview1(ctx, r)
+//	--snip--
+//	config := wazero.NewModuleConfig().WithStdin(recorder.Stdin(os.Stdin))
+//	mod, err := r.InstantiateModuleWithConfig(ctx, compiled, config)
+//	--snip--
+//	log := recorder.Log()
+//
+// Notes:
+// * This is safe for concurrent use, but the order of inputs requested concurrently isn't reproducible.
+// * Only the results of host functions are recorded, not any side effects, such as writes to guest memory.
+type Recorder struct {
+	sys Sys
+	mu  sync.Mutex
+	log ReplayLog
+}
+
+// NewRecorder returns a Recorder which reads inputs from sys, or the real clock and crypto/rand if nil.
+func NewRecorder(sys Sys) *Recorder {
+	if sys == nil {
+		sys = realSys{}
+	}
+	return &Recorder{sys: sys}
+}
+
+// Log returns a copy of the inputs recorded so far.
+func (r *Recorder) Log() ReplayLog {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return ReplayLog{Events: append([]ReplayEvent{}, r.log.Events...)}
+}
+
+func (r *Recorder) record(e ReplayEvent) {
+	r.mu.Lock()
+	r.log.Events = append(r.log.Events, e)
+	r.mu.Unlock()
+}
+
+// TimeNowUnixNano implements Sys.TimeNowUnixNano
+func (r *Recorder) TimeNowUnixNano() uint64 {
+	now := r.sys.TimeNowUnixNano()
+	r.record(ReplayEvent{Source: replaySourceClock, Values: []uint64{now}})
+	return now
+}
+
+// RandSource implements Sys.RandSource
+func (r *Recorder) RandSource(p []byte) error {
+	if err := r.sys.RandSource(p); err != nil {
+		return err
+	}
+	r.record(ReplayEvent{Source: replaySourceRandom, Data: append([]byte{}, p...)})
+	return nil
+}
+
+// Sleep implements Sleeper.Sleep by delegating to the Sys, if it is a Sleeper, or time.Sleep.
+func (r *Recorder) Sleep(ctx context.Context, ns uint64) {
+	if s, ok := r.sys.(Sleeper); ok {
+		s.Sleep(ctx, ns)
+	} else {
+		time.Sleep(time.Duration(ns))
+	}
+}
+
+// Stdin returns a reader which records the bytes read from stdin. Errors besides io.EOF are not recorded.
+func (r *Recorder) Stdin(stdin io.Reader) io.Reader {
+	return &recordingReader{r: r, stdin: stdin}
+}
+
+type recordingReader struct {
+	r     *Recorder
+	stdin io.Reader
+}
+
+// Read implements io.Reader
+func (rr *recordingReader) Read(p []byte) (int, error) {
+	n, err := rr.stdin.Read(p)
+	if n > 0 {
+		rr.r.record(ReplayEvent{Source: replaySourceStdin, Data: append([]byte{}, p[:n]...)})
+	}
+	if err == io.EOF {
+		rr.r.record(ReplayEvent{Source: replaySourceStdin})
+	}
+	return n, err
+}
+
+// HostFunction returns a host function for wazero.ModuleBuilder ExportFunction, which records the results of fn
+// under the name. Use the same name with Replayer.HostFunction.
+//
+// This errs if fn isn't a function, or any result isn't a fixed-size integer or float. Ex. uint32 or float64
+func (r *Recorder) HostFunction(name string, fn interface{}) (interface{}, error) {
+	fnV := reflect.ValueOf(fn)
+	if err := checkReplayFunc(fnV.Type(), fn); err != nil {
+		return nil, err
+	}
+	return reflect.MakeFunc(fnV.Type(), func(args []reflect.Value) []reflect.Value {
+		results := fnV.Call(args)
+		values := make([]uint64, len(results))
+		for i, v := range results {
+			values[i] = valueBits(v)
+		}
+		r.record(ReplayEvent{Source: replaySourceFunc + name, Values: values})
+		return results
+	}).Interface(), nil
+}
+
+// Replayer reproduces a guest run from a ReplayLog, by returning its inputs instead of reading them from their source.
+// Assign it the same way as the Recorder of the log.
+//
+// When the guest requests an input that isn't the next in the log, the call traps with an error that wraps
+// ErrReplayDiverged. After a run, Remaining is non-zero when the guest requested fewer inputs than recorded.
+//
+// Note: Sleep returns immediately, as the clock is read from the log.
+type Replayer struct {
+	mu     sync.Mutex
+	events []ReplayEvent
+}
+
+// NewReplayer returns a Replayer of the log.
+func NewReplayer(log ReplayLog) *Replayer {
+	return &Replayer{events: log.Events}
+}
+
+// Remaining returns the count of inputs in the log not yet requested.
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.events)
+}
+
+// next returns the next event, or panics with ErrReplayDiverged if it doesn't match the source.
+func (r *Replayer) next(source string, dataLen int) ReplayEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	requested := &ReplayEvent{Source: source, Data: make([]byte, dataLen)}
+	if len(r.events) == 0 {
+		panic(fmt.Errorf("%w: %s requested after the end of the log", ErrReplayDiverged, requested))
+	}
+	e := r.events[0]
+	if e.Source != source || (source == replaySourceRandom && len(e.Data) != dataLen) ||
+		(source == replaySourceStdin && len(e.Data) > dataLen) {
+		panic(fmt.Errorf("%w: %s requested, but the log has %s", ErrReplayDiverged, requested, &e))
+	}
+	r.events = r.events[1:]
+	return e
+}
+
+// TimeNowUnixNano implements Sys.TimeNowUnixNano
+func (r *Replayer) TimeNowUnixNano() uint64 {
+	e := r.next(replaySourceClock, 0)
+	if len(e.Values) != 1 {
+		panic(fmt.Errorf("%w: clock has %d values", ErrReplayDiverged, len(e.Values)))
+	}
+	return e.Values[0]
+}
+
+// RandSource implements Sys.RandSource
+func (r *Replayer) RandSource(p []byte) error {
+	copy(p, r.next(replaySourceRandom, len(p)).Data)
+	return nil
+}
+
+// Sleep implements Sleeper.Sleep
+func (r *Replayer) Sleep(context.Context, uint64) {}
+
+// Stdin returns a reader of the bytes recorded by Recorder.Stdin.
+func (r *Replayer) Stdin() io.Reader {
+	return replayingReader{r}
+}
+
+type replayingReader struct {
+	r *Replayer
+}
+
+// Read implements io.Reader
+func (rr replayingReader) Read(p []byte) (int, error) {
+	e := rr.r.next(replaySourceStdin, len(p))
+	if len(e.Data) == 0 {
+		return 0, io.EOF
+	}
+	return copy(p, e.Data), nil
+}
+
+// HostFunction returns a host function for wazero.ModuleBuilder ExportFunction, which returns the results recorded by
+// Recorder.HostFunction under the name, instead of calling fn.
+func (r *Replayer) HostFunction(name string, fn interface{}) (interface{}, error) {
+	fnT := reflect.TypeOf(fn)
+	if err := checkReplayFunc(fnT, fn); err != nil {
+		return nil, err
+	}
+	source := replaySourceFunc + name
+	return reflect.MakeFunc(fnT, func([]reflect.Value) []reflect.Value {
+		e := r.next(source, 0)
+		if len(e.Values) != fnT.NumOut() {
+			panic(fmt.Errorf("%w: %s has %d results, but the log has %d", ErrReplayDiverged, source, fnT.NumOut(), len(e.Values)))
+		}
+		results := make([]reflect.Value, len(e.Values))
+		for i, bits := range e.Values {
+			results[i] = reflect.New(fnT.Out(i)).Elem()
+			setValueBits(results[i], bits)
+		}
+		return results
+	}).Interface(), nil
+}
+
+// checkReplayFunc returns an error unless fnT is a function whose results are fixed-size integers or floats.
+func checkReplayFunc(fnT reflect.Type, fn interface{}) error {
+	if fnT == nil || fnT.Kind() != reflect.Func {
+		return fmt.Errorf("%T is not a function", fn)
+	}
+	for i := 0; i < fnT.NumOut(); i++ {
+		switch fnT.Out(i).Kind() {
+		case reflect.Uint32, reflect.Int32, reflect.Uint64, reflect.Int64, reflect.Float32, reflect.Float64:
+		default:
+			return fmt.Errorf("result[%d] has unsupported type %s", i, fnT.Out(i))
+		}
+	}
+	return nil
+}
+
+// setValueBits sets the integer or float v to the bits returned by valueBits.
+func setValueBits(v reflect.Value, bits uint64) {
+	switch v.Kind() {
+	case reflect.Int32, reflect.Int64:
+		v.SetInt(int64(bits))
+	case reflect.Float32:
+		v.SetFloat(float64(math.Float32frombits(uint32(bits))))
+	case reflect.Float64:
+		v.SetFloat(math.Float64frombits(bits))
+	default:
+		v.SetUint(bits)
+	}
+}
+
+// realSys is the Sys of a Recorder when none is given.
+type realSys struct{}
+
+// TimeNowUnixNano implements Sys.TimeNowUnixNano
+func (realSys) TimeNowUnixNano() uint64 {
+	return uint64(time.Now().UnixNano())
+}
+
+// RandSource implements Sys.RandSource
+func (realSys) RandSource(p []byte) error {
+	_, err := crand.Read(p)
+	return err
+}
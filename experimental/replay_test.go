@@ -0,0 +1,150 @@
+package experimental_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/wasi"
+)
+
+// replaySource writes 8 random bytes at offset zero, the realtime clock at offset 8 and the result of env.counter at
+// offset 16.
+const replaySource = `(module
+  (import "wasi_snapshot_preview1" "random_get"
+    (func $wasi.random_get (param $buf i32) (param $buf_len i32) (result (;errno;) i32)))
+  (import "wasi_snapshot_preview1" "clock_time_get"
+    (func $wasi.clock_time_get (param $id i32) (param $precision i64) (param $result.timestamp i32) (result (;errno;) i32)))
+  (import "env" "counter" (func $counter (result i64)))
+  (memory 1 1)
+  (func $run
+    i32.const 0 i32.const 8 call $wasi.random_get drop
+    i32.const 0 i64.const 0 i32.const 8 call $wasi.clock_time_get drop
+    i32.const 16 call $counter i64.store
+  )
+  (export "run" (func $run))
+)`
+
+// runReplaySource returns the memory written by replaySource when its inputs are read from sys and counter.
+func runReplaySource(t *testing.T, sys experimental.Sys, counter interface{}) ([]byte, error) {
+	ctx := context.WithValue(context.Background(), experimental.SysKey{}, sys)
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+
+	_, err := wasi.InstantiateSnapshotPreview1(ctx, r)
+	require.NoError(t, err)
+	_, err = r.NewModuleBuilder("env").ExportFunction("counter", counter).Instantiate(ctx)
+	require.NoError(t, err)
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(replaySource))
+	require.NoError(t, err)
+
+	if _, err = mod.ExportedFunction("run").Call(ctx); err != nil {
+		return nil, err
+	}
+	mem, ok := mod.Memory().Read(ctx, 0, 24)
+	require.True(t, ok)
+	return append([]byte{}, mem...), nil
+}
+
+func TestRecorder_Replayer(t *testing.T) {
+	var calls int64
+	counter := func() int64 {
+		calls++
+		return calls * 1000
+	}
+
+	// Record with the real clock and random source.
+	recorder := experimental.NewRecorder(nil)
+	recordedCounter, err := recorder.HostFunction("counter", counter)
+	require.NoError(t, err)
+	recorded, err := runReplaySource(t, recorder, recordedCounter)
+	require.NoError(t, err)
+	log := recorder.Log()
+	require.Equal(t, 3, len(log.Events))
+
+	// Replaying produces the same memory without calling counter again.
+	replayer := experimental.NewReplayer(log)
+	replayedCounter, err := replayer.HostFunction("counter", counter)
+	require.NoError(t, err)
+	replayed, err := runReplaySource(t, replayer, replayedCounter)
+	require.NoError(t, err)
+	require.Equal(t, recorded, replayed)
+	require.Equal(t, int64(1), calls)
+	require.Zero(t, replayer.Remaining())
+
+	t.Run("diverged", func(t *testing.T) {
+		// Drop the clock event, so the guest reads the clock when the log has the counter.
+		diverged := experimental.ReplayLog{Events: []experimental.ReplayEvent{log.Events[0], log.Events[2]}}
+		replayer := experimental.NewReplayer(diverged)
+		replayedCounter, err := replayer.HostFunction("counter", counter)
+		require.NoError(t, err)
+
+		_, err = runReplaySource(t, replayer, replayedCounter)
+		require.ErrorIs(t, err, experimental.ErrReplayDiverged)
+		require.Contains(t, err.Error(), "replay diverged: clock requested, but the log has func:counter")
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		replayer := experimental.NewReplayer(experimental.ReplayLog{Events: log.Events[:1]})
+		replayedCounter, err := replayer.HostFunction("counter", counter)
+		require.NoError(t, err)
+
+		_, err = runReplaySource(t, replayer, replayedCounter)
+		require.ErrorIs(t, err, experimental.ErrReplayDiverged)
+		require.Contains(t, err.Error(), "replay diverged: clock requested after the end of the log")
+	})
+}
+
+func TestRecorder_Stdin(t *testing.T) {
+	recorder := experimental.NewRecorder(nil)
+	stdin := recorder.Stdin(&chunkReader{chunks: []string{"wa", "zero"}})
+
+	var recorded []byte
+	buf := make([]byte, 4)
+	for {
+		n, err := stdin.Read(buf)
+		recorded = append(recorded, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	require.Equal(t, "wazero", string(recorded))
+
+	replayer := experimental.NewReplayer(recorder.Log())
+	var replayed []byte
+	for {
+		n, err := replayer.Stdin().Read(buf)
+		replayed = append(replayed, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	require.Equal(t, recorded, replayed)
+	require.Zero(t, replayer.Remaining())
+}
+
+func TestRecorder_HostFunction_Errors(t *testing.T) {
+	recorder := experimental.NewRecorder(nil)
+	_, err := recorder.HostFunction("counter", 1)
+	require.EqualError(t, err, "int is not a function")
+
+	_, err = recorder.HostFunction("counter", func() string { return "" })
+	require.EqualError(t, err, "result[0] has unsupported type string")
+}
+
+// chunkReader returns a chunk per read, followed by io.EOF.
+type chunkReader struct {
+	chunks []string
+}
+
+// Read implements io.Reader
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(r.chunks) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, r.chunks[0])
+	r.chunks = r.chunks[1:]
+	return n, nil
+}
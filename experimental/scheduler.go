@@ -0,0 +1,130 @@
+package experimental
+
+import (
+	"context"
+	"errors"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// ErrTaskStopped is the cause of the error returned by a Task stopped by the Scheduler when it yielded.
+var ErrTaskStopped = errors.New("task stopped by scheduler")
+
+// Scheduler runs calls to guest functions cooperatively: only one Task runs at a time, until it calls Yield. This
+// allows a host to interleave multiple guest calls without suspending and resuming the WebAssembly stack.
+//
+// Ex. Given guests importing "yield" from the module "scheduler", this runs two of their functions cooperatively:
+//	s := experimental.NewScheduler()
+//	_, err := r.NewModuleBuilder("scheduler").ExportFunction("yield", s.Yield).Instantiate(ctx)
+//	--snip--
+//	a := s.Go(guestA.ExportedFunction("run"))
+//	b := s.Go(guestB.ExportedFunction("run"))
+//	s.Run(ctx, nil) // returns when both are done.
+//	results, err := a.Results()
+//
+// Each Task calls its function on a separate goroutine, which blocks while another runs. As each call has its own
+// stack, a Task which traps, or is stopped on Yield, doesn't affect the others.
+//
+// Note: A Scheduler is not safe for concurrent use, except Yield, which is called by the tasks it runs.
+type Scheduler struct {
+	tasks []*Task
+	// switched receives a task when it yields or completes, returning control to Run.
+	switched chan *Task
+}
+
+// NewScheduler returns a Scheduler with no tasks.
+func NewScheduler() *Scheduler {
+	return &Scheduler{switched: make(chan *Task)}
+}
+
+// Task is a function call run by a Scheduler.
+type Task struct {
+	s      *Scheduler
+	fn     api.Function
+	params []uint64
+	// resume receives whether the task should continue after it yielded.
+	resume        chan bool
+	started, done bool
+	stop          bool
+	yields        int
+	results       []uint64
+	err           error
+}
+
+// taskKey is a context.Context Value key. Its associated value is the *Task calling a function.
+type taskKey struct{}
+
+// Go adds a Task calling fn with the given params, which begins on the next Run.
+func (s *Scheduler) Go(fn api.Function, params ...uint64) *Task {
+	t := &Task{s: s, fn: fn, params: params, resume: make(chan bool)}
+	s.tasks = append(s.tasks, t)
+	return t
+}
+
+// Run runs tasks in the order they were added, switching to the next each time one calls Yield, until all are done.
+//
+// When onYield is non-nil, it is called each time a task yields, to decide whether it should continue. If it returns
+// false, the task's call fails with ErrTaskStopped when it is next resumed.
+func (s *Scheduler) Run(ctx context.Context, onYield func(*Task) bool) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for running := true; running; {
+		running = false
+		for _, t := range s.tasks {
+			if t.done {
+				continue
+			}
+			running = true
+			if !t.started {
+				t.started = true
+				go t.call(ctx)
+			} else {
+				t.resume <- !t.stop
+			}
+			if <-s.switched; !t.done { // only t runs, so it is what switched.
+				t.yields++
+				t.stop = onYield != nil && !onYield(t)
+			}
+		}
+	}
+	s.tasks = nil // all done
+}
+
+// call calls the function of the task, and returns control to Run when done.
+func (t *Task) call(ctx context.Context) {
+	t.results, t.err = t.fn.Call(context.WithValue(ctx, taskKey{}, t), t.params...)
+	t.done = true
+	t.s.switched <- t
+}
+
+// Yield is a host function for guests to return control to Run, so that other tasks can run. It returns when Run
+// resumes the calling task. This does nothing when not called by a Task of this Scheduler.
+//
+// Ex. To allow guests to import it as "yield" from the module "scheduler":
+//	_, err := r.NewModuleBuilder("scheduler").ExportFunction("yield", s.Yield).Instantiate(ctx)
+func (s *Scheduler) Yield(ctx context.Context) {
+	t, ok := ctx.Value(taskKey{}).(*Task)
+	if !ok || t.s != s {
+		return
+	}
+	s.switched <- t
+	if !<-t.resume {
+		panic(ErrTaskStopped) // unwinds the call, which fails with an error wrapping this.
+	}
+}
+
+// Yields returns the count of times the task yielded so far.
+func (t *Task) Yields() int {
+	return t.yields
+}
+
+// Done returns true when the call of the task completed.
+func (t *Task) Done() bool {
+	return t.done
+}
+
+// Results returns the results of the call, once Done.
+func (t *Task) Results() ([]uint64, error) {
+	return t.results, t.err
+}
@@ -0,0 +1,127 @@
+package experimental_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+const schedulerGuest = `(module
+	(import "scheduler" "yield" (func $yield))
+	(import "env" "log" (func $log (param i32 i32)))
+	(memory 1)
+	(func $run (param $id i32) (result i32)
+		local.get 0 i32.const 0 call $log call $yield
+		local.get 0 i32.const 1 call $log call $yield
+		local.get 0 i32.const 2 call $log
+		local.get 0
+	)
+	(func $trap (param $id i32)
+		local.get 0 i32.const 0 call $log call $yield
+		i32.const 65536 i32.load drop ;; out of bounds
+	)
+	(export "run" (func $run))
+	(export "trap" (func $trap))
+)`
+
+func TestScheduler(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name   string
+		config wazero.RuntimeConfig
+	}{
+		{name: "interpreter", config: wazero.NewRuntimeConfigInterpreter()},
+		{name: "jit", config: wazero.NewRuntimeConfigJIT()},
+	} {
+		tc := tc
+		if tc.name == "jit" && !wazero.JITSupported {
+			continue
+		}
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(tc.config)
+
+			s := experimental.NewScheduler()
+			_, err := r.NewModuleBuilder("scheduler").ExportFunction("yield", s.Yield).Instantiate(ctx)
+			require.NoError(t, err)
+
+			// Only one task runs at a time, so this doesn't need to be guarded.
+			var log []string
+			_, err = r.NewModuleBuilder("env").ExportFunction("log", func(id, step uint32) {
+				log = append(log, fmt.Sprintf("%d.%d", id, step))
+			}).Instantiate(ctx)
+			require.NoError(t, err)
+
+			compiled, err := r.CompileModule(ctx, []byte(schedulerGuest))
+			require.NoError(t, err)
+			defer compiled.Close(ctx)
+
+			guestA, err := r.InstantiateModuleWithConfig(ctx, compiled, wazero.NewModuleConfig().WithName("a"))
+			require.NoError(t, err)
+			guestB, err := r.InstantiateModuleWithConfig(ctx, compiled, wazero.NewModuleConfig().WithName("b"))
+			require.NoError(t, err)
+
+			t.Run("interleaves", func(t *testing.T) {
+				log = nil
+				a := s.Go(guestA.ExportedFunction("run"), 1)
+				b := s.Go(guestB.ExportedFunction("run"), 2)
+				s.Run(ctx, nil)
+
+				require.Equal(t, []string{"1.0", "2.0", "1.1", "2.1", "1.2", "2.2"}, log)
+				for i, task := range []*experimental.Task{a, b} {
+					require.True(t, task.Done())
+					require.Equal(t, 2, task.Yields())
+					results, err := task.Results()
+					require.NoError(t, err)
+					require.Equal(t, []uint64{uint64(i + 1)}, results)
+				}
+			})
+
+			t.Run("trap after yield", func(t *testing.T) {
+				log = nil
+				a := s.Go(guestA.ExportedFunction("trap"), 1)
+				b := s.Go(guestB.ExportedFunction("run"), 2)
+				s.Run(ctx, nil)
+
+				require.Equal(t, []string{"1.0", "2.0", "2.1", "2.2"}, log)
+				_, err := a.Results()
+				require.EqualError(t, err, `wasm error: out of bounds memory access
+wasm stack trace:
+	a.trap(i32)`)
+
+				// The trap didn't affect the other task.
+				results, err := b.Results()
+				require.NoError(t, err)
+				require.Equal(t, []uint64{2}, results)
+			})
+
+			t.Run("stopped on yield", func(t *testing.T) {
+				log = nil
+				a := s.Go(guestA.ExportedFunction("run"), 1)
+				b := s.Go(guestB.ExportedFunction("run"), 2)
+				s.Run(ctx, func(task *experimental.Task) bool {
+					return task != a
+				})
+
+				require.Equal(t, []string{"1.0", "2.0", "2.1", "2.2"}, log)
+				_, err := a.Results()
+				require.True(t, errors.Is(err, experimental.ErrTaskStopped))
+
+				results, err := b.Results()
+				require.NoError(t, err)
+				require.Equal(t, []uint64{2}, results)
+
+				// The stopped call unwound cleanly, so the module can still be called.
+				results, err = guestA.ExportedFunction("run").Call(ctx, 3)
+				require.NoError(t, err)
+				require.Equal(t, []uint64{3}, results)
+			})
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package experimental
+
+import (
+	"context"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// StepListenerKey is a context.Context Value key. Its associated value should be a StepListener.
+//
+// Note: This is interpreter-only for now!
+type StepListenerKey struct{}
+
+// StepListener is notified before each operation of a function executes, which allows inspecting or pausing the
+// execution one operation at a time. This is heavyweight as it is called on every operation, so only enable it for
+// debugging. See Stepper
+type StepListener interface {
+	// Step is called before the operation at pc of the function fn executes. depth is the count of functions which
+	// called fn in the same call, so zero is the function called. stack is the operand stack, shared by all functions
+	// in the call, and is only valid until Step returns.
+	//
+	// Note: pc is the index of the operation in the compiled function, so is unrelated to offsets in the binary.
+	Step(ctx context.Context, fn api.FunctionDefinition, depth int, pc uint64, stack []uint64)
+}
+
+// Stepper calls a function one operation at a time, pausing before each one to allow inspecting the operand stack and
+// the function executing it. See NewStepper
+//
+// Ex. To print the stack before each operation of a call:
+//	s := experimental.NewStepper(ctx, fn, 1)
+//	for ok := true; ok; ok = s.Step() {
+//		fmt.Println(s.Function().Name(), s.PC(), s.Stack())
+//	}
+//	results, err := s.Continue()
+//
+// Note: This is not safe for concurrent use.
+type Stepper struct {
+	steps    chan stepState
+	resume   chan bool // true to pause before the next operation, false to run to completion.
+	done     chan struct{}
+	finished bool
+	state    stepState
+	results  []uint64
+	err      error
+}
+
+type stepState struct {
+	fn    api.FunctionDefinition
+	depth int
+	pc    uint64
+	stack []uint64
+}
+
+// NewStepper calls fn with the params, and pauses before its first operation. Use Step to execute operations, and
+// Continue to run the call to completion.
+//
+// Continue must be called to release the call, as it executes in a new goroutine. When the runtime doesn't support
+// StepListener, the call completes without pausing.
+func NewStepper(ctx context.Context, fn api.Function, params ...uint64) *Stepper {
+	s := &Stepper{steps: make(chan stepState), resume: make(chan bool), done: make(chan struct{})}
+	ctx = context.WithValue(ctx, StepListenerKey{}, &stepperListener{s: s})
+	go func() {
+		defer close(s.done)
+		s.results, s.err = fn.Call(ctx, params...)
+	}()
+	s.wait()
+	return s
+}
+
+// wait waits for the call to pause before its next operation, or to complete. This returns false if it completed.
+func (s *Stepper) wait() bool {
+	select {
+	case s.state = <-s.steps:
+		return true
+	case <-s.done:
+		s.finished = true
+		s.state = stepState{}
+		return false
+	}
+}
+
+// Step executes the operation at PC, and returns true when the call paused before another operation. When this
+// returns false, the call completed, so use Continue to read its results.
+func (s *Stepper) Step() bool {
+	if s.finished {
+		return false
+	}
+	s.resume <- true
+	return s.wait()
+}
+
+// Continue runs the call to completion without pausing, and returns its results.
+func (s *Stepper) Continue() ([]uint64, error) {
+	if !s.finished {
+		s.resume <- false
+		<-s.done
+		s.finished = true
+		s.state = stepState{}
+	}
+	return s.results, s.err
+}
+
+// Function returns the function of the next operation, or nil if the call completed.
+func (s *Stepper) Function() api.FunctionDefinition {
+	return s.state.fn
+}
+
+// Depth returns the count of functions which called Function in the same call. This increases when a call steps into
+// another function, and decreases when it returns.
+func (s *Stepper) Depth() int {
+	return s.state.depth
+}
+
+// PC returns the index of the next operation in Function. See StepListener.Step
+func (s *Stepper) PC() uint64 {
+	return s.state.pc
+}
+
+// Stack returns a copy of the operand stack before the next operation, or nil if the call completed.
+func (s *Stepper) Stack() []uint64 {
+	return s.state.stack
+}
+
+// stepperListener is the StepListener of a Stepper. It is only called by the goroutine of the call.
+type stepperListener struct {
+	s         *Stepper
+	continued bool
+}
+
+// Step implements StepListener.Step
+func (l *stepperListener) Step(_ context.Context, fn api.FunctionDefinition, depth int, pc uint64, stack []uint64) {
+	if l.continued {
+		return
+	}
+	l.s.steps <- stepState{fn: fn, depth: depth, pc: pc, stack: append([]uint64{}, stack...)}
+	l.continued = !<-l.s.resume
+}
@@ -0,0 +1,106 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// stepperSource exports "identity", and "call_identity" which adds one to the result of calling it.
+const stepperSource = `(module
+  (func $identity (param i32) (result i32) local.get 0)
+  (func $call_identity (param i32) (result i32) local.get 0 call $identity i32.const 1 i32.add)
+  (export "identity" (func $identity))
+  (export "call_identity" (func $call_identity))
+)`
+
+type step struct {
+	function string
+	depth    int
+	pc       uint64
+	stack    []uint64
+}
+
+func TestStepper(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(stepperSource))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		function      string
+		expectedSteps []step
+		expected      uint64
+	}{
+		{
+			name:     "identity",
+			function: "identity",
+			expectedSteps: []step{
+				{"identity", 0, 0, []uint64{42}},     // local.get 0
+				{"identity", 0, 1, []uint64{42, 42}}, // drop the param, keeping the result
+				{"identity", 0, 2, []uint64{42}},     // return
+			},
+			expected: 42,
+		},
+		{
+			name:     "nested call",
+			function: "call_identity",
+			expectedSteps: []step{
+				{"call_identity", 0, 0, []uint64{42}},        // local.get 0
+				{"call_identity", 0, 1, []uint64{42, 42}},    // call $identity
+				{"identity", 1, 0, []uint64{42, 42}},         // local.get 0
+				{"identity", 1, 1, []uint64{42, 42, 42}},     // drop the param, keeping the result
+				{"identity", 1, 2, []uint64{42, 42}},         // return
+				{"call_identity", 0, 2, []uint64{42, 42}},    // i32.const 1
+				{"call_identity", 0, 3, []uint64{42, 42, 1}}, // i32.add
+				{"call_identity", 0, 4, []uint64{42, 43}},    // drop the param, keeping the result
+				{"call_identity", 0, 5, []uint64{43}},        // return
+			},
+			expected: 43,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			s := experimental.NewStepper(ctx, mod.ExportedFunction(tc.function), 42)
+			var steps []step
+			for ok := true; ok; ok = s.Step() {
+				steps = append(steps, step{s.Function().Name(), s.Depth(), s.PC(), s.Stack()})
+			}
+			require.Equal(t, tc.expectedSteps, steps)
+			require.Nil(t, s.Function())
+			require.Nil(t, s.Stack())
+
+			results, err := s.Continue()
+			require.NoError(t, err)
+			require.Equal(t, []uint64{tc.expected}, results)
+		})
+	}
+}
+
+func TestStepper_Continue(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfigInterpreter())
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(stepperSource))
+	require.NoError(t, err)
+
+	s := experimental.NewStepper(ctx, mod.ExportedFunction("call_identity"), 1)
+	require.True(t, s.Step())
+
+	// Continuing runs the remaining operations, including those of the nested call.
+	results, err := s.Continue()
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, results)
+	require.False(t, s.Step())
+
+	// Continuing again returns the same results.
+	results, err = s.Continue()
+	require.NoError(t, err)
+	require.Equal(t, []uint64{2}, results)
+}
@@ -0,0 +1,81 @@
+package experimental
+
+import (
+	"context"
+	"io"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// StreamResults returns a host function for wazero.ModuleBuilder ExportFunction, which a guest calls once per result
+// it produces, instead of buffering all results in its memory. The guest passes each result as the i32 offset and byte
+// count of its bytes in memory, which are passed to fn.
+//
+// Ex. Given a tokenizer guest importing "emit" from the module "stream", this sends each token to a channel:
+//	tokens := make(chan string)
+//	emit := experimental.StreamResults(func(_ context.Context, result []byte) error {
+//		tokens <- string(result) // copy, as the result is a view of guest memory.
+//		return nil
+//	})
+//	_, err := r.NewModuleBuilder("stream").ExportFunction("emit", emit).Instantiate(ctx)
+//
+// The call is synchronous: the guest doesn't continue until fn returns, so a slow consumer applies backpressure to the
+// guest. If fn returns an error, the guest traps, so its call fails with an error wrapping it.
+//
+// Note: The result is a view of guest memory, only valid until fn returns. Copy it to retain the bytes.
+// Note: Calls trap if the result isn't entirely within memory.
+func StreamResults(fn func(ctx context.Context, result []byte) error) func(ctx context.Context, m api.Module, offset, byteCount uint32) {
+	return func(ctx context.Context, m api.Module, offset, byteCount uint32) {
+		mem := m.Memory()
+		if mem == nil {
+			panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+		}
+		result, ok := ReadAlias(ctx, mem, offset, byteCount)
+		if !ok {
+			panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+		}
+		if err := fn(ctx, result); err != nil {
+			panic(err) // traps the guest, which fails with an error wrapping this.
+		}
+	}
+}
+
+// NewReaderHostFunc returns a host function for wazero.ModuleBuilder ExportFunction, which a guest calls repeatedly to
+// read from r into a buffer in its memory. The guest passes the i32 offset and byte count of the buffer, and the result
+// is the count of bytes read, which is zero at EOF.
+//
+// Ex. Given a guest importing "read" from the module "env", this allows it to pull a large file in chunks:
+//	f, err := os.Open("input.bin")
+//	--snip--
+//	_, err = r.NewModuleBuilder("env").ExportFunction("read", experimental.NewReaderHostFunc(f)).Instantiate(ctx)
+//
+// Bytes are read directly into guest memory, so r isn't buffered by the host.
+//
+// Notes:
+// * A zero-length buffer returns zero without reading from r. Only a guest which passes a non-empty buffer should
+//   interpret zero as EOF.
+// * Calls trap if the buffer isn't entirely within memory, or r fails with an error other than io.EOF.
+func NewReaderHostFunc(r io.Reader) func(ctx context.Context, m api.Module, bufPtr, bufLen uint32) uint32 {
+	return func(ctx context.Context, m api.Module, bufPtr, bufLen uint32) uint32 {
+		if bufLen == 0 {
+			return 0
+		}
+		mem := m.Memory()
+		if mem == nil {
+			panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+		}
+		buf, ok := ReadAlias(ctx, mem, bufPtr, bufLen)
+		if !ok {
+			panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+		}
+		n, err := r.Read(buf)
+		for n == 0 && err == nil { // io.Reader discourages this, but doesn't prohibit it, so retry to not imply EOF.
+			n, err = r.Read(buf)
+		}
+		if err != nil && err != io.EOF {
+			panic(err) // traps the guest, which fails with an error wrapping this.
+		}
+		return uint32(n)
+	}
+}
@@ -0,0 +1,187 @@
+package experimental_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// tokenizerWasm emits the words of "hello wasm world", which the host writes to memory at offset zero.
+const tokenizerWasm = `(module
+  (import "stream" "emit" (func $emit (param i32 i32)))
+  (func $run
+    i32.const 0  i32.const 5 call $emit
+    i32.const 6  i32.const 4 call $emit
+    i32.const 11 i32.const 5 call $emit
+    i32.const 65535 i32.const 2 call $emit
+  )
+  (memory 1)
+  (export "run" (func $run))
+)`
+
+func TestStreamResults(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	// Results are sent to an unbuffered channel, so the guest blocks until each is received.
+	tokens := make(chan string)
+	var stop string
+	emit := experimental.StreamResults(func(_ context.Context, result []byte) error {
+		if token := string(result); token != stop {
+			tokens <- token
+			return nil
+		}
+		return errors.New("stopped")
+	})
+
+	host, err := r.NewModuleBuilder("stream").ExportFunction("emit", emit).Instantiate(ctx)
+	require.NoError(t, err)
+	defer host.Close(ctx)
+
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(tokenizerWasm))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+	require.True(t, mod.Memory().Write(ctx, 0, []byte("hello wasm world")))
+
+	run := func() <-chan error {
+		done := make(chan error, 1)
+		go func() {
+			_, err := mod.ExportedFunction("run").Call(ctx)
+			done <- err
+			close(tokens)
+		}()
+		return done
+	}
+
+	t.Run("out of bounds after all tokens", func(t *testing.T) {
+		done := run()
+		var received []string
+		for token := range tokens {
+			received = append(received, token)
+		}
+		require.Equal(t, []string{"hello", "wasm", "world"}, received)
+		require.EqualError(t, <-done, `wasm error: out of bounds memory access
+wasm stack trace:
+	stream.emit(i32,i32)
+	.run()`)
+	})
+
+	t.Run("host error traps", func(t *testing.T) {
+		tokens, stop = make(chan string), "wasm"
+		done := run()
+		var received []string
+		for token := range tokens {
+			received = append(received, token)
+		}
+		require.Equal(t, []string{"hello"}, received)
+		require.EqualError(t, <-done, `stopped (recovered by wazero)
+wasm stack trace:
+	stream.emit(i32,i32)
+	.run()`)
+	})
+}
+
+// drainWasm exports "drain", which reads into memory from the imported "read", with chunks of its i32 param, until EOF.
+// Each chunk is read after the previous, from offset zero, and the result is the total bytes read.
+var drainWasm = binary.EncodeModule(&wasm.Module{
+	TypeSection: []*wasm.FunctionType{
+		{Params: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+		{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+	},
+	ImportSection:   []*wasm.Import{{Module: "env", Name: "read", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+	FunctionSection: []wasm.Index{1},
+	CodeSection: []*wasm.Code{{
+		LocalTypes: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}, // total, n
+		Body: []byte{
+			wasm.OpcodeLoop, 0x40,
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, // n = read(total, chunk)
+			wasm.OpcodeLocalTee, 2,
+			wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeLocalSet, 1, // total += n
+			wasm.OpcodeLocalGet, 2, wasm.OpcodeBrIf, 0, // until n == 0
+			wasm.OpcodeEnd,
+			wasm.OpcodeLocalGet, 1,
+			wasm.OpcodeEnd,
+		},
+	}},
+	MemorySection: &wasm.Memory{Min: 1},
+	ExportSection: []*wasm.Export{{Name: "drain", Type: wasm.ExternTypeFunc, Index: 1}},
+})
+
+// countingReader counts the calls to Read.
+type countingReader struct {
+	io.Reader
+	reads int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	r.reads++
+	return r.Reader.Read(p)
+}
+
+func TestNewReaderHostFunc(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	input := "hello wasm world"
+	reader := &countingReader{Reader: strings.NewReader(input)}
+	host, err := r.NewModuleBuilder("env").ExportFunction("read", experimental.NewReaderHostFunc(reader)).Instantiate(ctx)
+	require.NoError(t, err)
+	defer host.Close(ctx)
+
+	t.Run("zero-length buffer", func(t *testing.T) {
+		results, err := host.ExportedFunction("read").Call(ctx, 0, 0)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{0}, results)
+		require.Zero(t, reader.reads)
+	})
+
+	mod, err := r.InstantiateModuleFromCode(ctx, drainWasm)
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	results, err := mod.ExportedFunction("drain").Call(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{uint64(len(input))}, results)
+	require.Equal(t, 5, reader.reads) // 4 chunks, then EOF
+
+	read, ok := mod.Memory().Read(ctx, 0, uint32(len(input)))
+	require.True(t, ok)
+	require.Equal(t, input, string(read))
+
+	t.Run("read error traps", func(t *testing.T) {
+		failing := experimental.NewReaderHostFunc(io.MultiReader(strings.NewReader("a"), errReader{}))
+		host, err := r.NewModuleBuilder("failing").ExportFunction("read", failing).Instantiate(ctx)
+		require.NoError(t, err)
+		defer host.Close(ctx)
+
+		compiled, err := r.CompileModule(ctx, drainWasm)
+		require.NoError(t, err)
+		defer compiled.Close(ctx)
+
+		mod, err := r.InstantiateModuleWithConfig(ctx, compiled,
+			wazero.NewModuleConfig().WithName("drain").WithImportModule("env", "failing"))
+		require.NoError(t, err)
+		defer mod.Close(ctx)
+
+		_, err = mod.ExportedFunction("drain").Call(ctx, 5)
+		require.EqualError(t, err, `read failed (recovered by wazero)
+wasm stack trace:
+	failing.read(i32,i32) i32
+	drain.[1](i32) i32`)
+	})
+}
+
+// errReader always fails.
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) {
+	return 0, errors.New("read failed")
+}
@@ -0,0 +1,303 @@
+package experimental
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	moduleType  = reflect.TypeOf((*api.Module)(nil)).Elem()
+	uint32Type  = reflect.TypeOf(uint32(0))
+)
+
+// WithStructParams returns a host function for wazero.ModuleBuilder ExportFunction, which decodes each pointer to
+// struct parameter of fn from guest memory. The guest passes these as an i32 offset, instead of each field.
+//
+// Ex. Given a guest with a C struct `struct point { uint32_t x; uint32_t y; }`, this receives `point*` as an i32:
+//	type point struct{ X, Y uint32 }
+//	fn, err := experimental.WithStructParams(func(p *point) uint32 { return p.X + p.Y })
+//	if err != nil {
+//		return err
+//	}
+//	_, err = r.NewModuleBuilder("env").ExportFunction("sum", fn).Instantiate(ctx)
+//
+// The layout is that of a C struct in a 32-bit guest: Fields are little-endian, and each is aligned to its size, as is
+// the struct to its largest field. Fields must be exported fixed-size integers or floats. Ex. uint32 or float64
+//
+// When fn returns, changes to the struct are written back to guest memory, so fn can also use them for output.
+//
+// Note: fn may accept a context.Context and api.Module as leading parameters, like any other host function.
+// Note: Calls trap if the struct isn't entirely within memory, or its offset isn't aligned.
+func WithStructParams(fn interface{}) (interface{}, error) {
+	fnV := reflect.ValueOf(fn)
+	fnT := fnV.Type()
+	if fnT.Kind() != reflect.Func {
+		return nil, fmt.Errorf("%T is not a function", fn)
+	}
+
+	// Find parameters which are already the context.Context and api.Module.
+	pIdx := 0
+	var hasContext, hasModule bool
+	if pIdx < fnT.NumIn() && fnT.In(pIdx) == contextType {
+		hasContext = true
+		pIdx++
+	}
+	if pIdx < fnT.NumIn() && fnT.In(pIdx) == moduleType {
+		hasModule = true
+		pIdx++
+	}
+
+	// The result always accepts both, as decoding requires the module's memory.
+	in := []reflect.Type{contextType, moduleType}
+	layouts := make([]*structLayout, fnT.NumIn()-pIdx)
+	for i := range layouts {
+		t := fnT.In(pIdx + i)
+		if t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+			l, err := newStructLayout(t.Elem())
+			if err != nil {
+				return nil, fmt.Errorf("param[%d]: %w", pIdx+i, err)
+			}
+			layouts[i] = l
+			t = uint32Type // the offset of the struct in memory
+		}
+		in = append(in, t)
+	}
+	out := make([]reflect.Type, fnT.NumOut())
+	for i := range out {
+		out[i] = fnT.Out(i)
+	}
+
+	return reflect.MakeFunc(reflect.FuncOf(in, out, false), func(args []reflect.Value) []reflect.Value {
+		ctx := args[0].Interface().(context.Context)
+		mem := args[1].Interface().(api.Module).Memory()
+
+		fnArgs := make([]reflect.Value, 0, fnT.NumIn())
+		if hasContext {
+			fnArgs = append(fnArgs, args[0])
+		}
+		if hasModule {
+			fnArgs = append(fnArgs, args[1])
+		}
+		structs := make([]reflect.Value, len(layouts))
+		for i, arg := range args[2:] {
+			if l := layouts[i]; l != nil {
+				structs[i] = l.read(ctx, mem, uint32(arg.Uint()))
+				arg = structs[i]
+			}
+			fnArgs = append(fnArgs, arg)
+		}
+
+		results := fnV.Call(fnArgs)
+
+		for i, l := range layouts {
+			if l != nil {
+				l.write(ctx, mem, uint32(args[2+i].Uint()), structs[i])
+			}
+		}
+		return results
+	}).Interface(), nil
+}
+
+// ReadStruct decodes the struct ptr points to from guest memory at the offset. This uses the same layout as
+// WithStructParams, which is that of a C struct in a 32-bit guest:
+// * Fields are little-endian, and in the order they are declared.
+// * Each field is at an offset aligned to its size, after padding, ex. a uint64 following a uint32 is at offset 8.
+// * The struct is aligned to its largest field, and padded at the end to a multiple of that.
+//
+// Ex. To read a C `struct point { uint32_t x; uint64_t y; }`, which is 16 bytes:
+//	type point struct{ X uint32; Y uint64 }
+//	var p point
+//	err := experimental.ReadStruct(ctx, mod.Memory(), offset, &p)
+//
+// This errs if ptr isn't a pointer to a struct, or any field isn't an exported fixed-size integer or float. Ex. a
+// pointer, slice, string or nested struct. This also errs if the struct isn't entirely within memory, or its offset
+// isn't aligned.
+func ReadStruct(ctx context.Context, mem api.Memory, offset uint32, ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("%T is not a pointer to a struct", ptr)
+	}
+	l, err := newStructLayout(v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	if err = l.checkOffset(ctx, mem, offset); err != nil {
+		return err
+	}
+	buf, _ := mem.Read(ctx, offset, l.size)
+	l.decode(buf, v.Elem())
+	return nil
+}
+
+// WriteStruct encodes the struct v, or the struct it points to, into guest memory at the offset. The layout and
+// errors are the same as ReadStruct.
+//
+// Note: Padding between fields is written as zero.
+func WriteStruct(ctx context.Context, mem api.Memory, offset uint32, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("%T is not a struct or a pointer to one", v)
+	}
+	l, err := newStructLayout(rv.Type())
+	if err != nil {
+		return err
+	}
+	if err = l.checkOffset(ctx, mem, offset); err != nil {
+		return err
+	}
+	mem.Write(ctx, offset, l.encode(rv))
+	return nil
+}
+
+// structLayout is the layout of a Go struct in guest memory.
+type structLayout struct {
+	t           reflect.Type
+	fields      []structField
+	size, align uint32
+}
+
+// structField is the position of a Go struct field in guest memory.
+type structField struct {
+	// index is the index of the field in the Go struct.
+	index        int
+	offset, size uint32
+}
+
+// newStructLayout returns the layout of the struct type, or an error if it has unsupported fields.
+func newStructLayout(t reflect.Type) (*structLayout, error) {
+	l := &structLayout{t: t, align: 1}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			return nil, fmt.Errorf("%s.%s is not exported", t, f.Name)
+		}
+		var size uint32
+		switch f.Type.Kind() {
+		case reflect.Uint8, reflect.Int8:
+			size = 1
+		case reflect.Uint16, reflect.Int16:
+			size = 2
+		case reflect.Uint32, reflect.Int32, reflect.Float32:
+			size = 4
+		case reflect.Uint64, reflect.Int64, reflect.Float64:
+			size = 8
+		default:
+			return nil, fmt.Errorf("%s.%s has unsupported type %s", t, f.Name, f.Type)
+		}
+		l.size = alignTo(l.size, size)
+		l.fields = append(l.fields, structField{index: i, offset: l.size, size: size})
+		l.size += size
+		if size > l.align {
+			l.align = size
+		}
+	}
+	l.size = alignTo(l.size, l.align)
+	return l, nil
+}
+
+// alignTo rounds the offset up to a multiple of the alignment.
+func alignTo(offset, align uint32) uint32 {
+	return (offset + align - 1) / align * align
+}
+
+// checkOffset returns an error if the struct at the offset isn't entirely within memory, or the offset isn't aligned.
+func (l *structLayout) checkOffset(ctx context.Context, mem api.Memory, offset uint32) error {
+	if mem == nil || uint64(offset)+uint64(l.size) > uint64(mem.Size(ctx)) {
+		return wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess
+	}
+	if offset%l.align != 0 {
+		return wasmruntime.New(fmt.Sprintf("unaligned %s at offset %d", l.t, offset))
+	}
+	return nil
+}
+
+// read returns a pointer to the struct decoded from memory at the offset, or traps if checkOffset fails.
+func (l *structLayout) read(ctx context.Context, mem api.Memory, offset uint32) reflect.Value {
+	if err := l.checkOffset(ctx, mem, offset); err != nil {
+		panic(err)
+	}
+	buf, _ := mem.Read(ctx, offset, l.size)
+
+	ptr := reflect.New(l.t)
+	l.decode(buf, ptr.Elem())
+	return ptr
+}
+
+// decode sets the fields of the struct v from buf, which is the size of the struct.
+func (l *structLayout) decode(buf []byte, v reflect.Value) {
+	for _, f := range l.fields {
+		fv, b := v.Field(f.index), buf[f.offset:f.offset+f.size]
+		switch fv.Kind() {
+		case reflect.Uint8:
+			fv.SetUint(uint64(b[0]))
+		case reflect.Int8:
+			fv.SetInt(int64(int8(b[0])))
+		case reflect.Uint16:
+			fv.SetUint(uint64(binary.LittleEndian.Uint16(b)))
+		case reflect.Int16:
+			fv.SetInt(int64(int16(binary.LittleEndian.Uint16(b))))
+		case reflect.Uint32:
+			fv.SetUint(uint64(binary.LittleEndian.Uint32(b)))
+		case reflect.Int32:
+			fv.SetInt(int64(int32(binary.LittleEndian.Uint32(b))))
+		case reflect.Float32:
+			fv.SetFloat(float64(math.Float32frombits(binary.LittleEndian.Uint32(b))))
+		case reflect.Uint64:
+			fv.SetUint(binary.LittleEndian.Uint64(b))
+		case reflect.Int64:
+			fv.SetInt(int64(binary.LittleEndian.Uint64(b)))
+		case reflect.Float64:
+			fv.SetFloat(math.Float64frombits(binary.LittleEndian.Uint64(b)))
+		}
+	}
+}
+
+// write encodes the struct ptr points to into memory at the offset, or traps if checkOffset fails.
+func (l *structLayout) write(ctx context.Context, mem api.Memory, offset uint32, ptr reflect.Value) {
+	if err := l.checkOffset(ctx, mem, offset); err != nil {
+		panic(err)
+	}
+	mem.Write(ctx, offset, l.encode(ptr.Elem()))
+}
+
+// encode returns the struct v encoded into a buffer of its size.
+func (l *structLayout) encode(v reflect.Value) []byte {
+	buf := make([]byte, l.size)
+	for _, f := range l.fields {
+		fv, b := v.Field(f.index), buf[f.offset:f.offset+f.size]
+		switch fv.Kind() {
+		case reflect.Uint8, reflect.Int8:
+			b[0] = byte(valueBits(fv))
+		case reflect.Uint16, reflect.Int16:
+			binary.LittleEndian.PutUint16(b, uint16(valueBits(fv)))
+		case reflect.Uint32, reflect.Int32, reflect.Float32:
+			binary.LittleEndian.PutUint32(b, uint32(valueBits(fv)))
+		default:
+			binary.LittleEndian.PutUint64(b, valueBits(fv))
+		}
+	}
+	return buf
+}
+
+// valueBits returns the bits of an integer or float field, truncated by the caller to its size.
+func valueBits(v reflect.Value) uint64 {
+	switch v.Kind() {
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return uint64(v.Int())
+	case reflect.Float32:
+		return uint64(math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		return math.Float64bits(v.Float())
+	}
+	return v.Uint()
+}
@@ -0,0 +1,211 @@
+package experimental_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+type point struct {
+	X uint32
+	Y uint64
+}
+
+func TestWithStructParams(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	// sum returns the sum of the fields, and swaps them to show changes are written back.
+	sum, err := experimental.WithStructParams(func(p *point) uint64 {
+		ret := uint64(p.X) + p.Y
+		p.X, p.Y = uint32(p.Y), uint64(p.X)
+		return ret
+	})
+	require.NoError(t, err)
+
+	host, err := r.NewModuleBuilder("env").ExportFunction("sum", sum).Instantiate(ctx)
+	require.NoError(t, err)
+	defer host.Close(ctx)
+
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(`(module
+  (import "env" "sum" (func $sum (param i32) (result i64)))
+  (func $run (param i32) (result i64) local.get 0 call $sum)
+  (memory 1)
+  (export "memory" (memory 0))
+  (export "run" (func $run))
+)`))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+
+	// The struct is 16 bytes as Y is aligned to 8 bytes.
+	require.True(t, mod.Memory().WriteUint32Le(ctx, 8, 2))
+	require.True(t, mod.Memory().WriteUint64Le(ctx, 16, 40))
+
+	results, err := mod.ExportedFunction("run").Call(ctx, 8)
+	require.NoError(t, err)
+	require.Equal(t, uint64(42), results[0])
+
+	x, _ := mod.Memory().ReadUint32Le(ctx, 8)
+	require.Equal(t, uint32(40), x)
+	y, _ := mod.Memory().ReadUint64Le(ctx, 16)
+	require.Equal(t, uint64(2), y)
+
+	t.Run("out of bounds", func(t *testing.T) {
+		_, err := mod.ExportedFunction("run").Call(ctx, 65536-8)
+		require.EqualError(t, err, `wasm error: out of bounds memory access
+wasm stack trace:
+	env.sum(i32) i64
+	.run(i32) i64`)
+	})
+
+	t.Run("unaligned", func(t *testing.T) {
+		_, err := mod.ExportedFunction("run").Call(ctx, 4)
+		require.EqualError(t, err, `wasm error: unaligned experimental_test.point at offset 4
+wasm stack trace:
+	env.sum(i32) i64
+	.run(i32) i64`)
+	})
+}
+
+func TestWithStructParams_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		fn          interface{}
+		expectedErr string
+	}{
+		{
+			name:        "not a function",
+			fn:          point{},
+			expectedErr: "experimental_test.point is not a function",
+		},
+		{
+			name:        "unexported field",
+			fn:          func(context.Context, *struct{ x uint32 }) {},
+			expectedErr: "param[1]: struct { x uint32 }.x is not exported",
+		},
+		{
+			name:        "unsupported field",
+			fn:          func(*struct{ X string }) {},
+			expectedErr: "param[0]: struct { X string }.X has unsupported type string",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := experimental.WithStructParams(tc.fn)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+// header mixes integer and float fields, so has padding between Kind and Scale, and at the end.
+type header struct {
+	Kind  uint8
+	Count int16
+	Scale float64
+	Bias  float32
+	Total uint64
+	Flags uint32
+}
+
+func TestReadStruct_WriteStruct(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(`(module (memory 1) (export "memory" (memory 0)))`))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+	mem := mod.Memory()
+
+	expected := header{Kind: 7, Count: -2, Scale: 1.5, Bias: -0.25, Total: 1 << 40, Flags: 0xffffffff}
+	require.NoError(t, experimental.WriteStruct(ctx, mem, 8, expected))
+
+	// Verify the C layout: Scale is aligned to 8, and the struct is padded to 40 bytes, a multiple of 8.
+	kind, _ := mem.ReadByte(ctx, 8)
+	require.Equal(t, byte(7), kind)
+	count, _ := mem.ReadUint32Le(ctx, 8) // Count is at offset 2
+	require.Equal(t, uint32(0xfffe0007), count)
+	scale, _ := mem.ReadFloat64Le(ctx, 8+8)
+	require.Equal(t, 1.5, scale)
+	bias, _ := mem.ReadFloat32Le(ctx, 8+16)
+	require.Equal(t, float32(-0.25), bias)
+	total, _ := mem.ReadUint64Le(ctx, 8+24)
+	require.Equal(t, uint64(1<<40), total)
+	flags, _ := mem.ReadUint32Le(ctx, 8+32)
+	require.Equal(t, uint32(0xffffffff), flags)
+
+	var actual header
+	require.NoError(t, experimental.ReadStruct(ctx, mem, 8, &actual))
+	require.Equal(t, expected, actual)
+
+	// A pointer to a struct can also be written.
+	actual.Count = 3
+	require.NoError(t, experimental.WriteStruct(ctx, mem, 8, &actual))
+	var updated header
+	require.NoError(t, experimental.ReadStruct(ctx, mem, 8, &updated))
+	require.Equal(t, int16(3), updated.Count)
+}
+
+func TestReadStruct_WriteStruct_Errors(t *testing.T) {
+	ctx := context.Background()
+	r := wazero.NewRuntime()
+
+	mod, err := r.InstantiateModuleFromCode(ctx, []byte(`(module (memory 1) (export "memory" (memory 0)))`))
+	require.NoError(t, err)
+	defer mod.Close(ctx)
+	mem := mod.Memory()
+
+	tests := []struct {
+		name        string
+		offset      uint32
+		v           interface{}
+		expectedErr string
+	}{
+		{
+			name:        "not a struct",
+			v:           new(uint32),
+			expectedErr: "*uint32 is not",
+		},
+		{
+			name:        "pointer field",
+			v:           &struct{ X *uint32 }{},
+			expectedErr: "struct { X *uint32 }.X has unsupported type *uint32",
+		},
+		{
+			name:        "slice field",
+			v:           &struct{ X []byte }{},
+			expectedErr: "struct { X []uint8 }.X has unsupported type []uint8",
+		},
+		{
+			name:        "out of bounds",
+			offset:      65536 - 8,
+			v:           &point{},
+			expectedErr: "out of bounds memory access",
+		},
+		{
+			name:        "unaligned",
+			offset:      4,
+			v:           &point{},
+			expectedErr: "unaligned experimental_test.point at offset 4",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := experimental.ReadStruct(ctx, mem, tc.offset, tc.v)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expectedErr)
+
+			err = experimental.WriteStruct(ctx, mem, tc.offset, tc.v)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.expectedErr)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package experimental
 
+import "context"
+
 // SysKey is a context.Context Value key. Its associated value should be a Sys.
 //
 // See https://github.com/tetratelabs/wazero/issues/491
@@ -13,3 +15,17 @@ type Sys interface {
 	// RandSource allows you to control the value returned by rand.Read().
 	RandSource([]byte) error
 }
+
+// Sleeper is an optional interface implemented by a Sys to control sleeping, such as a WASI "poll_oneoff" clock
+// subscription. When a Sys does not implement Sleeper, sleeping uses time.Sleep.
+//
+// Implement this to put guests on a virtual clock: Sleep can advance the value returned by Sys.TimeNowUnixNano instead
+// of waiting in real time, so that tests which sleep complete instantly.
+//
+// Note: Each sleeping guest calls Sleep from its own goroutine. wazero doesn't provide a virtual clock, so waking
+// concurrent sleepers in the order of their deadlines is up to the implementation. For example, it can advance the
+// clock to the earliest deadline only when all guests are sleeping.
+type Sleeper interface {
+	// Sleep returns after the clock returned by Sys.TimeNowUnixNano advanced by at least the duration in nanoseconds.
+	Sleep(ctx context.Context, ns uint64)
+}
@@ -0,0 +1,53 @@
+// Package wazerotime contains a Go-defined function to read a high-resolution clock. This is accessible from
+// WebAssembly-defined functions via importing ModuleName, for guests that don't import WASI "clock_time_get".
+package wazerotime
+
+import (
+	"context"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
+)
+
+// ModuleName is the module name the time functions are exported into.
+const ModuleName = "wazero_time"
+
+const (
+	// functionNowNs returns the current time in nanoseconds.
+	functionNowNs = "now_ns"
+
+	// importNowNs is the WebAssembly 1.0 (20191205) Text format import of functionNowNs.
+	importNowNs = `(import "wazero_time" "now_ns" (func $wazero_time.now_ns (result (;ns;) i64)))`
+)
+
+// Instantiate instantiates ModuleName, so that other modules can import its functions.
+//
+// The clock is read from experimental.Sys when the context includes experimental.SysKey, so that a virtual clock
+// controls the value returned to the guest. Otherwise, the clock is monotonic and starts at the current Unix time.
+func Instantiate(ctx context.Context, r wazero.Runtime) (api.Module, error) {
+	return r.NewModuleBuilder(ModuleName).
+		ExportFunction(functionNowNs, newNowNs(ctx)).
+		Instantiate(ctx)
+}
+
+// newNowNs returns the Go-defined function named functionNowNs, which returns the current time in nanoseconds.
+//
+// Note: importNowNs shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+func newNowNs(ctx context.Context) func() uint64 {
+	if ctx != nil { // Test to see if internal code are using an experimental feature.
+		if sys := ctx.Value(experimental.SysKey{}); sys != nil {
+			return sys.(experimental.Sys).TimeNowUnixNano
+		}
+	}
+	return monotonicNowNs
+}
+
+// epoch is the reference which makes monotonicNowNs immune to changes in the wall clock.
+var epoch = time.Now()
+
+// monotonicNowNs returns the Unix time of epoch, advanced by the monotonic time elapsed since.
+func monotonicNowNs() uint64 {
+	return uint64(epoch.UnixNano()) + uint64(time.Since(epoch))
+}
@@ -0,0 +1,72 @@
+package wazerotime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/experimental"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// fakeSys is a virtual clock which advances a nanosecond each time it is read.
+type fakeSys struct{ ns uint64 }
+
+func (s *fakeSys) TimeNowUnixNano() uint64 {
+	s.ns++
+	return s.ns
+}
+
+func (s *fakeSys) RandSource([]byte) error {
+	return nil
+}
+
+// nowNsWat exports a function that returns the result of functionNowNs.
+const nowNsWat = `(module
+  ` + importNowNs + `
+  (func $now (result i64) call $wazero_time.now_ns)
+  (export "now" (func $now))
+)`
+
+func TestInstantiate_NowNs(t *testing.T) {
+	t.Run("experimental.Sys", func(t *testing.T) {
+		ctx := context.WithValue(context.Background(), experimental.SysKey{}, &fakeSys{ns: 1640995200000000000})
+
+		r := wazero.NewRuntime()
+		_, err := Instantiate(ctx, r)
+		require.NoError(t, err)
+
+		mod, err := r.InstantiateModuleFromCode(ctx, []byte(nowNsWat))
+		require.NoError(t, err)
+		defer mod.Close(ctx)
+
+		// The guest sees the virtual clock, not real time.
+		results, err := mod.ExportedFunction("now").Call(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1640995200000000001}, results)
+
+		results, err = mod.ExportedFunction("now").Call(ctx)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{1640995200000000002}, results)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		ctx := context.Background()
+
+		r := wazero.NewRuntime()
+		_, err := Instantiate(ctx, r)
+		require.NoError(t, err)
+
+		mod, err := r.InstantiateModuleFromCode(ctx, []byte(nowNsWat))
+		require.NoError(t, err)
+		defer mod.Close(ctx)
+
+		first, err := mod.ExportedFunction("now").Call(ctx)
+		require.NoError(t, err)
+		require.True(t, first[0] >= uint64(epoch.UnixNano()))
+
+		second, err := mod.ExportedFunction("now").Call(ctx)
+		require.NoError(t, err)
+		require.True(t, second[0] >= first[0])
+	})
+}
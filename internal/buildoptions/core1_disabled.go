@@ -0,0 +1,5 @@
+//go:build !wazero_core1
+
+package buildoptions
+
+const IsCore1Only = false
@@ -0,0 +1,7 @@
+//go:build wazero_core1
+
+package buildoptions
+
+// IsCore1Only is true when built with the "wazero_core1" tag. This restricts features to WebAssembly 1.0 (20191205),
+// regardless of RuntimeConfig, so that branches for other features are constant and can be removed by the compiler.
+const IsCore1Only = true
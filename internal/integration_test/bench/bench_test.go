@@ -49,6 +49,50 @@ func BenchmarkInitialization(b *testing.B) {
 	}
 }
 
+func BenchmarkCaller(b *testing.B) {
+	b.Run("interpreter", func(b *testing.B) {
+		m := instantiateHostFunctionModuleWithEngine(b, wazero.NewRuntimeConfigInterpreter())
+		defer m.Close(testCtx)
+		runCallerBench(b, m)
+	})
+	if runtime.GOARCH == "amd64" || runtime.GOARCH == "arm64" {
+		b.Run("jit", func(b *testing.B) {
+			m := instantiateHostFunctionModuleWithEngine(b, wazero.NewRuntimeConfigJIT())
+			defer m.Close(testCtx)
+			runCallerBench(b, m)
+		})
+	}
+}
+
+// runCallerBench compares api.Function Call to a wazero.Caller, which reuses buffers between calls.
+func runCallerBench(b *testing.B, m api.Module) {
+	fibonacci := m.ExportedFunction("fibonacci")
+
+	b.Run("Call", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if _, err := fibonacci.Call(testCtx, 5); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+
+	b.Run("Caller", func(b *testing.B) {
+		caller, err := wazero.NewCaller(m, fibonacci)
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			caller.Params()[0] = 5
+			if err := caller.Call(testCtx); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
 func runInitializationBench(b *testing.B, r wazero.Runtime) {
 	compiled, err := r.CompileModule(testCtx, caseWasm)
 	if err != nil {
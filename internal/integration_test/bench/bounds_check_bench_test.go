@@ -0,0 +1,61 @@
+package bench
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+// constLoadLoopWasm has a function "loop" which sums two constant-offset loads, repeated as many times as its param.
+var constLoadLoopWasm = binary.EncodeModule(&wasm.Module{
+	TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+	FunctionSection: []wasm.Index{0},
+	MemorySection:   &wasm.Memory{Min: 1, Max: 1},
+	CodeSection: []*wasm.Code{{LocalTypes: []wasm.ValueType{wasm.ValueTypeI32}, Body: []byte{
+		wasm.OpcodeLoop, 0x40,
+		wasm.OpcodeLocalGet, 1,
+		wasm.OpcodeI32Const, 16, wasm.OpcodeI32Load, 0x2, 0x0, // i32.load (i32.const 16)
+		wasm.OpcodeI32Add,
+		wasm.OpcodeI32Const, 32, wasm.OpcodeI32Load, 0x2, 0x4, // i32.load offset=4 (i32.const 32)
+		wasm.OpcodeI32Add,
+		wasm.OpcodeLocalSet, 1,
+		wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub, wasm.OpcodeLocalTee, 0,
+		wasm.OpcodeBrIf, 0,
+		wasm.OpcodeEnd,
+		wasm.OpcodeLocalGet, 1,
+		wasm.OpcodeEnd,
+	}}},
+	ExportSection: []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "loop", Index: 0}},
+})
+
+func BenchmarkBoundsChecks(b *testing.B) {
+	if runtime.GOARCH != "amd64" && runtime.GOARCH != "arm64" {
+		b.Skip()
+	}
+
+	b.Run("checked", func(b *testing.B) {
+		runConstLoadLoopBench(b, wazero.NewRuntimeConfigJIT())
+	})
+	b.Run("optimized", func(b *testing.B) {
+		runConstLoadLoopBench(b, wazero.NewRuntimeConfigJIT().WithOptimizeBoundsChecks(true))
+	})
+}
+
+func runConstLoadLoopBench(b *testing.B, config wazero.RuntimeConfig) {
+	m, err := wazero.NewRuntimeWithConfig(config).InstantiateModuleFromCode(testCtx, constLoadLoopWasm)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer m.Close(testCtx)
+
+	loop := m.ExportedFunction("loop")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := loop.Call(testCtx, 1000); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
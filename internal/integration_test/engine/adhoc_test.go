@@ -3,15 +3,18 @@ package adhoc
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -29,6 +32,9 @@ var tests = map[string]func(t *testing.T, r wazero.Runtime){
 	"close module with in-flight calls":       testCloseInFlight,
 	"multiple instantiation from same source": testMultipleInstantiation,
 	"exported function that grows memory":     testMemOps,
+	"call stack exhausted":                    testCallStackExhausted,
+	"call stack exhausted by call_indirect":   testCallIndirectStackExhausted,
+	"exported table functions":                testExportedTableFunctions,
 }
 
 func TestEngineJIT(t *testing.T) {
@@ -113,6 +119,119 @@ func testRecursiveEntry(t *testing.T, r wazero.Runtime) {
 	require.NoError(t, err)
 }
 
+// testCallStackExhausted ensures direct and mutual recursion trap the same way, as in the spec's assert_exhaustion.
+func testCallStackExhausted(t *testing.T, r wazero.Runtime) {
+	module, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $exhaustion
+	(func $runaway call $runaway)
+	(func $mutual-runaway1 call $mutual-runaway2)
+	(func $mutual-runaway2 call $mutual-runaway1)
+	(export "runaway" (func $runaway))
+	(export "mutual-runaway" (func $mutual-runaway1))
+)`))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	for _, name := range []string{"runaway", "mutual-runaway"} {
+		_, err = module.ExportedFunction(name).Call(testCtx)
+		require.True(t, errors.Is(err, sys.ErrCallStackExhausted), name)
+		require.True(t, strings.HasPrefix(err.Error(), "wasm error: call stack exhausted\n"), err.Error())
+	}
+}
+
+// testCallIndirectStackExhausted ensures mutual recursion via call_indirect counts against the call stack limit.
+func testCallIndirectStackExhausted(t *testing.T, r wazero.Runtime) {
+	// recurse returns its param after calling the other function via the table with its param minus one, until zero.
+	recurse := func(tableIndex byte) *wasm.Code {
+		return &wasm.Code{Body: []byte{
+			wasm.OpcodeLocalGet, 0,
+			wasm.OpcodeIf, 0x7f, // (result i32)
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Sub,
+			wasm.OpcodeI32Const, tableIndex,
+			wasm.OpcodeCallIndirect, 0, 0, // type index and table index
+			wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add,
+			wasm.OpcodeElse,
+			wasm.OpcodeI32Const, 0,
+			wasm.OpcodeEnd,
+			wasm.OpcodeEnd,
+		}}
+	}
+	zero, one := wasm.Index(0), wasm.Index(1)
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection:     []*wasm.Code{recurse(1), recurse(0)},
+		TableSection:    []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&zero, &one},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "recurse", Index: 0}},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	recurseFn := module.ExportedFunction("recurse")
+
+	// Deep, but bounded recursion completes.
+	results, err := recurseFn.Call(testCtx, 1000)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1000), results[0])
+
+	// Unbounded recursion traps the same as direct recursion.
+	_, err = recurseFn.Call(testCtx, math.MaxUint32)
+	require.True(t, errors.Is(err, sys.ErrCallStackExhausted))
+}
+
+func testExportedTableFunctions(t *testing.T, r wazero.Runtime) {
+	zero, one := wasm.Index(0), wasm.Index(1)
+	module, err := r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+		},
+		FunctionSection: []wasm.Index{0, 0},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeLocalGet, 1, wasm.OpcodeI32Sub, wasm.OpcodeEnd}},
+		},
+		TableSection: []*wasm.Table{{Min: 3, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{ // the last element is null
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&one, &zero},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "add", Index: 0},
+			{Type: wasm.ExternTypeTable, Name: "table", Index: 0},
+		},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "math",
+			FunctionNames: wasm.NameMap{{Index: 0, Name: "add"}, {Index: 1, Name: "sub"}},
+		},
+	}))
+	require.NoError(t, err)
+	defer module.Close(testCtx)
+
+	require.Nil(t, module.ExportedTable("add"))
+	table := module.ExportedTable("table")
+	require.Equal(t, uint32(3), table.Size(testCtx))
+
+	var dumped []string
+	for i := uint32(0); i < table.Size(testCtx); i++ {
+		fn, err := table.GetFunction(testCtx, i)
+		require.NoError(t, err)
+		if fn == nil {
+			dumped = append(dumped, "null")
+		} else {
+			dumped = append(dumped, fmt.Sprintf("%s.%s%v index=%d", fn.ModuleName(), fn.Name(), fn.ExportNames(), fn.Index()))
+		}
+	}
+	require.Equal(t, []string{"math.sub[] index=1", "math.add[add] index=0", "null"}, dumped)
+
+	_, err = table.GetFunction(testCtx, 3)
+	require.EqualError(t, err, "index 3 is out of range of table with 3 elements")
+}
+
 func TestImportedAndExportedFunc(t *testing.T) {
 	r := wazero.NewRuntime()
 	testImportedAndExportedFunc(t, r)
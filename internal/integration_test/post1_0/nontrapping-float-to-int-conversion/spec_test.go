@@ -1,3 +1,5 @@
+//go:build !wazero_core1
+
 package nontrapping_float_to_int_conversion
 
 import (
@@ -18,6 +18,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/wasm/binary"
 	"github.com/tetratelabs/wazero/internal/wasm/text"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 //go:embed testdata/*.wasm
@@ -264,7 +265,7 @@ func addSpectestModule(t *testing.T, store *wasm.Store) {
 
 	//  (table (export "table") 10 20 funcref)
 	tableLimitMax := uint32(20)
-	mod.TableSection = []*wasm.Table{{Min: 10, Max: &tableLimitMax}}
+	mod.TableSection = []*wasm.Table{{Min: 10, Max: &tableLimitMax, Type: wasm.RefTypeFuncref}}
 	mod.ExportSection = append(mod.ExportSection, &wasm.Export{Name: "table", Index: 0, Type: wasm.ExternTypeTable})
 
 	maybeSetMemoryCap(mod)
@@ -429,7 +430,7 @@ func Run(t *testing.T, testDataFS embed.FS, newEngine func(wasm.Features) wasm.E
 								msg += " in module " + c.Action.Module
 							}
 							_, _, err := callFunction(store, moduleName, c.Action.Field, args...)
-							require.ErrorIs(t, err, wasmruntime.ErrRuntimeCallStackOverflow, msg)
+							require.ErrorIs(t, err, sys.ErrCallStackExhausted, msg)
 						default:
 							t.Fatalf("unsupported action type type: %v", c)
 						}
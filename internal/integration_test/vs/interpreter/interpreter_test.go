@@ -3,6 +3,7 @@ package interpreter
 import (
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/integration_test/vs"
 )
 
@@ -21,6 +22,9 @@ func TestBenchmarkAllocation_Call_JITFastest(t *testing.T) {
 }
 
 func TestFactorial(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	vs.RunTestFactorial(t, runtime)
 }
 
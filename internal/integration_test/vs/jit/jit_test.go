@@ -3,6 +3,7 @@ package jit
 import (
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/integration_test/vs"
 )
 
@@ -17,6 +18,9 @@ func BenchmarkAllocation(b *testing.B) {
 }
 
 func TestFactorial(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	vs.RunTestFactorial(t, runtime)
 }
 
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -26,6 +27,9 @@ const (
 
 // TestModGen is like a end-to-end test and verifies that our module generator only generates valid compilable modules.
 func TestModGen(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tested := map[string]struct{}{}
 	rand := rand.New(rand.NewSource(0)) // use deterministic seed source for easy debugging.
 	runtime := wazero.NewRuntimeWithConfig(wazero.NewRuntimeConfig().WithWasmCore2())
@@ -126,6 +130,9 @@ func TestGenerator_newValueType(t *testing.T) {
 }
 
 func TestGenerator_typeSection(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	g := newGenerator(100, []int{
 		// Params = 1, Reults = 1, i32, i32
 		1, 1, 0, 0,
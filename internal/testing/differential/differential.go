@@ -0,0 +1,99 @@
+// Package differential runs the same function on both the JIT and interpreter engines, failing the test unless they
+// behave identically.
+//
+// Ex.
+//	results := differential.RunOnBothEngines(t, facWasm, "fac", []uint64{10}, differential.Options{})
+//	require.Equal(t, []uint64{3628800}, results)
+package differential
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// testCtx is an arbitrary, non-default context. Non-nil also prevents linter errors.
+var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
+
+const (
+	canonicalNaN32 = uint64(0x7fc00000)
+	canonicalNaN64 = uint64(0x7ff8000000000000)
+)
+
+// Options customize RunOnBothEngines.
+type Options struct {
+	// Config, when set, customizes the wazero.RuntimeConfig of each engine. Ex. to enable features.
+	Config func(wazero.RuntimeConfig) wazero.RuntimeConfig
+
+	// CanonicalizeNaN considers float results equal when both are NaN, regardless of their payload (sign and
+	// mantissa bits). The WebAssembly specification leaves the payload of most NaN results non-deterministic.
+	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#nan-propagation%E2%91%A0
+	CanonicalizeNaN bool
+}
+
+// RunOnBothEngines instantiates the source on each engine, calls the function exported as fn with the given params,
+// and fails the test unless both return the same results, or err with the same message, ex. on a trap. This returns
+// the results of the interpreter, or nil if it erred.
+//
+// Note: The test is skipped when the JIT engine isn't supported on this platform.
+func RunOnBothEngines(t *testing.T, source []byte, fn string, params []uint64, opts Options) []uint64 {
+	if !wazero.JITSupported {
+		t.Skip("JIT is not supported on this platform")
+	}
+
+	interpreterResults, interpreterErr := run(t, wazero.NewRuntimeConfigInterpreter(), source, fn, params, opts)
+	jitResults, jitErr := run(t, wazero.NewRuntimeConfigJIT(), source, fn, params, opts)
+
+	if interpreterErr != nil || jitErr != nil {
+		require.Error(t, interpreterErr, "only jit erred: %v", jitErr)
+		require.Error(t, jitErr, "only interpreter erred: %v", interpreterErr)
+		require.Equal(t, interpreterErr.Error(), jitErr.Error())
+		return nil
+	}
+	require.Equal(t, interpreterResults, jitResults)
+	return interpreterResults
+}
+
+// run calls the function on an engine, returning the results canonicalized per Options.CanonicalizeNaN.
+func run(t *testing.T, config wazero.RuntimeConfig, source []byte, fn string, params []uint64, opts Options) ([]uint64, error) {
+	if opts.Config != nil {
+		config = opts.Config(config)
+	}
+	r := wazero.NewRuntimeWithConfig(config)
+
+	mod, err := r.InstantiateModuleFromCode(testCtx, source)
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	f := mod.ExportedFunction(fn)
+	require.NotNil(t, f, "%s is not an exported function", fn)
+
+	results, err := f.Call(testCtx, params...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.CanonicalizeNaN {
+		canonicalizeNaN(f.ResultTypes(), results)
+	}
+	return results, nil
+}
+
+// canonicalizeNaN replaces any NaN in results with the canonical NaN of its type, so that payloads don't matter.
+func canonicalizeNaN(resultTypes []api.ValueType, results []uint64) {
+	for i, rt := range resultTypes {
+		switch rt {
+		case api.ValueTypeF32:
+			if f := math.Float32frombits(uint32(results[i])); f != f {
+				results[i] = canonicalNaN32
+			}
+		case api.ValueTypeF64:
+			if math.IsNaN(math.Float64frombits(results[i])) {
+				results[i] = canonicalNaN64
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package differential
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestRunOnBothEngines(t *testing.T) {
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{wasm.ValueTypeI64}, Results: []wasm.ValueType{wasm.ValueTypeI64}},
+			{},
+			{Results: []wasm.ValueType{wasm.ValueTypeF32}},
+		},
+		FunctionSection: []wasm.Index{0, 1, 2},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{ // recursive factorial
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Eqz,
+				wasm.OpcodeIf, wasm.ValueTypeI64,
+				wasm.OpcodeI64Const, 1,
+				wasm.OpcodeElse,
+				wasm.OpcodeLocalGet, 0,
+				wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Const, 1, wasm.OpcodeI64Sub,
+				wasm.OpcodeCall, 0,
+				wasm.OpcodeI64Mul,
+				wasm.OpcodeEnd,
+				wasm.OpcodeEnd,
+			}},
+			{Body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeF32Const, 0x00, 0x00, 0xa0, 0x7f, wasm.OpcodeEnd}}, // NaN with a non-canonical payload
+		},
+		ExportSection: []*wasm.Export{
+			{Name: "fac", Type: wasm.ExternTypeFunc, Index: 0},
+			{Name: "trap", Type: wasm.ExternTypeFunc, Index: 1},
+			{Name: "nan", Type: wasm.ExternTypeFunc, Index: 2},
+		},
+	})
+
+	t.Run("factorial", func(t *testing.T) {
+		results := RunOnBothEngines(t, source, "fac", []uint64{20}, Options{})
+		require.Equal(t, []uint64{2432902008176640000}, results)
+	})
+
+	t.Run("trap", func(t *testing.T) {
+		require.Nil(t, RunOnBothEngines(t, source, "trap", nil, Options{}))
+	})
+
+	t.Run("NaN", func(t *testing.T) {
+		require.Equal(t, []uint64{0x7fa00000}, RunOnBothEngines(t, source, "nan", nil, Options{}))
+		require.Equal(t, []uint64{canonicalNaN32}, RunOnBothEngines(t, source, "nan", nil, Options{CanonicalizeNaN: true}))
+	})
+}
+
+func TestCanonicalizeNaN(t *testing.T) {
+	results := []uint64{
+		0x7fa00000,                    // f32 NaN
+		0xffc00001,                    // f32 negative NaN
+		math.Float64bits(-math.NaN()), // f64 NaN
+		uint64(math.Float32bits(1.5)), // f32 number
+		0x7ff8000000000001,            // i64 with NaN bits
+		math.Float64bits(math.Inf(1)), // f64 infinity
+	}
+	canonicalizeNaN([]api.ValueType{
+		api.ValueTypeF32, api.ValueTypeF32, api.ValueTypeF64, api.ValueTypeF32, api.ValueTypeI64, api.ValueTypeF64,
+	}, results)
+	require.Equal(t, []uint64{
+		canonicalNaN32,
+		canonicalNaN32,
+		canonicalNaN64,
+		uint64(math.Float32bits(1.5)),
+		0x7ff8000000000001,
+		math.Float64bits(math.Inf(1)),
+	}, results)
+}
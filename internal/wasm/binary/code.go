@@ -10,10 +10,16 @@ import (
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
+// maximumFunctionLocals is the maximum count of locals a function can declare. This is an implementation limit, the same
+// as other runtimes such as V8, which bounds the allocation for locals: a few bytes can otherwise declare billions.
+const maximumFunctionLocals = 50000
+
 func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
 	ss, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get the size of code: %w", err)
+	} else if ss > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(ss, r)
 	}
 	remaining := int64(ss)
 
@@ -24,6 +30,8 @@ func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
 		return nil, fmt.Errorf("get the size locals: %v", err)
 	} else if remaining < 0 {
 		return nil, io.EOF
+	} else if uint64(ls) > uint64(remaining) { // each declaration is at least two bytes
+		return nil, fmt.Errorf("local declarations: size %d exceeds the remaining %d bytes", ls, remaining)
 	}
 
 	var nums []uint64
@@ -56,6 +64,8 @@ func decodeCode(r *bytes.Reader) (*wasm.Code, error) {
 
 	if sum > math.MaxUint32 {
 		return nil, fmt.Errorf("too many locals: %d", sum)
+	} else if sum > maximumFunctionLocals {
+		return nil, fmt.Errorf("too many locals: %d > %d", sum, maximumFunctionLocals)
 	}
 
 	var localTypes []wasm.ValueType
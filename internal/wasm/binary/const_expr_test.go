@@ -5,11 +5,15 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 func TestDecodeConstantExpression(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	for i, tc := range []struct {
 		in  []byte
 		exp *wasm.ConstantExpression
@@ -60,6 +64,9 @@ func TestDecodeConstantExpression(t *testing.T) {
 }
 
 func TestDecodeConstantExpression_errors(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	for _, tc := range []struct {
 		in          []byte
 		expectedErr string
@@ -64,6 +64,8 @@ func decodeDataSegment(r *bytes.Reader, enabledFeatures wasm.Features) (*wasm.Da
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get the size of vector: %v", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	b := make([]byte, vs)
@@ -5,11 +5,15 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 func Test_decodeDataSegment(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	for i, tc := range []struct {
 		in       []byte
 		exp      *wasm.DataSegment
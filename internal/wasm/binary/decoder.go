@@ -41,6 +41,11 @@ func DecodeModule(binary []byte, enabledFeatures wasm.Features, memoryLimitPages
 			return nil, fmt.Errorf("get size of section %s: %v", wasm.SectionIDName(sectionID), err)
 		}
 
+		// Check the size before decoding, as section decoders allocate based on sizes declared in the section.
+		if sectionSize > uint32(r.Len()) {
+			return nil, fmt.Errorf("section %s: %v", wasm.SectionIDName(sectionID), errSizeExceedsInput(sectionSize, r))
+		}
+
 		sectionContentStart := r.Len()
 		switch sectionID {
 		case wasm.SectionIDCustom:
@@ -52,20 +57,23 @@ func DecodeModule(binary []byte, enabledFeatures wasm.Features, memoryLimitPages
 			} else if sectionSize < nameSize {
 				err = fmt.Errorf("malformed custom section %s", name)
 				break
-			} else if name == "name" && m.NameSection != nil {
+			} else if (name == "name" && m.NameSection != nil) || (name == "dylink.0" && m.DylinkSection != nil) {
 				err = fmt.Errorf("redundant custom section %s", name)
 				break
 			}
 
-			// Now, either decode the NameSection or skip an unsupported one
+			// Now, either decode the NameSection or DylinkSection, or skip an unsupported one
 			limit := sectionSize - nameSize
 			if name == "name" {
 				m.NameSection, err = decodeNameSection(r, uint64(limit))
+			} else if name == "dylink.0" {
+				m.DylinkSection, err = decodeDylinkSection(r, uint64(limit))
 			} else {
 				// Note: Not Seek because it doesn't err when given an offset past EOF. Rather, it leads to undefined state.
 				if _, err = io.CopyN(io.Discard, r, int64(limit)); err != nil {
 					return nil, fmt.Errorf("failed to skip name[%s]: %w", name, err)
 				}
+				m.SkippedCustomSections = append(m.SkippedCustomSections, name)
 			}
 
 		case wasm.SectionIDType:
@@ -79,7 +87,7 @@ func DecodeModule(binary []byte, enabledFeatures wasm.Features, memoryLimitPages
 		case wasm.SectionIDTable:
 			m.TableSection, err = decodeTableSection(r, enabledFeatures)
 		case wasm.SectionIDMemory:
-			m.MemorySection, err = decodeMemorySection(r, memoryLimitPages)
+			m.MemorySection, err = decodeMemorySection(r, memoryLimitPages, enabledFeatures)
 		case wasm.SectionIDGlobal:
 			if m.GlobalSection, err = decodeGlobalSection(r, enabledFeatures); err != nil {
 				return nil, err // avoid re-wrapping the error.
@@ -95,9 +103,8 @@ func DecodeModule(binary []byte, enabledFeatures wasm.Features, memoryLimitPages
 		case wasm.SectionIDData:
 			m.DataSection, err = decodeDataSection(r, enabledFeatures)
 		case wasm.SectionIDDataCount:
-			if err := enabledFeatures.Require(wasm.FeatureBulkMemoryOperations); err != nil {
-				return nil, fmt.Errorf("data count section not supported as %v", err)
-			}
+			// Decoded regardless of FeatureBulkMemoryOperations, as some toolchains emit this section anyway. The
+			// instructions which need it, such as memory.init, are still gated on the feature during validation.
 			m.DataCountSection, err = decodeDataCountSection(r)
 		default:
 			err = ErrInvalidSectionID
@@ -119,3 +126,9 @@ func DecodeModule(binary []byte, enabledFeatures wasm.Features, memoryLimitPages
 	}
 	return m, nil
 }
+
+// errSizeExceedsInput is returned when a declared size is larger than the remaining input. This is checked before
+// allocating, so that a small malicious module can't cause a large allocation: each element is at least one byte.
+func errSizeExceedsInput(size uint32, r *bytes.Reader) error {
+	return fmt.Errorf("size %d exceeds the remaining %d bytes", size, r.Len())
+}
@@ -93,7 +93,7 @@ func TestDecodeModule(t *testing.T) {
 			1, 2, 3, 4, 5, 6, 7, 8, 9, 0)
 		m, e := DecodeModule(input, wasm.Features20191205, wasm.MemoryLimitPages)
 		require.NoError(t, e)
-		require.Equal(t, &wasm.Module{}, m)
+		require.Equal(t, &wasm.Module{SkippedCustomSections: []string{"meme"}}, m)
 	})
 
 	t.Run("skips custom section, but not name", func(t *testing.T) {
@@ -108,13 +108,18 @@ func TestDecodeModule(t *testing.T) {
 			's', 'i', 'm', 'p', 'l', 'e')
 		m, e := DecodeModule(input, wasm.Features20191205, wasm.MemoryLimitPages)
 		require.NoError(t, e)
-		require.Equal(t, &wasm.Module{NameSection: &wasm.NameSection{ModuleName: "simple"}}, m)
+		require.Equal(t, &wasm.Module{
+			NameSection:           &wasm.NameSection{ModuleName: "simple"},
+			SkippedCustomSections: []string{"meme"},
+		}, m)
 	})
-	t.Run("data count section disabled", func(t *testing.T) {
+	t.Run("data count section without bulk-memory-operations", func(t *testing.T) {
 		input := append(append(Magic, version...),
 			wasm.SectionIDDataCount, 1, 0)
-		_, e := DecodeModule(input, wasm.Features20191205, wasm.MemoryLimitPages)
-		require.EqualError(t, e, `data count section not supported as feature "bulk-memory-operations" is disabled`)
+		m, e := DecodeModule(input, wasm.Features20191205, wasm.MemoryLimitPages)
+		require.NoError(t, e)
+		zero := uint32(0)
+		require.Equal(t, &wasm.Module{DataCountSection: &zero}, m)
 	})
 }
 
@@ -146,6 +151,59 @@ func TestDecodeModule_Errors(t *testing.T) {
 				subsectionIDModuleName, 0x02, 0x01, 'x'),
 			expectedErr: "section custom: redundant custom section name",
 		},
+		{
+			name: "section size exceeds input",
+			input: append(append(Magic, version...),
+				wasm.SectionIDCode, 0xff, 0xff, 0xff, 0xff, 0x0f, // 4GiB in this section
+				0xff, 0xff, 0xff, 0xff, 0x0f), // 4 billion functions
+			expectedErr: "section code: size 4294967295 exceeds the remaining 5 bytes",
+		},
+		{
+			name: "vector size exceeds section",
+			input: append(append(Magic, version...),
+				wasm.SectionIDType, 0x05, // 5 bytes in this section
+				0xff, 0xff, 0xff, 0xff, 0x0f), // 4 billion types
+			expectedErr: "section type: size 4294967295 exceeds the remaining 0 bytes",
+		},
+		{
+			name: "export vector size exceeds section",
+			input: append(append(Magic, version...),
+				wasm.SectionIDExport, 0x05, // 5 bytes in this section
+				0xff, 0xff, 0xff, 0xff, 0x0f), // 4 billion exports
+			expectedErr: "section export: size 4294967295 exceeds the remaining 0 bytes",
+		},
+		{
+			name: "table vector size exceeds section",
+			input: append(append(Magic, version...),
+				wasm.SectionIDTable, 0x05, // 5 bytes in this section
+				0xff, 0xff, 0xff, 0xff, 0x0f), // 4 billion tables
+			expectedErr: "section table: size 4294967295 exceeds the remaining 0 bytes",
+		},
+		{
+			name: "param count exceeds section",
+			input: append(append(Magic, version...),
+				wasm.SectionIDType, 0x07, // 7 bytes in this section
+				0x01, 0x60, // one function type
+				0xff, 0xff, 0xff, 0xff, 0x0f), // 4 billion params
+			expectedErr: "section type: read 0-th type: could not read parameter types: size 4294967295 exceeds the remaining 0 bytes",
+		},
+		{
+			name: "local declarations exceed body",
+			input: append(append(Magic, version...),
+				wasm.SectionIDCode, 0x07, // 7 bytes in this section
+				0x01, 0x05, // one body of 5 bytes
+				0xff, 0xff, 0xff, 0xff, 0x0f), // 4 billion local declarations
+			expectedErr: "section code: read 0-th code segment: local declarations: size 4294967295 exceeds the remaining 0 bytes",
+		},
+		{
+			name: "too many locals",
+			input: append(append(Magic, version...),
+				wasm.SectionIDCode, 0x0a, // 10 bytes in this section
+				0x01, 0x08, // one body of 8 bytes
+				0x01, 0xff, 0xff, 0xff, 0xff, 0x0f, wasm.ValueTypeI32, // 4 billion i32 locals
+				wasm.OpcodeEnd),
+			expectedErr: "section code: read 0-th code segment: too many locals: 4294967295 > 50000",
+		},
 	}
 
 	for _, tt := range tests {
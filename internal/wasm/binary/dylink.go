@@ -0,0 +1,73 @@
+package binary
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+const (
+	// subsectionIDDylinkMemInfo contains the memory and table requirements of the module.
+	subsectionIDDylinkMemInfo = uint8(1)
+	// subsectionIDDylinkNeeded contains the names of the shared libraries the module depends on.
+	subsectionIDDylinkNeeded = uint8(2)
+)
+
+// decodeDylinkSection deserializes the data associated with the "dylink.0" key in SectionIDCustom, skipping
+// subsections other than WASM_DYLINK_MEM_INFO and WASM_DYLINK_NEEDED.
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md#the-dylink0-section
+func decodeDylinkSection(r *bytes.Reader, limit uint64) (*wasm.DylinkSection, error) {
+	data := make([]byte, limit)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("failed to read dylink.0: %w", err)
+	}
+	r = bytes.NewReader(data)
+
+	result := &wasm.DylinkSection{}
+	for r.Len() > 0 {
+		subsectionID, _ := r.ReadByte() // can't fail as r isn't empty.
+		subsectionSize, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the size of dylink.0 subsection[%d]: %w", subsectionID, err)
+		} else if subsectionSize > uint32(r.Len()) {
+			return nil, fmt.Errorf("dylink.0 subsection[%d]: %v", subsectionID, errSizeExceedsInput(subsectionSize, r))
+		}
+
+		switch subsectionID {
+		case subsectionIDDylinkMemInfo:
+			for _, v := range []*uint32{&result.MemorySize, &result.MemoryAlignment, &result.TableSize, &result.TableAlignment} {
+				if *v, _, err = leb128.DecodeUint32(r); err != nil {
+					return nil, fmt.Errorf("failed to read dylink.0 memory info: %w", err)
+				}
+			}
+		case subsectionIDDylinkNeeded:
+			if result.Needed, err = decodeDylinkNeeded(r); err != nil {
+				return nil, err
+			}
+		default: // Skip other subsections, such as export and import info.
+			_, _ = r.Seek(int64(subsectionSize), io.SeekCurrent) // within bounds per the size check above.
+		}
+	}
+	return result, nil
+}
+
+func decodeDylinkNeeded(r *bytes.Reader) ([]string, error) {
+	count, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the count of dylink.0 needed libraries: %w", err)
+	} else if count > uint32(r.Len()) { // each name is at least one byte
+		return nil, fmt.Errorf("dylink.0 needed libraries: %v", errSizeExceedsInput(count, r))
+	}
+
+	result := make([]string, count)
+	for i := range result {
+		if result[i], _, err = decodeUTF8(r, "dylink.0 needed[%d]", i); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
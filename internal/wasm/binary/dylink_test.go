@@ -0,0 +1,100 @@
+package binary
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+func TestDecodeDylinkSection(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    []byte
+		expected *wasm.DylinkSection
+	}{
+		{
+			name:     "empty",
+			input:    []byte{},
+			expected: &wasm.DylinkSection{},
+		},
+		{
+			name: "memory info",
+			input: []byte{
+				subsectionIDDylinkMemInfo, 5, // 5 bytes in this subsection
+				0x80, 0x08, // memory size 1024
+				2, // memory alignment
+				3, // table size
+				0, // table alignment
+			},
+			expected: &wasm.DylinkSection{MemorySize: 1024, MemoryAlignment: 2, TableSize: 3},
+		},
+		{
+			name: "needed",
+			input: []byte{
+				subsectionIDDylinkNeeded, 11, // 11 bytes in this subsection
+				2, // 2 libraries
+				4, 'l', 'i', 'b', 'a',
+				4, 'l', 'i', 'b', 'b',
+			},
+			expected: &wasm.DylinkSection{Needed: []string{"liba", "libb"}},
+		},
+		{
+			name: "skips other subsections",
+			input: []byte{
+				3, 2, 0xff, 0xff, // WASM_DYLINK_EXPORT_INFO
+				subsectionIDDylinkMemInfo, 4, 1, 0, 2, 0,
+			},
+			expected: &wasm.DylinkSection{MemorySize: 1, TableSize: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := decodeDylinkSection(bytes.NewReader(tc.input), uint64(len(tc.input)))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, d)
+		})
+	}
+}
+
+func TestDecodeDylinkSection_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       []byte
+		expectedErr string
+	}{
+		{
+			name:        "subsection size exceeds the section",
+			input:       []byte{subsectionIDDylinkMemInfo, 10, 1, 2},
+			expectedErr: "dylink.0 subsection[1]: size 10 exceeds the remaining 2 bytes",
+		},
+		{
+			name:        "memory info too short",
+			input:       []byte{subsectionIDDylinkMemInfo, 2, 1, 2},
+			expectedErr: "failed to read dylink.0 memory info: EOF",
+		},
+		{
+			name:        "needed count exceeds the section",
+			input:       []byte{subsectionIDDylinkNeeded, 2, 5, 0},
+			expectedErr: "dylink.0 needed libraries: size 5 exceeds the remaining 1 bytes",
+		},
+		{
+			name:        "needed name too short",
+			input:       []byte{subsectionIDDylinkNeeded, 3, 1, 4, 'l'},
+			expectedErr: "failed to read dylink.0 needed[0]: unexpected EOF",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := decodeDylinkSection(bytes.NewReader(tc.input), uint64(len(tc.input)))
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
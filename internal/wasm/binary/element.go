@@ -23,6 +23,8 @@ func decodeElementInitValueVector(r *bytes.Reader) ([]*wasm.Index, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	vec := make([]*wasm.Index, vs)
@@ -40,6 +42,8 @@ func decodeElementConstExprVector(r *bytes.Reader, enabledFeatures wasm.Features
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 	vec := make([]*wasm.Index, vs)
 	for i := range vec {
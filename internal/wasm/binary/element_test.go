@@ -5,6 +5,7 @@ import (
 	"strconv"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -41,6 +42,9 @@ func Test_decodeElementInitValueVector(t *testing.T) {
 }
 
 func Test_decodeElementConstExprVector(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	for i, tc := range []struct {
 		in       []byte
 		exp      []*wasm.Index
@@ -82,6 +86,9 @@ func Test_decodeElementConstExprVector(t *testing.T) {
 }
 
 func TestDecodeElementSegment(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	for _, tc := range []struct {
 		name     string
 		in       []byte
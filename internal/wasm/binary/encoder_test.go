@@ -197,6 +197,14 @@ func TestModule_Encode(t *testing.T) {
 				wasm.ExternTypeGlobal, 0x00, // global[0]
 			),
 		},
+		{
+			name:  "data count section",
+			input: &wasm.Module{DataCountSection: &zero},
+			expected: append(append(Magic, version...),
+				wasm.SectionIDDataCount, 0x01, // 1 byte in this section
+				0x00, // no data segments
+			),
+		},
 	}
 
 	for _, tt := range tests {
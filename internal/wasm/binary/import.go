@@ -29,7 +29,7 @@ func decodeImport(r *bytes.Reader, idx uint32, memoryLimitPages uint32, enabledF
 	case wasm.ExternTypeTable:
 		i.DescTable, err = decodeTable(r, enabledFeatures)
 	case wasm.ExternTypeMemory:
-		i.DescMem, err = decodeMemory(r, memoryLimitPages)
+		i.DescMem, err = decodeMemory(r, memoryLimitPages, enabledFeatures)
 	case wasm.ExternTypeGlobal:
 		i.DescGlobal, err = decodeGlobalType(r)
 	default:
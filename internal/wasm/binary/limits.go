@@ -10,7 +10,10 @@ import (
 // decodeLimitsType returns the `limitsType` (min, max) decoded with the WebAssembly 1.0 (20191205) Binary Format.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#limits%E2%91%A6
-func decodeLimitsType(r *bytes.Reader) (min uint32, max *uint32, err error) {
+//
+// Note: The flags 0x02 and 0x03 additionally mark the limits as shared. Callers must require FeatureThreads and a max.
+// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md#spec-changes
+func decodeLimitsType(r *bytes.Reader) (min uint32, max *uint32, shared bool, err error) {
 	var flag byte
 	if flag, err = r.ReadByte(); err != nil {
 		err = fmt.Errorf("read leading byte: %v", err)
@@ -18,11 +21,17 @@ func decodeLimitsType(r *bytes.Reader) (min uint32, max *uint32, err error) {
 	}
 
 	switch flag {
+	case 0x02:
+		shared = true
+		fallthrough
 	case 0x00:
 		min, _, err = leb128.DecodeUint32(r)
 		if err != nil {
 			err = fmt.Errorf("read min of limit: %v", err)
 		}
+	case 0x03:
+		shared = true
+		fallthrough
 	case 0x01:
 		min, _, err = leb128.DecodeUint32(r)
 		if err != nil {
@@ -55,8 +55,9 @@ func TestLimitsType(t *testing.T) {
 		})
 
 		t.Run(fmt.Sprintf("decode - %s", tc.name), func(t *testing.T) {
-			min, max, err := decodeLimitsType(bytes.NewReader(b))
+			min, max, shared, err := decodeLimitsType(bytes.NewReader(b))
 			require.NoError(t, err)
+			require.False(t, shared)
 			require.Equal(t, min, tc.min)
 			require.Equal(t, max, tc.max)
 		})
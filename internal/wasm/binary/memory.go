@@ -2,6 +2,7 @@ package binary
 
 import (
 	"bytes"
+	"fmt"
 
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -9,11 +10,18 @@ import (
 // decodeMemory returns the api.Memory decoded with the WebAssembly 1.0 (20191205) Binary Format.
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#binary-memory
-func decodeMemory(r *bytes.Reader, memoryLimitPages uint32) (*wasm.Memory, error) {
-	min, maxP, err := decodeLimitsType(r)
+func decodeMemory(r *bytes.Reader, memoryLimitPages uint32, enabledFeatures wasm.Features) (*wasm.Memory, error) {
+	min, maxP, shared, err := decodeLimitsType(r)
 	if err != nil {
 		return nil, err
 	}
+	if shared {
+		if err = enabledFeatures.Require(wasm.FeatureThreads); err != nil {
+			return nil, fmt.Errorf("shared memory invalid as %w", err)
+		} else if maxP == nil {
+			return nil, fmt.Errorf("shared memory must have max")
+		}
+	}
 
 	var max uint32
 	var isMaxEncoded bool
@@ -21,7 +29,7 @@ func decodeMemory(r *bytes.Reader, memoryLimitPages uint32) (*wasm.Memory, error
 		isMaxEncoded = true
 		max = *maxP
 	}
-	mem := &wasm.Memory{Min: min, Max: max, IsMaxEncoded: isMaxEncoded}
+	mem := &wasm.Memory{Min: min, Max: max, IsMaxEncoded: isMaxEncoded, IsShared: shared}
 	return mem, mem.ValidateMinMax(memoryLimitPages)
 }
 
@@ -33,5 +41,9 @@ func encodeMemory(i *wasm.Memory) []byte {
 	if !i.IsMaxEncoded {
 		maxPtr = nil
 	}
-	return encodeLimitsType(i.Min, maxPtr)
+	ret := encodeLimitsType(i.Min, maxPtr)
+	if i.IsShared {
+		ret[0] = 0x03 // shared memory always has a max, so flag the leading 0x01.
+	}
+	return ret
 }
@@ -5,11 +5,15 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 func TestMemoryType(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	zero := uint32(0)
 	max := wasm.MemoryLimitPages
 
@@ -48,6 +52,11 @@ func TestMemoryType(t *testing.T) {
 			input:    &wasm.Memory{Min: max, Max: max, IsMaxEncoded: true},
 			expected: []byte{0x1, 0x80, 0x80, 0x4, 0x80, 0x80, 0x4},
 		},
+		{
+			name:     "shared",
+			input:    &wasm.Memory{Min: 1, Max: 2, IsMaxEncoded: true, IsShared: true},
+			expected: []byte{0x3, 1, 2},
+		},
 	}
 
 	for _, tt := range tests {
@@ -59,7 +68,7 @@ func TestMemoryType(t *testing.T) {
 		})
 
 		t.Run(fmt.Sprintf("decode - %s", tc.name), func(t *testing.T) {
-			binary, err := decodeMemory(bytes.NewReader(b), max)
+			binary, err := decodeMemory(bytes.NewReader(b), max, wasm.FeatureThreads)
 			require.NoError(t, err)
 			require.Equal(t, binary, tc.input)
 		})
@@ -67,6 +76,9 @@ func TestMemoryType(t *testing.T) {
 }
 
 func TestDecodeMemoryType_Errors(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name             string
 		input            []byte
@@ -88,6 +100,11 @@ func TestDecodeMemoryType_Errors(t *testing.T) {
 			input:       []byte{0x1, 0, 0xff, 0xff, 0xff, 0xff, 0xf},
 			expectedErr: "max 4294967295 pages (3 Ti) over limit of 65536 pages (4 Gi)",
 		},
+		{
+			name:        "shared without max",
+			input:       []byte{0x2, 1},
+			expectedErr: "shared memory must have max",
+		},
 	}
 
 	for _, tt := range tests {
@@ -98,7 +115,7 @@ func TestDecodeMemoryType_Errors(t *testing.T) {
 		}
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := decodeMemory(bytes.NewReader(tc.input), tc.memoryLimitPages)
+			_, err := decodeMemory(bytes.NewReader(tc.input), tc.memoryLimitPages, wasm.FeatureThreads)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
@@ -13,6 +13,8 @@ func decodeTypeSection(enabledFeatures wasm.Features, r *bytes.Reader) ([]*wasm.
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	result := make([]*wasm.FunctionType, vs)
@@ -28,6 +30,8 @@ func decodeImportSection(r *bytes.Reader, memoryLimitPages uint32, enabledFeatur
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	result := make([]*wasm.Import, vs)
@@ -43,6 +47,8 @@ func decodeFunctionSection(r *bytes.Reader) ([]uint32, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	result := make([]uint32, vs)
@@ -58,6 +64,8 @@ func decodeTableSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("error reading size")
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 	if vs > 1 {
 		if err := enabledFeatures.Require(wasm.FeatureReferenceTypes); err != nil {
@@ -76,7 +84,7 @@ func decodeTableSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm
 	return ret, nil
 }
 
-func decodeMemorySection(r *bytes.Reader, memoryLimitPages uint32) (*wasm.Memory, error) {
+func decodeMemorySection(r *bytes.Reader, memoryLimitPages uint32, enabledFeatures wasm.Features) (*wasm.Memory, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("error reading size")
@@ -85,13 +93,15 @@ func decodeMemorySection(r *bytes.Reader, memoryLimitPages uint32) (*wasm.Memory
 		return nil, fmt.Errorf("at most one memory allowed in module, but read %d", vs)
 	}
 
-	return decodeMemory(r, memoryLimitPages)
+	return decodeMemory(r, memoryLimitPages, enabledFeatures)
 }
 
 func decodeGlobalSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm.Global, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	result := make([]*wasm.Global, vs)
@@ -107,6 +117,8 @@ func decodeExportSection(r *bytes.Reader) ([]*wasm.Export, error) {
 	vs, _, sizeErr := leb128.DecodeUint32(r)
 	if sizeErr != nil {
 		return nil, fmt.Errorf("get size of vector: %v", sizeErr)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	usedName := make(map[string]struct{}, vs)
@@ -138,6 +150,8 @@ func decodeElementSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wa
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	result := make([]*wasm.ElementSegment, vs)
@@ -153,6 +167,8 @@ func decodeCodeSection(r *bytes.Reader) ([]*wasm.Code, error) {
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	result := make([]*wasm.Code, vs)
@@ -168,6 +184,8 @@ func decodeDataSection(r *bytes.Reader, enabledFeatures wasm.Features) ([]*wasm.
 	vs, _, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return nil, fmt.Errorf("get size of vector: %w", err)
+	} else if vs > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(vs, r)
 	}
 
 	result := make([]*wasm.DataSegment, vs)
@@ -325,3 +343,11 @@ func encodeDataSection(datum []*wasm.DataSegment) []byte {
 	}
 	return encodeSection(wasm.SectionIDData, contents)
 }
+
+// encodeDataCountSection encodes a wasm.SectionIDDataCount for the count of data segments in WebAssembly 2.0 (20220419)
+// Binary Format.
+//
+// See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/binary/modules.html#data-count-section
+func encodeDataCountSection(count uint32) []byte {
+	return encodeSection(wasm.SectionIDDataCount, leb128.EncodeUint32(count))
+}
@@ -4,11 +4,15 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 func TestTableSection(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	three := uint32(3)
 	tests := []struct {
 		name     string
@@ -100,7 +104,7 @@ func TestMemorySection(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			memories, err := decodeMemorySection(bytes.NewReader(tc.input), wasm.MemoryLimitPages)
+			memories, err := decodeMemorySection(bytes.NewReader(tc.input), wasm.MemoryLimitPages, wasm.Features20191205)
 			require.NoError(t, err)
 			require.Equal(t, tc.expected, memories)
 		})
@@ -123,6 +127,22 @@ func TestMemorySection_Errors(t *testing.T) {
 			},
 			expectedErr: "at most one memory allowed in module, but read 2",
 		},
+		{
+			name: "shared without threads",
+			input: []byte{
+				0x01,             // 1 memory
+				0x03, 0x01, 0x02, // (memory 1 2 shared)
+			},
+			expectedErr: `shared memory invalid as feature "threads" is disabled`,
+		},
+		{
+			name: "shared without max or threads",
+			input: []byte{
+				0x01,       // 1 memory
+				0x02, 0x01, // (memory 1 shared)
+			},
+			expectedErr: `shared memory invalid as feature "threads" is disabled`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -133,7 +153,7 @@ func TestMemorySection_Errors(t *testing.T) {
 		}
 
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := decodeMemorySection(bytes.NewReader(tc.input), tc.memoryLimitPages)
+			_, err := decodeMemorySection(bytes.NewReader(tc.input), tc.memoryLimitPages, wasm.Features20191205)
 			require.EqualError(t, err, tc.expectedErr)
 		})
 	}
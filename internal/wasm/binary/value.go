@@ -45,6 +45,8 @@ func encodeValTypes(vt []wasm.ValueType) []byte {
 func decodeValueTypes(r *bytes.Reader, num uint32) ([]wasm.ValueType, error) {
 	if num == 0 {
 		return nil, nil
+	} else if num > uint32(r.Len()) {
+		return nil, errSizeExceedsInput(num, r)
 	}
 	ret := make([]wasm.ValueType, num)
 	buf := make([]wasm.ValueType, num)
@@ -70,6 +72,12 @@ func decodeUTF8(r *bytes.Reader, contextFormat string, contextArgs ...interface{
 	size, sizeOfSize, err := leb128.DecodeUint32(r)
 	if err != nil {
 		return "", 0, fmt.Errorf("failed to read %s size: %w", fmt.Sprintf(contextFormat, contextArgs...), err)
+	} else if size > uint32(r.Len()) { // fail before allocating, with the same error as io.ReadFull.
+		err = io.ErrUnexpectedEOF
+		if r.Len() == 0 {
+			err = io.EOF
+		}
+		return "", 0, fmt.Errorf("failed to read %s: %w", fmt.Sprintf(contextFormat, contextArgs...), err)
 	}
 
 	buf := make([]byte, size)
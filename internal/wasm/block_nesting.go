@@ -0,0 +1,37 @@
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ValidateBlockNesting returns an error if any function body nests `block`, `loop` or `if` instructions deeper than
+// maxNesting. The function body itself isn't counted, so a body without blocks has a nesting of zero.
+//
+// This only tracks the depth, so is safe to call before Validate to bound the work of validating pathological bodies.
+// Malformed bodies are left for Validate to report.
+func (m *Module) ValidateBlockNesting(maxNesting int) error {
+	for i, c := range m.CodeSection {
+		r := bytes.NewReader(c.Body)
+		nesting := 0
+		for r.Len() > 0 {
+			op, err := r.ReadByte()
+			if err != nil {
+				break
+			}
+			switch op {
+			case OpcodeBlock, OpcodeLoop, OpcodeIf:
+				if nesting++; nesting > maxNesting {
+					return fmt.Errorf("invalid %s: block nesting exceeds %d at offset %d",
+						m.funcDesc(SectionIDFunction, Index(i)), maxNesting, len(c.Body)-r.Len()-1)
+				}
+			case OpcodeEnd:
+				nesting--
+			}
+			if err = skipImmediates(op, r); err != nil {
+				break
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package wasm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+// nestedBlocksBody returns a function body of depth nested blocks, alternating between `block`, `loop` and `if`.
+func nestedBlocksBody(depth int) []byte {
+	var body []byte
+	for i := 0; i < depth; i++ {
+		switch i % 3 {
+		case 0:
+			body = append(body, OpcodeBlock, 0x40)
+		case 1:
+			body = append(body, OpcodeLoop, 0x40)
+		case 2:
+			body = append(body, OpcodeI32Const, 1, OpcodeIf, 0x40)
+		}
+	}
+	body = append(body, bytes.Repeat([]byte{OpcodeEnd}, depth+1)...)
+	return body
+}
+
+func TestModule_ValidateBlockNesting(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "no blocks",
+			body: []byte{OpcodeI32Const, 1, OpcodeDrop, OpcodeEnd},
+		},
+		{
+			name: "at limit",
+			body: nestedBlocksBody(100),
+		},
+		{
+			name: "sibling blocks at limit",
+			// Drop the function end of the first body, so the second nests under the function, not the first body.
+			body: append(nestedBlocksBody(100)[:len(nestedBlocksBody(100))-1], nestedBlocksBody(100)...),
+		},
+		{
+			name:        "over limit",
+			body:        nestedBlocksBody(101),
+			expectedErr: "invalid function[0]: block nesting exceeds 100 at offset 266",
+		},
+		{
+			name: "block type isn't an opcode",
+			// The block type 0x02 is a type index, not OpcodeBlock, so this only nests once.
+			body: []byte{OpcodeBlock, 0x02, OpcodeEnd, OpcodeEnd},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{CodeSection: []*Code{{Body: tc.body}}}
+			err := m.ValidateBlockNesting(100)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -2,10 +2,12 @@ package wasm
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync/atomic"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -45,6 +47,36 @@ type CallContext struct {
 	// Note: Exclusively reading and updating this with atomics guarantees cross-goroutine observations.
 	// See /RATIONALE.md
 	closed *uint64
+
+	// lazyImports is set by SetLazyImports.
+	lazyImports *LazyImports
+
+	// exitCodeAsError is set by SetExitCodeAsError, and when nil, any exit during a call is an error.
+	exitCodeAsError *bool
+
+	// reactorInitialized is non-nil when set by SetReactor, and is one once ReactorInitFunction was called. Only
+	// accessed atomically. This is a pointer, so copies made by WithMemory share it.
+	reactorInitialized *uint32
+
+	// onClose is set by SetOnClose.
+	onClose func()
+
+	// trapSink is set by SetTrapSink.
+	trapSink func(funcName string, err error)
+}
+
+// ReactorInitFunction is the function a WASI reactor exports to initialize itself, which may only be called once.
+const ReactorInitFunction = "_initialize"
+
+// SetReactor marks the module as a WASI reactor, so ExportedFunction returns a ReactorInitFunction which errs when
+// called more than once, whether as a start function or by the host.
+func (m *CallContext) SetReactor() {
+	m.reactorInitialized = new(uint32)
+}
+
+// ReactorInitialized returns true if the module is a reactor whose ReactorInitFunction was called.
+func (m *CallContext) ReactorInitialized() bool {
+	return m.reactorInitialized != nil && atomic.LoadUint32(m.reactorInitialized) == 1
 }
 
 // FailIfClosed returns a sys.ExitError if CloseWithExitCode was called.
@@ -55,6 +87,50 @@ func (m *CallContext) FailIfClosed() error {
 	return nil
 }
 
+// SetOnClose sets a function called once, when the module is closed.
+// See wazero.CompiledCode InstanceCount
+func (m *CallContext) SetOnClose(onClose func()) {
+	m.onClose = onClose
+}
+
+// SetExitCodeAsError sets whether a call during which the module exits with a non-zero code fails with a
+// sys.ExitError. Regardless, an exit code of zero is a normal termination, so the call doesn't fail.
+// See wazero.ModuleConfig WithExitCodeAsError
+func (m *CallContext) SetExitCodeAsError(exitCodeAsError bool) {
+	m.exitCodeAsError = &exitCodeAsError
+}
+
+// SetTrapSink sets a function called with the error of each call to an exported function of this module that fails
+// with a trap, ex. wasmruntime.ErrRuntimeUnreachable. funcName is the FunctionInstance DebugName of the called function.
+// See wazero.ModuleConfig WithTrapSink
+func (m *CallContext) SetTrapSink(trapSink func(funcName string, err error)) {
+	m.trapSink = trapSink
+}
+
+// reportTrap calls the trap sink, if set, when the error of a call to the function is a trap.
+func (m *CallContext) reportTrap(f *FunctionInstance, err error) {
+	if m == nil || m.trapSink == nil {
+		return
+	}
+	var trap *wasmruntime.Error
+	if errors.As(err, &trap) {
+		m.trapSink(f.DebugName, err)
+	}
+}
+
+// FailIfExited is like FailIfClosed, except for use at the end of a call, so returns nil if the exit code is a normal
+// termination per SetExitCodeAsError.
+func (m *CallContext) FailIfExited() error {
+	err := m.FailIfClosed()
+	if err == nil || m.exitCodeAsError == nil {
+		return err
+	}
+	if exitCode := err.(*sys.ExitError).ExitCode(); exitCode == 0 || !*m.exitCodeAsError {
+		return nil
+	}
+	return err
+}
+
 // Name implements the same method as documented on api.Module
 func (m *CallContext) Name() string {
 	return m.module.Name
@@ -63,7 +139,18 @@ func (m *CallContext) Name() string {
 // WithMemory allows overriding memory without re-allocation when the result would be the same.
 func (m *CallContext) WithMemory(memory *MemoryInstance) *CallContext {
 	if memory != nil && memory != m.memory { // only re-allocate if it will change the effective memory
-		return &CallContext{module: m.module, memory: memory, Sys: m.Sys, closed: m.closed}
+		return &CallContext{
+			module:             m.module,
+			memory:             memory,
+			store:              m.store,
+			Sys:                m.Sys,
+			closed:             m.closed,
+			lazyImports:        m.lazyImports,
+			exitCodeAsError:    m.exitCodeAsError,
+			reactorInitialized: m.reactorInitialized,
+			onClose:            m.onClose,
+			trapSink:           m.trapSink,
+		}
 	}
 	return m
 }
@@ -79,7 +166,7 @@ func (m *CallContext) Close(ctx context.Context) (err error) {
 }
 
 // CloseWithExitCode implements the same method as documented on api.Module.
-func (m *CallContext) CloseWithExitCode(_ context.Context, exitCode uint32) (err error) {
+func (m *CallContext) CloseWithExitCode(ctx context.Context, exitCode uint32) (err error) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
 	closed := uint64(1) + uint64(exitCode)<<32 // Store exitCode as high-order bits.
@@ -87,14 +174,50 @@ func (m *CallContext) CloseWithExitCode(_ context.Context, exitCode uint32) (err
 		return nil
 	}
 	m.store.deleteModule(m.Name())
+	if m.onClose != nil {
+		m.onClose()
+	}
+	if l := m.lazyImports; l != nil {
+		_ = l.hostModule.Close(ctx) // only fails on sys, which host modules don't have.
+	}
 	if sys := m.Sys; sys != nil { // ex nil if from ModuleBuilder
 		return sys.Close()
 	}
 	return
 }
 
+// RecentStdout returns the most recent bytes the module wrote to stdout, or nil unless configured with a RingBuffer.
+// See SysContext.RecentStdout
+func (m *CallContext) RecentStdout() []byte {
+	if m.Sys == nil {
+		return nil
+	}
+	return m.Sys.RecentStdout()
+}
+
+// RecentStderr returns the most recent bytes the module wrote to stderr, or nil unless configured with a RingBuffer.
+// See SysContext.RecentStderr
+func (m *CallContext) RecentStderr() []byte {
+	if m.Sys == nil {
+		return nil
+	}
+	return m.Sys.RecentStderr()
+}
+
+// MemoryGrowthPages returns how many pages the memory grew beyond its initial minimum, or zero if there's no memory.
+func (m *CallContext) MemoryGrowthPages() uint32 {
+	mem := m.module.Memory
+	if mem == nil {
+		return 0
+	}
+	return mem.PageSize(context.Background()) - mem.Min
+}
+
 // Memory implements the same method as documented on api.Module.
 func (m *CallContext) Memory() api.Memory {
+	if m.module.Memory == nil {
+		return nil // as opposed to a typed nil, which would not equal nil.
+	}
 	return m.module.Memory
 }
 
@@ -107,17 +230,47 @@ func (m *CallContext) ExportedMemory(name string) api.Memory {
 	return exp.Memory
 }
 
+// ExportedTable implements the same method as documented on api.Module.
+func (m *CallContext) ExportedTable(name string) api.Table {
+	exp, err := m.module.getExport(name, ExternTypeTable)
+	if err != nil {
+		return nil
+	}
+	return &exportedTable{table: exp.Table, engine: m.module.Engine}
+}
+
 // ExportedFunction implements the same method as documented on api.Module.
 func (m *CallContext) ExportedFunction(name string) api.Function {
 	exp, err := m.module.getExport(name, ExternTypeFunc)
 	if err != nil {
 		return nil
 	}
+	var fn api.Function
 	if exp.Function.Module == m.module {
-		return exp.Function
+		fn = exp.Function
 	} else {
-		return &importedFn{importingModule: m, importedFn: exp.Function}
+		fn = &importedFn{importingModule: m, importedFn: exp.Function}
+	}
+	if name == ReactorInitFunction && m.reactorInitialized != nil {
+		return &reactorInitFn{Function: fn, callCtx: m}
 	}
+	return fn
+}
+
+// reactorInitFn implements api.Function and errs on all but the first Call, as a WASI reactor must only be
+// initialized once.
+type reactorInitFn struct {
+	api.Function
+	callCtx *CallContext
+}
+
+// Call implements the same method as documented on api.Function.
+func (f *reactorInitFn) Call(ctx context.Context, params ...uint64) ([]uint64, error) {
+	if !atomic.CompareAndSwapUint32(f.callCtx.reactorInitialized, 0, 1) {
+		return nil, fmt.Errorf("module[%s] function[%s] was already called: a reactor is initialized only once",
+			f.callCtx.Name(), ReactorInitFunction)
+	}
+	return f.Function.Call(ctx, params...)
 }
 
 // importedFn implements api.Function and ensures the call context of an imported function is the importing module.
@@ -142,7 +295,11 @@ func (f *importedFn) Call(ctx context.Context, params ...uint64) (ret []uint64,
 		ctx = context.Background()
 	}
 	mod := f.importingModule
-	return f.importedFn.Module.Engine.Call(ctx, mod, f.importedFn, params...)
+	err = mod.hookedCall(ctx, f.importedFn, func(ctx context.Context) (err error) {
+		ret, err = f.importedFn.Module.Engine.Call(ctx, mod, f.importedFn, params...)
+		return
+	})
+	return
 }
 
 // ParamTypes implements the same method as documented on api.Function.
@@ -161,7 +318,70 @@ func (f *FunctionInstance) Call(ctx context.Context, params ...uint64) (ret []ui
 		ctx = context.Background()
 	}
 	mod := f.Module
-	return mod.Engine.Call(ctx, mod.CallCtx, f, params...)
+	err = mod.CallCtx.hookedCall(ctx, f, func(ctx context.Context) (err error) {
+		ret, err = mod.Engine.Call(ctx, mod.CallCtx, f, params...)
+		return
+	})
+	return
+}
+
+// CallHooks wrap each api.Function Call made by the embedder. See wazero.RuntimeConfig WithCallHook
+type CallHooks struct {
+	// Before is called before the function, and returns the context to call it with, or is nil.
+	Before func(ctx context.Context, mod api.Module, fn api.FunctionDefinition) context.Context
+	// After is called after the function with the error it returned, if any, or is nil.
+	After func(ctx context.Context, mod api.Module, fn api.FunctionDefinition, err error)
+}
+
+// callHookKey is a context.Context Value key present during a call wrapped by CallHooks, so that calls made during it,
+// ex. by a host function, aren't wrapped again.
+type callHookKey struct{}
+
+// callHooks returns the CallHooks of the store, or nil if there are none or this call is made during another.
+func (m *CallContext) callHooks(ctx context.Context) *CallHooks {
+	if m == nil || m.store == nil || m.store.CallHooks == nil || ctx.Value(callHookKey{}) != nil {
+		return nil
+	}
+	return m.store.CallHooks
+}
+
+// hookedCall invokes call, which calls the function f with this call context, between any CallHooks, and reports any
+// trap it returns. This is the path of each api.Function Call, and Caller.Call.
+func (m *CallContext) hookedCall(ctx context.Context, f *FunctionInstance, call func(context.Context) error) (err error) {
+	if h := m.callHooks(ctx); h == nil {
+		err = call(ctx)
+	} else {
+		if h.Before != nil {
+			ctx = h.Before(ctx, m, f)
+		}
+		ctx = context.WithValue(ctx, callHookKey{}, struct{}{})
+		err = call(ctx)
+		if h.After != nil {
+			h.After(ctx, m, f, err)
+		}
+	}
+	if err != nil {
+		m.reportTrap(f, err)
+	}
+	return
+}
+
+// WatchGlobal sets a function called with the old and new value whenever the exported global changes, replacing any
+// previous one. A nil function removes the watch. This errs if the global isn't exported or is immutable, or if the
+// ModuleEngine isn't a GlobalWatcher, as changes made by the "global.set" instruction would be missed.
+func (m *CallContext) WatchGlobal(name string, fn func(old, new uint64)) error {
+	if _, ok := m.module.Engine.(GlobalWatcher); !ok {
+		return fmt.Errorf("module %q can't watch globals: its engine doesn't intercept global.set", m.Name())
+	}
+	exp, err := m.module.getExport(name, ExternTypeGlobal)
+	if err != nil {
+		return err
+	}
+	if !exp.Global.Type.Mutable {
+		return fmt.Errorf("global %q in module %q is immutable, so never changes", name, m.Name())
+	}
+	exp.Global.Watch = fn
+	return nil
 }
 
 // ExportedGlobal implements the same method as documented on api.Module.
@@ -55,6 +55,40 @@ func TestCallContext_WithMemory(t *testing.T) {
 			}
 		})
 	}
+
+	t.Run("copies state", func(t *testing.T) {
+		s := newStore()
+		mod, err := s.Instantiate(testCtx, &Module{}, t.Name(), nil, nil)
+		require.NoError(t, err)
+		host, err := s.Instantiate(testCtx, &Module{}, t.Name()+"-host", nil, nil)
+		require.NoError(t, err)
+		mod.SetLazyImports(&LazyImports{}, host)
+		onClosed := 0
+		mod.SetOnClose(func() { onClosed++ })
+
+		mod2 := mod.WithMemory(&MemoryInstance{})
+		require.Same(t, mod.store, mod2.store)
+		require.Same(t, mod.lazyImports, mod2.lazyImports)
+
+		// Closing the copy closes the original, including what it closes along with it.
+		require.NoError(t, mod2.Close(testCtx))
+		require.Equal(t, 1, onClosed)
+		require.Nil(t, s.Module(mod.Name()))
+		require.Nil(t, s.Module(host.Name()))
+	})
+}
+
+func TestCallContext_Memory(t *testing.T) {
+	t.Run("no memory", func(t *testing.T) {
+		mod := &CallContext{module: &ModuleInstance{}}
+		require.Nil(t, mod.Memory())
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		mem := &MemoryInstance{}
+		mod := &CallContext{module: &ModuleInstance{Memory: mem}}
+		require.Equal(t, mem, mod.Memory())
+	})
 }
 
 func TestCallContext_String(t *testing.T) {
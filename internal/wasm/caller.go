@@ -0,0 +1,118 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/api"
+)
+
+// CallerEngine is optionally implemented by a ModuleEngine to reuse resources, such as its stack, between calls made
+// by the same Caller.
+type CallerEngine interface {
+	// NewCallFunc returns a function which calls f like ModuleEngine.Call, except results are written to the given
+	// slice, which is sized to the result count of f.
+	//
+	// Note: The returned function is not safe for concurrent use.
+	NewCallFunc(m *CallContext, f *FunctionInstance) func(ctx context.Context, params, results []uint64) error
+}
+
+// Caller calls the same function repeatedly, reusing its parameter and result buffers. See CallContext.NewCaller
+type Caller struct {
+	callCtx *CallContext
+	f       *FunctionInstance
+	params  []uint64
+	// results are overwritten by each call.
+	results []uint64
+	call    func(ctx context.Context, params, results []uint64) error
+}
+
+// NewCaller returns a Caller of a function exported by this module. Buffers are sized to the signature of the function.
+//
+// Note: The Caller is not safe for concurrent use, as calls share the same buffers and engine resources.
+func (m *CallContext) NewCaller(fn api.Function) (*Caller, error) {
+	callCtx, f, err := m.ownFunction(fn)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Caller{
+		callCtx: callCtx,
+		f:       f,
+		params:  make([]uint64, len(f.Type.Params)),
+		results: make([]uint64, len(f.Type.Results)),
+	}
+	if ce, ok := f.Module.Engine.(CallerEngine); ok {
+		c.call = ce.NewCallFunc(callCtx, f)
+	} else {
+		c.call = func(ctx context.Context, params, results []uint64) error {
+			ret, err := f.Module.Engine.Call(ctx, callCtx, f, params...)
+			copy(results, ret)
+			return err
+		}
+	}
+	return c, nil
+}
+
+// Params returns the parameters of the next call, which the caller sets before calling Call.
+func (c *Caller) Params() []uint64 {
+	return c.params
+}
+
+// Results returns the results of the last call, which are overwritten on each call.
+func (c *Caller) Results() []uint64 {
+	return c.results
+}
+
+// Call calls the function with Params, overwriting Results. Like api.Function Call, this is wrapped by any CallHooks.
+func (c *Caller) Call(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return c.callCtx.hookedCall(ctx, c.f, func(ctx context.Context) error {
+		return c.call(ctx, c.params, c.results)
+	})
+}
+
+// ownFunction returns the function instance of fn and the CallContext to call it with, or an error if fn isn't a
+// function of this module.
+func (m *CallContext) ownFunction(fn api.Function) (*CallContext, *FunctionInstance, error) {
+	var callCtx *CallContext
+	var f *FunctionInstance
+	switch fn := fn.(type) {
+	case *FunctionInstance:
+		callCtx, f = fn.Module.CallCtx, fn
+	case *importedFn:
+		callCtx, f = fn.importingModule, fn.importedFn
+	default:
+		return nil, nil, fmt.Errorf("unsupported api.Function implementation: %#v", fn)
+	}
+	if callCtx.module != m.module {
+		return nil, nil, fmt.Errorf("%s is not a function of module[%s]", f.DebugName, m.Name())
+	}
+	return callCtx, f, nil
+}
+
+// CallWithLimits calls the function, which must be a function of this module, with memory.grow limited to maxPages
+// for the duration of the call. This errs before calling fn if the memory is already larger than maxPages.
+//
+// Note: The limit is carried by the context of the call, so it doesn't affect concurrent calls, or the Max of the
+// memory, which may be imported by other modules. A nested CallWithLimits can only lower the limit.
+func (m *CallContext) CallWithLimits(ctx context.Context, fn api.Function, maxPages uint32, params ...uint64) ([]uint64, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if _, _, err := m.ownFunction(fn); err != nil {
+		return nil, err
+	}
+	if mem := m.module.Memory; mem != nil {
+		if pages := mem.PageSize(ctx); pages > maxPages {
+			return nil, fmt.Errorf("memory of %d pages is already over the limit of %d pages", pages, maxPages)
+		}
+		if limit := growLimitPages(ctx, mem); limit < maxPages {
+			maxPages = limit
+		}
+		ctx = context.WithValue(ctx, growLimitKey{}, &growLimit{memory: mem, maxPages: maxPages})
+	}
+	return fn.Call(ctx, params...)
+}
@@ -33,6 +33,19 @@ type Engine interface {
 	DeleteCompiledModule(module *Module)
 }
 
+// CodeCache is optionally implemented by an Engine whose compiled code can be saved and restored, to avoid compiling
+// the same module again. See wazero.SerializeCompiledCode
+type CodeCache interface {
+	// CodeFormat identifies the format of SerializeCode, which DeserializeCode of another engine must match.
+	CodeFormat() string
+
+	// SerializeCode returns the code compiled by CompileModule for the given module.
+	SerializeCode(module *Module) ([]byte, error)
+
+	// DeserializeCode restores code returned by SerializeCode, instead of calling CompileModule for the given module.
+	DeserializeCode(module *Module, data []byte) error
+}
+
 // ModuleEngine implements function calls for a given module.
 type ModuleEngine interface {
 	// Name returns the name of the module this engine was compiled for.
@@ -44,6 +57,18 @@ type ModuleEngine interface {
 	// CreateFuncElementInstance creates an ElementInstance whose references are engine-specific function pointers
 	// corresponding to the given `indexes`.
 	CreateFuncElementInstance(indexes []*Index) *ElementInstance
+
+	// ReferencedFunction returns the function instance of a funcref Reference, or nil if it is a null reference.
+	//
+	// Note: The reference may have been created by another ModuleEngine of the same Engine, ex. via an imported table.
+	ReferencedFunction(ref Reference) *FunctionInstance
+}
+
+// GlobalWatcher is optionally implemented by a ModuleEngine which calls GlobalInstance.Watch when a function changes
+// the global. See CallContext.WatchGlobal
+type GlobalWatcher interface {
+	// WatchesGlobalSet marks that the "global.set" instruction calls GlobalInstance.Watch.
+	WatchesGlobalSet()
 }
 
 // TableInitMap is a mapping of Table's index to a mapping of TableInstance.Table index to the function index.
@@ -3,6 +3,8 @@ package wasm
 import (
 	"fmt"
 	"strings"
+
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 )
 
 // Features are the currently enabled features.
@@ -97,6 +99,12 @@ const (
 	//
 	// See https://github.com/WebAssembly/spec/blob/main/proposals/sign-extension-ops/Overview.md
 	FeatureSignExtensionOps
+
+	// FeatureThreads decides if parsing should succeed on a memory with the shared flag (limits flag 0x03).
+	//
+	// Note: Atomic instructions are not yet supported, so this only affects how memory is decoded and accessed.
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+	FeatureThreads
 )
 
 // Set assigns the value for the given feature.
@@ -108,13 +116,21 @@ func (f Features) Set(feature Features, val bool) Features {
 }
 
 // Get returns the value of the given feature.
+//
+// Note: When built with the "wazero_core1" tag, this is always false for features not in Features20191205.
 func (f Features) Get(feature Features) bool {
+	if buildoptions.IsCore1Only {
+		f &= Features20191205
+	}
 	return f&feature != 0
 }
 
 // Require fails with a configuration error if the given feature is not enabled
+//
+// Note: When built with the "wazero_core1" tag, this always fails for features not in Features20191205,
+// with the same error as when the feature is disabled in configuration.
 func (f Features) Require(feature Features) error {
-	if f&feature == 0 {
+	if !f.Get(feature) {
 		return fmt.Errorf("feature %q is disabled", feature)
 	}
 	return nil
@@ -123,9 +139,9 @@ func (f Features) Require(feature Features) error {
 // String implements fmt.Stringer by returning each enabled feature.
 func (f Features) String() string {
 	var builder strings.Builder
-	for i := Features(0); i < 63; i++ { // cycle through all bits to reduce code and maintenance
-		if f.Get(i) {
-			if name := featureName(i); name != "" {
+	for i := 0; i < 63; i++ { // cycle through all bits to reduce code and maintenance
+		if feature := Features(1) << i; f&feature != 0 {
+			if name := featureName(feature); name != "" {
 				if builder.Len() > 0 {
 					builder.WriteByte('|')
 				}
@@ -136,6 +152,35 @@ func (f Features) String() string {
 	return builder.String()
 }
 
+// ParseFeatures returns the features named in the input, separated by '|' as in String, or ','. Whitespace around names
+// is ignored, and this errs on any unknown name.
+//
+// Ex. "multi-value|sign-extension-ops" -> FeatureMultiValue | FeatureSignExtensionOps
+func ParseFeatures(input string) (Features, error) {
+	var ret Features
+	for _, name := range strings.FieldsFunc(input, func(r rune) bool { return r == '|' || r == ',' }) {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		feature := featureNamed(name)
+		if feature == 0 {
+			return 0, fmt.Errorf("unknown feature: %q", name)
+		}
+		ret |= feature
+	}
+	return ret, nil
+}
+
+// featureNamed returns the feature whose featureName is the input, or zero if there is none.
+func featureNamed(name string) Features {
+	for i := 0; i < 63; i++ {
+		if feature := Features(1) << i; featureName(feature) == name {
+			return feature
+		}
+	}
+	return 0
+}
+
 func featureName(f Features) string {
 	switch f {
 	case FeatureMutableGlobal:
@@ -156,6 +201,9 @@ func featureName(f Features) string {
 	case FeatureReferenceTypes:
 		// match https://github.com/WebAssembly/spec/blob/main/proposals/reference-types/Overview.md
 		return "reference-types"
+	case FeatureThreads:
+		// match https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md
+		return "threads"
 	}
 	return ""
 }
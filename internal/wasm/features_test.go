@@ -3,6 +3,7 @@ package wasm
 import (
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -16,6 +17,9 @@ func TestFeatures_ZeroIsInvalid(t *testing.T) {
 
 // TestFeatures tests the bitset works as expected
 func TestFeatures(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name    string
 		feature Features
@@ -60,6 +64,7 @@ func TestFeatures_String(t *testing.T) {
 		{name: "mutable-global", feature: FeatureMutableGlobal, expected: "mutable-global"},
 		{name: "sign-extension-ops", feature: FeatureSignExtensionOps, expected: "sign-extension-ops"},
 		{name: "multi-value", feature: FeatureMultiValue, expected: "multi-value"},
+		{name: "threads", feature: FeatureThreads, expected: "threads"},
 		{name: "features", feature: FeatureMutableGlobal | FeatureMultiValue, expected: "multi-value|mutable-global"},
 		{name: "undefined", feature: 1 << 63, expected: ""},
 		{name: "2.0", feature: Features20220419, expected: "bulk-memory-operations|multi-value|mutable-global|nontrapping-float-to-int-conversion|reference-types|sign-extension-ops"},
@@ -73,6 +78,35 @@ func TestFeatures_String(t *testing.T) {
 	}
 }
 
+func TestParseFeatures(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    Features
+		expectedErr string
+	}{
+		{name: "empty", input: "", expected: 0},
+		{name: "one", input: "multi-value", expected: FeatureMultiValue},
+		{name: "String", input: "multi-value|mutable-global", expected: FeatureMultiValue | FeatureMutableGlobal},
+		{name: "commas and spaces", input: " multi-value , threads,", expected: FeatureMultiValue | FeatureThreads},
+		{name: "2.0", input: Features20220419.String(), expected: Features20220419},
+		{name: "unknown", input: "multi-value|simd", expectedErr: `unknown feature: "simd"`},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+		t.Run(tc.name, func(t *testing.T) {
+			features, err := ParseFeatures(tc.input)
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, features)
+			}
+		})
+	}
+}
+
 func TestFeatures_Require(t *testing.T) {
 	tests := []struct {
 		name        string
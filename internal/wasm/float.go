@@ -0,0 +1,78 @@
+package wasm
+
+import (
+	"bytes"
+)
+
+// UsesFloatingPoint returns true if the module has any f32 or f64 value types, in signatures, locals or globals, or any
+// instructions which operate on floats, such as f32.const or i32.trunc_f32_s.
+//
+// Note: This is true even if the module has no float arithmetic, ex. it only passes an f64 param to an imported
+// function, as it still manipulates floats.
+func (m *Module) UsesFloatingPoint() bool {
+	for _, t := range m.TypeSection {
+		if hasFloat(t.Params) || hasFloat(t.Results) {
+			return true
+		}
+	}
+	for _, i := range m.ImportSection {
+		if i.Type == ExternTypeGlobal && isFloat(i.DescGlobal.ValType) {
+			return true
+		}
+	}
+	for _, g := range m.GlobalSection {
+		if isFloat(g.Type.ValType) {
+			return true
+		}
+	}
+	for _, c := range m.CodeSection {
+		if hasFloat(c.LocalTypes) || bodyUsesFloatingPoint(c.Body) {
+			return true
+		}
+	}
+	return false
+}
+
+// bodyUsesFloatingPoint returns true if the function body has any instruction which operates on floats. Malformed
+// bodies are scanned until the first error, as they are rejected by validation.
+func bodyUsesFloatingPoint(body []byte) bool {
+	r := bytes.NewReader(body)
+	for r.Len() > 0 {
+		op, err := r.ReadByte()
+		if err != nil {
+			break
+		}
+		switch {
+		case op == OpcodeF32Load || op == OpcodeF64Load || op == OpcodeF32Store || op == OpcodeF64Store,
+			op == OpcodeF32Const || op == OpcodeF64Const,
+			OpcodeF32Eq <= op && op <= OpcodeF64Ge,
+			OpcodeF32Abs <= op && op <= OpcodeF64Copysign,
+			// All conversions involve a float, except those between i32 and i64.
+			OpcodeI32TruncF32S <= op && op <= OpcodeF64ReinterpretI64 && op != OpcodeI64ExtendI32S && op != OpcodeI64ExtendI32U:
+			return true
+		case op == OpcodeMiscPrefix:
+			// The saturating truncations are the first sub-opcodes, so are encoded in a single byte.
+			if miscOp, err := r.ReadByte(); err == nil && OpcodeMisc(miscOp) <= OpcodeMiscI64TruncSatF64U {
+				return true
+			}
+			_ = r.UnreadByte()
+		}
+		if err = skipImmediates(op, r); err != nil {
+			break
+		}
+	}
+	return false
+}
+
+func hasFloat(types []ValueType) bool {
+	for _, t := range types {
+		if isFloat(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func isFloat(t ValueType) bool {
+	return t == ValueTypeF32 || t == ValueTypeF64
+}
@@ -0,0 +1,92 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_UsesFloatingPoint(t *testing.T) {
+	i32, i64, f32, f64 := ValueTypeI32, ValueTypeI64, ValueTypeF32, ValueTypeF64
+	// withBody returns a module of a function of type v_v with the body.
+	withBody := func(body ...byte) *Module {
+		return &Module{
+			TypeSection:     []*FunctionType{{}},
+			FunctionSection: []Index{0},
+			CodeSection:     []*Code{{Body: append(body, OpcodeEnd)}},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		module   *Module
+		expected bool
+	}{
+		{name: "empty", module: &Module{}},
+		{
+			name: "integers only",
+			module: &Module{
+				TypeSection:     []*FunctionType{{Params: []ValueType{i32, i64}, Results: []ValueType{i64}}},
+				ImportSection:   []*Import{{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: i32}}},
+				GlobalSection:   []*Global{{Type: &GlobalType{ValType: i64}}},
+				FunctionSection: []Index{0},
+				CodeSection: []*Code{{LocalTypes: []ValueType{i32}, Body: []byte{
+					OpcodeLocalGet, 0, OpcodeI64ExtendI32S, OpcodeLocalGet, 1, OpcodeI64Add,
+					OpcodeI32Const, 0, OpcodeI32Load, 2, 0, OpcodeDrop,
+					OpcodeMiscPrefix, byte(OpcodeMiscMemoryFill), 0, // not a saturating truncation
+					OpcodeEnd,
+				}}},
+			},
+		},
+		{
+			// Constants of other types can have the same bytes as float opcodes, so must be skipped.
+			name:   "float opcode as immediate",
+			module: withBody(OpcodeI32Const, OpcodeF32Const, OpcodeDrop),
+		},
+		{
+			name:     "param only",
+			module:   &Module{TypeSection: []*FunctionType{{Params: []ValueType{f64}}}},
+			expected: true,
+		},
+		{
+			name:     "result only",
+			module:   &Module{TypeSection: []*FunctionType{{Results: []ValueType{f32}}}},
+			expected: true,
+		},
+		{
+			name:     "imported global",
+			module:   &Module{ImportSection: []*Import{{Type: ExternTypeGlobal, DescGlobal: &GlobalType{ValType: f32}}}},
+			expected: true,
+		},
+		{
+			name:     "global",
+			module:   &Module{GlobalSection: []*Global{{Type: &GlobalType{ValType: f64}}}},
+			expected: true,
+		},
+		{
+			name: "local",
+			module: &Module{
+				TypeSection:     []*FunctionType{{}},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{LocalTypes: []ValueType{f32}, Body: []byte{OpcodeEnd}}},
+			},
+			expected: true,
+		},
+		{name: "const", module: withBody(OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0, OpcodeDrop), expected: true},
+		{name: "load", module: withBody(OpcodeI32Const, 0, OpcodeF32Load, 2, 0, OpcodeDrop), expected: true},
+		{name: "conversion", module: withBody(OpcodeI32Const, 0, OpcodeF64ConvertI32S, OpcodeDrop), expected: true},
+		{
+			name:     "saturating truncation",
+			module:   withBody(OpcodeMiscPrefix, byte(OpcodeMiscI32TruncSatF32S)),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.module.UsesFloatingPoint())
+		})
+	}
+}
@@ -40,7 +40,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	memory *Memory,
 	tables []*Table,
 	maxStackValues int,
-) error {
+) (err error) {
 	functionType := m.TypeSection[m.FunctionSection[idx]]
 	body := m.CodeSection[idx].Body
 	localTypes := m.CodeSection[idx].LocalTypes
@@ -48,12 +48,25 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 	// We start with the outermost control block which is for function return if the code branches into it.
 	controlBlockStack := []*controlBlock{{blockType: functionType}}
+	// lastEnded is the control block most recently closed by OpcodeEnd, used to report a missing end.
+	var lastEnded *controlBlock
 	// Create the valueTypeStack to track the state of Wasm value stacks at anypoint of execution.
 	valueTypeStack := &valueTypeStack{}
 
+	// instruction is the index of the current instruction in the body, and instructionOffset is its offset, used to
+	// locate a type mismatch.
+	var instruction int
+	var instructionOffset uint64
+	defer func() {
+		if errors.Is(err, errTypeMismatch) {
+			err = fmt.Errorf("%w at instruction %d (offset %d)", err, instruction, instructionOffset)
+		}
+	}()
+
 	// Now start walking through all the instructions in the body while tracking
 	// control blocks and value types to check the validity of all instructions.
-	for pc := uint64(0); pc < uint64(len(body)); pc++ {
+	for pc := uint64(0); pc < uint64(len(body)); pc, instruction = pc+1, instruction+1 {
+		instructionOffset = pc
 		op := body[pc]
 		if OpcodeI32Load <= op && op <= OpcodeI64Store32 {
 			if memory == nil {
@@ -62,7 +75,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc++
 			align, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read memory align: %v", err)
+				return fmt.Errorf("read memory align: %w", err)
 			}
 			switch op {
 			case OpcodeI32Load:
@@ -240,7 +253,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			// offset
 			_, num, err = leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read memory offset: %v", err)
+				return fmt.Errorf("read memory offset: %w", err)
 			}
 			pc += num - 1
 		} else if OpcodeMemorySize <= op && op <= OpcodeMemoryGrow {
@@ -250,7 +263,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc++
 			val, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read immediate: %v", err)
+				return fmt.Errorf("read immediate: %w", err)
 			}
 			if val != 0 || num != 1 {
 				return fmt.Errorf("memory instruction reserved bytes not zero with 1 byte")
@@ -278,7 +291,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			case OpcodeI64Const:
 				_, num, err := leb128.DecodeInt64(bytes.NewReader(body[pc:]))
 				if err != nil {
-					return fmt.Errorf("read i64 immediate: %v", err)
+					return fmt.Errorf("read i64 immediate: %w", err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 				pc += num - 1
@@ -293,7 +306,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc++
 			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read immediate: %v", err)
+				return fmt.Errorf("read immediate: %w", err)
 			}
 			pc += num - 1
 			switch op {
@@ -358,7 +371,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc++
 			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read immediate: %v", err)
+				return fmt.Errorf("read immediate: %w", err)
 			} else if int(index) >= len(controlBlockStack) {
 				return fmt.Errorf("invalid %s operation: index out of range", OpcodeBrName)
 			}
@@ -380,7 +393,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc++
 			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read immediate: %v", err)
+				return fmt.Errorf("read immediate: %w", err)
 			} else if int(index) >= len(controlBlockStack) {
 				return fmt.Errorf(
 					"invalid ln param given for %s: index=%d with %d for the current lable stack length",
@@ -471,7 +484,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc++
 			index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read immediate: %v", err)
+				return fmt.Errorf("read immediate: %w", err)
 			}
 			pc += num - 1
 			if int(index) >= len(functions) {
@@ -480,7 +493,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			funcType := types[functions[index]]
 			for i := 0; i < len(funcType.Params); i++ {
 				if err := valueTypeStack.popAndVerifyType(funcType.Params[len(funcType.Params)-1-i]); err != nil {
-					return fmt.Errorf("type mismatch on %s operation param type: %v", OpcodeCallName, err)
+					return fmt.Errorf("type mismatch on %s operation param type: %w", OpcodeCallName, err)
 				}
 			}
 			for _, exp := range funcType.Results {
@@ -490,7 +503,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc++
 			typeIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read immediate: %v", err)
+				return fmt.Errorf("read immediate: %w", err)
 			}
 			pc += num
 
@@ -500,7 +513,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 			tableIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 			if err != nil {
-				return fmt.Errorf("read table index: %v", err)
+				return fmt.Errorf("read table index: %w", err)
 			}
 			pc += num - 1
 			if tableIndex != 0 {
@@ -536,53 +549,53 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			switch op {
 			case OpcodeI32Eqz:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeI32EqzName, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeI32EqzName, err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI32Eq, OpcodeI32Ne, OpcodeI32LtS,
 				OpcodeI32LtU, OpcodeI32GtS, OpcodeI32GtU, OpcodeI32LeS,
 				OpcodeI32LeU, OpcodeI32GeS, OpcodeI32GeU:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the 1st i32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st i32 operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the 2nd i32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd i32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI64Eqz:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeI64EqzName, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeI64EqzName, err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI64Eq, OpcodeI64Ne, OpcodeI64LtS,
 				OpcodeI64LtU, OpcodeI64GtS, OpcodeI64GtU,
 				OpcodeI64LeS, OpcodeI64LeU, OpcodeI64GeS, OpcodeI64GeU:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the 1st i64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st i64 operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the 2nd i64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd i64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeF32Eq, OpcodeF32Ne, OpcodeF32Lt, OpcodeF32Gt, OpcodeF32Le, OpcodeF32Ge:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the 1st f32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st f32 operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the 2nd f32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd f32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeF64Eq, OpcodeF64Ne, OpcodeF64Lt, OpcodeF64Gt, OpcodeF64Le, OpcodeF64Ge:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the 1st f64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st f64 operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the 2nd f64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd f64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI32Clz, OpcodeI32Ctz, OpcodeI32Popcnt:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the i32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the i32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI32Add, OpcodeI32Sub, OpcodeI32Mul, OpcodeI32DivS,
@@ -590,15 +603,15 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				OpcodeI32Or, OpcodeI32Xor, OpcodeI32Shl, OpcodeI32ShrS,
 				OpcodeI32ShrU, OpcodeI32Rotl, OpcodeI32Rotr:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the 1st operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the 2nd operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI64Clz, OpcodeI64Ctz, OpcodeI64Popcnt:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the i64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the i64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 			case OpcodeI64Add, OpcodeI64Sub, OpcodeI64Mul, OpcodeI64DivS,
@@ -606,124 +619,124 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				OpcodeI64Or, OpcodeI64Xor, OpcodeI64Shl, OpcodeI64ShrS,
 				OpcodeI64ShrU, OpcodeI64Rotl, OpcodeI64Rotr:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the 1st i64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st i64 operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the 2nd i64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd i64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 			case OpcodeF32Abs, OpcodeF32Neg, OpcodeF32Ceil,
 				OpcodeF32Floor, OpcodeF32Trunc, OpcodeF32Nearest,
 				OpcodeF32Sqrt:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the 1st f32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st f32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF32)
 			case OpcodeF32Add, OpcodeF32Sub, OpcodeF32Mul,
 				OpcodeF32Div, OpcodeF32Min, OpcodeF32Max,
 				OpcodeF32Copysign:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the 1st f32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st f32 operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the 2nd f32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd f32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF32)
 			case OpcodeF64Abs, OpcodeF64Neg, OpcodeF64Ceil,
 				OpcodeF64Floor, OpcodeF64Trunc, OpcodeF64Nearest,
 				OpcodeF64Sqrt:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the 1st f64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st f64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF64)
 			case OpcodeF64Add, OpcodeF64Sub, OpcodeF64Mul,
 				OpcodeF64Div, OpcodeF64Min, OpcodeF64Max,
 				OpcodeF64Copysign:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the 1st f64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 1st f64 operand for %s: %w", InstructionName(op), err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the 2nd f64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the 2nd f64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF64)
 			case OpcodeI32WrapI64:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeI32WrapI64Name, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeI32WrapI64Name, err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI32TruncF32S, OpcodeI32TruncF32U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the f32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the f32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI32TruncF64S, OpcodeI32TruncF64U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the f64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the f64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI64ExtendI32S, OpcodeI64ExtendI32U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the i32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the i32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 			case OpcodeI64TruncF32S, OpcodeI64TruncF32U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the f32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the f32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 			case OpcodeI64TruncF64S, OpcodeI64TruncF64U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the f64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the f64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 			case OpcodeF32ConvertI32s, OpcodeF32ConvertI32U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the i32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the i32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF32)
 			case OpcodeF32ConvertI64S, OpcodeF32ConvertI64U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the i64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the i64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF32)
 			case OpcodeF32DemoteF64:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeF32DemoteF64Name, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeF32DemoteF64Name, err)
 				}
 				valueTypeStack.push(ValueTypeF32)
 			case OpcodeF64ConvertI32S, OpcodeF64ConvertI32U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the i32 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the i32 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF64)
 			case OpcodeF64ConvertI64S, OpcodeF64ConvertI64U:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the i64 operand for %s: %v", InstructionName(op), err)
+					return fmt.Errorf("cannot pop the i64 operand for %s: %w", InstructionName(op), err)
 				}
 				valueTypeStack.push(ValueTypeF64)
 			case OpcodeF64PromoteF32:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeF64PromoteF32Name, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeF64PromoteF32Name, err)
 				}
 				valueTypeStack.push(ValueTypeF64)
 			case OpcodeI32ReinterpretF32:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF32); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeI32ReinterpretF32Name, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeI32ReinterpretF32Name, err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI64ReinterpretF64:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeF64); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeI64ReinterpretF64Name, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeI64ReinterpretF64Name, err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 			case OpcodeF32ReinterpretI32:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeF32ReinterpretI32Name, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeF32ReinterpretI32Name, err)
 				}
 				valueTypeStack.push(ValueTypeF32)
 			case OpcodeF64ReinterpretI64:
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", OpcodeF64ReinterpretI64Name, err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", OpcodeF64ReinterpretI64Name, err)
 				}
 				valueTypeStack.push(ValueTypeF64)
 			case OpcodeI32Extend8S, OpcodeI32Extend16S:
@@ -731,7 +744,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					return fmt.Errorf("%s invalid as %v", instructionNames[op], err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", instructionNames[op], err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", instructionNames[op], err)
 				}
 				valueTypeStack.push(ValueTypeI32)
 			case OpcodeI64Extend8S, OpcodeI64Extend16S, OpcodeI64Extend32S:
@@ -739,7 +752,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					return fmt.Errorf("%s invalid as %v", instructionNames[op], err)
 				}
 				if err := valueTypeStack.popAndVerifyType(ValueTypeI64); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", instructionNames[op], err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", instructionNames[op], err)
 				}
 				valueTypeStack.push(ValueTypeI64)
 			default:
@@ -766,7 +779,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					inType, outType = ValueTypeF64, ValueTypeI64
 				}
 				if err := valueTypeStack.popAndVerifyType(inType); err != nil {
-					return fmt.Errorf("cannot pop the operand for %s: %v", miscInstructionNames[miscOpcode], err)
+					return fmt.Errorf("cannot pop the operand for %s: %w", miscInstructionNames[miscOpcode], err)
 				}
 				valueTypeStack.push(outType)
 			} else if miscOpcode >= OpcodeMiscMemoryInit && miscOpcode <= OpcodeMiscTableCopy {
@@ -785,7 +798,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					pc++
 					index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 					if err != nil {
-						return fmt.Errorf("failed to read data segment index for %s: %v", MiscInstructionName(miscOpcode), err)
+						return fmt.Errorf("failed to read data segment index for %s: %w", MiscInstructionName(miscOpcode), err)
 					}
 					if int(index) >= len(m.DataSection) {
 						return fmt.Errorf("index %d out of range of data section(len=%d)", index, len(m.DataSection))
@@ -806,7 +819,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 						pc++
 						index, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 						if err != nil {
-							return fmt.Errorf("failed to read data segment index for %s: %v", MiscInstructionName(miscOpcode), err)
+							return fmt.Errorf("failed to read data segment index for %s: %w", MiscInstructionName(miscOpcode), err)
 						}
 						if int(index) >= len(m.DataSection) {
 							return fmt.Errorf("index %d out of range of data section(len=%d)", index, len(m.DataSection))
@@ -817,7 +830,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					pc++
 					val, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 					if err != nil {
-						return fmt.Errorf("failed to read memory index for %s: %v", MiscInstructionName(miscOpcode), err)
+						return fmt.Errorf("failed to read memory index for %s: %w", MiscInstructionName(miscOpcode), err)
 					}
 					if val != 0 || num != 1 {
 						return fmt.Errorf("%s reserved byte must be zero encoded with 1 byte", MiscInstructionName(miscOpcode))
@@ -827,7 +840,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 						// memory.copy needs two memory index which are reserved as zero.
 						val, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 						if err != nil {
-							return fmt.Errorf("failed to read memory index for %s: %v", MiscInstructionName(miscOpcode), err)
+							return fmt.Errorf("failed to read memory index for %s: %w", MiscInstructionName(miscOpcode), err)
 						}
 						if val != 0 || num != 1 {
 							return fmt.Errorf("%s reserved byte must be zero encoded with 1 byte", MiscInstructionName(miscOpcode))
@@ -839,7 +852,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					pc++
 					elementIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 					if err != nil {
-						return fmt.Errorf("failed to read element segment index for %s: %v", MiscInstructionName(miscOpcode), err)
+						return fmt.Errorf("failed to read element segment index for %s: %w", MiscInstructionName(miscOpcode), err)
 					}
 					if int(elementIndex) >= len(m.ElementSection) {
 						return fmt.Errorf("index %d out of range of element section(len=%d)", elementIndex, len(m.ElementSection))
@@ -848,7 +861,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 					tableIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 					if err != nil {
-						return fmt.Errorf("failed to read source table index for %s: %v", MiscInstructionName(miscOpcode), err)
+						return fmt.Errorf("failed to read source table index for %s: %w", MiscInstructionName(miscOpcode), err)
 					}
 					if tableIndex != 0 {
 						if err := enabledFeatures.Require(FeatureReferenceTypes); err != nil {
@@ -870,7 +883,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 					pc++
 					elementIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 					if err != nil {
-						return fmt.Errorf("failed to read element segment index for %s: %v", MiscInstructionName(miscOpcode), err)
+						return fmt.Errorf("failed to read element segment index for %s: %w", MiscInstructionName(miscOpcode), err)
 					} else if int(elementIndex) >= len(m.ElementSection) {
 						return fmt.Errorf("index %d out of range of element section(len=%d)", elementIndex, len(m.ElementSection))
 					}
@@ -881,7 +894,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 					dstTableIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 					if err != nil {
-						return fmt.Errorf("failed to read destination table index for %s: %v", MiscInstructionName(miscOpcode), err)
+						return fmt.Errorf("failed to read destination table index for %s: %w", MiscInstructionName(miscOpcode), err)
 					}
 					if dstTableIndex != 0 {
 						if err := enabledFeatures.Require(FeatureReferenceTypes); err != nil {
@@ -895,7 +908,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 
 					srcTableIndex, num, err := leb128.DecodeUint32(bytes.NewReader(body[pc:]))
 					if err != nil {
-						return fmt.Errorf("failed to read source table index for %s: %v", MiscInstructionName(miscOpcode), err)
+						return fmt.Errorf("failed to read source table index for %s: %w", MiscInstructionName(miscOpcode), err)
 					}
 					if srcTableIndex != 0 {
 						if err := enabledFeatures.Require(FeatureReferenceTypes); err != nil {
@@ -915,7 +928,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				}
 				for _, p := range params {
 					if err := valueTypeStack.popAndVerifyType(p); err != nil {
-						return fmt.Errorf("cannot pop the operand for %s: %v", miscInstructionNames[miscOpcode], err)
+						return fmt.Errorf("cannot pop the operand for %s: %w", miscInstructionNames[miscOpcode], err)
 					}
 				}
 			}
@@ -963,7 +976,7 @@ func (m *Module) validateFunctionWithMaxStackValues(
 				op:             op,
 			})
 			if err = valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-				return fmt.Errorf("cannot pop the operand for 'if': %v", err)
+				return fmt.Errorf("cannot pop the operand for 'if': %w", err)
 			}
 			if err = valueTypeStack.popParams(op, bt.Params, false); err != nil {
 				return err
@@ -976,6 +989,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			pc += num
 		} else if op == OpcodeElse {
 			bl := controlBlockStack[len(controlBlockStack)-1]
+			if bl.op != OpcodeIf {
+				return fmt.Errorf("unexpected else at offset %d: no matching if", pc)
+			} else if bl.elseAt > bl.startAt {
+				return fmt.Errorf("unexpected else at offset %d: if started at offset %d already has an else at offset %d",
+					pc, bl.startAt, bl.elseAt)
+			}
 			bl.elseAt = pc
 			// Check the type soundness of the instructions *before* entering this else Op.
 			if err := valueTypeStack.popResults(OpcodeIf, bl.blockType.Results, true); err != nil {
@@ -991,6 +1010,12 @@ func (m *Module) validateFunctionWithMaxStackValues(
 			bl := controlBlockStack[len(controlBlockStack)-1]
 			bl.endAt = pc
 			controlBlockStack = controlBlockStack[:len(controlBlockStack)-1]
+			lastEnded = bl
+
+			// Only the last instruction can end the function itself.
+			if len(controlBlockStack) == 0 && pc != uint64(len(body))-1 {
+				return fmt.Errorf("unexpected end at offset %d: function body continues after it", pc)
+			}
 
 			// OpcodeEnd can end a block or the function itself. Check to see what it is:
 
@@ -1037,19 +1062,19 @@ func (m *Module) validateFunctionWithMaxStackValues(
 		} else if op == OpcodeDrop {
 			_, err := valueTypeStack.pop()
 			if err != nil {
-				return fmt.Errorf("invalid drop: %v", err)
+				return fmt.Errorf("invalid drop: %w", err)
 			}
 		} else if op == OpcodeSelect {
 			if err := valueTypeStack.popAndVerifyType(ValueTypeI32); err != nil {
-				return fmt.Errorf("type mismatch on 3rd select operand: %v", err)
+				return fmt.Errorf("type mismatch on 3rd select operand: %w", err)
 			}
 			v1, err := valueTypeStack.pop()
 			if err != nil {
-				return fmt.Errorf("invalid select: %v", err)
+				return fmt.Errorf("invalid select: %w", err)
 			}
 			v2, err := valueTypeStack.pop()
 			if err != nil {
-				return fmt.Errorf("invalid select: %v", err)
+				return fmt.Errorf("invalid select: %w", err)
 			}
 			if v1 != v2 && v1 != valueTypeUnknown && v2 != valueTypeUnknown {
 				return fmt.Errorf("type mismatch on 1st and 2nd select operands")
@@ -1069,7 +1094,15 @@ func (m *Module) validateFunctionWithMaxStackValues(
 	}
 
 	if len(controlBlockStack) > 0 {
-		return fmt.Errorf("ill-nested block exists")
+		// The last end closed a block instead of the function, so report that block as missing its end.
+		if lastEnded != nil {
+			name := OpcodeBlockName // OpcodeBlock leaves controlBlock.op zero
+			if lastEnded.op != 0 {
+				name = InstructionName(lastEnded.op)
+			}
+			return fmt.Errorf("missing end for %s started at offset %d", name, lastEnded.startAt)
+		}
+		return fmt.Errorf("missing end for function body")
 	}
 	if valueTypeStack.maximumStackPointer > maxStackValues {
 		return fmt.Errorf("function may have %d stack values, which exceeds limit %d", valueTypeStack.maximumStackPointer, maxStackValues)
@@ -1115,14 +1148,19 @@ func (s *valueTypeStack) pop() (ValueType, error) {
 	}
 }
 
+// errTypeMismatch is wrapped by the error of popAndVerifyType when the stack value has an unexpected type.
+var errTypeMismatch = errors.New("type mismatch")
+
 // popAndVerifyType returns an error if the stack value is unexpected.
+//
+// Note: A valueTypeUnknown, pushed in a stack-polymorphic context such as after `unreachable`, matches any type.
 func (s *valueTypeStack) popAndVerifyType(expected ValueType) error {
 	have, _, ok := s.tryPop()
 	if !ok {
 		return fmt.Errorf("%s missing", ValueTypeName(expected))
 	}
 	if have != expected && have != valueTypeUnknown && expected != valueTypeUnknown {
-		return fmt.Errorf("type mismatch: expected %s, but was %s", ValueTypeName(expected), ValueTypeName(have))
+		return fmt.Errorf("%w: expected %s, but was %s", errTypeMismatch, ValueTypeName(expected), ValueTypeName(have))
 	}
 	return nil
 }
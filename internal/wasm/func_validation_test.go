@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -43,6 +44,55 @@ func TestModule_ValidateFunction_validateFunctionWithMaxStackValues(t *testing.T
 	})
 }
 
+func TestModule_ValidateFunction_TypeMismatch(t *testing.T) {
+	f64Zero := []byte{OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0}
+
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name:        "i32.add of f64",
+			body:        append(append([]byte{OpcodeI32Const, 1}, f64Zero...), OpcodeI32Add, OpcodeDrop, OpcodeEnd),
+			expectedErr: "cannot pop the 1st operand for i32.add: type mismatch: expected i32, but was f64 at instruction 2 (offset 11)",
+		},
+		{
+			name: "after unreachable",
+			// The stack is polymorphic, so i32.add pops two unknown values.
+			body: []byte{OpcodeUnreachable, OpcodeI32Add, OpcodeDrop, OpcodeEnd},
+		},
+		{
+			name: "after br",
+			body: []byte{OpcodeBlock, 0x40, OpcodeBr, 0, OpcodeI32Add, OpcodeDrop, OpcodeEnd, OpcodeEnd},
+		},
+		{
+			name: "f64 pushed after unreachable",
+			// Only values below those pushed after unreachable are unknown.
+			body:        append(append([]byte{OpcodeUnreachable}, f64Zero...), OpcodeI32Add, OpcodeDrop, OpcodeEnd),
+			expectedErr: "cannot pop the 1st operand for i32.add: type mismatch: expected i32, but was f64 at instruction 2 (offset 10)",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(Features20191205, 0, []Index{0}, nil, nil, nil)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
 func TestModule_ValidateFunction_SignExtensionOps(t *testing.T) {
 	tests := []struct {
 		input                Opcode
@@ -104,6 +154,9 @@ func TestModule_ValidateFunction_SignExtensionOps(t *testing.T) {
 }
 
 func TestModule_ValidateFunction_NonTrappingFloatToIntConversion(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		input                Opcode
 		expectedErrOnDisable string
@@ -181,6 +234,9 @@ func TestModule_ValidateFunction_NonTrappingFloatToIntConversion(t *testing.T) {
 //
 // See https://github.com/WebAssembly/spec/commit/484180ba3d9d7638ba1cb400b699ffede796927c
 func TestModule_ValidateFunction_MultiValue(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name                 string
 		module               *Module
@@ -258,6 +314,9 @@ func TestModule_ValidateFunction_MultiValue(t *testing.T) {
 }
 
 func TestModule_ValidateFunction_BulkMemoryOperations(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	t.Run("ok", func(t *testing.T) {
 		for _, op := range []OpcodeMisc{
 			OpcodeMiscMemoryInit, OpcodeMiscDataDrop, OpcodeMiscMemoryCopy,
@@ -677,6 +736,9 @@ var (
 //
 // See https://github.com/WebAssembly/spec/commit/484180ba3d9d7638ba1cb400b699ffede796927c
 func TestModule_ValidateFunction_MultiValue_TypeMismatch(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name            string
 		module          *Module
@@ -2183,6 +2245,9 @@ func TestModule_ValidateFunction_MultiValue_TypeMismatch(t *testing.T) {
 }
 
 func TestModule_funcValidation_CallIndirect(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	t.Run("ok", func(t *testing.T) {
 		m := &Module{
 			TypeSection:     []*FunctionType{v_v},
@@ -2229,3 +2294,96 @@ func TestModule_funcValidation_CallIndirect(t *testing.T) {
 		require.EqualError(t, err, "table is not funcref type but was externref for call_indirect")
 	})
 }
+
+func TestModule_ValidateFunction_Nesting(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        []byte
+		expectedErr string
+	}{
+		{
+			name: "block missing end",
+			body: []byte{
+				OpcodeNop,
+				OpcodeBlock, 0x40, // (block
+				OpcodeNop,
+				OpcodeEnd, // func, but closes the block
+			},
+			expectedErr: "missing end for block started at offset 1",
+		},
+		{
+			name: "if missing end",
+			body: []byte{
+				OpcodeI32Const, 0,
+				OpcodeIf, 0x40, // (if
+				OpcodeElse, // (else
+				OpcodeEnd,  // func, but closes the if
+			},
+			expectedErr: "missing end for if started at offset 2",
+		},
+		{
+			name: "loop missing end",
+			body: []byte{
+				OpcodeBlock, 0x40, // (block
+				OpcodeLoop, 0x40, // (loop
+				OpcodeEnd, // loop, but closes the block
+				OpcodeEnd, // func, but closes the loop
+			},
+			expectedErr: "missing end for block started at offset 0",
+		},
+		{
+			name: "stray end",
+			body: []byte{
+				OpcodeBlock, 0x40, // (block
+				OpcodeEnd, // block
+				OpcodeEnd, // func
+				OpcodeEnd, // stray
+			},
+			expectedErr: "unexpected end at offset 3: function body continues after it",
+		},
+		{
+			name: "else in function",
+			body: []byte{
+				OpcodeElse, // stray
+				OpcodeEnd,  // func
+			},
+			expectedErr: "unexpected else at offset 0: no matching if",
+		},
+		{
+			name: "else in block",
+			body: []byte{
+				OpcodeBlock, 0x40, // (block
+				OpcodeElse, // stray
+				OpcodeEnd,  // block
+				OpcodeEnd,  // func
+			},
+			expectedErr: "unexpected else at offset 2: no matching if",
+		},
+		{
+			name: "else twice",
+			body: []byte{
+				OpcodeI32Const, 0,
+				OpcodeIf, 0x40, // (if
+				OpcodeElse, // (else
+				OpcodeElse, // stray
+				OpcodeEnd,  // if
+				OpcodeEnd,  // func
+			},
+			expectedErr: "unexpected else at offset 5: if started at offset 2 already has an else at offset 4",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			m := &Module{
+				TypeSection:     []*FunctionType{v_v},
+				FunctionSection: []Index{0},
+				CodeSection:     []*Code{{Body: tc.body}},
+			}
+			err := m.validateFunction(Features20191205, 0, []Index{0}, nil, nil, nil)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
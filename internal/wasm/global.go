@@ -30,7 +30,11 @@ func (g *mutableGlobal) Get(_ context.Context) uint64 {
 func (g *mutableGlobal) Set(_ context.Context, v uint64) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	old := g.g.Val
 	g.g.Val = v
+	if w := g.g.Watch; w != nil && old != v {
+		w(old, v)
+	}
 }
 
 // String implements fmt.Stringer
@@ -67,6 +67,14 @@ func PopValues(count int, popper func() uint64) []uint64 {
 	return params
 }
 
+// PopValuesInto is like PopValues, except values are written to dst, which is sized to the count of values.
+func PopValuesInto(dst []uint64, popper func() uint64) []uint64 {
+	for i := len(dst) - 1; i >= 0; i-- {
+		dst[i] = popper()
+	}
+	return dst
+}
+
 // CallGoFunc executes the FunctionInstance.GoFunc by converting params to Go types. The results of the function call
 // are converted back to api.ValueType.
 //
@@ -130,6 +138,13 @@ func CallGoFunc(ctx context.Context, callCtx *CallContext, f *FunctionInstance,
 			panic("BUG: invalid return type")
 		}
 	}
+
+	// If the function exited the module, ex. via "proc_exit", unwind the guest which called it instead of returning to
+	// it. The engine recovers the sys.ExitError, so the call fails with it instead of a trap. This also applies when a
+	// guest function the host function called exited the module, so the exit propagates through nested calls.
+	if err := callCtx.FailIfClosed(); err != nil {
+		panic(err)
+	}
 	return results
 }
 
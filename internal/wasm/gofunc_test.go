@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
 
@@ -14,6 +15,9 @@ import (
 var testCtx = context.WithValue(context.Background(), struct{}{}, "arbitrary")
 
 func TestGetFunctionType(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	var tests = []struct {
 		name         string
 		inputFunc    interface{}
@@ -197,6 +201,13 @@ func TestPopValues(t *testing.T) {
 	}
 }
 
+func TestPopValuesInto(t *testing.T) {
+	dst := []uint64{0, 0, 0}
+	vals := PopValuesInto(dst, (&stack{[]uint64{1, 2, 3, 4, 5, 6, 7}}).pop)
+	require.Equal(t, []uint64{5, 6, 7}, vals)
+	require.Equal(t, []uint64{5, 6, 7}, dst) // overwritten in place
+}
+
 func TestPopGoFuncParams(t *testing.T) {
 	stackVals := []uint64{1, 2, 3, 4, 5, 6, 7}
 	var tests = []struct {
@@ -257,7 +268,10 @@ func TestPopGoFuncParams(t *testing.T) {
 }
 
 func TestCallGoFunc(t *testing.T) {
-	callCtx := &CallContext{}
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	callCtx := &CallContext{closed: new(uint64)}
 
 	var tests = []struct {
 		name                         string
@@ -108,6 +108,37 @@ func addFuncs(m *Module, nameToGoFunc map[string]interface{}, enabledFeatures Fe
 	return nil
 }
 
+// validateHostFunctions returns an error if the signature of any host function disagrees with its type in the
+// TypeSection. CallGoFunc pushes a result for each result of the Go function, so a function returning fewer or more
+// results than its type would otherwise corrupt the stack of its caller when called.
+//
+// Note: This only guards modules built by hand, as NewHostModule declares each type from its Go function, so can't
+// disagree.
+func (m *Module) validateHostFunctions(enabledFeatures Features) error {
+	if len(m.HostFunctionSection) != len(m.FunctionSection) {
+		return fmt.Errorf("host function count (%d) != function count (%d)", len(m.HostFunctionSection), len(m.FunctionSection))
+	}
+	for idx, fn := range m.HostFunctionSection {
+		desc := m.funcDesc(SectionIDHostFunction, Index(idx))
+		typeIndex := m.FunctionSection[idx]
+		if typeIndex >= uint32(len(m.TypeSection)) {
+			return fmt.Errorf("invalid %s: type section index %d out of range", desc, typeIndex)
+		}
+		_, ft, err := getFunctionType(fn, enabledFeatures)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", desc, err)
+		}
+		declared := m.TypeSection[typeIndex]
+		if len(ft.Results) != len(declared.Results) {
+			return fmt.Errorf("invalid %s: returns %d results, but its type %s has %d",
+				desc, len(ft.Results), declared, len(declared.Results))
+		} else if !declared.EqualsSignature(ft.Params, ft.Results) {
+			return fmt.Errorf("invalid %s: signature %s doesn't match its type %s", desc, ft, declared)
+		}
+	}
+	return nil
+}
+
 func addMemory(m *Module, nameToMemory map[string]*Memory) error {
 	memoryCount := uint32(len(nameToMemory))
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 )
@@ -30,6 +31,9 @@ func swap(x, y uint32) (uint32, uint32) {
 }
 
 func TestNewHostModule(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	i32 := ValueTypeI32
 
 	a := wasiAPI{}
@@ -268,3 +272,60 @@ func TestNewHostModule_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestModule_validateHostFunctions(t *testing.T) {
+	void := &FunctionType{}
+	i32_v := &FunctionType{Params: []ValueType{ValueTypeI32}}
+	v_i32 := &FunctionType{Results: []ValueType{ValueTypeI32}}
+
+	tests := []struct {
+		name        string
+		goFunc      interface{}
+		declared    *FunctionType
+		expectedErr string
+	}{
+		{
+			name:     "matches",
+			goFunc:   func(uint32) {},
+			declared: i32_v,
+		},
+		{
+			name:        "void function declared with a result",
+			goFunc:      func() {},
+			declared:    v_i32,
+			expectedErr: "invalid host_function[0] export[\"fn\"]: returns 0 results, but its type v_i32 has 1",
+		},
+		{
+			name:        "function with a result declared void",
+			goFunc:      func() uint32 { return 0 },
+			declared:    void,
+			expectedErr: "invalid host_function[0] export[\"fn\"]: returns 1 results, but its type v_v has 0",
+		},
+		{
+			name:        "params differ",
+			goFunc:      func(uint64) {},
+			declared:    i32_v,
+			expectedErr: "invalid host_function[0] export[\"fn\"]: signature i64_v doesn't match its type i32_v",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			fn := reflect.ValueOf(tc.goFunc)
+			m := &Module{
+				TypeSection:         []*FunctionType{tc.declared},
+				FunctionSection:     []Index{0},
+				HostFunctionSection: []*reflect.Value{&fn},
+				ExportSection:       []*Export{{Type: ExternTypeFunc, Name: "fn", Index: 0}},
+			}
+			err := m.Validate(Features20191205)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package wasm
+
+// DuplicateImport is a module and name imported more than once. See Module.DuplicateImports
+type DuplicateImport struct {
+	Module, Name string
+	// Indices are the positions in the ImportSection of each import of the module and name, in order.
+	Indices []Index
+	// Conflicting is true if the imports differ in kind or type. Otherwise, they are identical.
+	Conflicting bool
+}
+
+// DuplicateImports returns each module and name imported more than once, in order of the first such import.
+//
+// Duplicate imports are valid, even when they are of different kinds, ex. a function and a global. However, a name
+// resolves to a single export, which can't satisfy imports of a different kind or type. Identical duplicates always
+// resolve, so are only redundant.
+//
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#imports%E2%91%A0
+func (m *Module) DuplicateImports() (ret []*DuplicateImport) {
+	type key struct{ module, name string }
+	var keys []key // in order of the first import of each
+	seen := map[key]*DuplicateImport{}
+	for i, imp := range m.ImportSection {
+		k := key{imp.Module, imp.Name}
+		if d, ok := seen[k]; !ok {
+			keys = append(keys, k)
+			seen[k] = &DuplicateImport{Module: imp.Module, Name: imp.Name, Indices: []Index{Index(i)}}
+		} else {
+			if !m.sameImportType(m.ImportSection[d.Indices[0]], imp) {
+				d.Conflicting = true
+			}
+			d.Indices = append(d.Indices, Index(i))
+		}
+	}
+
+	for _, k := range keys {
+		if d := seen[k]; len(d.Indices) > 1 {
+			ret = append(ret, d)
+		}
+	}
+	return
+}
+
+// sameImportType returns true if the imports are of the same kind and type. Function types are compared by
+// signature, as different type indices can have the same one.
+func (m *Module) sameImportType(a, b *Import) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case ExternTypeFunc:
+		if a.DescFunc == b.DescFunc {
+			return true
+		}
+		typeCount := Index(len(m.TypeSection))
+		return a.DescFunc < typeCount && b.DescFunc < typeCount &&
+			m.TypeSection[a.DescFunc].String() == m.TypeSection[b.DescFunc].String()
+	case ExternTypeTable:
+		return a.DescTable.Type == b.DescTable.Type && a.DescTable.Min == b.DescTable.Min &&
+			equalMax(a.DescTable.Max, b.DescTable.Max)
+	case ExternTypeMemory:
+		return a.DescMem.Min == b.DescMem.Min && a.DescMem.Max == b.DescMem.Max &&
+			a.DescMem.IsMaxEncoded == b.DescMem.IsMaxEncoded && a.DescMem.IsShared == b.DescMem.IsShared
+	case ExternTypeGlobal:
+		return *a.DescGlobal == *b.DescGlobal
+	}
+	return false
+}
+
+func equalMax(a, b *uint32) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,97 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_DuplicateImports(t *testing.T) {
+	one, two := uint32(1), uint32(2)
+	m := &Module{
+		TypeSection: []*FunctionType{{}, {Params: []ValueType{ValueTypeI32}}, {}},
+	}
+
+	tests := []struct {
+		name     string
+		input    []*Import
+		expected []*DuplicateImport
+	}{
+		{
+			name: "none",
+			input: []*Import{
+				{Type: ExternTypeFunc, Module: "env", Name: "a"},
+				{Type: ExternTypeFunc, Module: "env", Name: "b"},
+				{Type: ExternTypeFunc, Module: "other", Name: "a"},
+			},
+		},
+		{
+			name: "identical functions",
+			input: []*Import{
+				{Type: ExternTypeFunc, Module: "env", Name: "a", DescFunc: 0},
+				{Type: ExternTypeFunc, Module: "env", Name: "a", DescFunc: 2}, // same signature as type[0]
+				{Type: ExternTypeFunc, Module: "env", Name: "a", DescFunc: 0},
+			},
+			expected: []*DuplicateImport{{Module: "env", Name: "a", Indices: []Index{0, 1, 2}}},
+		},
+		{
+			name: "conflicting functions",
+			input: []*Import{
+				{Type: ExternTypeFunc, Module: "env", Name: "a", DescFunc: 0},
+				{Type: ExternTypeFunc, Module: "env", Name: "a", DescFunc: 1},
+			},
+			expected: []*DuplicateImport{{Module: "env", Name: "a", Indices: []Index{0, 1}, Conflicting: true}},
+		},
+		{
+			name: "different kinds",
+			input: []*Import{
+				{Type: ExternTypeFunc, Module: "env", Name: "a", DescFunc: 0},
+				{Type: ExternTypeGlobal, Module: "env", Name: "a", DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+			},
+			expected: []*DuplicateImport{{Module: "env", Name: "a", Indices: []Index{0, 1}, Conflicting: true}},
+		},
+		{
+			name: "globals",
+			input: []*Import{
+				{Type: ExternTypeGlobal, Module: "env", Name: "a", DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+				{Type: ExternTypeGlobal, Module: "env", Name: "b", DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+				{Type: ExternTypeGlobal, Module: "env", Name: "a", DescGlobal: &GlobalType{ValType: ValueTypeI32}},
+				{Type: ExternTypeGlobal, Module: "env", Name: "b", DescGlobal: &GlobalType{ValType: ValueTypeI32, Mutable: true}},
+			},
+			expected: []*DuplicateImport{
+				{Module: "env", Name: "a", Indices: []Index{0, 2}},
+				{Module: "env", Name: "b", Indices: []Index{1, 3}, Conflicting: true},
+			},
+		},
+		{
+			name: "memories",
+			input: []*Import{
+				{Type: ExternTypeMemory, Module: "env", Name: "a", DescMem: &Memory{Min: 1}},
+				{Type: ExternTypeMemory, Module: "env", Name: "a", DescMem: &Memory{Min: 1, Max: 2, IsMaxEncoded: true}},
+			},
+			expected: []*DuplicateImport{{Module: "env", Name: "a", Indices: []Index{0, 1}, Conflicting: true}},
+		},
+		{
+			name: "tables",
+			input: []*Import{
+				{Type: ExternTypeTable, Module: "env", Name: "a", DescTable: &Table{Min: 1, Max: &one}},
+				{Type: ExternTypeTable, Module: "env", Name: "a", DescTable: &Table{Min: 1, Max: &one}},
+				{Type: ExternTypeTable, Module: "env", Name: "b", DescTable: &Table{Min: 1, Max: &one}},
+				{Type: ExternTypeTable, Module: "env", Name: "b", DescTable: &Table{Min: 1, Max: &two}},
+			},
+			expected: []*DuplicateImport{
+				{Module: "env", Name: "a", Indices: []Index{0, 1}},
+				{Module: "env", Name: "b", Indices: []Index{2, 3}, Conflicting: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			m.ImportSection = tc.input
+			require.Equal(t, tc.expected, m.DuplicateImports())
+		})
+	}
+}
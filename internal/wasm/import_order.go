@@ -0,0 +1,75 @@
+package wasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ImportOrder returns the indices of modules in the order they can be instantiated, such that each module follows any
+// others it imports. names are the module names each will be instantiated as. Imports of modules not in names are
+// ignored, as they resolve against the Store.
+//
+// Modules whose name is empty are unnamed, so are only identified by their index: there can be several, and no
+// module can import them.
+//
+// This errs if the imports form a cycle, including a module importing itself, as no module in the cycle can be
+// instantiated before the others.
+func ImportOrder(names []string, modules []*Module) ([]int, error) {
+	nameToIdx := make(map[string]int, len(names))
+	for i, name := range names {
+		if name == "" { // unnamed, so not importable.
+			continue
+		}
+		if _, ok := nameToIdx[name]; ok {
+			return nil, fmt.Errorf("module[%s] is defined more than once", name)
+		}
+		nameToIdx[name] = i
+	}
+
+	const (
+		unvisited byte = iota
+		visiting
+		visited
+	)
+	state := make([]byte, len(modules))
+	order := make([]int, 0, len(modules))
+	var path []int // the modules currently visiting, each importing the next.
+
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visited:
+			return nil
+		case visiting: // i is already in the path, so the imports since then loop back to it.
+			var cycle []string
+			for j := len(path) - 1; j >= 0; j-- {
+				cycle = append([]string{names[path[j]]}, cycle...)
+				if path[j] == i {
+					break
+				}
+			}
+			return fmt.Errorf("import cycle: %s -> %s", strings.Join(cycle, " -> "), names[i])
+		}
+
+		state[i] = visiting
+		path = append(path, i)
+		for _, imp := range modules[i].ImportSection {
+			if dep, ok := nameToIdx[imp.Module]; ok {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		order = append(order, i)
+		return nil
+	}
+
+	for i := range modules {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
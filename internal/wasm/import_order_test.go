@@ -0,0 +1,92 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestImportOrder(t *testing.T) {
+	importing := func(moduleNames ...string) *Module {
+		m := &Module{TypeSection: []*FunctionType{{}}}
+		for _, name := range moduleNames {
+			m.ImportSection = append(m.ImportSection, &Import{Module: name, Name: "f", Type: ExternTypeFunc})
+		}
+		return m
+	}
+
+	tests := []struct {
+		name          string
+		names         []string
+		modules       []*Module
+		expected      []int
+		expectedError string
+	}{
+		{
+			name:     "no modules",
+			expected: []int{},
+		},
+		{
+			name:     "no imports between modules",
+			names:    []string{"a", "b"},
+			modules:  []*Module{importing("env"), importing()},
+			expected: []int{0, 1},
+		},
+		{
+			name:     "imports are ordered first",
+			names:    []string{"a", "b", "c"},
+			modules:  []*Module{importing("b", "env"), importing("c"), importing()},
+			expected: []int{2, 1, 0},
+		},
+		{
+			name:     "shared import",
+			names:    []string{"a", "b", "c"},
+			modules:  []*Module{importing("c"), importing("c", "a"), importing()},
+			expected: []int{2, 0, 1},
+		},
+		{
+			name:          "two module cycle",
+			names:         []string{"a", "b"},
+			modules:       []*Module{importing("b"), importing("a")},
+			expectedError: "import cycle: a -> b -> a",
+		},
+		{
+			name:          "cycle after a path",
+			names:         []string{"a", "b", "c"},
+			modules:       []*Module{importing("b"), importing("c"), importing("b")},
+			expectedError: "import cycle: b -> c -> b",
+		},
+		{
+			name:          "self-import",
+			names:         []string{"a", "b"},
+			modules:       []*Module{importing(), importing("b")},
+			expectedError: "import cycle: b -> b",
+		},
+		{
+			name:     "unnamed modules",
+			names:    []string{"", "a", ""},
+			modules:  []*Module{importing("a"), importing(), importing("")},
+			expected: []int{1, 0, 2},
+		},
+		{
+			name:          "duplicate name",
+			names:         []string{"a", "a"},
+			modules:       []*Module{importing(), importing()},
+			expectedError: "module[a] is defined more than once",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			order, err := ImportOrder(tc.names, tc.modules)
+			if tc.expectedError != "" {
+				require.EqualError(t, err, tc.expectedError)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expected, order)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/internal/wazeroir"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 var callStackCeiling = buildoptions.CallStackCeiling
@@ -25,6 +26,25 @@ type engine struct {
 	enabledFeatures wasm.Features
 	codes           map[wasm.ModuleID][]*code // guarded by mutex.
 	mux             sync.RWMutex
+
+	// validateResults is set by EnableResultValidation.
+	validateResults bool
+
+	// memoryWriteLog is set by SetMemoryWriteLog.
+	memoryWriteLog func(offset uint32, data []byte)
+	// memoryGrowthListener is set by SetMemoryGrowthListener.
+	memoryGrowthListener func(memoryIndex, before, delta uint32, ok bool)
+	// nullFuncrefHandler is set by SetNullFuncrefHandler.
+	nullFuncrefHandler func(tableIndex, offset uint32) error
+
+	// labelTracing is set by EnableLabelTracing.
+	labelTracing bool
+
+	// lenientFloatToInt is set by EnableLenientFloatToInt.
+	lenientFloatToInt bool
+
+	// perModuleTypeIDs is set by EnablePerModuleTypeIDs.
+	perModuleTypeIDs bool
 }
 
 func NewEngine(enabledFeatures wasm.Features) wasm.Engine {
@@ -34,6 +54,61 @@ func NewEngine(enabledFeatures wasm.Features) wasm.Engine {
 	}
 }
 
+// EnableResultValidation ensures the results of each function called via wasm.ModuleEngine match its signature.
+// This is defense-in-depth against engine bugs, so is disabled by default for performance.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) EnableResultValidation() {
+	e.validateResults = true
+}
+
+// EnableLabelTracing keeps the label operations of compiled functions, so that an experimental.LabelListener in the
+// context.Context of a call is notified. Otherwise, labels are dropped, as branches jump to their address directly.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) EnableLabelTracing() {
+	e.labelTracing = true
+}
+
+// EnableLenientFloatToInt makes trapping float-to-int conversions saturate instead, as if the non-trapping variant.
+// This is not conformant to the WebAssembly specification.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) EnableLenientFloatToInt() {
+	e.lenientFloatToInt = true
+}
+
+// EnablePerModuleTypeIDs supports wasm.Store PerModuleTypeIDs, by comparing signatures instead of type IDs when
+// call_indirect calls a function of another module.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) EnablePerModuleTypeIDs() {
+	e.perModuleTypeIDs = true
+}
+
+// SetMemoryWriteLog sets a function called after each write to memory by a guest instruction, or nil to disable.
+// Bulk operations, such as memory.fill, are logged as a single range.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) SetMemoryWriteLog(memoryWriteLog func(offset uint32, data []byte)) {
+	e.memoryWriteLog = memoryWriteLog
+}
+
+// SetMemoryGrowthListener sets a function called after each memory.grow by a guest instruction, or nil to disable.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) SetMemoryGrowthListener(memoryGrowthListener func(memoryIndex, before, delta uint32, ok bool)) {
+	e.memoryGrowthListener = memoryGrowthListener
+}
+
+// SetNullFuncrefHandler sets a function called when call_indirect references a null table element, or nil to trap
+// instead. When it returns nil, the call returns zero values. Otherwise, the call traps with the error.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) SetNullFuncrefHandler(nullFuncrefHandler func(tableIndex, offset uint32) error) {
+	e.nullFuncrefHandler = nullFuncrefHandler
+}
+
 // DeleteCompiledModule implements the same method as documented on wasm.Engine.
 func (e *engine) DeleteCompiledModule(m *wasm.Module) {
 	e.deleteCodes(m)
@@ -70,6 +145,18 @@ type moduleEngine struct {
 	// parentEngine holds *engine from which this module engine is created from.
 	parentEngine          *engine
 	importedFunctionCount uint32
+
+	// memoryWriteLog is engine.memoryWriteLog, copied to avoid dereferencing parentEngine on each call.
+	memoryWriteLog func(offset uint32, data []byte)
+
+	// memoryGrowthListener is engine.memoryGrowthListener, copied to avoid dereferencing parentEngine on each call.
+	memoryGrowthListener func(memoryIndex, before, delta uint32, ok bool)
+
+	// nullFuncrefHandler is engine.nullFuncrefHandler, copied to avoid dereferencing parentEngine on each call.
+	nullFuncrefHandler func(tableIndex, offset uint32) error
+
+	// perModuleTypeIDs is engine.perModuleTypeIDs, copied to avoid dereferencing parentEngine on each call.
+	perModuleTypeIDs bool
 }
 
 // callEngine holds context per moduleEngine.Call, and shared across all the
@@ -81,6 +168,20 @@ type callEngine struct {
 
 	// frames are the function call stack.
 	frames []*callFrame
+
+	// callIndirectInterceptor is read from the context.Context of each call, or nil if there is none.
+	callIndirectInterceptor experimental.CallIndirectInterceptor
+
+	// labelListener is read from the context.Context of each call, or nil if there is none.
+	labelListener experimental.LabelListener
+
+	// stepListener is read from the context.Context of each call, or nil if there is none.
+	stepListener experimental.StepListener
+
+	// ctx is the context.Context of each call. See failIfDone
+	ctx context.Context
+	// done is ctx.Done, cached to avoid an interface call on each check, or nil if ctx can never be done.
+	done <-chan struct{}
 }
 
 func (me *moduleEngine) newCallEngine() *callEngine {
@@ -213,8 +314,11 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 		if err != nil {
 			return err
 		}
+		if e.lenientFloatToInt {
+			wazeroir.SaturateFloatToInt(irs)
+		}
 		for i, ir := range irs {
-			compiled, err := e.lowerIR(ir)
+			compiled, err := e.lowerIR(ir, e.labelTracing)
 			if err != nil {
 				return fmt.Errorf("function[%d/%d] failed to convert wazeroir operations: %w", i, len(module.FunctionSection)-1, err)
 			}
@@ -233,6 +337,10 @@ func (e *engine) NewModuleEngine(name string, module *wasm.Module, importedFunct
 		name:                  name,
 		parentEngine:          e,
 		importedFunctionCount: imported,
+		memoryWriteLog:        e.memoryWriteLog,
+		memoryGrowthListener:  e.memoryGrowthListener,
+		nullFuncrefHandler:    e.nullFuncrefHandler,
+		perModuleTypeIDs:      e.perModuleTypeIDs,
 	}
 
 	for _, f := range importedFunctions {
@@ -259,12 +367,23 @@ func (e *engine) NewModuleEngine(name string, module *wasm.Module, importedFunct
 	return me, nil
 }
 
-// lowerIR lowers the wazeroir operations to engine friendly struct.
-func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
+// lowerIR lowers the wazeroir operations to engine friendly struct. Label operations are dropped unless keepLabels.
+func (e *engine) lowerIR(ir *wazeroir.CompilationResult, keepLabels bool) (*code, error) {
 	ops := ir.Operations
 	ret := &code{}
 	labelAddress := map[string]uint64{}
 	onLabelAddressResolved := map[string][]func(addr uint64){}
+
+	// Each if has an else label, which no other frame has. Its labels are paired with its continuation at runtime.
+	ifFrames := map[uint32]struct{}{}
+	if keepLabels {
+		for _, original := range ops {
+			if o, ok := original.(*wazeroir.OperationLabel); ok && o.Label.Kind == wazeroir.LabelKindElse {
+				ifFrames[o.Label.FrameID] = struct{}{}
+			}
+		}
+	}
+
 	for _, original := range ops {
 		op := &interpreterOp{kind: original.Kind()}
 		switch o := original.(type) {
@@ -279,7 +398,13 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 			delete(onLabelAddressResolved, labelKey)
 			// We just ignore the label operation
 			// as we translate branch operations to the direct address jmp.
-			continue
+			if !keepLabels {
+				continue
+			}
+			// Otherwise, the label is kept for experimental.LabelListener.
+			op.us = []uint64{uint64(o.Label.FrameID)}
+			op.b1 = o.Label.Kind
+			_, op.b3 = ifFrames[o.Label.FrameID]
 		case *wazeroir.OperationBr:
 			op.us = make([]uint64, 1)
 			if o.Target.IsReturnTarget() {
@@ -553,11 +678,22 @@ func (e *engine) lowerIR(ir *wazeroir.CompilationResult) (*code, error) {
 	return ret, nil
 }
 
+// WatchesGlobalSet implements wasm.GlobalWatcher, as the interpreter calls wasm.GlobalInstance Watch on "global.set".
+func (me *moduleEngine) WatchesGlobalSet() {}
+
 // Name implements the same method as documented on wasm.ModuleEngine.
 func (me *moduleEngine) Name() string {
 	return me.name
 }
 
+// ReferencedFunction implements the same method as documented on wasm.ModuleEngine.
+func (me *moduleEngine) ReferencedFunction(ref wasm.Reference) *wasm.FunctionInstance {
+	if f, ok := ref.(*function); ok && f != nil {
+		return f.source
+	}
+	return nil
+}
+
 // CreateFuncElementInstance implements the same method as documented on wasm.ModuleEngine.
 func (me *moduleEngine) CreateFuncElementInstance(indexes []*wasm.Index) *wasm.ElementInstance {
 	refs := make([]wasm.Reference, len(indexes))
@@ -574,6 +710,27 @@ func (me *moduleEngine) CreateFuncElementInstance(indexes []*wasm.Index) *wasm.E
 
 // Call implements the same method as documented on wasm.ModuleEngine.
 func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.FunctionInstance, params ...uint64) (results []uint64, err error) {
+	return me.call(ctx, m, f, me.newCallEngine(), params, nil)
+}
+
+// NewCallFunc implements the same method as documented on wasm.CallerEngine.
+func (me *moduleEngine) NewCallFunc(m *wasm.CallContext, f *wasm.FunctionInstance) func(ctx context.Context, params, results []uint64) error {
+	ce := me.newCallEngine()
+	return func(ctx context.Context, params, results []uint64) (err error) {
+		// Reuse the stacks of the last call, which may have been interrupted by an error.
+		ce.stack, ce.frames = ce.stack[:0], ce.frames[:0]
+		_, err = me.call(ctx, m, f, ce, params, results)
+		return
+	}
+}
+
+// call invokes the function on the call engine. When buf is non-nil, results are written to it instead of a new slice.
+func (me *moduleEngine) call(ctx context.Context, m *wasm.CallContext, f *wasm.FunctionInstance, ce *callEngine, params, buf []uint64) (results []uint64, err error) {
+	// Fail fast if the module already exited, as FailIfExited doesn't consider all exit codes an error.
+	if err = m.FailIfClosed(); err != nil {
+		return
+	}
+
 	// Note: The input parameters are pre-validated, so a compiled function is only absent on close. Updates to
 	// code on close aren't locked, neither is this read.
 	compiled := me.functions[f.Idx]
@@ -590,11 +747,10 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 		return nil, fmt.Errorf("expected %d params, but passed %d", len(paramSignature), paramCount)
 	}
 
-	ce := me.newCallEngine()
 	defer func() {
-		// If the module closed during the call, and the call didn't err for another reason, set an ExitError.
+		// If the module exited during the call, and the call didn't err for another reason, set an ExitError.
 		if err == nil {
-			err = m.FailIfClosed()
+			err = m.FailIfExited()
 		}
 		// TODO: ^^ Will not fail if the function was imported from a closed module.
 
@@ -606,10 +762,19 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 				fn := frame.f.source
 				builder.AddFrame(fn.DebugName, fn.ParamTypes(), fn.ResultTypes())
 			}
-			err = builder.FromRecovered(v)
+			if _, ok := v.(*sys.ExitError); ok {
+				err = m.FailIfExited() // unwound as the module exited, which isn't a trap.
+			} else {
+				err = builder.FromRecovered(v)
+			}
 		}
 	}()
 
+	ce.callIndirectInterceptor, _ = ctx.Value(experimental.CallIndirectInterceptorKey{}).(experimental.CallIndirectInterceptor)
+	ce.labelListener, _ = ctx.Value(experimental.LabelListenerKey{}).(experimental.LabelListener)
+	ce.stepListener, _ = ctx.Value(experimental.StepListenerKey{}).(experimental.StepListener)
+	ce.ctx, ce.done = ctx, ctx.Done()
+
 	if f.Kind == wasm.FunctionKindWasm {
 		if f.FunctionListener != nil {
 			ctx = f.FunctionListener.Before(ctx, params)
@@ -618,17 +783,97 @@ func (me *moduleEngine) Call(ctx context.Context, m *wasm.CallContext, f *wasm.F
 			ce.pushValue(param)
 		}
 		ce.callNativeFunc(ctx, m, compiled)
-		results = wasm.PopValues(len(f.Type.Results), ce.popValue)
+		if me.parentEngine.validateResults {
+			if err = validateResults(f, ce.stack); err != nil {
+				return
+			}
+		}
+		if buf != nil {
+			results = wasm.PopValuesInto(buf, ce.popValue)
+		} else {
+			results = wasm.PopValues(len(f.Type.Results), ce.popValue)
+		}
 		if f.FunctionListener != nil {
 			// TODO: This doesn't get the error due to use of panic to propagate them.
 			f.FunctionListener.After(ctx, nil, results)
 		}
 	} else {
 		results = ce.callGoFunc(ctx, m, compiled, params)
+		if me.parentEngine.validateResults {
+			err = validateResults(f, results)
+		}
+		if buf != nil {
+			results = buf[:copy(buf, results)]
+		}
 	}
 	return
 }
 
+// validateResults returns an error if the results of calling the function don't match its result signature. The count must match exactly, and each value must be valid for its result type.
+func validateResults(f *wasm.FunctionInstance, stack []uint64) error {
+	resultTypes := f.Type.Results
+	if len(stack) != len(resultTypes) {
+		return fmt.Errorf("BUG: %s returned %d results, but its signature has %d", f.DebugName, len(stack), len(resultTypes))
+	}
+	for i, t := range resultTypes {
+		if v := stack[i]; !isValidValue(t, v) {
+			return fmt.Errorf("BUG: %s result[%d] is not a valid %s: 0x%x", f.DebugName, i, wasm.ValueTypeName(t), v)
+		}
+	}
+	return nil
+}
+
+// isValidValue returns true if the value is a possible representation of the type. This cannot detect all invalid
+// values, as the stack is untyped. However, 32-bit types have a limited range: an i32 may be zero or sign-extended, but
+// an f32 is always zero-extended.
+func isValidValue(t wasm.ValueType, v uint64) bool {
+	hi := v >> 32
+	switch t {
+	case wasm.ValueTypeI32:
+		return hi == 0 || (hi == math.MaxUint32 && v&(1<<31) != 0)
+	case wasm.ValueTypeF32:
+		return hi == 0
+	}
+	return true
+}
+
+// interceptCallIndirect calls the experimental.CallIndirectInterceptor with the params of a call_indirect on the stack.
+// When it handles the call, this replaces the params with its results and returns true. Otherwise, the stack is
+// unchanged.
+func (ce *callEngine) interceptCallIndirect(ctx context.Context, m *wasm.ModuleInstance, tableIndex, typeIndex, offset uint64) bool {
+	ft := m.Types[typeIndex]
+	paramsStart := len(ce.stack) - len(ft.Params)
+	params := make([]uint64, len(ft.Params))
+	copy(params, ce.stack[paramsStart:])
+
+	results, handled := ce.callIndirectInterceptor.InterceptCallIndirect(ctx, m.CallCtx, uint32(tableIndex), uint32(typeIndex), uint32(offset), params)
+	if !handled {
+		return false
+	}
+	if len(results) != len(ft.Results) {
+		panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+	}
+	for i, t := range ft.Results {
+		if !isValidValue(t, results[i]) {
+			panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+		}
+	}
+	ce.stack = append(ce.stack[:paramsStart], results...)
+	return true
+}
+
+// callNullFuncrefHandler calls the handler for a call_indirect of a null table element. When it returns nil, this
+// replaces the params of the call on the stack with zero values of its results. Otherwise, this panics with the error.
+func (ce *callEngine) callNullFuncrefHandler(handler func(tableIndex, offset uint32) error, ft *wasm.FunctionType, tableIndex, offset uint64) {
+	if err := handler(uint32(tableIndex), uint32(offset)); err != nil {
+		panic(err)
+	}
+	ce.stack = ce.stack[:len(ce.stack)-len(ft.Params)]
+	for range ft.Results {
+		ce.pushValue(0)
+	}
+}
+
 func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext, f *function, params []uint64) (results []uint64) {
 	if len(ce.frames) > 0 {
 		// Use the caller's memory, which might be different from the defining module on an imported function.
@@ -648,34 +893,67 @@ func (ce *callEngine) callGoFunc(ctx context.Context, callCtx *wasm.CallContext,
 	return
 }
 
+// failIfDone panics with the error of the context.Context of the call if it is done, ex. its deadline passed. This is
+// checked on each function entry and backward branch (br, br_if and br_table), so the guest can't loop or recurse
+// indefinitely past it. However, a host function or a single long instruction, ex. memory.fill, isn't interrupted.
+func (ce *callEngine) failIfDone() {
+	select {
+	case <-ce.done:
+		panic(ce.ctx.Err())
+	default:
+	}
+}
+
 func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallContext, f *function) {
+	if ce.done != nil {
+		ce.failIfDone()
+	}
 	frame := &callFrame{f: f}
 	moduleInst := f.source.Module
 	memoryInst := moduleInst.Memory
 	globals := moduleInst.Globals
 	tables := moduleInst.Tables
 	typeIDs := f.source.Module.TypeIDs
-	functions := f.source.Module.Engine.(*moduleEngine).functions
+	me := f.source.Module.Engine.(*moduleEngine)
+	functions := me.functions
+	memoryWriteLog := me.memoryWriteLog
 	dataInstances := f.source.Module.DataInstances
 	elementInstances := f.source.Module.ElementInstances
 	listener := f.source.FunctionListener
+	var openIfs []uint32 // frame IDs of the ifs entered, but not yet continued. See notifyLabel
 	ce.pushFrame(frame)
 	bodyLen := uint64(len(frame.f.body))
 	for frame.pc < bodyLen {
 		op := frame.f.body[frame.pc]
+		if ce.stepListener != nil {
+			ce.stepListener.Step(ctx, f.source, len(ce.frames)-1, frame.pc, ce.stack)
+		}
 		// TODO: add description of each operation/case
 		// on, for example, how many args are used,
 		// how the stack is modified, etc.
 		switch op.kind {
 		case wazeroir.OperationKindUnreachable:
 			panic(wasmruntime.ErrRuntimeUnreachable)
+		case wazeroir.OperationKindLabel:
+			{
+				if ce.labelListener != nil {
+					openIfs = ce.notifyLabel(ctx, callCtx, f, openIfs, op)
+				}
+				frame.pc++
+			}
 		case wazeroir.OperationKindBr:
 			{
+				if ce.done != nil && op.us[0] <= frame.pc { // backward, so possibly an infinite loop.
+					ce.failIfDone()
+				}
 				frame.pc = op.us[0]
 			}
 		case wazeroir.OperationKindBrIf:
 			{
 				if ce.popValue() > 0 {
+					if ce.done != nil && op.us[0] <= frame.pc { // backward, so possibly an infinite loop.
+						ce.failIfDone()
+					}
 					ce.drop(op.rs[0])
 					frame.pc = op.us[0]
 				} else {
@@ -685,14 +963,15 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindBrTable:
 			{
+				target := uint64(0) // Default branch.
 				if v := uint64(ce.popValue()); v < uint64(len(op.us)-1) {
-					ce.drop(op.rs[v+1])
-					frame.pc = op.us[v+1]
-				} else {
-					// Default branch.
-					ce.drop(op.rs[0])
-					frame.pc = op.us[0]
+					target = v + 1
 				}
+				if ce.done != nil && op.us[target] <= frame.pc { // backward, so possibly an infinite loop.
+					ce.failIfDone()
+				}
+				ce.drop(op.rs[target])
+				frame.pc = op.us[target]
 			}
 		case wazeroir.OperationKindCall:
 			{
@@ -709,13 +988,27 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindCallIndirect:
 			{
 				offset := ce.popValue()
+				if ce.callIndirectInterceptor != nil && ce.interceptCallIndirect(ctx, moduleInst, op.us[1], op.us[0], offset) {
+					frame.pc++
+					continue
+				}
 				table := tables[op.us[1]]
 				if offset >= uint64(len(table.References)) {
 					panic(wasmruntime.ErrRuntimeInvalidTableAccess)
 				}
+				if table.References[offset] == nil && me.nullFuncrefHandler != nil {
+					ce.callNullFuncrefHandler(me.nullFuncrefHandler, moduleInst.Types[op.us[0]], op.us[1], offset)
+					frame.pc++
+					continue
+				}
 				tf, ok := table.References[offset].(*function)
 				if !ok {
 					panic(wasmruntime.ErrRuntimeInvalidTableAccess)
+				} else if me.perModuleTypeIDs && tf.source.Module != moduleInst {
+					// Type IDs of different modules aren't comparable, so compare the signatures instead.
+					if expected := moduleInst.Types[op.us[0]]; !tf.source.Type.EqualsSignature(expected.Params, expected.Results) {
+						panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
+					}
 				} else if tf.source.TypeID != typeIDs[op.us[0]] {
 					panic(wasmruntime.ErrRuntimeIndirectCallTypeMismatch)
 				}
@@ -765,7 +1058,11 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 		case wazeroir.OperationKindGlobalSet:
 			{
 				g := globals[op.us[0]] // TODO: Not yet traceable as it doesn't use the types in global.go
+				old := g.Val
 				g.Val = ce.popValue()
+				if g.Watch != nil && old != g.Val {
+					g.Watch(old, g.Val)
+				}
 				frame.pc++
 			}
 		case wazeroir.OperationKindLoad:
@@ -773,13 +1070,17 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				offset := ce.popMemoryOffset(op)
 				switch wazeroir.UnsignedType(op.b1) {
 				case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
-					if val, ok := memoryInst.ReadUint32Le(ctx, offset); !ok {
+					if val, ok := loadMMIO(ctx, memoryInst, offset, 4); ok {
+						ce.pushValue(val)
+					} else if val, ok := memoryInst.ReadUint32Le(ctx, offset); !ok {
 						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 					} else {
 						ce.pushValue(uint64(val))
 					}
 				case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
-					if val, ok := memoryInst.ReadUint64Le(ctx, offset); !ok {
+					if val, ok := loadMMIO(ctx, memoryInst, offset, 8); ok {
+						ce.pushValue(val)
+					} else if val, ok := memoryInst.ReadUint64Le(ctx, offset); !ok {
 						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 					} else {
 						ce.pushValue(val)
@@ -789,8 +1090,11 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindLoad8:
 			{
-				val, ok := memoryInst.ReadByte(ctx, ce.popMemoryOffset(op))
-				if !ok {
+				offset := ce.popMemoryOffset(op)
+				var val byte
+				if v, ok := loadMMIO(ctx, memoryInst, offset, 1); ok {
+					val = byte(v)
+				} else if val, ok = memoryInst.ReadByte(ctx, offset); !ok {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				}
 
@@ -804,8 +1108,11 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindLoad16:
 			{
-				val, ok := memoryInst.ReadUint16Le(ctx, ce.popMemoryOffset(op))
-				if !ok {
+				offset := ce.popMemoryOffset(op)
+				var val uint16
+				if v, ok := loadMMIO(ctx, memoryInst, offset, 2); ok {
+					val = uint16(v)
+				} else if val, ok = memoryInst.ReadUint16Le(ctx, offset); !ok {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				}
 
@@ -819,8 +1126,11 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			}
 		case wazeroir.OperationKindLoad32:
 			{
-				val, ok := memoryInst.ReadUint32Le(ctx, ce.popMemoryOffset(op))
-				if !ok {
+				offset := ce.popMemoryOffset(op)
+				var val uint32
+				if v, ok := loadMMIO(ctx, memoryInst, offset, 4); ok {
+					val = uint32(v)
+				} else if val, ok = memoryInst.ReadUint32Le(ctx, offset); !ok {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 				}
 
@@ -835,24 +1145,38 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			{
 				val := ce.popValue()
 				offset := ce.popMemoryOffset(op)
+				var size uint32
 				switch wazeroir.UnsignedType(op.b1) {
 				case wazeroir.UnsignedTypeI32, wazeroir.UnsignedTypeF32:
-					if !memoryInst.WriteUint32Le(ctx, offset, uint32(val)) {
+					size = 4
+					if storeMMIO(ctx, memoryInst, offset, size, uint64(uint32(val))) {
+						size = 0 // nothing was written to the memory.
+					} else if !memoryInst.WriteUint32Le(ctx, offset, uint32(val)) {
 						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 					}
 				case wazeroir.UnsignedTypeI64, wazeroir.UnsignedTypeF64:
-					if !memoryInst.WriteUint64Le(ctx, offset, val) {
+					size = 8
+					if storeMMIO(ctx, memoryInst, offset, size, val) {
+						size = 0 // nothing was written to the memory.
+					} else if !memoryInst.WriteUint64Le(ctx, offset, val) {
 						panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 					}
 				}
+				if memoryWriteLog != nil && size > 0 {
+					memoryWriteLog(offset, memoryInst.Buffer[offset:offset+size])
+				}
 				frame.pc++
 			}
 		case wazeroir.OperationKindStore8:
 			{
 				val := byte(ce.popValue())
 				offset := ce.popMemoryOffset(op)
-				if !memoryInst.WriteByte(ctx, offset, val) {
+				if storeMMIO(ctx, memoryInst, offset, 1, uint64(val)) {
+					// The region handled the store, so nothing was written to the memory.
+				} else if !memoryInst.WriteByte(ctx, offset, val) {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				} else if memoryWriteLog != nil {
+					memoryWriteLog(offset, memoryInst.Buffer[offset:offset+1])
 				}
 				frame.pc++
 			}
@@ -860,8 +1184,12 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			{
 				val := uint16(ce.popValue())
 				offset := ce.popMemoryOffset(op)
-				if !memoryInst.WriteUint16Le(ctx, offset, val) {
+				if storeMMIO(ctx, memoryInst, offset, 2, uint64(val)) {
+					// The region handled the store, so nothing was written to the memory.
+				} else if !memoryInst.WriteUint16Le(ctx, offset, val) {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				} else if memoryWriteLog != nil {
+					memoryWriteLog(offset, memoryInst.Buffer[offset:offset+2])
 				}
 				frame.pc++
 			}
@@ -869,8 +1197,12 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			{
 				val := uint32(ce.popValue())
 				offset := ce.popMemoryOffset(op)
-				if !memoryInst.WriteUint32Le(ctx, offset, val) {
+				if storeMMIO(ctx, memoryInst, offset, 4, uint64(val)) {
+					// The region handled the store, so nothing was written to the memory.
+				} else if !memoryInst.WriteUint32Le(ctx, offset, val) {
 					panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
+				} else if memoryWriteLog != nil {
+					memoryWriteLog(offset, memoryInst.Buffer[offset:offset+4])
 				}
 				frame.pc++
 			}
@@ -884,6 +1216,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				n := ce.popValue()
 				res := memoryInst.Grow(ctx, uint32(n))
 				ce.pushValue(uint64(res))
+				if me.memoryGrowthListener != nil {
+					wasm.NotifyMemoryGrowth(me.memoryGrowthListener, memoryInst, uint32(n), res)
+				}
 				frame.pc++
 			}
 		case wazeroir.OperationKindConstI32, wazeroir.OperationKindConstI64,
@@ -1754,6 +2089,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				panic(wasmruntime.ErrRuntimeOutOfBoundsMemoryAccess)
 			} else if copySize != 0 {
 				copy(memoryInst.Buffer[inMemoryOffset:inMemoryOffset+copySize], dataInstance[inDataOffset:])
+				if memoryWriteLog != nil {
+					memoryWriteLog(uint32(inMemoryOffset), memoryInst.Buffer[inMemoryOffset:inMemoryOffset+copySize])
+				}
 			}
 			frame.pc++
 		case wazeroir.OperationKindDataDrop:
@@ -1769,6 +2107,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			} else if copySize != 0 {
 				copy(memoryInst.Buffer[destinationOffset:],
 					memoryInst.Buffer[sourceOffset:sourceOffset+copySize])
+				if memoryWriteLog != nil {
+					memoryWriteLog(uint32(destinationOffset), memoryInst.Buffer[destinationOffset:destinationOffset+copySize])
+				}
 			}
 			frame.pc++
 		case wazeroir.OperationKindMemoryFill:
@@ -1785,6 +2126,9 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 				for i := 1; i < len(buf); i *= 2 {
 					copy(buf[i:], buf[:i])
 				}
+				if memoryWriteLog != nil {
+					memoryWriteLog(uint32(offset), buf)
+				}
 			}
 			frame.pc++
 		case wazeroir.OperationKindTableInit:
@@ -1816,9 +2160,38 @@ func (ce *callEngine) callNativeFunc(ctx context.Context, callCtx *wasm.CallCont
 			frame.pc++
 		}
 	}
+	// Returning skips the continuation of any if still entered.
+	for i := len(openIfs) - 1; i >= 0; i-- {
+		ce.labelListener.Label(ctx, callCtx, uint32(f.source.Idx), openIfs[i], experimental.LabelKindContinuation)
+	}
 	ce.popFrame()
 }
 
+// notifyLabel calls the experimental.LabelListener with the label of the operation, and returns openIfs updated.
+//
+// openIfs are the frame IDs of the ifs whose "then" or "else" was reached, but not yet their continuation. A branch
+// can only land on a label of a frame enclosing its own, and frame IDs increase with nesting. So, any if in openIfs
+// with a frame ID greater than that of the label was skipped, and its continuation is notified first.
+func (ce *callEngine) notifyLabel(ctx context.Context, callCtx *wasm.CallContext, f *function, openIfs []uint32, op *interpreterOp) []uint32 {
+	funcIndex, frameID, kind := uint32(f.source.Idx), uint32(op.us[0]), experimental.LabelKind(op.b1)
+	for len(openIfs) > 0 {
+		last := openIfs[len(openIfs)-1]
+		if last < frameID {
+			break
+		}
+		openIfs = openIfs[:len(openIfs)-1]
+		if last == frameID { // reached the continuation of the if.
+			break
+		}
+		ce.labelListener.Label(ctx, callCtx, funcIndex, last, experimental.LabelKindContinuation)
+	}
+	ce.labelListener.Label(ctx, callCtx, funcIndex, frameID, kind)
+	if op.b3 && kind != experimental.LabelKindContinuation {
+		openIfs = append(openIfs, frameID)
+	}
+	return openIfs
+}
+
 func (ce *callEngine) callNativeFuncWithListener(ctx context.Context, callCtx *wasm.CallContext, f *function, fnl experimental.FunctionListener) context.Context {
 	ctx = fnl.Before(ctx, ce.peekValues(len(f.source.Type.Params)))
 	ce.callNativeFunc(ctx, callCtx, f)
@@ -1838,6 +2211,35 @@ func (ce *callEngine) popMemoryOffset(op *interpreterOp) uint32 {
 	return uint32(offset)
 }
 
+// loadMMIO returns the value of the size bytes at offset, and true, if an experimental.MMIORegion of the memory handles
+// them. This panics if the access straddles the boundary of the region.
+func loadMMIO(ctx context.Context, memoryInst *wasm.MemoryInstance, offset, size uint32) (uint64, bool) {
+	region, regionOffset, straddles := memoryInst.MMIO(offset, size)
+	if straddles {
+		panic(wasmruntime.ErrRuntimeMMIOStraddle)
+	} else if region == nil {
+		return 0, false
+	}
+	val := region.Load(ctx, regionOffset, size)
+	if size < 8 {
+		val &= 1<<(size*8) - 1
+	}
+	return val, true
+}
+
+// storeMMIO returns true if an experimental.MMIORegion of the memory handled the store of the size bytes of val at
+// offset. This panics if the access straddles the boundary of the region.
+func storeMMIO(ctx context.Context, memoryInst *wasm.MemoryInstance, offset, size uint32, val uint64) bool {
+	region, regionOffset, straddles := memoryInst.MMIO(offset, size)
+	if straddles {
+		panic(wasmruntime.ErrRuntimeMMIOStraddle)
+	} else if region == nil {
+		return false
+	}
+	region.Store(ctx, regionOffset, size, val)
+	return true
+}
+
 func (ce *callEngine) callGoFuncWithStack(ctx context.Context, callCtx *wasm.CallContext, f *function) {
 	params := wasm.PopGoFuncParams(f.source, ce.popValue)
 	results := ce.callGoFunc(ctx, callCtx, f, params)
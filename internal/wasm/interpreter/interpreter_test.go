@@ -47,7 +47,7 @@ func TestInterpreter_CallEngine_PushFrame_StackOverflow(t *testing.T) {
 	vm.pushFrame(f3)
 
 	captured := require.CapturePanic(func() { vm.pushFrame(f4) })
-	require.EqualError(t, captured, "callstack overflow")
+	require.EqualError(t, captured, "call stack exhausted")
 }
 
 // et is used for tests defined in the enginetest package.
@@ -403,6 +403,115 @@ func TestInterpreter_CallEngine_callNativeFunc_signExtend(t *testing.T) {
 	})
 }
 
+func TestInterpreter_ModuleEngine_Call_ResultValidation(t *testing.T) {
+	// returnsTwo declares a single result, but leaves two values on the stack, as if the engine had a bug.
+	returnsTwo := func(validateResults bool) (*moduleEngine, *wasm.FunctionInstance, *wasm.CallContext) {
+		me := &moduleEngine{parentEngine: &engine{validateResults: validateResults}}
+		module := &wasm.ModuleInstance{Engine: me}
+		f := &wasm.FunctionInstance{
+			DebugName: "test.returnsTwo",
+			Kind:      wasm.FunctionKindWasm,
+			Type:      &wasm.FunctionType{Results: []wasm.ValueType{wasm.ValueTypeI32}},
+			Module:    module,
+		}
+		me.functions = []*function{{
+			source: f,
+			body: []*interpreterOp{
+				{kind: wazeroir.OperationKindConstI32, us: []uint64{1}},
+				{kind: wazeroir.OperationKindConstI32, us: []uint64{2}},
+				{kind: wazeroir.OperationKindBr, us: []uint64{math.MaxUint64}},
+			},
+		}}
+		return me, f, wasm.NewCallContext(nil, module, nil)
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		me, f, m := returnsTwo(false)
+		results, err := me.Call(testCtx, m, f)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{2}, results)
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		me, f, m := returnsTwo(true)
+		_, err := me.Call(testCtx, m, f)
+		require.EqualError(t, err, "BUG: test.returnsTwo returned 2 results, but its signature has 1")
+	})
+}
+
+func TestInterpreter_validateResults(t *testing.T) {
+	i32, i64, f32, f64 := wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32, wasm.ValueTypeF64
+	tests := []struct {
+		name        string
+		resultTypes []wasm.ValueType
+		stack       []uint64
+		expectedErr string
+	}{
+		{
+			name: "no results",
+		},
+		{
+			name:        "i32",
+			resultTypes: []wasm.ValueType{i32},
+			stack:       []uint64{math.MaxUint32},
+		},
+		{
+			name:        "i32 sign-extended",
+			resultTypes: []wasm.ValueType{i32},
+			stack:       []uint64{0xffffffff_80000000},
+		},
+		{
+			name:        "multi-value",
+			resultTypes: []wasm.ValueType{i32, i64, f32, f64},
+			stack:       []uint64{1, math.MaxUint64, uint64(math.Float32bits(1.5)), math.Float64bits(1.5)},
+		},
+		{
+			name:        "too few results",
+			resultTypes: []wasm.ValueType{i32, i64},
+			stack:       []uint64{1},
+			expectedErr: "BUG: test.fn returned 1 results, but its signature has 2",
+		},
+		{
+			name:        "too many results",
+			resultTypes: []wasm.ValueType{i32},
+			stack:       []uint64{1, 2},
+			expectedErr: "BUG: test.fn returned 2 results, but its signature has 1",
+		},
+		{
+			name:        "i32 upper bits",
+			resultTypes: []wasm.ValueType{i64, i32},
+			stack:       []uint64{1, 1 << 32},
+			expectedErr: "BUG: test.fn result[1] is not a valid i32: 0x100000000",
+		},
+		{
+			name:        "i32 upper bits without sign bit",
+			resultTypes: []wasm.ValueType{i32},
+			stack:       []uint64{0xffffffff_00000001},
+			expectedErr: "BUG: test.fn result[0] is not a valid i32: 0xffffffff00000001",
+		},
+		{
+			name:        "f32 upper bits",
+			resultTypes: []wasm.ValueType{f32, f64},
+			stack:       []uint64{math.Float64bits(1.5), math.Float64bits(1.5)},
+			expectedErr: "BUG: test.fn result[0] is not a valid f32: 0x3ff8000000000000",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			f := &wasm.FunctionInstance{DebugName: "test.fn", Type: &wasm.FunctionType{Results: tc.resultTypes}}
+			err := validateResults(f, tc.stack)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
 func TestInterpreter_Compile(t *testing.T) {
 	t.Run("uncompiled", func(t *testing.T) {
 		e := et.NewEngine(wasm.Features20191205).(*engine)
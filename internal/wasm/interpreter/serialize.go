@@ -0,0 +1,187 @@
+package interpreter
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wazeroir"
+)
+
+// codeFormat is the wasm.CodeCache CodeFormat of this engine.
+//
+// Note: The format only includes fixed-width bytes and varints, so is neutral to the CPU architecture of the host.
+const codeFormat = "interpreter"
+
+// CodeFormat implements wasm.CodeCache CodeFormat
+//
+// Settings which change the compiled code are suffixed to codeFormat, so code isn't restored into an engine which
+// would compile it differently. Ex. with EnableLenientFloatToInt, trapping conversions are compiled to saturate.
+func (e *engine) CodeFormat() string {
+	format := codeFormat
+	if e.labelTracing {
+		format += "+labels"
+	}
+	if e.lenientFloatToInt {
+		format += "+lenient-f2i"
+	}
+	return format
+}
+
+// SerializeCode implements wasm.CodeCache SerializeCode
+func (e *engine) SerializeCode(module *wasm.Module) ([]byte, error) {
+	if module.IsHostModule() {
+		return nil, errors.New("host functions are not serializable")
+	}
+	codes, ok := e.getCodes(module)
+	if !ok {
+		return nil, errors.New("module isn't compiled")
+	}
+
+	var ret []byte
+	ret = appendUvarint(ret, uint64(len(codes)))
+	for _, c := range codes {
+		ret = appendUvarint(ret, uint64(len(c.body)))
+		for _, op := range c.body {
+			ret = append(ret, byte(op.kind), op.b1, op.b2, boolToByte(op.b3))
+			ret = appendUvarint(ret, uint64(len(op.us)))
+			for _, u := range op.us {
+				ret = appendUvarint(ret, u)
+			}
+			ret = appendUvarint(ret, uint64(len(op.rs)))
+			for _, r := range op.rs {
+				if r == nil {
+					ret = append(ret, 0)
+					continue
+				}
+				ret = append(ret, 1)
+				ret = appendVarint(ret, int64(r.Start))
+				ret = appendVarint(ret, int64(r.End))
+			}
+		}
+	}
+	return ret, nil
+}
+
+// DeserializeCode implements wasm.CodeCache DeserializeCode
+func (e *engine) DeserializeCode(module *wasm.Module, data []byte) error {
+	if _, ok := e.getCodes(module); ok { // cache hit!
+		return nil
+	}
+
+	d := &codeDecoder{data: data}
+	count := d.uvarint()
+	if d.err == nil && count != uint64(len(module.FunctionSection)) {
+		return fmt.Errorf("code has %d functions, but the module has %d", count, len(module.FunctionSection))
+	}
+
+	codes := make([]*code, 0, count)
+	for i := uint64(0); i < count && d.err == nil; i++ {
+		c := &code{}
+		for n := d.uvarint(); n > 0 && d.err == nil; n-- {
+			op := &interpreterOp{}
+			op.kind = wazeroir.OperationKind(d.byte())
+			op.b1, op.b2, op.b3 = d.byte(), d.byte(), d.byte() != 0
+			if n := d.uvarint(); n > 0 && d.err == nil {
+				op.us = make([]uint64, 0, d.capacity(n))
+				for ; n > 0 && d.err == nil; n-- {
+					op.us = append(op.us, d.uvarint())
+				}
+			}
+			if n := d.uvarint(); n > 0 && d.err == nil {
+				op.rs = make([]*wazeroir.InclusiveRange, 0, d.capacity(n))
+				for ; n > 0 && d.err == nil; n-- {
+					var r *wazeroir.InclusiveRange
+					if d.byte() != 0 {
+						r = &wazeroir.InclusiveRange{Start: int(d.varint()), End: int(d.varint())}
+					}
+					op.rs = append(op.rs, r)
+				}
+			}
+			c.body = append(c.body, op)
+		}
+		codes = append(codes, c)
+	}
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("%d unexpected trailing bytes", len(d.data))
+	}
+	if d.err != nil {
+		return fmt.Errorf("invalid code: %w", d.err)
+	}
+	e.addCodes(module, codes)
+	return nil
+}
+
+// codeDecoder reads data written by SerializeCode, retaining the first error.
+type codeDecoder struct {
+	data []byte
+	err  error
+}
+
+var errUnexpectedEnd = errors.New("unexpected end of data")
+
+func (d *codeDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if len(d.data) == 0 {
+		d.err = errUnexpectedEnd
+		return 0
+	}
+	b := d.data[0]
+	d.data = d.data[1:]
+	return b
+}
+
+func (d *codeDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errUnexpectedEnd
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *codeDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = errUnexpectedEnd
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+// capacity returns n, limited to the remaining bytes, as each element is at least one byte. This prevents corrupt
+// lengths from allocating excessive memory.
+func (d *codeDecoder) capacity(n uint64) uint64 {
+	if remaining := uint64(len(d.data)); n > remaining {
+		return remaining
+	}
+	return n
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutUvarint(buf[:], v)]...)
+}
+
+func appendVarint(b []byte, v int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(b, buf[:binary.PutVarint(buf[:], v)]...)
+}
+
+func boolToByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
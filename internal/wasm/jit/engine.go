@@ -13,6 +13,7 @@ import (
 	"github.com/tetratelabs/wazero/internal/wasmdebug"
 	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/internal/wazeroir"
+	"github.com/tetratelabs/wazero/sys"
 )
 
 type (
@@ -23,6 +24,12 @@ type (
 		mux             sync.RWMutex
 		// setFinalizer defaults to runtime.SetFinalizer, but overridable for tests.
 		setFinalizer func(obj interface{}, finalizer interface{})
+		// lenientFloatToInt is set by EnableLenientFloatToInt.
+		lenientFloatToInt bool
+		// optimizeBoundsChecks is set by EnableBoundsCheckOptimization.
+		optimizeBoundsChecks bool
+		// memoryGrowthListener is set by SetMemoryGrowthListener.
+		memoryGrowthListener func(memoryIndex, before, delta uint32, ok bool)
 	}
 
 	// moduleEngine implements wasm.ModuleEngine
@@ -37,6 +44,9 @@ type (
 		functions []*function
 
 		importedFunctionCount uint32
+
+		// memoryGrowthListener is engine.memoryGrowthListener, copied as the builtin memory.grow can't reach the engine.
+		memoryGrowthListener func(memoryIndex, before, delta uint32, ok bool)
 	}
 
 	// callEngine holds context per moduleEngine.Call, and shared across all the
@@ -436,6 +446,17 @@ func (e *engine) CompileModule(ctx context.Context, module *wasm.Module) error {
 		if err != nil {
 			return err
 		}
+		if e.lenientFloatToInt {
+			wazeroir.SaturateFloatToInt(irs)
+		}
+		if e.optimizeBoundsChecks {
+			// An imported memory is at least as large as the minimum this module declares, or it fails to link.
+			if _, _, mem, _, err := module.AllDeclarations(); err != nil {
+				return err
+			} else if mem != nil {
+				wazeroir.EliminateBoundsChecks(irs, uint64(wasm.MemoryPagesToBytesNum(mem.Min)))
+			}
+		}
 
 		for funcIndex := range module.FunctionSection {
 			compiled, err := compileWasmFunction(e.enabledFeatures, irs[funcIndex])
@@ -463,6 +484,7 @@ func (e *engine) NewModuleEngine(name string, module *wasm.Module, importedFunct
 		name:                  name,
 		functions:             make([]*function, 0, imported+uint32(len(moduleFunctions))),
 		importedFunctionCount: imported,
+		memoryGrowthListener:  e.memoryGrowthListener,
 	}
 
 	for _, f := range importedFunctions {
@@ -513,6 +535,14 @@ func (me *moduleEngine) Name() string {
 	return me.name
 }
 
+// ReferencedFunction implements the same method as documented on wasm.ModuleEngine.
+func (me *moduleEngine) ReferencedFunction(ref wasm.Reference) *wasm.FunctionInstance {
+	if f, ok := ref.(*function); ok && f != nil {
+		return f.source
+	}
+	return nil
+}
+
 // CreateFuncElementInstance implements the same method as documented on wasm.ModuleEngine.
 func (me *moduleEngine) CreateFuncElementInstance(indexes []*wasm.Index) *wasm.ElementInstance {
 	refs := make([]wasm.Reference, len(indexes))
@@ -529,6 +559,29 @@ func (me *moduleEngine) CreateFuncElementInstance(indexes []*wasm.Index) *wasm.E
 
 // Call implements the same method as documented on wasm.ModuleEngine.
 func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance, params ...uint64) (results []uint64, err error) {
+	return me.call(ctx, callCtx, f, nil, params, nil)
+}
+
+// NewCallFunc implements the same method as documented on wasm.CallerEngine.
+func (me *moduleEngine) NewCallFunc(callCtx *wasm.CallContext, f *wasm.FunctionInstance) func(ctx context.Context, params, results []uint64) error {
+	ce := me.newCallEngine()
+	return func(ctx context.Context, params, results []uint64) (err error) {
+		// Reuse the stacks of the last call, which may have been interrupted by an error.
+		ce.valueStackContext = valueStackContext{}
+		ce.globalContext.callFrameStackPointer = 0
+		_, err = me.call(ctx, callCtx, f, ce, params, results)
+		return
+	}
+}
+
+// call invokes the function on the call engine, or a new one if nil. When buf is non-nil, results are written to it
+// instead of a new slice.
+func (me *moduleEngine) call(ctx context.Context, callCtx *wasm.CallContext, f *wasm.FunctionInstance, ce *callEngine, params, buf []uint64) (results []uint64, err error) {
+	// Fail fast if the module already exited, as FailIfExited doesn't consider all exit codes an error.
+	if err = callCtx.FailIfClosed(); err != nil {
+		return
+	}
+
 	// Note: The input parameters are pre-validated, so a compiled function is only absent on close. Updates to
 	// code on close aren't locked, neither is this read.
 	compiled := me.functions[f.Idx]
@@ -545,16 +598,18 @@ func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *
 		return nil, fmt.Errorf("expected %d params, but passed %d", len(paramSignature), paramCount)
 	}
 
-	ce := me.newCallEngine()
+	if ce == nil {
+		ce = me.newCallEngine()
+	}
 
 	// We ensure that this Call method never panics as
 	// this Call method is indirectly invoked by embedders via store.CallFunction,
 	// and we have to make sure that all the runtime errors, including the one happening inside
 	// host functions, will be captured as errors, not panics.
 	defer func() {
-		// If the module closed during the call, and the call didn't err for another reason, set an ExitError.
+		// If the module exited during the call, and the call didn't err for another reason, set an ExitError.
 		if err == nil {
-			err = callCtx.FailIfClosed()
+			err = callCtx.FailIfExited()
 		}
 		// TODO: ^^ Will not fail if the function was imported from a closed module.
 
@@ -569,7 +624,11 @@ func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *
 				fn := ce.callFrameStack[ce.globalContext.callFrameStackPointer-1-i].function.source
 				builder.AddFrame(fn.DebugName, fn.ParamTypes(), fn.ResultTypes())
 			}
-			err = builder.FromRecovered(v)
+			if _, ok := v.(*sys.ExitError); ok {
+				err = callCtx.FailIfExited() // unwound as the module exited, which isn't a trap.
+			} else {
+				err = builder.FromRecovered(v)
+			}
 		}
 	}()
 
@@ -578,9 +637,16 @@ func (me *moduleEngine) Call(ctx context.Context, callCtx *wasm.CallContext, f *
 			ce.pushValue(v)
 		}
 		ce.execWasmFunction(ctx, callCtx, compiled)
-		results = wasm.PopValues(len(f.Type.Results), ce.popValue)
+		if buf != nil {
+			results = wasm.PopValuesInto(buf, ce.popValue)
+		} else {
+			results = wasm.PopValues(len(f.Type.Results), ce.popValue)
+		}
 	} else {
 		results = wasm.CallGoFunc(ctx, callCtx, compiled.source, params)
+		if buf != nil {
+			results = buf[:copy(buf, results)]
+		}
 	}
 	return
 }
@@ -597,6 +663,28 @@ func newEngine(enabledFeatures wasm.Features) *engine {
 	}
 }
 
+// EnableLenientFloatToInt makes trapping float-to-int conversions saturate instead, as if the non-trapping variant.
+// This is not conformant to the WebAssembly specification.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) EnableLenientFloatToInt() {
+	e.lenientFloatToInt = true
+}
+
+// EnableBoundsCheckOptimization omits the bounds checks of memory accesses proven within the minimum memory size.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) EnableBoundsCheckOptimization() {
+	e.optimizeBoundsChecks = true
+}
+
+// SetMemoryGrowthListener sets a function called after each memory.grow by a guest instruction, or nil to disable.
+//
+// Note: This must be called before the engine is used.
+func (e *engine) SetMemoryGrowthListener(memoryGrowthListener func(memoryIndex, before, delta uint32, ok bool)) {
+	e.memoryGrowthListener = memoryGrowthListener
+}
+
 // Do not make these variables as constants, otherwise there would be
 // dangerous memory access from native code.
 //
@@ -721,7 +809,7 @@ jitentry:
 			switch ce.exitContext.builtinFunctionCallIndex {
 			case builtinFunctionIndexMemoryGrow:
 				callercode := ce.callFrameTop().function
-				ce.builtinFunctionMemoryGrow(ctx, callercode.source.Module.Memory)
+				ce.builtinFunctionMemoryGrow(ctx, callercode.source.Module)
 			case builtinFunctionIndexGrowValueStack:
 				callercode := ce.callFrameTop().function
 				ce.builtinFunctionGrowValueStack(callercode.stackPointerCeil)
@@ -771,11 +859,15 @@ func (ce *callEngine) builtinFunctionGrowCallFrameStack() {
 	ce.globalContext.callFrameStackElementZeroAddress = stackSliceHeader.Data
 }
 
-func (ce *callEngine) builtinFunctionMemoryGrow(ctx context.Context, mem *wasm.MemoryInstance) {
+func (ce *callEngine) builtinFunctionMemoryGrow(ctx context.Context, m *wasm.ModuleInstance) {
+	mem := m.Memory
 	newPages := ce.popValue()
 
 	res := mem.Grow(ctx, uint32(newPages))
 	ce.pushValue(uint64(res))
+	if memoryGrowthListener := m.Engine.(*moduleEngine).memoryGrowthListener; memoryGrowthListener != nil {
+		wasm.NotifyMemoryGrowth(memoryGrowthListener, mem, uint32(newPages), res)
+	}
 
 	// Update the moduleContext fields as they become stale after the update ^^.
 	bufSliceHeader := (*reflect.SliceHeader)(unsafe.Pointer(&mem.Buffer))
@@ -238,6 +238,31 @@ func TestJIT_CompileModule(t *testing.T) {
 	})
 }
 
+// TestJIT_NewModuleEngine_SharesCode ensures instances of the same module share its compiled code.
+func TestJIT_NewModuleEngine_SharesCode(t *testing.T) {
+	e := et.NewEngine(wasm.Features20191205).(*engine)
+	m := &wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeEnd}}},
+		ID:              wasm.ModuleID{1},
+	}
+	require.NoError(t, e.CompileModule(testCtx, m))
+
+	var mes []*moduleEngine
+	for _, name := range []string{"m1", "m2"} {
+		f := &wasm.FunctionInstance{Module: &wasm.ModuleInstance{Name: name}}
+		me, err := e.NewModuleEngine(name, m, nil, []*wasm.FunctionInstance{f}, nil, nil)
+		require.NoError(t, err)
+		mes = append(mes, me.(*moduleEngine))
+	}
+
+	f1, f2 := mes[0].functions[0], mes[1].functions[0]
+	require.NotEqual(t, f1.moduleInstanceAddress, f2.moduleInstanceAddress)
+	require.True(t, f1.parent == f2.parent)
+	require.Equal(t, f1.codeInitialAddress, f2.codeInitialAddress)
+}
+
 // TestJIT_Releasecode_Panic tests that an unexpected panic has some identifying information in it.
 func TestJIT_Releasecode_Panic(t *testing.T) {
 	captured := require.CapturePanic(func() {
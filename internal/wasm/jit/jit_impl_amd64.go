@@ -3116,7 +3116,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 		targetSizeInBytes = 64 / 8
 	}
 
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3151,7 +3151,7 @@ func (c *amd64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 // compileLoad8 implements compiler.compileLoad8 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 	const targetSizeInBytes = 1
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3185,7 +3185,7 @@ func (c *amd64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 // compileLoad16 implements compiler.compileLoad16 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 	const targetSizeInBytes = 16 / 8
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3217,7 +3217,7 @@ func (c *amd64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 // compileLoad32 implements compiler.compileLoad32 for the amd64 architecture.
 func (c *amd64Compiler) compileLoad32(o *wazeroir.OperationLoad32) error {
 	const targetSizeInBytes = 32 / 8
-	reg, err := c.compileMemoryAccessCeilSetup(o.Arg.Offset, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(o.Arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -3246,14 +3246,14 @@ func (c *amd64Compiler) compileLoad32(o *wazeroir.OperationLoad32) error {
 //
 // Note: this also emits the instructions to check the out of bounds memory access.
 // In other words, if the ceil exceeds the memory size, the code exits with jitCallStatusCodeMemoryOutOfBounds status.
-func (c *amd64Compiler) compileMemoryAccessCeilSetup(offsetArg uint32, targetSizeInBytes int64) (asm.Register, error) {
+func (c *amd64Compiler) compileMemoryAccessCeilSetup(arg *wazeroir.MemoryImmediate, targetSizeInBytes int64) (asm.Register, error) {
 	base := c.locationStack.pop()
 	if err := c.compileEnsureOnGeneralPurposeRegister(base); err != nil {
 		return 0, err
 	}
 
 	result := base.register
-	if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
+	if offsetConst := int64(arg.Offset) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
 		c.assembler.CompileConstToRegister(amd64.ADDQ, offsetConst, result)
 	} else {
 		// If the offset const is too large, we exit with jitCallStatusCodeMemoryOutOfBounds.
@@ -3261,6 +3261,11 @@ func (c *amd64Compiler) compileMemoryAccessCeilSetup(offsetArg uint32, targetSiz
 		return result, nil
 	}
 
+	if arg.InBounds { // proven within the minimum memory size, so the check would always pass.
+		c.locationStack.markRegisterUnused(result)
+		return result, nil
+	}
+
 	// Now we compare the value with the memory length which is held by callEngine.
 	c.assembler.CompileMemoryToRegister(amd64.CMPQ,
 		amd64ReservedRegisterForCallEngine, callEngineModuleContextMemorySliceLenOffset, result)
@@ -3289,31 +3294,31 @@ func (c *amd64Compiler) compileStore(o *wazeroir.OperationStore) error {
 		movInst = amd64.MOVQ
 		targetSizeInByte = 64 / 8
 	}
-	return c.compileStoreImpl(o.Arg.Offset, movInst, targetSizeInByte)
+	return c.compileStoreImpl(o.Arg, movInst, targetSizeInByte)
 }
 
 // compileStore8 implements compiler.compileStore8 for the amd64 architecture.
 func (c *amd64Compiler) compileStore8(o *wazeroir.OperationStore8) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVB, 1)
+	return c.compileStoreImpl(o.Arg, amd64.MOVB, 1)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore16(o *wazeroir.OperationStore16) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVW, 16/8)
+	return c.compileStoreImpl(o.Arg, amd64.MOVW, 16/8)
 }
 
 // compileStore32 implements compiler.compileStore32 for the amd64 architecture.
 func (c *amd64Compiler) compileStore32(o *wazeroir.OperationStore32) error {
-	return c.compileStoreImpl(o.Arg.Offset, amd64.MOVL, 32/8)
+	return c.compileStoreImpl(o.Arg, amd64.MOVL, 32/8)
 }
 
-func (c *amd64Compiler) compileStoreImpl(offsetConst uint32, inst asm.Instruction, targetSizeInBytes int64) error {
+func (c *amd64Compiler) compileStoreImpl(arg *wazeroir.MemoryImmediate, inst asm.Instruction, targetSizeInBytes int64) error {
 	val := c.locationStack.pop()
 	if err := c.compileEnsureOnGeneralPurposeRegister(val); err != nil {
 		return err
 	}
 
-	reg, err := c.compileMemoryAccessCeilSetup(offsetConst, targetSizeInBytes)
+	reg, err := c.compileMemoryAccessCeilSetup(arg, targetSizeInBytes)
 	if err != nil {
 		return nil
 	}
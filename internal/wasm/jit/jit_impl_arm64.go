@@ -2501,7 +2501,7 @@ func (c *arm64Compiler) compileLoad(o *wazeroir.OperationLoad) error {
 		isFloat = true
 		targetSizeInBytes = 64 / 8
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, targetSizeInBytes, isFloat)
+	return c.compileLoadImpl(o.Arg, loadInst, targetSizeInBytes, isFloat)
 }
 
 // compileLoad8 implements compiler.compileLoad8 for the arm64 architecture.
@@ -2515,7 +2515,7 @@ func (c *arm64Compiler) compileLoad8(o *wazeroir.OperationLoad8) error {
 	case wazeroir.SignedUint32, wazeroir.SignedUint64:
 		loadInst = arm64.MOVBU
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, 1, false)
+	return c.compileLoadImpl(o.Arg, loadInst, 1, false)
 }
 
 // compileLoad16 implements compiler.compileLoad16 for the arm64 architecture.
@@ -2529,7 +2529,7 @@ func (c *arm64Compiler) compileLoad16(o *wazeroir.OperationLoad16) error {
 	case wazeroir.SignedUint32, wazeroir.SignedUint64:
 		loadInst = arm64.MOVHU
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, 16/8, false)
+	return c.compileLoadImpl(o.Arg, loadInst, 16/8, false)
 }
 
 // compileLoad32 implements compiler.compileLoad32 for the arm64 architecture.
@@ -2540,12 +2540,12 @@ func (c *arm64Compiler) compileLoad32(o *wazeroir.OperationLoad32) error {
 	} else {
 		loadInst = arm64.MOVWU
 	}
-	return c.compileLoadImpl(o.Arg.Offset, loadInst, 32/8, false)
+	return c.compileLoadImpl(o.Arg, loadInst, 32/8, false)
 }
 
 // compileLoadImpl implements compileLoadImpl* variants for arm64 architecture.
-func (c *arm64Compiler) compileLoadImpl(offsetArg uint32, loadInst asm.Instruction, targetSizeInBytes int64, isFloat bool) error {
-	offsetReg, err := c.compileMemoryAccessOffsetSetup(offsetArg, targetSizeInBytes)
+func (c *arm64Compiler) compileLoadImpl(arg *wazeroir.MemoryImmediate, loadInst asm.Instruction, targetSizeInBytes int64, isFloat bool) error {
+	offsetReg, err := c.compileMemoryAccessOffsetSetup(arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -2588,26 +2588,26 @@ func (c *arm64Compiler) compileStore(o *wazeroir.OperationStore) error {
 		movInst = arm64.FMOVD
 		targetSizeInBytes = 64 / 8
 	}
-	return c.compileStoreImpl(o.Arg.Offset, movInst, targetSizeInBytes)
+	return c.compileStoreImpl(o.Arg, movInst, targetSizeInBytes)
 }
 
 // compileStore8 implements compiler.compileStore8 for the arm64 architecture.
 func (c *arm64Compiler) compileStore8(o *wazeroir.OperationStore8) error {
-	return c.compileStoreImpl(o.Arg.Offset, arm64.MOVB, 1)
+	return c.compileStoreImpl(o.Arg, arm64.MOVB, 1)
 }
 
 // compileStore16 implements compiler.compileStore16 for the arm64 architecture.
 func (c *arm64Compiler) compileStore16(o *wazeroir.OperationStore16) error {
-	return c.compileStoreImpl(o.Arg.Offset, arm64.MOVH, 16/8)
+	return c.compileStoreImpl(o.Arg, arm64.MOVH, 16/8)
 }
 
 // compileStore32 implements compiler.compileStore32 for the arm64 architecture.
 func (c *arm64Compiler) compileStore32(o *wazeroir.OperationStore32) error {
-	return c.compileStoreImpl(o.Arg.Offset, arm64.MOVW, 32/8)
+	return c.compileStoreImpl(o.Arg, arm64.MOVW, 32/8)
 }
 
 // compileStoreImpl implements compleStore* variants for arm64 architecture.
-func (c *arm64Compiler) compileStoreImpl(offsetArg uint32, storeInst asm.Instruction, targetSizeInBytes int64) error {
+func (c *arm64Compiler) compileStoreImpl(arg *wazeroir.MemoryImmediate, storeInst asm.Instruction, targetSizeInBytes int64) error {
 	val, err := c.popValueOnRegister()
 	if err != nil {
 		return err
@@ -2615,7 +2615,7 @@ func (c *arm64Compiler) compileStoreImpl(offsetArg uint32, storeInst asm.Instruc
 	// Mark temporarily used as compileMemoryAccessOffsetSetup might try allocating register.
 	c.markRegisterUsed(val.register)
 
-	offsetReg, err := c.compileMemoryAccessOffsetSetup(offsetArg, targetSizeInBytes)
+	offsetReg, err := c.compileMemoryAccessOffsetSetup(arg, targetSizeInBytes)
 	if err != nil {
 		return err
 	}
@@ -2637,7 +2637,7 @@ func (c *arm64Compiler) compileStoreImpl(offsetArg uint32, storeInst asm.Instruc
 //
 // Note: this also emits the instructions to check the out of bounds memory access.
 // In other words, if the offset+targetSizeInBytes exceeds the memory size, the code exits with jitCallStatusCodeMemoryOutOfBounds status.
-func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint32, targetSizeInBytes int64) (offsetRegister asm.Register, err error) {
+func (c *arm64Compiler) compileMemoryAccessOffsetSetup(arg *wazeroir.MemoryImmediate, targetSizeInBytes int64) (offsetRegister asm.Register, err error) {
 	base, err := c.popValueOnRegister()
 	if err != nil {
 		return 0, err
@@ -2652,7 +2652,13 @@ func (c *arm64Compiler) compileMemoryAccessOffsetSetup(offsetArg uint32, targetS
 		c.assembler.CompileRegisterToRegister(arm64.MOVD, arm64.REGZERO, offsetRegister)
 	}
 
-	if offsetConst := int64(offsetArg) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
+	if arg.InBounds { // proven within the minimum memory size, so skip the check.
+		// "offsetRegister = base + offsetArg"
+		c.assembler.CompileConstToRegister(arm64.ADD, int64(arg.Offset), offsetRegister)
+		return offsetRegister, nil
+	}
+
+	if offsetConst := int64(arg.Offset) + targetSizeInBytes; offsetConst <= math.MaxUint32 {
 		// "offsetRegister = base + offsetArg + targetSizeInBytes"
 		c.assembler.CompileConstToRegister(arm64.ADD, offsetConst, offsetRegister)
 	} else {
@@ -0,0 +1,204 @@
+package wasm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
+)
+
+// LazyImports are placeholders for function imports which are bound after instantiation. See CallContext.BindImport
+type LazyImports struct {
+	// imports are keyed by the import module and name, joined by a NUL character.
+	imports map[string]*lazyImport
+
+	// hostModule implements the placeholders. This is closed with the module that imports them.
+	hostModule *CallContext
+}
+
+// lazyImport is a placeholder which delegates to fn, once bound.
+type lazyImport struct {
+	moduleName, name string
+	funcType         *FunctionType
+	// exportName is the name of the placeholder in LazyImports.hostModule, which is readable in stack traces.
+	exportName string
+
+	mux sync.RWMutex
+	fn  api.Function // guarded by mux
+}
+
+// lazyImportKey returns the key of an import in LazyImports.
+func lazyImportKey(moduleName, name string) string {
+	return moduleName + "\x00" + name
+}
+
+// NewLazyImports returns a host module which exports a placeholder for each function import in the module matching
+// one of the keys, which are the import module and name joined by a NUL character.
+//
+// The result must be instantiated in the Store under hostModuleName, then passed to SetLazyImports. The module must
+// then import the placeholders from it, as done by LazyImports.ReplaceImports. When there are no matching imports, this
+// returns nil.
+func NewLazyImports(hostModuleName string, module *Module, keys map[string]struct{}, enabledFeatures Features) (*Module, *LazyImports, error) {
+	l := &LazyImports{imports: map[string]*lazyImport{}}
+	nameToGoFunc := map[string]interface{}{}
+	for _, i := range module.ImportSection {
+		key := lazyImportKey(i.Module, i.Name)
+		if _, ok := keys[key]; !ok || i.Type != ExternTypeFunc {
+			continue
+		}
+		if int(i.DescFunc) >= len(module.TypeSection) {
+			return nil, nil, fmt.Errorf("lazy import %s.%s: function type out of range", i.Module, i.Name)
+		}
+		li := &lazyImport{moduleName: i.Module, name: i.Name, funcType: module.TypeSection[i.DescFunc]}
+		li.exportName = i.Module + "." + i.Name
+		if _, ok := nameToGoFunc[li.exportName]; ok { // Ex. "a.b" "c" and "a" "b.c"
+			li.exportName = fmt.Sprintf("%s#%d", li.exportName, len(nameToGoFunc))
+		}
+		goFunc, err := li.goFunc()
+		if err != nil {
+			return nil, nil, fmt.Errorf("lazy import %s.%s: %w", i.Module, i.Name, err)
+		}
+		l.imports[key] = li
+		nameToGoFunc[li.exportName] = goFunc
+	}
+	if len(l.imports) == 0 {
+		return nil, nil, nil
+	}
+
+	hostModule, err := NewHostModule(hostModuleName, nameToGoFunc, nil, nil, enabledFeatures)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The placeholders are created by reflection, so they are indistinguishable from others with the same signature.
+	// Re-assign the ID, so that the engine doesn't re-use placeholders cached for another module.
+	hostModule.AssignModuleID([]byte(fmt.Sprintf("%s:%p", hostModuleName, l)))
+	return hostModule, l, nil
+}
+
+// ReplaceImports returns a copy of the module which imports the placeholders from hostModuleName.
+func (l *LazyImports) ReplaceImports(hostModuleName string, module *Module) *Module {
+	ret := *module // shallow copy
+	ret.ImportSection = make([]*Import, len(module.ImportSection))
+	for idx, i := range module.ImportSection {
+		if li, ok := l.imports[lazyImportKey(i.Module, i.Name)]; ok && i.Type == ExternTypeFunc {
+			cp := *i // shallow copy
+			cp.Module = hostModuleName
+			cp.Name = li.exportName
+			i = &cp
+		}
+		ret.ImportSection[idx] = i
+	}
+	return &ret
+}
+
+// SetLazyImports sets the placeholders bound by BindImport and closes hostModule when this module closes.
+func (m *CallContext) SetLazyImports(l *LazyImports, hostModule *CallContext) {
+	l.hostModule = hostModule
+	m.lazyImports = l
+}
+
+// BindImport binds the function to an import declared lazy, replacing any function previously bound. The function
+// must have the same signature as the import.
+func (m *CallContext) BindImport(moduleName, name string, fn api.Function) error {
+	var li *lazyImport
+	if m.lazyImports != nil {
+		li = m.lazyImports.imports[lazyImportKey(moduleName, name)]
+	}
+	if li == nil {
+		return fmt.Errorf("%s.%s is not a lazy import of module[%s]", moduleName, name, m.Name())
+	}
+	if fn == nil { // ex. api.Module ExportedFunction of a missing name.
+		return fmt.Errorf("function is nil binding %s.%s", moduleName, name)
+	}
+	if !li.funcType.EqualsSignature(fn.ParamTypes(), fn.ResultTypes()) {
+		return fmt.Errorf("signature mismatch binding %s.%s: %s != %s", moduleName, name, li.funcType,
+			&FunctionType{Params: fn.ParamTypes(), Results: fn.ResultTypes()})
+	}
+	li.mux.Lock()
+	li.fn = fn
+	li.mux.Unlock()
+	return nil
+}
+
+// goFunc returns a Go function of the same signature as the import, which delegates to the bound function. Calling it
+// before the import is bound panics with a wasmruntime.Error.
+func (li *lazyImport) goFunc() (interface{}, error) {
+	in := []reflect.Type{goContextType}
+	for _, t := range li.funcType.Params {
+		goType, err := goTypeOf(t)
+		if err != nil {
+			return nil, err
+		}
+		in = append(in, goType)
+	}
+	out := make([]reflect.Type, 0, len(li.funcType.Results))
+	for _, t := range li.funcType.Results {
+		goType, err := goTypeOf(t)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, goType)
+	}
+
+	fnType := reflect.FuncOf(in, out, false)
+	return reflect.MakeFunc(fnType, func(args []reflect.Value) []reflect.Value {
+		li.mux.RLock()
+		fn := li.fn
+		li.mux.RUnlock()
+		if fn == nil {
+			panic(wasmruntime.New(fmt.Sprintf("import not yet bound: %s.%s", li.moduleName, li.name)))
+		}
+
+		ctx := args[0].Interface().(context.Context)
+		params := make([]uint64, 0, len(args)-1)
+		for _, arg := range args[1:] {
+			switch arg.Kind() {
+			case reflect.Float32:
+				params = append(params, uint64(math.Float32bits(float32(arg.Float()))))
+			case reflect.Float64:
+				params = append(params, math.Float64bits(arg.Float()))
+			default:
+				params = append(params, arg.Uint())
+			}
+		}
+
+		results, err := fn.Call(ctx, params...)
+		if err != nil {
+			panic(err)
+		}
+
+		ret := make([]reflect.Value, len(results))
+		for i, raw := range results {
+			val := reflect.New(fnType.Out(i)).Elem()
+			switch val.Kind() {
+			case reflect.Float32:
+				val.SetFloat(float64(math.Float32frombits(uint32(raw))))
+			case reflect.Float64:
+				val.SetFloat(math.Float64frombits(raw))
+			default:
+				val.SetUint(raw)
+			}
+			ret[i] = val
+		}
+		return ret
+	}).Interface(), nil
+}
+
+// goTypeOf returns the Go type used for the ValueType in a host function.
+func goTypeOf(t ValueType) (reflect.Type, error) {
+	switch t {
+	case ValueTypeI32:
+		return reflect.TypeOf(uint32(0)), nil
+	case ValueTypeI64:
+		return reflect.TypeOf(uint64(0)), nil
+	case ValueTypeF32:
+		return reflect.TypeOf(float32(0)), nil
+	case ValueTypeF64:
+		return reflect.TypeOf(float64(0)), nil
+	}
+	return nil, fmt.Errorf("unsupported value type: %s", ValueTypeName(t))
+}
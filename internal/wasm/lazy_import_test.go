@@ -0,0 +1,51 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestNewLazyImports(t *testing.T) {
+	i32 := ValueTypeI32
+	module := &Module{
+		TypeSection: []*FunctionType{{Params: []ValueType{i32}, Results: []ValueType{i32}}},
+		ImportSection: []*Import{
+			{Module: "plugin", Name: "run", Type: ExternTypeFunc, DescFunc: 0},
+			{Module: "plugin", Name: "memory", Type: ExternTypeMemory, DescMem: &Memory{Min: 1}},
+			{Module: "env", Name: "abort", Type: ExternTypeFunc, DescFunc: 0},
+		},
+	}
+
+	t.Run("no match", func(t *testing.T) {
+		hostModule, l, err := NewLazyImports("lazy", module, map[string]struct{}{"plugin\x00stop": {}}, Features20191205)
+		require.NoError(t, err)
+		require.Nil(t, hostModule)
+		require.Nil(t, l)
+	})
+
+	t.Run("only functions", func(t *testing.T) {
+		keys := map[string]struct{}{"plugin\x00run": {}, "plugin\x00memory": {}}
+		hostModule, l, err := NewLazyImports("lazy", module, keys, Features20191205)
+		require.NoError(t, err)
+		require.Equal(t, []*Export{{Type: ExternTypeFunc, Name: "plugin.run", Index: 0}}, hostModule.ExportSection)
+		require.Equal(t, []*FunctionType{module.TypeSection[0]}, hostModule.TypeSection)
+
+		replaced := l.ReplaceImports("lazy", module)
+		require.Equal(t, []*Import{
+			{Module: "lazy", Name: "plugin.run", Type: ExternTypeFunc, DescFunc: 0},
+			module.ImportSection[1],
+			module.ImportSection[2],
+		}, replaced.ImportSection)
+		require.Equal(t, "plugin", module.ImportSection[0].Module) // original not mutated
+	})
+
+	t.Run("unique module ID", func(t *testing.T) {
+		keys := map[string]struct{}{"plugin\x00run": {}}
+		m1, _, err := NewLazyImports("lazy", module, keys, Features20191205)
+		require.NoError(t, err)
+		m2, _, err := NewLazyImports("lazy", module, keys, Features20191205)
+		require.NoError(t, err)
+		require.NotEqual(t, m1.ID, m2.ID)
+	})
+}
@@ -4,12 +4,16 @@ import (
 	"bytes"
 	"context"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
 	"reflect"
+	"sort"
+	"sync"
 	"unsafe"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/experimental"
 )
 
 const (
@@ -35,12 +39,36 @@ var _ api.Memory = &MemoryInstance{}
 type MemoryInstance struct {
 	Buffer        []byte
 	Min, Cap, Max uint32
+
+	// Shared is true when the memory was declared shared. Its Buffer is allocated at Max pages, so its backing array
+	// never moves, and Grow only reslices it. The api.Memory functions read-lock mux, and Grow write-locks it.
+	//
+	// Note: This doesn't make the memory goroutine-safe. Guest code accesses the Buffer without locking, and atomic
+	// instructions are not yet supported, so functions using the memory must not be called concurrently.
+	Shared bool
+	mux    sync.RWMutex
+
+	// capacityPages returns the Cap to use when Grow exceeds it, given the new size in pages. When nil, or the result
+	// is less than the new size, Cap becomes the new size. See RuntimeConfig.WithMemoryCapacityPages
+	capacityPages func(minPages uint32) uint32
+
+	// mmio are the ranges registered by MapMMIO, ordered by offset.
+	//
+	// Note: This isn't locked, as guest loads and stores read it without synchronization. See experimental.MapMMIO
+	mmio []mmioRange
+}
+
+// mmioRange is a range of memory whose guest loads and stores are handled by region. See experimental.MapMMIO
+type mmioRange struct {
+	offset, size uint32
+	region       experimental.MMIORegion
 }
 
 // Size implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Size(_ context.Context) uint32 {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return m.size()
 }
 
@@ -48,6 +76,7 @@ func (m *MemoryInstance) Size(_ context.Context) uint32 {
 func (m *MemoryInstance) IndexByte(_ context.Context, offset uint32, c byte) (uint32, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	if offset >= uint32(len(m.Buffer)) {
 		return 0, false
 	}
@@ -63,6 +92,7 @@ func (m *MemoryInstance) IndexByte(_ context.Context, offset uint32, c byte) (ui
 func (m *MemoryInstance) ReadByte(_ context.Context, offset uint32) (byte, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	if offset >= m.size() {
 		return 0, false
 	}
@@ -73,6 +103,7 @@ func (m *MemoryInstance) ReadByte(_ context.Context, offset uint32) (byte, bool)
 func (m *MemoryInstance) ReadUint16Le(_ context.Context, offset uint32) (uint16, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	if !m.hasSize(offset, 2) {
 		return 0, false
 	}
@@ -83,6 +114,7 @@ func (m *MemoryInstance) ReadUint16Le(_ context.Context, offset uint32) (uint16,
 func (m *MemoryInstance) ReadUint32Le(_ context.Context, offset uint32) (uint32, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return m.readUint32Le(offset)
 }
 
@@ -90,6 +122,7 @@ func (m *MemoryInstance) ReadUint32Le(_ context.Context, offset uint32) (uint32,
 func (m *MemoryInstance) ReadFloat32Le(_ context.Context, offset uint32) (float32, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	v, ok := m.readUint32Le(offset)
 	if !ok {
 		return 0, false
@@ -101,6 +134,7 @@ func (m *MemoryInstance) ReadFloat32Le(_ context.Context, offset uint32) (float3
 func (m *MemoryInstance) ReadUint64Le(_ context.Context, offset uint32) (uint64, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return m.readUint64Le(offset)
 }
 
@@ -108,6 +142,7 @@ func (m *MemoryInstance) ReadUint64Le(_ context.Context, offset uint32) (uint64,
 func (m *MemoryInstance) ReadFloat64Le(_ context.Context, offset uint32) (float64, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	v, ok := m.readUint64Le(offset)
 	if !ok {
 		return 0, false
@@ -115,10 +150,56 @@ func (m *MemoryInstance) ReadFloat64Le(_ context.Context, offset uint32) (float6
 	return math.Float64frombits(v), true
 }
 
+// ReadUint16Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) ReadUint16Be(_ context.Context, offset uint32) (uint16, bool) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	defer m.rlock()()
+	if !m.hasSize(offset, 2) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(m.Buffer[offset : offset+2]), true
+}
+
+// ReadUint32Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) ReadUint32Be(_ context.Context, offset uint32) (uint32, bool) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	defer m.rlock()()
+	if !m.hasSize(offset, 4) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(m.Buffer[offset : offset+4]), true
+}
+
+// ReadUint64Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) ReadUint64Be(_ context.Context, offset uint32) (uint64, bool) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	defer m.rlock()()
+	if !m.hasSize(offset, 8) {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(m.Buffer[offset : offset+8]), true
+}
+
 // Read implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Read(_ context.Context, offset, byteCount uint32) ([]byte, bool) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
+	if !m.hasSize(offset, byteCount) {
+		return nil, false
+	}
+	return append([]byte{}, m.Buffer[offset:offset+byteCount]...), true
+}
+
+// ReadAlias is like Read, except the result is a view of the underlying buffer instead of a copy.
+// See experimental.ReadAlias
+func (m *MemoryInstance) ReadAlias(_ context.Context, offset, byteCount uint32) ([]byte, bool) {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	defer m.rlock()()
 	if !m.hasSize(offset, byteCount) {
 		return nil, false
 	}
@@ -129,6 +210,7 @@ func (m *MemoryInstance) Read(_ context.Context, offset, byteCount uint32) ([]by
 func (m *MemoryInstance) WriteByte(_ context.Context, offset uint32, v byte) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	if offset >= m.size() {
 		return false
 	}
@@ -140,6 +222,7 @@ func (m *MemoryInstance) WriteByte(_ context.Context, offset uint32, v byte) boo
 func (m *MemoryInstance) WriteUint16Le(_ context.Context, offset uint32, v uint16) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	if !m.hasSize(offset, 2) {
 		return false
 	}
@@ -149,7 +232,9 @@ func (m *MemoryInstance) WriteUint16Le(_ context.Context, offset uint32, v uint1
 
 // WriteUint32Le implements the same method as documented on api.Memory.
 func (m *MemoryInstance) WriteUint32Le(_ context.Context, offset, v uint32) bool {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return m.writeUint32Le(offset, v)
 }
 
@@ -157,6 +242,7 @@ func (m *MemoryInstance) WriteUint32Le(_ context.Context, offset, v uint32) bool
 func (m *MemoryInstance) WriteFloat32Le(_ context.Context, offset uint32, v float32) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return m.writeUint32Le(offset, math.Float32bits(v))
 }
 
@@ -164,6 +250,7 @@ func (m *MemoryInstance) WriteFloat32Le(_ context.Context, offset uint32, v floa
 func (m *MemoryInstance) WriteUint64Le(_ context.Context, offset uint32, v uint64) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return m.writeUint64Le(offset, v)
 }
 
@@ -171,13 +258,51 @@ func (m *MemoryInstance) WriteUint64Le(_ context.Context, offset uint32, v uint6
 func (m *MemoryInstance) WriteFloat64Le(_ context.Context, offset uint32, v float64) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return m.writeUint64Le(offset, math.Float64bits(v))
 }
 
+// WriteUint16Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WriteUint16Be(_ context.Context, offset uint32, v uint16) bool {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	defer m.rlock()()
+	if !m.hasSize(offset, 2) {
+		return false
+	}
+	binary.BigEndian.PutUint16(m.Buffer[offset:], v)
+	return true
+}
+
+// WriteUint32Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WriteUint32Be(_ context.Context, offset, v uint32) bool {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	defer m.rlock()()
+	if !m.hasSize(offset, 4) {
+		return false
+	}
+	binary.BigEndian.PutUint32(m.Buffer[offset:], v)
+	return true
+}
+
+// WriteUint64Be implements the same method as documented on api.Memory.
+func (m *MemoryInstance) WriteUint64Be(_ context.Context, offset uint32, v uint64) bool {
+	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+
+	defer m.rlock()()
+	if !m.hasSize(offset, 8) {
+		return false
+	}
+	binary.BigEndian.PutUint64(m.Buffer[offset:], v)
+	return true
+}
+
 // Write implements the same method as documented on api.Memory.
 func (m *MemoryInstance) Write(_ context.Context, offset uint32, val []byte) bool {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	if !m.hasSize(offset, uint32(len(val))) {
 		return false
 	}
@@ -185,6 +310,17 @@ func (m *MemoryInstance) Write(_ context.Context, offset uint32, val []byte) boo
 	return true
 }
 
+// rlock read-locks the Buffer when Shared, and returns the function to unlock it. Ex. `defer m.rlock()()`
+func (m *MemoryInstance) rlock() (runlock func()) {
+	if !m.Shared {
+		return noopUnlock
+	}
+	m.mux.RLock()
+	return m.mux.RUnlock
+}
+
+func noopUnlock() {}
+
 // MemoryPagesToBytesNum converts the given pages into the number of bytes contained in these pages.
 func MemoryPagesToBytesNum(pages uint32) (bytesNum uint64) {
 	return uint64(pages) << MemoryPageSizeInBits
@@ -195,8 +331,15 @@ func MemoryPagesToBytesNum(pages uint32) (bytesNum uint64) {
 //
 // Returns -1 if the operation resulted in exceeding the maximum memory pages.
 // Otherwise, returns the prior memory size after growing the memory buffer.
-func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32) {
-	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
+func (m *MemoryInstance) Grow(ctx context.Context, delta uint32) (result uint32) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if m.Shared {
+		m.mux.Lock()
+		defer m.mux.Unlock()
+	}
 
 	currentPages := memoryBytesNumToPages(uint64(len(m.Buffer)))
 	if delta == 0 {
@@ -205,11 +348,21 @@ func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32) {
 
 	// If exceeds the max of memory size, we push -1 according to the spec.
 	newPages := currentPages + delta
-	if newPages > m.Max {
+	if newPages > m.Max || newPages > growLimitPages(ctx, m) {
 		return 0xffffffff // = -1 in signed 32-bit integer.
 	} else if newPages > m.Cap { // grow the memory.
-		m.Buffer = append(m.Buffer, make([]byte, MemoryPagesToBytesNum(delta))...)
-		m.Cap = newPages
+		if capPages := m.growCapacity(newPages); capPages > newPages {
+			buffer := make([]byte, MemoryPagesToBytesNum(newPages), MemoryPagesToBytesNum(capPages))
+			copy(buffer, m.Buffer)
+			m.Buffer = buffer
+			m.Cap = capPages
+		} else {
+			m.Buffer = append(m.Buffer, make([]byte, MemoryPagesToBytesNum(delta))...)
+			m.Cap = newPages
+		}
+		return currentPages
+	} else if m.Shared { // Cap is Max, so reslice instead of mutating the slice header in place.
+		m.Buffer = m.Buffer[:MemoryPagesToBytesNum(newPages)]
 		return currentPages
 	} else { // We already have the capacity we need.
 		sp := (*reflect.SliceHeader)(unsafe.Pointer(&m.Buffer))
@@ -218,13 +371,103 @@ func (m *MemoryInstance) Grow(_ context.Context, delta uint32) (result uint32) {
 	}
 }
 
+// growLimitKey is a context.Context Value key present during CallContext.CallWithLimits. Its value is a *growLimit.
+type growLimitKey struct{}
+
+// growLimit is the per-call cap of Grow for one memory. See CallContext.CallWithLimits
+type growLimit struct {
+	memory   *MemoryInstance
+	maxPages uint32
+}
+
+// growLimitPages returns the max pages the memory can grow to during the call of the given context.
+func growLimitPages(ctx context.Context, m *MemoryInstance) uint32 {
+	if limit, ok := ctx.Value(growLimitKey{}).(*growLimit); ok && limit.memory == m {
+		return limit.maxPages
+	}
+	return MemoryLimitPages
+}
+
+// NotifyMemoryGrowth calls the listener with the growth of the memory, given the delta and result of Grow.
+//
+// See RuntimeConfig.WithMemoryGrowthListener
+func NotifyMemoryGrowth(listener func(memoryIndex, before, delta uint32, ok bool), m *MemoryInstance, delta, result uint32) {
+	if result == 0xffffffff { // failed, so the size is unchanged.
+		listener(0, memoryBytesNumToPages(uint64(len(m.Buffer))), delta, false)
+	} else {
+		listener(0, result, delta, true)
+	}
+}
+
+// growCapacity returns the capacity in pages when growing beyond Cap to newPages, clamped to no more than Max. The result
+// is less than newPages when the capacity should be the new size.
+func (m *MemoryInstance) growCapacity(newPages uint32) uint32 {
+	if m.capacityPages == nil {
+		return newPages
+	}
+	if capPages := m.capacityPages(newPages); capPages < m.Max {
+		return capPages
+	}
+	return m.Max
+}
+
 // PageSize returns the current memory buffer size in pages.
 func (m *MemoryInstance) PageSize(_ context.Context) (result uint32) {
 	// Note: If you use the context.Context param, don't forget to coerce nil to context.Background()!
 
+	defer m.rlock()()
 	return memoryBytesNumToPages(uint64(len(m.Buffer)))
 }
 
+// MapMMIO implements the same method as documented on experimental.MapMMIO.
+func (m *MemoryInstance) MapMMIO(offset, size uint32, region experimental.MMIORegion) error {
+	if size == 0 {
+		return errors.New("MMIO range is empty")
+	} else if uint64(offset)+uint64(size) > math.MaxUint32+1 {
+		return fmt.Errorf("MMIO range [%d, %d) exceeds the address space", offset, uint64(offset)+uint64(size))
+	}
+	i := sort.Search(len(m.mmio), func(i int) bool { return m.mmio[i].offset >= offset })
+	if (i > 0 && m.mmio[i-1].end() > uint64(offset)) || (i < len(m.mmio) && uint64(m.mmio[i].offset) < uint64(offset)+uint64(size)) {
+		return fmt.Errorf("MMIO range [%d, %d) overlaps an existing range", offset, uint64(offset)+uint64(size))
+	}
+	m.mmio = append(m.mmio, mmioRange{})
+	copy(m.mmio[i+1:], m.mmio[i:])
+	m.mmio[i] = mmioRange{offset: offset, size: size, region: region}
+	return nil
+}
+
+// MMIO returns the experimental.MMIORegion which handles the access of size bytes at offset, and the offset relative
+// to its range, or nil if the access is outside all ranges. straddles is true if the access is partially in a range.
+func (m *MemoryInstance) MMIO(offset, size uint32) (region experimental.MMIORegion, regionOffset uint32, straddles bool) {
+	if len(m.mmio) == 0 {
+		return
+	}
+	return m.findMMIO(offset, size)
+}
+
+func (m *MemoryInstance) findMMIO(offset, size uint32) (region experimental.MMIORegion, regionOffset uint32, straddles bool) {
+	end := uint64(offset) + uint64(size)
+	// The first range which ends after the offset is the only one the access can start in.
+	i := sort.Search(len(m.mmio), func(i int) bool { return m.mmio[i].end() > uint64(offset) })
+	if i == len(m.mmio) {
+		return
+	}
+	r := &m.mmio[i]
+	switch {
+	case uint64(r.offset) >= end: // the access ends before the range.
+	case r.offset <= offset && end <= r.end():
+		region, regionOffset = r.region, offset-r.offset
+	default:
+		straddles = true
+	}
+	return
+}
+
+// end returns the offset after the last byte of the range.
+func (r *mmioRange) end() uint64 {
+	return uint64(r.offset) + uint64(r.size)
+}
+
 // PagesToUnitOfBytes converts the pages to a human-readable form similar to what's specified. Ex. 1 -> "64Ki"
 //
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#memory-instances%E2%91%A0
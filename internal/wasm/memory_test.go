@@ -3,6 +3,7 @@ package wasm
 import (
 	"context"
 	"math"
+	"sync"
 	"testing"
 
 	"github.com/tetratelabs/wazero/internal/testing/require"
@@ -80,6 +81,87 @@ func TestMemoryInstance_Grow_Size(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_Grow_capacityPages(t *testing.T) {
+	var minPages []uint32
+	m := &MemoryInstance{Min: 1, Cap: 1, Max: 10, Buffer: make([]byte, MemoryPagesToBytesNum(1))}
+	m.capacityPages = func(newPages uint32) uint32 {
+		minPages = append(minPages, newPages)
+		if newPages == 4 {
+			return 1 // less than the new size
+		}
+		return newPages * 2
+	}
+	m.Buffer[0] = 1
+
+	require.Equal(t, uint32(1), m.Grow(testCtx, 1))
+	require.Equal(t, uint32(4), m.Cap)
+	require.Equal(t, MemoryPagesToBytesNum(4), uint64(cap(m.Buffer)))
+	require.Equal(t, byte(1), m.Buffer[0]) // contents are retained when reallocated.
+
+	// Growing within the capacity doesn't re-evaluate it.
+	require.Equal(t, uint32(2), m.Grow(testCtx, 2))
+	require.Equal(t, uint32(4), m.Cap)
+
+	// Growing past the capacity re-evaluates it, clamped to no more than the max.
+	require.Equal(t, uint32(4), m.Grow(testCtx, 2))
+	require.Equal(t, uint32(10), m.Cap)
+	require.Equal(t, []uint32{2, 6}, minPages)
+
+	t.Run("clamped up to the new size", func(t *testing.T) {
+		m := &MemoryInstance{Min: 1, Cap: 1, Max: 10, Buffer: make([]byte, MemoryPagesToBytesNum(1))}
+		m.capacityPages = func(uint32) uint32 { return 1 }
+
+		require.Equal(t, uint32(1), m.Grow(testCtx, 3))
+		require.Equal(t, uint32(4), m.Cap)
+		require.Equal(t, uint32(4), m.PageSize(testCtx))
+	})
+}
+
+func TestMemoryInstance_Grow_growLimit(t *testing.T) {
+	m := &MemoryInstance{Min: 1, Cap: 1, Max: 10, Buffer: make([]byte, MemoryPagesToBytesNum(1))}
+	other := &MemoryInstance{Min: 1, Cap: 1, Max: 10, Buffer: make([]byte, MemoryPagesToBytesNum(1))}
+	limited := context.WithValue(testCtx, growLimitKey{}, &growLimit{memory: m, maxPages: 2})
+
+	// The limit only applies to calls with its context, and doesn't change the Max.
+	require.Equal(t, uint32(0xffffffff), m.Grow(limited, 2))
+	require.Equal(t, uint32(10), m.Max)
+	require.Equal(t, uint32(1), m.Grow(limited, 1))
+	require.Equal(t, uint32(2), m.Grow(testCtx, 2))
+
+	// The limit only applies to its memory.
+	require.Equal(t, uint32(1), other.Grow(limited, 2))
+}
+
+// TestMemoryInstance_Shared ensures reads of a shared memory are consistent while another goroutine grows it, even
+// though growing reallocates the Buffer. Run with -race to detect unsynchronized access.
+func TestMemoryInstance_Shared(t *testing.T) {
+	max := uint32(10)
+	m := (&Module{MemorySection: &Memory{Min: 1, Cap: 1, Max: max, IsMaxEncoded: true, IsShared: true}}).buildMemory()
+	require.True(t, m.WriteUint32Le(testCtx, 0, 0xdeadbeef))
+	buf, ok := m.ReadAlias(testCtx, 0, 4) // a view of the memory, read before growth.
+	require.True(t, ok)
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := uint32(1); i < max; i++ {
+			m.Grow(testCtx, 1)
+		}
+	}()
+	for m.PageSize(testCtx) < max {
+		v, ok := m.ReadUint32Le(testCtx, 0)
+		require.True(t, ok)
+		require.Equal(t, uint32(0xdeadbeef), v)
+	}
+	wg.Wait()
+	require.Equal(t, MemoryPagesToBytesNum(max), uint64(m.Size(testCtx)))
+
+	// The buffer never moved, so the view still aliases the memory.
+	require.True(t, m.WriteUint32Le(testCtx, 0, 0xcafebabe))
+	require.Equal(t, []byte{0xbe, 0xba, 0xfe, 0xca}, buf)
+}
+
 func TestIndexByte(t *testing.T) {
 	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
 		var mem = &MemoryInstance{Buffer: []byte{0, 0, 0, 0, 16, 0, 0, 0}, Min: 1}
@@ -233,6 +315,37 @@ func TestMemoryInstance_HasSize(t *testing.T) {
 	}
 }
 
+func TestMemoryInstance_Read(t *testing.T) {
+	memory := &MemoryInstance{Buffer: []byte{0, 1, 2, 3}, Min: 1}
+
+	buf, ok := memory.Read(testCtx, 1, 2)
+	require.True(t, ok)
+	require.Equal(t, []byte{1, 2}, buf)
+
+	// The result is a copy, so writing to it doesn't change the memory.
+	buf[0] = 0xff
+	require.Equal(t, []byte{0, 1, 2, 3}, memory.Buffer)
+
+	_, ok = memory.Read(testCtx, 3, 2)
+	require.False(t, ok)
+}
+
+func TestMemoryInstance_ReadAlias(t *testing.T) {
+	memory := &MemoryInstance{Buffer: []byte{0, 1, 2, 3}, Min: 1}
+
+	buf, ok := memory.ReadAlias(testCtx, 1, 2)
+	require.True(t, ok)
+	require.Equal(t, []byte{1, 2}, buf)
+
+	// The result is a view, so writing to it changes the memory, but only within its length.
+	buf[0] = 0xff
+	require.Equal(t, []byte{0, 0xff, 2, 3}, memory.Buffer)
+	require.Equal(t, 2, cap(buf))
+
+	_, ok = memory.ReadAlias(testCtx, 3, 2)
+	require.False(t, ok)
+}
+
 func TestMemoryInstance_ReadUint16Le(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -750,3 +863,64 @@ func TestMemoryInstance_WriteFloat64Le(t *testing.T) {
 		})
 	}
 }
+
+func TestMemoryInstance_BigEndian(t *testing.T) {
+	memory := &MemoryInstance{Buffer: make([]byte, 100)}
+	size := memory.Size(testCtx)
+
+	for _, ctx := range []context.Context{nil, testCtx} { // Ensure it doesn't crash on nil!
+		t.Run("uint16", func(t *testing.T) {
+			require.True(t, memory.WriteUint16Be(ctx, 0, 0x0102))
+			require.Equal(t, []byte{0x01, 0x02}, memory.Buffer[0:2])
+
+			v, ok := memory.ReadUint16Be(ctx, 0)
+			require.True(t, ok)
+			require.Equal(t, uint16(0x0102), v)
+
+			le, ok := memory.ReadUint16Le(ctx, 0)
+			require.True(t, ok)
+			require.Equal(t, uint16(0x0201), le)
+
+			require.True(t, memory.WriteUint16Be(ctx, size-2, 1))
+			require.False(t, memory.WriteUint16Be(ctx, size-1, 1))
+			_, ok = memory.ReadUint16Be(ctx, size-1)
+			require.False(t, ok)
+		})
+
+		t.Run("uint32", func(t *testing.T) {
+			require.True(t, memory.WriteUint32Be(ctx, 0, 0x01020304))
+			require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04}, memory.Buffer[0:4])
+
+			v, ok := memory.ReadUint32Be(ctx, 0)
+			require.True(t, ok)
+			require.Equal(t, uint32(0x01020304), v)
+
+			le, ok := memory.ReadUint32Le(ctx, 0)
+			require.True(t, ok)
+			require.Equal(t, uint32(0x04030201), le)
+
+			require.True(t, memory.WriteUint32Be(ctx, size-4, 1))
+			require.False(t, memory.WriteUint32Be(ctx, size-3, 1))
+			_, ok = memory.ReadUint32Be(ctx, size-3)
+			require.False(t, ok)
+		})
+
+		t.Run("uint64", func(t *testing.T) {
+			require.True(t, memory.WriteUint64Be(ctx, 0, 0x0102030405060708))
+			require.Equal(t, []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, memory.Buffer[0:8])
+
+			v, ok := memory.ReadUint64Be(ctx, 0)
+			require.True(t, ok)
+			require.Equal(t, uint64(0x0102030405060708), v)
+
+			le, ok := memory.ReadUint64Le(ctx, 0)
+			require.True(t, ok)
+			require.Equal(t, uint64(0x0807060504030201), le)
+
+			require.True(t, memory.WriteUint64Be(ctx, size-8, 1))
+			require.False(t, memory.WriteUint64Be(ctx, size-7, 1))
+			_, ok = memory.ReadUint64Be(ctx, size-7)
+			require.False(t, ok)
+		})
+	}
+}
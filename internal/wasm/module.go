@@ -150,6 +150,15 @@ type Module struct {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#custom-section%E2%91%A0
 	NameSection *NameSection
 
+	// DylinkSection is set when the SectionIDCustom "dylink.0" was successfully decoded from the binary format.
+	//
+	// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md#the-dylink0-section
+	DylinkSection *DylinkSection
+
+	// SkippedCustomSections are the names of any SectionIDCustom skipped by the binary decoder, as wazero doesn't use
+	// them, in order. See Warnings
+	SkippedCustomSections []string
+
 	// HostFunctionSection is index-correlated with FunctionSection and contains a host function defined in Go.
 	// When present, the CodeSection must be nil.
 	//
@@ -164,6 +173,10 @@ type Module struct {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#table-instances%E2%91%A0
 	validatedActiveElementSegments []*validatedActiveElementSegment
 
+	// activeDataSegments are the indexes of active segments in DataSection, built on Validate. Instantiation only
+	// validates and applies these, so passive segments, which have no offset, are skipped without iterating them.
+	activeDataSegments []Index
+
 	// DataCountSection is the optional section and holds the number of data segments in the data section.
 	//
 	// Note: This may exist in WebAssembly 2.0 or WebAssembly 1.0 with FeatureBulkMemoryOperations.
@@ -222,49 +235,92 @@ func (m *Module) TypeOfFunction(funcIdx Index) *FunctionType {
 }
 
 func (m *Module) Validate(enabledFeatures Features) error {
-	if err := m.validateStartSection(); err != nil {
-		return err
+	_, err := m.validate(enabledFeatures, false)
+	return err
+}
+
+// ValidateCollectingErrors is like Validate, except errors isolated to a function don't stop validation. Instead, they
+// are returned as functionErrs, in order of function index. The error is only returned for problems that aren't
+// isolated to a function, such as an invalid export.
+func (m *Module) ValidateCollectingErrors(enabledFeatures Features) (functionErrs []*FunctionError, err error) {
+	return m.validate(enabledFeatures, true)
+}
+
+// FunctionError is an error isolated to a function defined in the module.
+type FunctionError struct {
+	// Index is the index of the function in the function index namespace, which includes any imported functions.
+	Index Index
+	// Err describes why the function is invalid.
+	Err error
+}
+
+// Error implements error
+func (e *FunctionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap implements errors.Unwrap
+func (e *FunctionError) Unwrap() error {
+	return e.Err
+}
+
+// validate implements Validate and ValidateCollectingErrors. When collectFunctionErrors is false, the first
+// FunctionError is returned as the error instead.
+func (m *Module) validate(enabledFeatures Features, collectFunctionErrors bool) (functionErrs []*FunctionError, err error) {
+	if err = m.validateStartSection(); err != nil {
+		return
 	}
 
 	if m.SectionElementCount(SectionIDCode) > 0 && m.SectionElementCount(SectionIDHostFunction) > 0 {
-		return errors.New("cannot mix functions and host functions in the same module")
+		err = errors.New("cannot mix functions and host functions in the same module")
+		return
 	}
 
 	functions, globals, memory, tables, err := m.AllDeclarations()
 	if err != nil {
-		return err
+		return
 	}
 
 	if err = m.validateImports(enabledFeatures); err != nil {
-		return err
+		return
 	}
 
 	if err = m.validateGlobals(globals, MaximumGlobals); err != nil {
-		return err
+		return
 	}
 
 	if err = m.validateMemory(memory, globals, enabledFeatures); err != nil {
-		return err
+		return
 	}
 
 	if m.CodeSection != nil {
-		if err = m.validateFunctions(enabledFeatures, functions, globals, memory, tables, MaximumFunctionIndex); err != nil {
-			return err
+		if functionErrs, err = m.collectFunctionErrors(enabledFeatures, functions, globals, memory, tables, MaximumFunctionIndex); err != nil {
+			return
+		} else if !collectFunctionErrors && len(functionErrs) > 0 {
+			err = functionErrs[0]
+			functionErrs = nil
+			return
+		}
+	} else if m.HostFunctionSection != nil {
+		if err = m.validateHostFunctions(enabledFeatures); err != nil {
+			return
 		}
-	} // No need to validate host functions as NewHostModule validates
+	}
 
 	if _, err = m.validateTable(enabledFeatures, tables); err != nil {
-		return err
+		return
 	}
 
 	if err = m.validateExports(enabledFeatures, functions, globals, memory, tables); err != nil {
-		return err
+		return
 	}
 
-	if err := m.validateDataCountSection(); err != nil {
-		return err
+	if err = m.validateDataCountSection(); err != nil {
+		return
 	}
-	return nil
+
+	m.activeDataSegments = m.collectActiveData()
+	return
 }
 
 func (m *Module) validateStartSection() error {
@@ -300,31 +356,47 @@ func (m *Module) validateGlobals(globals []*GlobalType, maxGlobals uint32) error
 }
 
 func (m *Module) validateFunctions(enabledFeatures Features, functions []Index, globals []*GlobalType, memory *Memory, tables []*Table, maximumFunctionIndex uint32) error {
+	functionErrs, err := m.collectFunctionErrors(enabledFeatures, functions, globals, memory, tables, maximumFunctionIndex)
+	if err != nil {
+		return err
+	} else if len(functionErrs) > 0 {
+		return functionErrs[0]
+	}
+	return nil
+}
+
+// collectFunctionErrors is like validateFunctions, except each invalid function is returned as a FunctionError
+// instead of stopping validation. The error is only returned when the problem isn't isolated to a function.
+func (m *Module) collectFunctionErrors(enabledFeatures Features, functions []Index, globals []*GlobalType, memory *Memory, tables []*Table, maximumFunctionIndex uint32) ([]*FunctionError, error) {
 	if uint32(len(functions)) > maximumFunctionIndex {
-		return fmt.Errorf("too many functions in a store")
+		return nil, fmt.Errorf("too many functions in a store")
 	}
 
 	functionCount := m.SectionElementCount(SectionIDFunction)
 	codeCount := m.SectionElementCount(SectionIDCode)
 	if functionCount == 0 && codeCount == 0 {
-		return nil
+		return nil, nil
 	}
 
 	typeCount := m.SectionElementCount(SectionIDType)
 	if codeCount != functionCount {
-		return fmt.Errorf("code count (%d) != function count (%d)", codeCount, functionCount)
+		return nil, fmt.Errorf("code count (%d) != function count (%d)", codeCount, functionCount)
 	}
 
+	var functionErrs []*FunctionError
+	importedFunctionCount := m.importCount(ExternTypeFunc)
 	for idx, typeIndex := range m.FunctionSection {
+		var err error
 		if typeIndex >= typeCount {
-			return fmt.Errorf("invalid %s: type section index %d out of range", m.funcDesc(SectionIDFunction, Index(idx)), typeIndex)
+			err = fmt.Errorf("invalid %s: type section index %d out of range", m.funcDesc(SectionIDFunction, Index(idx)), typeIndex)
+		} else if err = m.validateFunction(enabledFeatures, Index(idx), functions, globals, memory, tables); err != nil {
+			err = fmt.Errorf("invalid %s: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
 		}
-
-		if err := m.validateFunction(enabledFeatures, Index(idx), functions, globals, memory, tables); err != nil {
-			return fmt.Errorf("invalid %s: %w", m.funcDesc(SectionIDFunction, Index(idx)), err)
+		if err != nil {
+			functionErrs = append(functionErrs, &FunctionError{Index: importedFunctionCount + Index(idx), Err: err})
 		}
 	}
-	return nil
+	return functionErrs, nil
 }
 
 func (m *Module) funcDesc(sectionID SectionID, sectionIndex Index) string {
@@ -466,6 +538,25 @@ func (m *Module) validateDataCountSection() (err error) {
 	return
 }
 
+// activeData returns the indexes of the active segments in DataSection, cached by Validate.
+func (m *Module) activeData() []Index {
+	if m.activeDataSegments != nil || len(m.DataSection) == 0 {
+		return m.activeDataSegments
+	}
+	return m.collectActiveData() // not validated, ex. a module defined in a test.
+}
+
+// collectActiveData returns the indexes of the active segments in DataSection, or an empty slice if there are none.
+func (m *Module) collectActiveData() []Index {
+	ret := []Index{}
+	for i, d := range m.DataSection {
+		if !d.IsPassive() {
+			ret = append(ret, Index(i))
+		}
+	}
+	return ret
+}
+
 func (m *Module) buildGlobals(importedGlobals []*GlobalInstance) (globals []*GlobalInstance) {
 	for _, gs := range m.GlobalSection {
 		var gv uint64
@@ -559,13 +650,18 @@ func paramNames(localNames IndirectNameMap, funcIdx uint32, paramLen int) []stri
 func (m *Module) buildMemory() (mem *MemoryInstance) {
 	memSec := m.MemorySection
 	if memSec != nil {
+		capPages := memSec.Cap
+		if memSec.IsShared { // allocate the max, so the buffer never moves. See MemoryInstance.Shared
+			capPages = memSec.Max
+		}
 		min := MemoryPagesToBytesNum(memSec.Min)
-		capacity := MemoryPagesToBytesNum(memSec.Cap)
+		capacity := MemoryPagesToBytesNum(capPages)
 		mem = &MemoryInstance{
 			Buffer: make([]byte, min, capacity),
 			Min:    memSec.Min,
-			Cap:    memSec.Cap,
+			Cap:    capPages,
 			Max:    memSec.Max,
+			Shared: memSec.IsShared,
 		}
 	}
 	return
@@ -653,6 +749,11 @@ type Memory struct {
 	Min, Cap, Max uint32
 	// IsMaxEncoded true if the Max is encoded in the original source (binary or text).
 	IsMaxEncoded bool
+	// IsShared is true if the memory can be accessed concurrently by multiple threads. This requires FeatureThreads and
+	// IsMaxEncoded.
+	//
+	// See https://github.com/WebAssembly/threads/blob/main/proposals/threads/Overview.md#shared-linear-memory
+	IsShared bool
 }
 
 // ValidateMinMax ensures values assigned to Min and Max are within valid thresholds.
@@ -736,6 +837,23 @@ func (d *DataSegment) IsPassive() bool {
 	return d.OffsetExpression == nil
 }
 
+// DylinkSection is the "dylink.0" custom section of a module which supports dynamic linking, ex. a side module
+// compiled by Emscripten. A host linker uses it to allocate the memory and table regions of the module.
+//
+// See https://github.com/WebAssembly/tool-conventions/blob/main/DynamicLinking.md#the-dylink0-section
+type DylinkSection struct {
+	// MemorySize is the size in bytes of the static data of the module, which the linker reserves in memory.
+	MemorySize uint32
+	// MemoryAlignment is the alignment of MemorySize, as a power of two. Ex. 2 means 4-byte aligned.
+	MemoryAlignment uint32
+	// TableSize is the count of table elements the linker reserves for the module.
+	TableSize uint32
+	// TableAlignment is the alignment of TableSize, as a power of two.
+	TableAlignment uint32
+	// Needed are the names of the shared libraries the module depends on, in order.
+	Needed []string
+}
+
 // NameSection represent the known custom name subsections defined in the WebAssembly Binary Format
 //
 // Note: This can be nil if no names were decoded for any reason including configuration.
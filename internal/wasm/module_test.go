@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/u64"
@@ -817,6 +818,19 @@ func TestModule_buildMemoryInstance(t *testing.T) {
 		mem := m.buildMemory()
 		require.Equal(t, min, mem.Min)
 		require.Equal(t, max, mem.Max)
+		require.False(t, mem.Shared)
+	})
+	t.Run("shared", func(t *testing.T) {
+		min := uint32(1)
+		max := uint32(10)
+		m := Module{MemorySection: &Memory{Min: min, Cap: min, Max: max, IsMaxEncoded: true, IsShared: true}}
+		mem := m.buildMemory()
+		require.True(t, mem.Shared)
+		// Shared memory is allocated up to its max, so the buffer never moves.
+		require.Equal(t, max, mem.Cap)
+		require.Equal(t, int(MemoryPagesToBytesNum(min)), len(mem.Buffer))
+		require.Equal(t, int(MemoryPagesToBytesNum(max)), cap(mem.Buffer))
+		require.Equal(t, max, mem.Max)
 	})
 }
 
@@ -853,3 +867,26 @@ func TestModule_validateDataCountSection(t *testing.T) {
 		}
 	})
 }
+
+func TestModule_activeData(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	active := &DataSegment{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}, Init: []byte{1}}
+	passive := &DataSegment{Init: []byte{1}}
+
+	m := &Module{
+		MemorySection: &Memory{Min: 1, Max: 1},
+		DataSection:   []*DataSegment{passive, active, passive, active},
+	}
+	require.Equal(t, []Index{1, 3}, m.activeData())
+	require.Nil(t, m.activeDataSegments)
+
+	// Validate caches the result, so instantiation doesn't iterate the passive segments.
+	require.NoError(t, m.Validate(Features20220419))
+	require.Equal(t, []Index{1, 3}, m.activeDataSegments)
+
+	passiveOnly := &Module{DataSection: []*DataSegment{passive}}
+	require.NoError(t, passiveOnly.Validate(Features20220419))
+	require.Equal(t, []Index{}, passiveOnly.activeData())
+}
@@ -0,0 +1,199 @@
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// ReachableFunctions returns the sorted indices of functions transitively reachable from the exported function,
+// including the export itself. Indices are in the function index namespace, so include imported functions, which are
+// reachable, but not analyzed further.
+//
+// Direct calls are followed precisely. Targets of `call_indirect` are unknown until runtime, so any function which can
+// be referenced by a table is conservatively considered reachable once a reachable function uses `call_indirect`.
+// Referenced functions are those in any element segment, and any operand of a reachable `ref.func`.
+//
+// Note: The module must be valid, as Validate ensures the bodies can be decoded.
+func (m *Module) ReachableFunctions(exportName string) ([]Index, error) {
+	var start *Export
+	for _, e := range m.ExportSection {
+		if e.Type == ExternTypeFunc && e.Name == exportName {
+			start = e
+			break
+		}
+	}
+	if start == nil {
+		return nil, fmt.Errorf("%q is not an exported function", exportName)
+	}
+
+	importedFunctionCount := m.importCount(ExternTypeFunc)
+	functionCount := importedFunctionCount + m.SectionElementCount(SectionIDFunction)
+
+	// tableReferenced are candidates for call_indirect, which are only reachable once a call_indirect is.
+	tableReferenced := map[Index]struct{}{}
+	for _, e := range m.ElementSection {
+		for _, idx := range e.Init {
+			if idx != nil {
+				tableReferenced[*idx] = struct{}{}
+			}
+		}
+	}
+
+	reachable := map[Index]struct{}{}
+	var callIndirect bool
+	// Use a work list instead of recursion, so that recursive functions are only visited once.
+	work := []Index{start.Index}
+	for len(work) > 0 {
+		idx := work[len(work)-1]
+		work = work[:len(work)-1]
+		if _, ok := reachable[idx]; ok {
+			continue
+		} else if idx >= functionCount {
+			return nil, fmt.Errorf("function[%d] out of range", idx)
+		}
+		reachable[idx] = struct{}{}
+
+		if idx < importedFunctionCount {
+			continue // imported functions have no body to analyze.
+		}
+		code := m.CodeSection[idx-importedFunctionCount]
+		refs, err := scanCalls(code.Body)
+		if err != nil {
+			return nil, fmt.Errorf("function[%d]: %w", idx, err)
+		}
+		work = append(work, refs.calls...)
+		for _, ref := range refs.refFuncs {
+			tableReferenced[ref] = struct{}{}
+		}
+		callIndirect = callIndirect || refs.callIndirect
+
+		// Once the direct calls are exhausted, add any functions that can be called indirectly.
+		if len(work) == 0 && callIndirect {
+			for ref := range tableReferenced {
+				if _, ok := reachable[ref]; !ok {
+					work = append(work, ref)
+				}
+			}
+		}
+	}
+
+	ret := make([]Index, 0, len(reachable))
+	for idx := range reachable {
+		ret = append(ret, idx)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i] < ret[j] })
+	return ret, nil
+}
+
+// functionRefs are the references to other functions found by scanCalls.
+type functionRefs struct {
+	// calls are the targets of any `call` instruction.
+	calls []Index
+	// refFuncs are the operands of any `ref.func` instruction.
+	refFuncs []Index
+	// callIndirect is true if there is any `call_indirect` instruction.
+	callIndirect bool
+}
+
+// scanCalls returns the functions referenced by instructions in the body, skipping the immediates of all others.
+func scanCalls(body []byte) (refs functionRefs, err error) {
+	r := bytes.NewReader(body)
+	for r.Len() > 0 {
+		var op Opcode
+		if op, err = r.ReadByte(); err != nil {
+			return
+		}
+
+		switch {
+		case op == OpcodeCall:
+			var idx Index
+			if idx, _, err = leb128.DecodeUint32(r); err != nil {
+				return
+			}
+			refs.calls = append(refs.calls, idx)
+		case op == OpcodeRefFunc:
+			var idx Index
+			if idx, _, err = leb128.DecodeUint32(r); err != nil {
+				return
+			}
+			refs.refFuncs = append(refs.refFuncs, idx)
+		case op == OpcodeCallIndirect:
+			refs.callIndirect = true
+			err = skipUint32s(r, 2) // type index and table index
+		default:
+			err = skipImmediates(op, r)
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// skipImmediates skips the immediates of the instruction, which begin at the reader.
+func skipImmediates(op Opcode, r *bytes.Reader) (err error) {
+	switch {
+	case op == OpcodeCall || op == OpcodeRefFunc:
+		err = skipUint32s(r, 1)
+	case op == OpcodeCallIndirect:
+		err = skipUint32s(r, 2) // type index and table index
+	case op == OpcodeBlock || op == OpcodeLoop || op == OpcodeIf:
+		_, _, err = leb128.DecodeInt33AsInt64(r)
+	case op == OpcodeBr || op == OpcodeBrIf ||
+		(OpcodeLocalGet <= op && op <= OpcodeTableSet):
+		err = skipUint32s(r, 1)
+	case op == OpcodeBrTable:
+		var count uint32
+		if count, _, err = leb128.DecodeUint32(r); err == nil {
+			err = skipUint32s(r, int(count)+1) // targets and the default
+		}
+	case OpcodeI32Load <= op && op <= OpcodeI64Store32:
+		err = skipUint32s(r, 2) // alignment and offset
+	case op == OpcodeMemorySize || op == OpcodeMemoryGrow || op == OpcodeRefNull:
+		_, err = r.ReadByte()
+	case op == OpcodeI32Const:
+		_, _, err = leb128.DecodeInt32(r)
+	case op == OpcodeI64Const:
+		_, _, err = leb128.DecodeInt64(r)
+	case op == OpcodeF32Const:
+		_, err = r.Seek(4, 1)
+	case op == OpcodeF64Const:
+		_, err = r.Seek(8, 1)
+	case op == OpcodeMiscPrefix:
+		err = skipMiscImmediates(r)
+	}
+	return
+}
+
+// skipMiscImmediates skips the sub-opcode and immediates of an instruction prefixed by OpcodeMiscPrefix.
+func skipMiscImmediates(r *bytes.Reader) error {
+	miscOp, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return err
+	}
+	switch OpcodeMisc(miscOp) {
+	case OpcodeMiscMemoryInit:
+		if err = skipUint32s(r, 1); err == nil {
+			_, err = r.ReadByte() // reserved memory index
+		}
+	case OpcodeMiscMemoryCopy:
+		_, err = r.Seek(2, 1) // reserved memory indices
+	case OpcodeMiscMemoryFill:
+		_, err = r.ReadByte() // reserved memory index
+	case OpcodeMiscDataDrop, OpcodeMiscElemDrop, OpcodeMiscTableGrow, OpcodeMiscTableSize, OpcodeMiscTableFill:
+		err = skipUint32s(r, 1)
+	case OpcodeMiscTableInit, OpcodeMiscTableCopy:
+		err = skipUint32s(r, 2)
+	}
+	return err
+}
+
+func skipUint32s(r *bytes.Reader, count int) (err error) {
+	for i := 0; i < count && err == nil; i++ {
+		_, _, err = leb128.DecodeUint32(r)
+	}
+	return
+}
@@ -0,0 +1,96 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_ReachableFunctions(t *testing.T) {
+	five := Index(5)
+	m := &Module{
+		TypeSection:     []*FunctionType{{}},
+		ImportSection:   []*Import{{Module: "env", Name: "log", Type: ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []Index{0, 0, 0, 0, 0, 0},
+		CodeSection: []*Code{
+			// func[1] calls func[2]
+			{Body: []byte{OpcodeCall, 2, OpcodeEnd}},
+			// func[2] calls itself and func[3]
+			{Body: []byte{OpcodeI32Const, 0, OpcodeIf, 0x40, OpcodeCall, 2, OpcodeEnd, OpcodeCall, 3, OpcodeEnd}},
+			// func[3] calls the import and func[2]
+			{Body: []byte{OpcodeCall, 0, OpcodeCall, 2, OpcodeEnd}},
+			// func[4] is never called
+			{Body: []byte{OpcodeCall, 1, OpcodeEnd}},
+			// func[5] is only in a table
+			{Body: []byte{OpcodeI64Const, 0x10, OpcodeDrop, OpcodeEnd}},
+			// func[6] calls indirectly
+			{Body: []byte{OpcodeI32Const, 0, OpcodeCallIndirect, 0, 0, OpcodeEnd}},
+		},
+		TableSection: []*Table{{Min: 1, Type: RefTypeFuncref}},
+		ElementSection: []*ElementSegment{{
+			OffsetExpr: &ConstantExpression{Opcode: OpcodeI32Const, Data: []byte{0}},
+			Init:       []*Index{&five},
+			Type:       RefTypeFuncref,
+		}},
+		ExportSection: []*Export{
+			{Type: ExternTypeFunc, Name: "run", Index: 1},
+			{Type: ExternTypeFunc, Name: "log", Index: 0},
+			{Type: ExternTypeFunc, Name: "indirect", Index: 6},
+			{Type: ExternTypeTable, Name: "table", Index: 0},
+		},
+	}
+	require.NoError(t, m.Validate(Features20191205))
+
+	tests := []struct {
+		name       string
+		exportName string
+		expected   []Index
+	}{
+		{
+			name:       "recursive calls",
+			exportName: "run",
+			expected:   []Index{0, 1, 2, 3},
+		},
+		{
+			name:       "imported",
+			exportName: "log",
+			expected:   []Index{0},
+		},
+		{
+			name:       "call_indirect",
+			exportName: "indirect",
+			expected:   []Index{5, 6},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			reachable, err := m.ReachableFunctions(tc.exportName)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, reachable)
+		})
+	}
+
+	t.Run("not an exported function", func(t *testing.T) {
+		_, err := m.ReachableFunctions("table")
+		require.EqualError(t, err, `"table" is not an exported function`)
+	})
+}
+
+func TestScanCalls(t *testing.T) {
+	refs, err := scanCalls([]byte{
+		OpcodeI32Const, 0x10, // 0x10 is the call opcode, but this is an immediate.
+		OpcodeI32Load, 0x2, 0x10,
+		OpcodeMiscPrefix, OpcodeMiscMemoryFill, 0x0,
+		OpcodeRefFunc, 4,
+		OpcodeDrop,
+		OpcodeCall, 1,
+		OpcodeI32Const, 0,
+		OpcodeBrTable, 2, 0x10, 0x10, 0x10,
+		OpcodeEnd,
+	})
+	require.NoError(t, err)
+	require.Equal(t, functionRefs{calls: []Index{1}, refFuncs: []Index{4}}, refs)
+}
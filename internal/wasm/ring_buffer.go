@@ -0,0 +1,72 @@
+package wasm
+
+import (
+	"io"
+	"sync"
+)
+
+// RingBuffer is an io.Writer which writes through to another, retaining only the most recent bytes written.
+// See wazero.ModuleConfig WithStderrRingBuffer
+type RingBuffer struct {
+	w   io.Writer
+	mux sync.Mutex
+	buf []byte
+	// pos is the index in buf of the next byte to write, which is also the oldest byte when full.
+	pos  int
+	full bool
+}
+
+// NewRingBuffer returns a RingBuffer which writes through to w, retaining the last size bytes. A nil w defaults to
+// io.Discard.
+func NewRingBuffer(w io.Writer, size int) *RingBuffer {
+	if w == nil {
+		w = io.Discard
+	}
+	return &RingBuffer{w: w, buf: make([]byte, size)}
+}
+
+// Write implements io.Writer, retaining the bytes the underlying writer accepted.
+func (r *RingBuffer) Write(p []byte) (int, error) {
+	n, err := r.w.Write(p)
+	r.mux.Lock()
+	r.retain(p[:n])
+	r.mux.Unlock()
+	return n, err
+}
+
+// retain copies p into the ring, overwriting the oldest bytes once full.
+func (r *RingBuffer) retain(p []byte) {
+	size := len(r.buf)
+	if size == 0 {
+		return
+	}
+	if len(p) >= size { // only the tail of p fits
+		copy(r.buf, p[len(p)-size:])
+		r.pos, r.full = 0, true
+		return
+	}
+	n := copy(r.buf[r.pos:], p)
+	if n < len(p) { // wrap around
+		copy(r.buf, p[n:])
+		r.full = true
+	}
+	r.pos = (r.pos + len(p)) % size
+	if r.pos == 0 {
+		r.full = true
+	}
+}
+
+// Bytes returns a copy of the retained bytes, oldest first.
+//
+// Note: These are raw bytes, so when older bytes were overwritten, the result may begin in the middle of a multi-byte
+// UTF-8 character.
+func (r *RingBuffer) Bytes() []byte {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	if !r.full {
+		return append([]byte{}, r.buf[:r.pos]...)
+	}
+	ret := make([]byte, 0, len(r.buf))
+	ret = append(ret, r.buf[r.pos:]...)
+	return append(ret, r.buf[:r.pos]...)
+}
@@ -0,0 +1,83 @@
+package wasm
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestRingBuffer(t *testing.T) {
+	tests := []struct {
+		name     string
+		size     int
+		writes   []string
+		expected string
+	}{
+		{
+			name:     "empty",
+			size:     4,
+			expected: "",
+		},
+		{
+			name:     "under size",
+			size:     4,
+			writes:   []string{"ab"},
+			expected: "ab",
+		},
+		{
+			name:     "exactly size",
+			size:     4,
+			writes:   []string{"ab", "cd"},
+			expected: "abcd",
+		},
+		{
+			name:     "wraps around",
+			size:     4,
+			writes:   []string{"abc", "def"},
+			expected: "cdef",
+		},
+		{
+			name:     "write larger than size",
+			size:     4,
+			writes:   []string{"ab", "cdefghij"},
+			expected: "ghij",
+		},
+		{
+			name:     "many small writes",
+			size:     4,
+			writes:   []string{"a", "b", "c", "d", "e", "f"},
+			expected: "cdef",
+		},
+		{
+			name:     "zero size",
+			size:     0,
+			writes:   []string{"abc"},
+			expected: "",
+		},
+		{
+			name:     "oldest bytes split UTF-8",
+			size:     3,
+			writes:   []string{"a€b"}, // € is 3 bytes: 0xe2 0x82 0xac
+			expected: "\x82\xacb",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r := NewRingBuffer(&out, tc.size)
+			var all string
+			for _, w := range tc.writes {
+				n, err := r.Write([]byte(w))
+				require.NoError(t, err)
+				require.Equal(t, len(w), n)
+				all += w
+			}
+			require.Equal(t, all, out.String())
+			require.Equal(t, tc.expected, string(r.Bytes()))
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strings"
 	"sync"
 
 	"github.com/tetratelabs/wazero/api"
@@ -29,6 +30,13 @@ type (
 		// EnabledFeatures are read-only to allow optimizations.
 		EnabledFeatures Features
 
+		// MemoryCapacityPages is RuntimeConfig.WithMemoryCapacityPages, which re-evaluates MemoryInstance.Cap when
+		// memory grows beyond it. When nil, the capacity is the new size.
+		MemoryCapacityPages func(minPages uint32, maxPages *uint32) uint32
+
+		// CallHooks is RuntimeConfig.WithCallHook, which wraps each api.Function Call not made during another.
+		CallHooks *CallHooks
+
 		// Engine is a global context for a Store which is in responsible for compilation and execution of Wasm modules.
 		Engine Engine
 
@@ -42,9 +50,15 @@ type (
 		// do type-checks on indirect function calls.
 		typeIDs map[string]FunctionTypeID
 
-		// functionMaxTypes represents the limit on the number of function types in a store.
-		// Note: this is fixed to 2^27 but have this a field for testability.
-		functionMaxTypes uint32
+		// FunctionMaxTypes is the limit on the number of function types in a store, which defaults to
+		// MaximumFunctionTypes. See RuntimeConfig.WithMaxFunctionTypes
+		FunctionMaxTypes uint32
+
+		// PerModuleTypeIDs assigns each module its own FunctionTypeID namespace, instead of typeIDs, when true. IDs of
+		// different modules aren't comparable, so the Engine must compare signatures for a call_indirect of a function
+		// from another module. FunctionMaxTypes then limits the types of each module.
+		// See RuntimeConfig.WithPerModuleTypeIDs
+		PerModuleTypeIDs bool
 
 		// mux is used to guard the fields from concurrent access.
 		mux sync.RWMutex
@@ -163,6 +177,9 @@ type (
 		// Val holds a 64-bit representation of the actual value.
 		Val uint64
 		// ^^ TODO: this should be guarded with atomics when mutable
+
+		// Watch is called when Val changes, if set by CallContext.WatchGlobal.
+		Watch func(old, new uint64)
 	}
 
 	// FunctionTypeID is a uniquely assigned integer for a function type.
@@ -171,34 +188,35 @@ type (
 	FunctionTypeID uint32
 )
 
-// Index implements the same method as documented on experimental.FunctionDefinition.
+// Index implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) Index() uint32 {
 	return f.Idx
 }
 
-// Name implements the same method as documented on experimental.FunctionDefinition.
+// Name implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) Name() string {
 	return f.name
 }
 
-// ModuleName implements the same method as documented on experimental.FunctionDefinition.
+// ModuleName implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) ModuleName() string {
 	return f.moduleName
 }
 
-// ExportNames implements the same method as documented on experimental.FunctionDefinition.
+// ExportNames implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) ExportNames() []string {
 	return f.exportNames
 }
 
-// ParamNames implements the same method as documented on experimental.FunctionDefinition.
+// ParamNames implements the same method as documented on api.FunctionDefinition.
 func (f *FunctionInstance) ParamNames() []string {
 	return f.paramNames
 }
 
 // The wazero specific limitations described at RATIONALE.md.
 const (
-	maximumFunctionTypes = 1 << 27
+	// MaximumFunctionTypes is the default and maximum value of Store.FunctionMaxTypes.
+	MaximumFunctionTypes = 1 << 27
 )
 
 // addSections adds section elements to the ModuleInstance
@@ -270,26 +288,32 @@ func (m *ModuleInstance) buildExports(exports []*Export) {
 	}
 }
 
-func (m *ModuleInstance) validateData(data []*DataSegment) (err error) {
-	for _, d := range data {
-		if !d.IsPassive() {
-			offset := int(executeConstExpression(m.Globals, d.OffsetExpression).(int32))
-			ceil := offset + len(d.Init)
-			if offset < 0 || ceil > len(m.Memory.Buffer) {
-				return fmt.Errorf("out of bounds memory access")
+// validateData returns an error if any active data segment doesn't fit in memory, describing which and why.
+//
+// Note: Passive data segments have no offset, so are skipped via Module.activeData.
+func (m *ModuleInstance) validateData(module *Module) (err error) {
+	for _, i := range module.activeData() {
+		d := module.DataSection[i]
+		// The offset is an unsigned i32, even though executeConstExpression returns int32.
+		offset := uint32(executeConstExpression(m.Globals, d.OffsetExpression).(int32))
+		if ceil := uint64(offset) + uint64(len(d.Init)); ceil > uint64(len(m.Memory.Buffer)) {
+			var from string
+			if d.OffsetExpression.Opcode == OpcodeGlobalGet {
+				id, _, _ := leb128.DecodeUint32(bytes.NewReader(d.OffsetExpression.Data))
+				from = fmt.Sprintf(" (from global[%d])", id)
 			}
+			return fmt.Errorf("data[%d]: out of bounds memory access: offset %d%s + length %d > memory size %d",
+				i, offset, from, len(d.Init), len(m.Memory.Buffer))
 		}
-
 	}
 	return
 }
 
-func (m *ModuleInstance) applyData(data []*DataSegment) {
-	for _, d := range data {
-		if !d.IsPassive() {
-			offset := executeConstExpression(m.Globals, d.OffsetExpression).(int32)
-			copy(m.Memory.Buffer[offset:], d.Init)
-		}
+func (m *ModuleInstance) applyData(module *Module) {
+	for _, i := range module.activeData() {
+		d := module.DataSection[i]
+		offset := uint32(executeConstExpression(m.Globals, d.OffsetExpression).(int32))
+		copy(m.Memory.Buffer[offset:], d.Init)
 	}
 }
 
@@ -312,7 +336,7 @@ func NewStore(enabledFeatures Features, engine Engine) *Store {
 		moduleNames:      map[string]struct{}{},
 		modules:          map[string]*ModuleInstance{},
 		typeIDs:          map[string]FunctionTypeID{},
-		functionMaxTypes: maximumFunctionTypes,
+		FunctionMaxTypes: MaximumFunctionTypes,
 	}
 }
 
@@ -330,6 +354,21 @@ func (s *Store) Instantiate(
 	name string,
 	sys *SysContext,
 	functionListenerFactory experimentalapi.FunctionListenerFactory,
+) (*CallContext, error) {
+	return s.InstantiateWithPreStart(ctx, module, name, sys, functionListenerFactory, nil)
+}
+
+// InstantiateWithPreStart is like Instantiate, except preStart, if non-nil, is called after the data segments are
+// applied, but before the start function. If preStart errs, instantiation fails with its error, releasing the name.
+//
+// See wazero.ModuleConfig WithPreStartHook
+func (s *Store) InstantiateWithPreStart(
+	ctx context.Context,
+	module *Module,
+	name string,
+	sys *SysContext,
+	functionListenerFactory experimentalapi.FunctionListenerFactory,
+	preStart func(ctx context.Context, callCtx *CallContext) error,
 ) (*CallContext, error) {
 	if ctx == nil {
 		ctx = context.Background()
@@ -357,6 +396,14 @@ func (s *Store) Instantiate(
 		return nil, err
 	}
 	globals, memory := module.buildGlobals(importedGlobals), module.buildMemory()
+	if memory != nil && s.MemoryCapacityPages != nil {
+		var maxPages *uint32
+		if module.MemorySection.IsMaxEncoded {
+			max := module.MemorySection.Max
+			maxPages = &max
+		}
+		memory.capacityPages = func(minPages uint32) uint32 { return s.MemoryCapacityPages(minPages, maxPages) }
+	}
 
 	// If there are no module-defined functions, assume this is a host module.
 	var functions []*FunctionInstance
@@ -373,7 +420,7 @@ func (s *Store) Instantiate(
 	m := &ModuleInstance{Name: name}
 	m.addSections(module, importedFunctions, functions, importedGlobals, globals, tables, importedMemory, memory, module.TypeSection, typeIDs)
 
-	if err = m.validateData(module.DataSection); err != nil {
+	if err = m.validateData(module); err != nil {
 		s.deleteModule(name)
 		return nil, err
 	}
@@ -388,18 +435,31 @@ func (s *Store) Instantiate(
 	m.buildElementInstances(module.ElementSection)
 
 	// Now all the validation passes, we are safe to mutate memory instances (possibly imported ones).
-	m.applyData(module.DataSection)
+	m.applyData(module)
 
 	// Build the default context for calls to this module.
 	m.CallCtx = NewCallContext(s, m, sys)
 
+	if preStart != nil {
+		if err = preStart(ctx, m.CallCtx); err != nil {
+			s.deleteModule(name)
+			return nil, err
+		}
+	}
+
 	// Execute the start function.
 	if module.StartSection != nil {
 		funcIdx := *module.StartSection
+		// When imported, f is the function of the exporting module, possibly a host function. Either way, it is
+		// called in the context of this module, the same as any other call to an imported function.
 		f := m.Functions[funcIdx]
 		if _, err = f.Module.Engine.Call(ctx, m.CallCtx, f); err != nil {
 			s.deleteModule(name)
-			return nil, fmt.Errorf("start %s failed: %w", module.funcDesc(funcSection, funcIdx), err)
+			desc := module.funcDesc(funcSection, funcIdx)
+			if funcIdx < module.ImportFuncCount() {
+				desc = fmt.Sprintf("imported %s", f.DebugName)
+			}
+			return nil, &StartError{FuncName: f.DebugName, desc: desc, Err: err}
 		}
 	}
 
@@ -408,6 +468,26 @@ func (s *Store) Instantiate(
 	return m.CallCtx, nil
 }
 
+// StartError is returned by Store.Instantiate when the start function of the module fails.
+type StartError struct {
+	// FuncName is the FunctionInstance DebugName of the start function.
+	FuncName string
+	// desc describes the start function in the error message, ex. "function[0]".
+	desc string
+	// Err is the error the start function failed with.
+	Err error
+}
+
+// Error implements error
+func (e *StartError) Error() string {
+	return fmt.Sprintf("start %s failed: %v", e.desc, e.Err)
+}
+
+// Unwrap implements errors.Unwrap
+func (e *StartError) Unwrap() error {
+	return e.Err
+}
+
 // deleteModule makes the moduleName available for instantiation again.
 func (s *Store) deleteModule(moduleName string) {
 	s.mux.Lock()
@@ -484,7 +564,7 @@ func (s *Store) resolveImports(module *Module) (
 
 			actualType := importedFunction.Type
 			if !expectedType.EqualsSignature(actualType.Params, actualType.Results) {
-				err = errorInvalidImport(i, idx, fmt.Errorf("signature mismatch: %s != %s", expectedType, actualType))
+				err = errorInvalidImport(i, idx, errorSignatureMismatch(expectedType, actualType))
 				return
 			}
 
@@ -493,6 +573,12 @@ func (s *Store) resolveImports(module *Module) (
 			expected := i.DescTable
 			importedTable := imported.Table
 
+			if expected.Type != importedTable.Type {
+				err = errorInvalidImport(i, idx, fmt.Errorf("element type mismatch: %s != %s",
+					RefTypeName(expected.Type), RefTypeName(importedTable.Type)))
+				return
+			}
+
 			if expected.Min > importedTable.Min {
 				err = errorMinSizeMismatch(i, idx, expected.Min, importedTable.Min)
 				return
@@ -559,6 +645,28 @@ func errorInvalidImport(i *Import, idx int, err error) error {
 	return fmt.Errorf("import[%d] %s[%s.%s]: %w", idx, ExternTypeName(i.Type), i.Module, i.Name, err)
 }
 
+// errorSignatureMismatch returns an error describing how the actual type differs from the expected one. When the count
+// of params or results differs, this states the counts. Otherwise, it lists each param or result of a different type.
+//
+// Ex. "signature mismatch: i32i32_i32 != i32f32_i32: param[1] i32 != f32"
+func errorSignatureMismatch(expected, actual *FunctionType) error {
+	diffs := appendValueTypeDiffs(nil, "param", expected.Params, actual.Params)
+	diffs = appendValueTypeDiffs(diffs, "result", expected.Results, actual.Results)
+	return fmt.Errorf("signature mismatch: %s != %s: %s", expected, actual, strings.Join(diffs, ", "))
+}
+
+func appendValueTypeDiffs(diffs []string, kind string, expected, actual []ValueType) []string {
+	if len(expected) != len(actual) {
+		return append(diffs, fmt.Sprintf("%s count %d != %d", kind, len(expected), len(actual)))
+	}
+	for i, t := range expected {
+		if t != actual[i] {
+			diffs = append(diffs, fmt.Sprintf("%s[%d] %s != %s", kind, i, ValueTypeName(t), ValueTypeName(actual[i])))
+		}
+	}
+	return diffs
+}
+
 // Global initialization constant expression can only reference the imported globals.
 // See the note on https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#constant-expressions%E2%91%A0
 func executeConstExpression(globals []*GlobalInstance, expr *ConstantExpression) (v interface{}) {
@@ -592,6 +700,10 @@ func executeConstExpression(globals []*GlobalInstance, expr *ConstantExpression)
 }
 
 func (s *Store) getFunctionTypeIDs(ts []*FunctionType) ([]FunctionTypeID, error) {
+	if s.PerModuleTypeIDs {
+		return s.getModuleFunctionTypeIDs(ts)
+	}
+
 	// We take write-lock here as the following might end up mutating typeIDs map.
 	s.mux.Lock()
 	defer s.mux.Unlock()
@@ -606,12 +718,31 @@ func (s *Store) getFunctionTypeIDs(ts []*FunctionType) ([]FunctionTypeID, error)
 	return ret, nil
 }
 
+// getModuleFunctionTypeIDs is like getFunctionTypeIDs, except IDs are only unique within the types of a module.
+func (s *Store) getModuleFunctionTypeIDs(ts []*FunctionType) ([]FunctionTypeID, error) {
+	typeIDs := map[string]FunctionTypeID{}
+	ret := make([]FunctionTypeID, len(ts))
+	for i, t := range ts {
+		key := t.String()
+		id, ok := typeIDs[key]
+		if !ok {
+			if uint32(len(typeIDs)) >= s.FunctionMaxTypes {
+				return nil, fmt.Errorf("too many function types in a module")
+			}
+			id = FunctionTypeID(len(typeIDs))
+			typeIDs[key] = id
+		}
+		ret[i] = id
+	}
+	return ret, nil
+}
+
 func (s *Store) getFunctionTypeID(t *FunctionType) (FunctionTypeID, error) {
 	key := t.String()
 	id, ok := s.typeIDs[key]
 	if !ok {
 		l := uint32(len(s.typeIDs))
-		if l >= s.functionMaxTypes {
+		if l >= s.FunctionMaxTypes {
 			return 0, fmt.Errorf("too many function types in a store")
 		}
 		id = FunctionTypeID(len(s.typeIDs))
@@ -399,6 +399,11 @@ func (me *mockModuleEngine) CreateFuncElementInstance([]*Index) *ElementInstance
 	return nil
 }
 
+// ReferencedFunction implements the same method as documented on wasm.ModuleEngine.
+func (e *mockModuleEngine) ReferencedFunction(Reference) *FunctionInstance {
+	return nil
+}
+
 // Name implements the same method as documented on wasm.ModuleEngine.
 func (e *mockModuleEngine) Name() string {
 	return e.name
@@ -421,7 +426,7 @@ func TestStore_getFunctionTypeID(t *testing.T) {
 	t.Run("too many functions", func(t *testing.T) {
 		s := newStore()
 		const max = 10
-		s.functionMaxTypes = max
+		s.FunctionMaxTypes = max
 		s.typeIDs = make(map[string]FunctionTypeID)
 		for i := 0; i < max; i++ {
 			s.typeIDs[strconv.Itoa(i)] = 0
@@ -590,7 +595,26 @@ func TestStore_resolveImports(t *testing.T) {
 				ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 0}},
 			}
 			_, _, _, _, err := s.resolveImports(m)
-			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: v_f32 != v_v")
+			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: v_f32 != v_v: result count 1 != 0")
+		})
+		t.Run("signature mismatch param type", func(t *testing.T) {
+			s := newStore()
+			s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{name: {
+				Function: &FunctionInstance{Type: &FunctionType{
+					Params:  []ValueType{ValueTypeI32, ValueTypeF32, ValueTypeI64},
+					Results: []ValueType{ValueTypeF64},
+				}},
+			}}, Name: moduleName}
+			m := &Module{
+				TypeSection: []*FunctionType{{
+					Params:  []ValueType{ValueTypeI32, ValueTypeI32, ValueTypeI64},
+					Results: []ValueType{ValueTypeI64},
+				}},
+				ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeFunc, DescFunc: 0}},
+			}
+			_, _, _, _, err := s.resolveImports(m)
+			require.EqualError(t, err, "import[0] func[test.target]: signature mismatch: i32i32i64_i64 != i32f32i64_f64: "+
+				"param[1] i32 != f32, result[0] i64 != f64")
 		})
 	})
 	t.Run("global", func(t *testing.T) {
@@ -621,6 +645,28 @@ func TestStore_resolveImports(t *testing.T) {
 			require.EqualError(t, err, "import[0] global[test.target]: value type mismatch: f64 != i32")
 		})
 	})
+	t.Run("table", func(t *testing.T) {
+		t.Run("ok", func(t *testing.T) {
+			s := newStore()
+			tableInst := &TableInstance{Min: 1, Type: RefTypeExternref}
+			s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{name: {
+				Type:  ExternTypeTable,
+				Table: tableInst,
+			}}, Name: moduleName}
+			_, _, tables, _, err := s.resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: &Table{Min: 1, Type: RefTypeExternref}}}})
+			require.NoError(t, err)
+			require.Equal(t, []*TableInstance{tableInst}, tables)
+		})
+		t.Run("element type mismatch", func(t *testing.T) {
+			s := newStore()
+			s.modules[moduleName] = &ModuleInstance{Exports: map[string]*ExportInstance{name: {
+				Type:  ExternTypeTable,
+				Table: &TableInstance{Min: 1, Type: RefTypeExternref},
+			}}, Name: moduleName}
+			_, _, _, _, err := s.resolveImports(&Module{ImportSection: []*Import{{Module: moduleName, Name: name, Type: ExternTypeTable, DescTable: &Table{Min: 1, Type: RefTypeFuncref}}}})
+			require.EqualError(t, err, "import[0] table[test.target]: element type mismatch: funcref != externref")
+		})
+	})
 	t.Run("memory", func(t *testing.T) {
 		t.Run("ok", func(t *testing.T) {
 			s := newStore()
@@ -659,39 +705,76 @@ func TestStore_resolveImports(t *testing.T) {
 }
 
 func TestModuleInstance_validateData(t *testing.T) {
-	m := &ModuleInstance{Memory: &MemoryInstance{Buffer: make([]byte, 5)}}
+	m := &ModuleInstance{
+		Memory:  &MemoryInstance{Buffer: make([]byte, 5)},
+		Globals: []*GlobalInstance{{Type: &GlobalType{ValType: ValueTypeI32}, Val: 4}}, // ex. imported
+	}
 	for _, tc := range []struct {
-		name   string
-		data   []*DataSegment
-		expErr bool
+		name        string
+		data        []*DataSegment
+		expectedErr string
 	}{
 		{
 			name: "ok",
 			data: []*DataSegment{
 				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}, Init: []byte{0}},
 				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeInt32(2)}, Init: []byte{0}},
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeGlobalGet, Data: []byte{0}}, Init: []byte{0}},
 			},
 		},
+		{
+			name: "ok - passive segments are skipped",
+			data: []*DataSegment{
+				{Init: make([]byte, 10)}, // would be out of bounds at any offset
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}, Init: []byte{0}},
+				{Init: make([]byte, 10)},
+			},
+		},
+		{
+			name: "out of bounds - active after passive",
+			data: []*DataSegment{
+				{Init: make([]byte, 10)},
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const1}, Init: []byte{0}},
+				{Init: make([]byte, 10)},
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeInt32(4)}, Init: []byte{0, 1}},
+			},
+			expectedErr: "data[3]: out of bounds memory access: offset 4 + length 2 > memory size 5",
+		},
 		{
 			name: "out of bounds - single one byte",
 			data: []*DataSegment{
 				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeInt32(5)}, Init: []byte{0}},
 			},
-			expErr: true,
+			expectedErr: "data[0]: out of bounds memory access: offset 5 + length 1 > memory size 5",
 		},
 		{
 			name: "out of bounds - multi bytes",
 			data: []*DataSegment{
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}, Init: []byte{0}},
 				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeInt32(3)}, Init: []byte{0, 1, 2}},
 			},
-			expErr: true,
+			expectedErr: "data[1]: out of bounds memory access: offset 3 + length 3 > memory size 5",
+		},
+		{
+			name: "out of bounds - negative offset is unsigned",
+			data: []*DataSegment{
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeInt32(-1)}, Init: []byte{0}},
+			},
+			expectedErr: "data[0]: out of bounds memory access: offset 4294967295 + length 1 > memory size 5",
+		},
+		{
+			name: "out of bounds - offset from global",
+			data: []*DataSegment{
+				{OffsetExpression: &ConstantExpression{Opcode: OpcodeGlobalGet, Data: []byte{0}}, Init: []byte{0, 1}},
+			},
+			expectedErr: "data[0]: out of bounds memory access: offset 4 (from global[0]) + length 2 > memory size 5",
 		},
 	} {
 		tc := tc
 		t.Run(tc.name, func(t *testing.T) {
-			err := m.validateData(tc.data)
-			if tc.expErr {
-				require.Error(t, err)
+			err := m.validateData(&Module{DataSection: tc.data})
+			if tc.expectedErr != "" {
+				require.EqualError(t, err, tc.expectedErr)
 			} else {
 				require.NoError(t, err)
 			}
@@ -701,10 +784,11 @@ func TestModuleInstance_validateData(t *testing.T) {
 
 func TestModuleInstance_applyData(t *testing.T) {
 	m := &ModuleInstance{Memory: &MemoryInstance{Buffer: make([]byte, 10)}}
-	m.applyData([]*DataSegment{
+	m.applyData(&Module{DataSection: []*DataSegment{
 		{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: const0}, Init: []byte{0xa, 0xf}},
+		{Init: []byte{0xb, 0xc}}, // passive
 		{OffsetExpression: &ConstantExpression{Opcode: OpcodeI32Const, Data: leb128.EncodeUint32(8)}, Init: []byte{0x1, 0x5}},
-	})
+	}})
 	require.Equal(t, []byte{0xa, 0xf, 0x0, 0x0, 0x0, 0x0, 0x0, 0x0, 0x1, 0x5}, m.Memory.Buffer)
 }
 
@@ -32,16 +32,28 @@ type SysContext struct {
 
 	// lastFD is not meant to be read directly. Rather by nextFD.
 	lastFD uint32
+
+	// noFSErrno is set by SetNoFSErrno.
+	noFSErrno *uint32
+
+	// writers are file descriptor numbers (>=3) set by SetWriter.
+	writers map[uint32]io.Writer
 }
 
-// nextFD gets the next file descriptor number in a goroutine safe way (monotonically) or zero if we ran out.
+// nextFD gets the next file descriptor number in a goroutine safe way (monotonically) or zero if we ran out. File
+// descriptors reserved by SetWriter are skipped.
 // TODO: opendFiles is still not goroutine safe!
 // TODO: This can return zero if we ran out of file descriptors. A future change can optimize by re-using an FD pool.
 func (c *SysContext) nextFD() uint32 {
-	if c.lastFD == math.MaxUint32 {
-		return 0
+	for {
+		if atomic.LoadUint32(&c.lastFD) == math.MaxUint32 {
+			return 0
+		}
+		fd := atomic.AddUint32(&c.lastFD, 1)
+		if _, ok := c.writers[fd]; !ok {
+			return fd
+		}
 	}
-	return atomic.AddUint32(&c.lastFD, 1)
 }
 
 // Args is like os.Args and defaults to nil.
@@ -96,6 +108,25 @@ func (c *SysContext) Stderr() io.Writer {
 	return c.stderr
 }
 
+// RecentStdout returns the most recent bytes written to Stdout, or nil if it isn't a RingBuffer.
+// See wazero.ModuleConfig WithStdoutRingBuffer
+func (c *SysContext) RecentStdout() []byte {
+	return recentBytes(c.stdout)
+}
+
+// RecentStderr returns the most recent bytes written to Stderr, or nil if it isn't a RingBuffer.
+// See wazero.ModuleConfig WithStderrRingBuffer
+func (c *SysContext) RecentStderr() []byte {
+	return recentBytes(c.stderr)
+}
+
+func recentBytes(w io.Writer) []byte {
+	if r, ok := w.(*RingBuffer); ok {
+		return r.Bytes()
+	}
+	return nil
+}
+
 // eofReader is safer than reading from os.DevNull as it can never overrun operating system file descriptors.
 type eofReader struct{}
 
@@ -225,6 +256,36 @@ func (c *SysContext) CloseFile(fd uint32) (bool, error) {
 	return true, nil
 }
 
+// SetNoFSErrno sets the errno to return when a path is opened, as no file system is configured.
+// See wazero.ModuleConfig WithNoFSErrno
+func (c *SysContext) SetNoFSErrno(errno uint32) {
+	c.noFSErrno = &errno
+}
+
+// NoFSErrno returns the errno set by SetNoFSErrno, or false if not set.
+func (c *SysContext) NoFSErrno() (uint32, bool) {
+	if c.noFSErrno == nil {
+		return 0, false
+	}
+	return *c.noFSErrno, true
+}
+
+// SetWriter sets the writer of a file descriptor (>=3) which isn't an opened file. File descriptors opened later skip
+// it, without skipping those in between, so even a large fd such as math.MaxUint32 leaves the rest available.
+// See wazero.ModuleConfig WithWriter
+func (c *SysContext) SetWriter(fd uint32, w io.Writer) {
+	if c.writers == nil {
+		c.writers = map[uint32]io.Writer{}
+	}
+	c.writers[fd] = w
+}
+
+// Writer returns the writer set by SetWriter or nil and false, if not.
+func (c *SysContext) Writer(fd uint32) (io.Writer, bool) {
+	w, ok := c.writers[fd]
+	return w, ok
+}
+
 // OpenedFile returns a file and true if it was opened or nil and false, if not.
 func (c *SysContext) OpenedFile(fd uint32) (*FileEntry, bool) {
 	f, ok := c.openedFiles[fd]
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"io"
 	"io/fs"
+	"math"
 	"os"
 	"path"
 	"testing"
@@ -242,6 +243,36 @@ func TestSysContext_Close(t *testing.T) {
 	})
 }
 
+func TestSysContext_SetWriter(t *testing.T) {
+	sys, err := NewSysContext(
+		0,   // max
+		nil, // args
+		nil, // environ
+		nil, // stdin
+		nil, // stdout
+		nil, // stderr
+		map[uint32]*FileEntry{ // openedFiles
+			3: {Path: "/"},
+		},
+	)
+	require.NoError(t, err)
+
+	sys.SetWriter(5, io.Discard)
+	sys.SetWriter(math.MaxUint32, io.Discard)
+
+	// Opened files skip the writers, but not the file descriptors in between.
+	fd, ok := sys.OpenFile(&FileEntry{Path: "a"})
+	require.True(t, ok)
+	require.Equal(t, uint32(4), fd)
+	fd, ok = sys.OpenFile(&FileEntry{Path: "b"})
+	require.True(t, ok)
+	require.Equal(t, uint32(6), fd)
+
+	w, ok := sys.Writer(math.MaxUint32)
+	require.True(t, ok)
+	require.Equal(t, io.Discard, w)
+}
+
 // createWriteableFile uses real files when io.Writer tests are needed.
 func createWriteableFile(t *testing.T, tmpDir string, pathName string, data []byte) (fs.File, fs.FS) {
 	require.NotNil(t, data)
@@ -2,9 +2,11 @@ package wasm
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"math"
 
+	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/internal/leb128"
 )
 
@@ -101,6 +103,31 @@ type TableInstance struct {
 	Type RefType
 }
 
+// exportedTable implements api.Table, resolving funcref elements with the engine of the exporting module.
+type exportedTable struct {
+	table  *TableInstance
+	engine ModuleEngine
+}
+
+// Size implements the same method as documented on api.Table.
+func (t *exportedTable) Size(context.Context) uint32 {
+	return uint32(len(t.table.References))
+}
+
+// GetFunction implements the same method as documented on api.Table.
+func (t *exportedTable) GetFunction(_ context.Context, index uint32) (api.FunctionDefinition, error) {
+	if t.table.Type != RefTypeFuncref {
+		return nil, fmt.Errorf("table of %s is not a table of funcref", RefTypeName(t.table.Type))
+	}
+	if size := uint32(len(t.table.References)); index >= size {
+		return nil, fmt.Errorf("index %d is out of range of table with %d elements", index, size)
+	}
+	if f := t.engine.ReferencedFunction(t.table.References[index]); f != nil {
+		return f, nil
+	}
+	return nil, nil // as opposed to a typed nil, which would not equal nil.
+}
+
 // ElementInstance represents an element instance in a module.
 //
 // See https://www.w3.org/TR/2022/WD-wasm-core-2-20220419/exec/runtime.html#element-instances
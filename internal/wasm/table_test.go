@@ -970,3 +970,41 @@ func TestModule_buildTable_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestExportedTable_GetFunction_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		table       *TableInstance
+		index       uint32
+		expectedErr string
+	}{
+		{
+			name:        "externref",
+			table:       &TableInstance{References: make([]Reference, 1), Type: RefTypeExternref},
+			expectedErr: "table of externref is not a table of funcref",
+		},
+		{
+			name:        "out of range",
+			table:       &TableInstance{References: make([]Reference, 1), Type: RefTypeFuncref},
+			index:       1,
+			expectedErr: "index 1 is out of range of table with 1 elements",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			table := &exportedTable{table: tc.table, engine: &mockModuleEngine{}}
+			_, err := table.GetFunction(testCtx, tc.index)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+
+	t.Run("null", func(t *testing.T) {
+		table := &exportedTable{table: &TableInstance{References: make([]Reference, 1), Type: RefTypeFuncref}, engine: &mockModuleEngine{}}
+		fn, err := table.GetFunction(testCtx, 0)
+		require.NoError(t, err)
+		require.Nil(t, fn)
+	})
+}
@@ -0,0 +1,80 @@
+package text
+
+// annotationParser removes annotations, ex. `(@custom "name" (after func))`, from the tokens passed to the next parser,
+// passing each to a DecodeOptions.Annotation hook instead.
+//
+// Note: An annotation is only recognized when '(' is followed by a tokenReserved beginning with '@'. As standard text
+// format has no such token, any other source passes through unchanged.
+// See https://github.com/WebAssembly/annotations/blob/main/proposals/annotations/Overview.md
+type annotationParser struct {
+	onAnnotation func(name string, tokens []string) error
+
+	// next is the parser of the next token outside an annotation.
+	next tokenParser
+
+	// lParenLine and lParenCol are the position of a '(' withheld from next until we know it isn't an annotation.
+	lParenLine, lParenCol uint32
+
+	// name is the name of the current annotation, without the '@' prefix.
+	name string
+
+	// tokens are the tokens of the current annotation after its name, including any nested parens.
+	tokens []string
+
+	// depth is the paren depth of the current annotation, which ends when this returns to zero.
+	depth int
+}
+
+func newAnnotationParser(onAnnotation func(name string, tokens []string) error, next tokenParser) *annotationParser {
+	return &annotationParser{onAnnotation: onAnnotation, next: next}
+}
+
+// parse withholds '(' until the next token, and otherwise passes the token to the next parser.
+func (p *annotationParser) parse(tok tokenType, tokenBytes []byte, line, col uint32) (tokenParser, error) {
+	if tok == tokenLParen {
+		p.lParenLine, p.lParenCol = line, col
+		return p.afterLParen, nil
+	}
+	return p.forward(tok, tokenBytes, line, col)
+}
+
+// afterLParen begins an annotation if the token is its name (ex. "@custom"), or otherwise passes both the withheld '('
+// and the token to the next parser.
+func (p *annotationParser) afterLParen(tok tokenType, tokenBytes []byte, line, col uint32) (tokenParser, error) {
+	if tok == tokenReserved && len(tokenBytes) > 1 && tokenBytes[0] == '@' {
+		p.name, p.tokens, p.depth = string(tokenBytes[1:]), nil, 1
+		return p.parseAnnotation, nil
+	}
+	if _, err := p.forward(tokenLParen, constantLParen, p.lParenLine, p.lParenCol); err != nil {
+		return nil, err
+	}
+	return p.parse(tok, tokenBytes, line, col)
+}
+
+// parseAnnotation records tokens until the annotation's closing ')', then calls the hook.
+func (p *annotationParser) parseAnnotation(tok tokenType, tokenBytes []byte, _, _ uint32) (tokenParser, error) {
+	switch tok {
+	case tokenLParen:
+		p.depth++
+	case tokenRParen:
+		p.depth--
+		if p.depth == 0 {
+			if err := p.onAnnotation(p.name, p.tokens); err != nil {
+				return nil, err
+			}
+			return p.parse, nil
+		}
+	}
+	p.tokens = append(p.tokens, string(tokenBytes))
+	return p.parseAnnotation, nil
+}
+
+// forward passes the token to the next parser, returning parse to handle the token after it.
+func (p *annotationParser) forward(tok tokenType, tokenBytes []byte, line, col uint32) (tokenParser, error) {
+	next, err := p.next(tok, tokenBytes, line, col)
+	if err != nil {
+		return nil, err
+	}
+	p.next = next
+	return p.parse, nil
+}
@@ -102,9 +102,27 @@ type moduleParser struct {
 	exportedName map[string]struct{}
 }
 
+// DecodeOptions customizes DecodeModuleWithOptions.
+type DecodeOptions struct {
+	// Annotation is called for each annotation in the source, ex. `(@custom "name" (after func))`, which are otherwise
+	// errors. The name excludes the '@' prefix, ex. "custom", and tokens are those following it, including any nested
+	// parens, ex. `"name"`, `(`, `after`, `func`, `)`.
+	//
+	// Annotations are removed from the source before parsing, so they are never in the resulting wasm.Module. Returning
+	// an error fails decoding at the position of the annotation's closing paren.
+	//
+	// Note: Standard text format has no annotations, so parses identically whether or not this is set.
+	Annotation func(name string, tokens []string) error
+}
+
 // DecodeModule implements wasm.DecodeModule for the WebAssembly 1.0 (20191205) Text Format
 // See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#text-format%E2%91%A0
 func DecodeModule(source []byte, enabledFeatures wasm.Features, memoryLimitPages uint32) (result *wasm.Module, err error) {
+	return DecodeModuleWithOptions(source, enabledFeatures, memoryLimitPages, DecodeOptions{})
+}
+
+// DecodeModuleWithOptions is like DecodeModule, except customized by the given options.
+func DecodeModuleWithOptions(source []byte, enabledFeatures wasm.Features, memoryLimitPages uint32, options DecodeOptions) (result *wasm.Module, err error) {
 	// TODO: when globals are supported, err on global vars if disabled
 
 	// names are the wasm.Module NameSection
@@ -119,7 +137,11 @@ func DecodeModule(source []byte, enabledFeatures wasm.Features, memoryLimitPages
 
 	// A valid source must begin with the token '(', but it could be preceded by whitespace or comments. For this
 	// reason, we cannot enforce source[0] == '(', and instead need to start the lexer to check the first token.
-	line, col, err := lex(p.ensureLParen, p.source)
+	var parser tokenParser = p.ensureLParen
+	if options.Annotation != nil {
+		parser = newAnnotationParser(options.Annotation, parser).parse
+	}
+	line, col, err := lex(parser, p.source)
 	if err != nil {
 		return nil, &FormatError{line, col, p.errorContext(), err}
 	}
@@ -2,13 +2,18 @@ package text
 
 import (
 	_ "embed"
+	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
 
 func TestDecodeModule(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	zero := uint32(0)
 	localGet0End := []byte{wasm.OpcodeLocalGet, 0x00, wasm.OpcodeEnd}
 
@@ -2181,3 +2186,62 @@ func TestModuleParser_ErrorContext(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeModuleWithOptions_Annotation(t *testing.T) {
+	const standard = `(module $math
+	(type $i32_i32 (func (param i32) (result i32)))
+	(func $id (type $i32_i32) local.get 0)
+	(export "id" (func $id))
+)`
+	const annotated = `(@custom "before" (module))
+(module $math
+	(@custom "types" (after type))
+	(type $i32_i32 (func (param i32) (result i32)))
+	(func $id (@inline) (type $i32_i32) local.get 0)
+	(export "id" (func $id))
+)`
+	expected, err := DecodeModule([]byte(standard), wasm.Features20191205, wasm.MemoryLimitPages)
+	require.NoError(t, err)
+
+	t.Run("standard is identical with hook", func(t *testing.T) {
+		m, err := DecodeModuleWithOptions([]byte(standard), wasm.Features20191205, wasm.MemoryLimitPages, DecodeOptions{
+			Annotation: func(name string, tokens []string) error {
+				t.Fatalf("unexpected annotation: %s", name)
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, expected, m)
+	})
+
+	t.Run("annotations are passed to the hook", func(t *testing.T) {
+		var annotations [][]string
+		m, err := DecodeModuleWithOptions([]byte(annotated), wasm.Features20191205, wasm.MemoryLimitPages, DecodeOptions{
+			Annotation: func(name string, tokens []string) error {
+				annotations = append(annotations, append([]string{name}, tokens...))
+				return nil
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, expected, m)
+		require.Equal(t, [][]string{
+			{"custom", `"before"`, "(", "module", ")"},
+			{"custom", `"types"`, "(", "after", "type", ")"},
+			{"inline"},
+		}, annotations)
+	})
+
+	t.Run("hook error", func(t *testing.T) {
+		_, err := DecodeModuleWithOptions([]byte(annotated), wasm.Features20191205, wasm.MemoryLimitPages, DecodeOptions{
+			Annotation: func(name string, tokens []string) error {
+				return fmt.Errorf("unsupported annotation: %s", name)
+			},
+		})
+		require.EqualError(t, err, "1:27: unsupported annotation: custom")
+	})
+
+	t.Run("errs without hook", func(t *testing.T) {
+		_, err := DecodeModule([]byte(annotated), wasm.Features20191205, wasm.MemoryLimitPages)
+		require.EqualError(t, err, "1:2: expected field, but parsed reserved")
+	})
+}
@@ -0,0 +1,507 @@
+package text
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+	"github.com/tetratelabs/wazero/internal/wasm"
+)
+
+// EncodeModule implements the inverse of DecodeModule, rendering the module in the WebAssembly 1.0 (20191205) Text
+// Format. Module fields are written in the same order as the binary format, and instructions are written in the flat
+// (non s-expression) form, one per line.
+//
+// Indices are always written numerically. IDs (ex. "$main") are only written for the module and functions when the
+// name is present in the wasm.NameSection and only includes valid ID characters.
+//
+// Ex. The "identity" module encodes as:
+//	(module
+//	  (type (func (param i32) (result i32)))
+//	  (func $identity (type 0) (param $x i32) (result i32)
+//	    local.get 0
+//	  )
+//	  (export "identity" (func 0))
+//	)
+//
+// Note: This errs on host modules (wasm.Module HostFunctionSection) as they have no instructions to render.
+// Note: This also errs on module fields DecodeModule doesn't yet support: tables, globals, element and data segments,
+// imports besides functions and shared memory. DecodeModule also doesn't yet support all instructions, so only
+// modules using instructions it supports round-trip.
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#text-format%E2%91%A0
+func EncodeModule(m *wasm.Module) (string, error) {
+	if len(m.HostFunctionSection) > 0 {
+		return "", errors.New("host modules cannot be encoded in the text format")
+	}
+	if err := requireDecodable(m); err != nil {
+		return "", err
+	}
+	if len(m.FunctionSection) != len(m.CodeSection) {
+		return "", fmt.Errorf("function and code section have inconsistent lengths: %d != %d",
+			len(m.FunctionSection), len(m.CodeSection))
+	}
+
+	e := &moduleEncoder{m: m}
+	e.buf.WriteString("(module")
+	if m.NameSection != nil {
+		e.writeID(m.NameSection.ModuleName)
+	}
+	e.buf.WriteByte('\n')
+
+	for _, t := range m.TypeSection {
+		e.buf.WriteString("  (type (func")
+		e.writeSignature(t, nil)
+		e.buf.WriteString("))\n")
+	}
+
+	var importedFuncCount wasm.Index
+	for _, i := range m.ImportSection {
+		e.buf.WriteString("  (import ")
+		writeString(&e.buf, []byte(i.Module))
+		e.buf.WriteByte(' ')
+		writeString(&e.buf, []byte(i.Name))
+		e.buf.WriteString(" (func")
+		if err := e.writeTypeUse(importedFuncCount, i.DescFunc); err != nil {
+			return "", err
+		}
+		importedFuncCount++
+		e.buf.WriteString("))\n")
+	}
+
+	for i, typeIdx := range m.FunctionSection {
+		funcIdx := importedFuncCount + wasm.Index(i)
+		e.buf.WriteString("  (func")
+		if err := e.writeTypeUse(funcIdx, typeIdx); err != nil {
+			return "", err
+		}
+		code := m.CodeSection[i]
+		paramCount := uint32(len(m.TypeSection[typeIdx].Params))
+		localNames := e.localNames(funcIdx)
+		for j, lt := range code.LocalTypes {
+			e.buf.WriteString(" (local")
+			e.writeID(localNames[paramCount+uint32(j)])
+			e.buf.WriteByte(' ')
+			e.buf.WriteString(valueTypeName(lt))
+			e.buf.WriteByte(')')
+		}
+		e.buf.WriteByte('\n')
+		if err := e.writeBody(code.Body); err != nil {
+			return "", fmt.Errorf("func[%d]: %w", funcIdx, err)
+		}
+		e.buf.WriteString("  )\n")
+	}
+
+	if m.MemorySection != nil {
+		e.buf.WriteString("  (memory")
+		e.writeMemory(m.MemorySection)
+		e.buf.WriteString(")\n")
+	}
+
+	for _, exp := range m.ExportSection {
+		e.buf.WriteString("  (export ")
+		writeString(&e.buf, []byte(exp.Name))
+		fmt.Fprintf(&e.buf, " (%s %d))\n", wasm.ExternTypeName(exp.Type), exp.Index)
+	}
+
+	if m.StartSection != nil {
+		fmt.Fprintf(&e.buf, "  (start %d)\n", *m.StartSection)
+	}
+
+	e.buf.WriteString(")\n")
+	return e.buf.String(), nil
+}
+
+// requireDecodable errs if the module has a field which DecodeModule doesn't yet support, so that EncodeModule never
+// writes text that can't be decoded.
+func requireDecodable(m *wasm.Module) error {
+	for _, id := range []wasm.SectionID{wasm.SectionIDTable, wasm.SectionIDGlobal, wasm.SectionIDElement, wasm.SectionIDData} {
+		if m.SectionElementCount(id) > 0 {
+			return fmt.Errorf("%s section is not yet supported by the text format", wasm.SectionIDName(id))
+		}
+	}
+	for i, imp := range m.ImportSection {
+		if imp.Type != wasm.ExternTypeFunc {
+			return fmt.Errorf("import[%d]: %s is not yet supported by the text format", i, wasm.ExternTypeName(imp.Type))
+		}
+	}
+	for i, exp := range m.ExportSection {
+		if exp.Type != wasm.ExternTypeFunc && exp.Type != wasm.ExternTypeMemory {
+			return fmt.Errorf("export[%d]: %s is not yet supported by the text format", i, wasm.ExternTypeName(exp.Type))
+		}
+	}
+	if m.MemorySection != nil && m.MemorySection.IsShared {
+		return errors.New("shared memory is not yet supported by the text format")
+	}
+	return nil
+}
+
+// moduleEncoder holds the state needed to write the text format of a single wasm.Module.
+type moduleEncoder struct {
+	m   *wasm.Module
+	buf bytes.Buffer
+}
+
+// writeID writes a space followed by the name as a tokenID, unless the name is empty or contains characters invalid
+// in an ID. Ex. " $main"
+func (e *moduleEncoder) writeID(name string) {
+	if name == "" {
+		return
+	}
+	for i := 0; i < len(name); i++ {
+		if !idChar[name[i]] {
+			return
+		}
+	}
+	e.buf.WriteString(" $")
+	e.buf.WriteString(name)
+}
+
+// funcName returns the possibly empty name of the function at this index in the function namespace.
+func (e *moduleEncoder) funcName(funcIdx wasm.Index) string {
+	if e.m.NameSection == nil {
+		return ""
+	}
+	for _, na := range e.m.NameSection.FunctionNames {
+		if na.Index == funcIdx {
+			return na.Name
+		}
+	}
+	return ""
+}
+
+// localNames returns the names of locals, including parameters, of the function at this index, keyed by local index.
+func (e *moduleEncoder) localNames(funcIdx wasm.Index) map[wasm.Index]string {
+	ret := map[wasm.Index]string{}
+	if e.m.NameSection == nil {
+		return ret
+	}
+	for _, nma := range e.m.NameSection.LocalNames {
+		if nma.Index == funcIdx {
+			for _, na := range nma.NameMap {
+				ret[na.Index] = na.Name
+			}
+		}
+	}
+	return ret
+}
+
+// writeTypeUse writes the function ID, type index and inlined signature of the function at this index.
+// Ex. " $identity (type 0) (param $x i32) (result i32)"
+func (e *moduleEncoder) writeTypeUse(funcIdx, typeIdx wasm.Index) error {
+	if typeIdx >= uint32(len(e.m.TypeSection)) {
+		return fmt.Errorf("func[%d]: type index %d out of range", funcIdx, typeIdx)
+	}
+	e.writeID(e.funcName(funcIdx))
+	fmt.Fprintf(&e.buf, " (type %d)", typeIdx)
+	e.writeSignature(e.m.TypeSection[typeIdx], e.localNames(funcIdx))
+	return nil
+}
+
+// writeSignature writes the params and results of the function type, using paramNames if present.
+func (e *moduleEncoder) writeSignature(t *wasm.FunctionType, paramNames map[wasm.Index]string) {
+	for i, p := range t.Params {
+		e.buf.WriteString(" (param")
+		e.writeID(paramNames[wasm.Index(i)])
+		e.buf.WriteByte(' ')
+		e.buf.WriteString(valueTypeName(p))
+		e.buf.WriteByte(')')
+	}
+	if len(t.Results) > 0 {
+		e.buf.WriteString(" (result")
+		for _, r := range t.Results {
+			e.buf.WriteByte(' ')
+			e.buf.WriteString(valueTypeName(r))
+		}
+		e.buf.WriteByte(')')
+	}
+}
+
+func (e *moduleEncoder) writeMemory(m *wasm.Memory) {
+	fmt.Fprintf(&e.buf, " %d", m.Min)
+	if m.IsMaxEncoded {
+		fmt.Fprintf(&e.buf, " %d", m.Max)
+	}
+}
+
+// writeBody writes each instruction in the function body on its own line, indenting per block.
+func (e *moduleEncoder) writeBody(body []byte) error {
+	r := bytes.NewReader(body)
+	depth := 2
+	for r.Len() > 0 {
+		op, _ := r.ReadByte()
+		switch op {
+		case wasm.OpcodeEnd:
+			depth--
+			if depth < 2 { // The last end is implicit in the text format.
+				if r.Len() > 0 {
+					return fmt.Errorf("unexpected end at offset %d", len(body)-r.Len()-1)
+				}
+				return nil
+			}
+		case wasm.OpcodeElse:
+			depth--
+		}
+
+		e.buf.WriteString(strings.Repeat("  ", depth))
+		if op == wasm.OpcodeMiscPrefix {
+			miscOp, _, err := leb128.DecodeUint32(r)
+			if err != nil {
+				return fmt.Errorf("read misc opcode: %w", err)
+			}
+			name := wasm.MiscInstructionName(wasm.OpcodeMisc(miscOp))
+			if name == "" {
+				return fmt.Errorf("unsupported misc opcode: 0x%x", miscOp)
+			}
+			e.buf.WriteString(name)
+			if err = e.writeMiscImmediates(wasm.OpcodeMisc(miscOp), r); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		} else {
+			name := wasm.InstructionName(op)
+			if name == "" {
+				return fmt.Errorf("unsupported opcode: 0x%x", op)
+			}
+			e.buf.WriteString(name)
+			if err := e.writeImmediates(op, r); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		e.buf.WriteByte('\n')
+
+		switch op {
+		case wasm.OpcodeBlock, wasm.OpcodeLoop, wasm.OpcodeIf, wasm.OpcodeElse:
+			depth++
+		}
+	}
+	return errors.New("missing end")
+}
+
+// writeImmediates reads any immediates of the opcode and writes them with a leading space.
+func (e *moduleEncoder) writeImmediates(op wasm.Opcode, r *bytes.Reader) (err error) {
+	switch op {
+	case wasm.OpcodeBlock, wasm.OpcodeLoop, wasm.OpcodeIf:
+		return e.writeBlockType(r)
+	case wasm.OpcodeBr, wasm.OpcodeBrIf, wasm.OpcodeCall, wasm.OpcodeRefFunc,
+		wasm.OpcodeLocalGet, wasm.OpcodeLocalSet, wasm.OpcodeLocalTee,
+		wasm.OpcodeGlobalGet, wasm.OpcodeGlobalSet, wasm.OpcodeTableGet, wasm.OpcodeTableSet:
+		return e.writeIndices(r, 1)
+	case wasm.OpcodeBrTable:
+		var count uint32
+		if count, _, err = leb128.DecodeUint32(r); err != nil {
+			return fmt.Errorf("read label count: %w", err)
+		}
+		return e.writeIndices(r, int(count)+1) // +1 for the default label
+	case wasm.OpcodeCallIndirect:
+		var typeIdx, tableIdx uint32
+		if typeIdx, _, err = leb128.DecodeUint32(r); err != nil {
+			return fmt.Errorf("read type index: %w", err)
+		}
+		if tableIdx, _, err = leb128.DecodeUint32(r); err != nil {
+			return fmt.Errorf("read table index: %w", err)
+		}
+		if tableIdx != 0 {
+			fmt.Fprintf(&e.buf, " %d", tableIdx)
+		}
+		fmt.Fprintf(&e.buf, " (type %d)", typeIdx)
+	case wasm.OpcodeMemorySize, wasm.OpcodeMemoryGrow:
+		_, err = r.ReadByte() // reserved
+	case wasm.OpcodeI32Const:
+		var v int32
+		if v, _, err = leb128.DecodeInt32(r); err == nil {
+			fmt.Fprintf(&e.buf, " %d", v)
+		}
+	case wasm.OpcodeI64Const:
+		var v int64
+		if v, _, err = leb128.DecodeInt64(r); err == nil {
+			fmt.Fprintf(&e.buf, " %d", v)
+		}
+	case wasm.OpcodeF32Const:
+		b := make([]byte, 4)
+		if _, err = io.ReadFull(r, b); err == nil {
+			e.buf.WriteByte(' ')
+			e.buf.WriteString(formatFloat(uint64(binary.LittleEndian.Uint32(b)), 32))
+		}
+	case wasm.OpcodeF64Const:
+		b := make([]byte, 8)
+		if _, err = io.ReadFull(r, b); err == nil {
+			e.buf.WriteByte(' ')
+			e.buf.WriteString(formatFloat(binary.LittleEndian.Uint64(b), 64))
+		}
+	case wasm.OpcodeRefNull:
+		var t byte
+		if t, err = r.ReadByte(); err == nil {
+			if t == wasm.RefTypeExternref {
+				e.buf.WriteString(" extern")
+			} else {
+				e.buf.WriteString(" func")
+			}
+		}
+	default:
+		if op >= wasm.OpcodeI32Load && op <= wasm.OpcodeI64Store32 {
+			return e.writeMemArg(op, r)
+		}
+	}
+	return
+}
+
+func (e *moduleEncoder) writeMiscImmediates(op wasm.OpcodeMisc, r *bytes.Reader) (err error) {
+	switch op {
+	case wasm.OpcodeMiscMemoryInit:
+		if err = e.writeIndices(r, 1); err == nil {
+			_, err = r.ReadByte() // reserved memory index
+		}
+	case wasm.OpcodeMiscMemoryCopy:
+		_, err = r.Seek(2, 1) // reserved memory indices
+	case wasm.OpcodeMiscMemoryFill:
+		_, err = r.ReadByte() // reserved memory index
+	case wasm.OpcodeMiscDataDrop, wasm.OpcodeMiscElemDrop,
+		wasm.OpcodeMiscTableGrow, wasm.OpcodeMiscTableSize, wasm.OpcodeMiscTableFill:
+		err = e.writeIndices(r, 1)
+	case wasm.OpcodeMiscTableInit:
+		// The binary format is elemidx then tableidx, but the text format is tableidx then elemidx.
+		var elemIdx, tableIdx uint32
+		if elemIdx, _, err = leb128.DecodeUint32(r); err != nil {
+			return
+		}
+		if tableIdx, _, err = leb128.DecodeUint32(r); err != nil {
+			return
+		}
+		fmt.Fprintf(&e.buf, " %d %d", tableIdx, elemIdx)
+	case wasm.OpcodeMiscTableCopy:
+		err = e.writeIndices(r, 2)
+	}
+	return
+}
+
+// writeIndices reads and writes count uint32 indices.
+func (e *moduleEncoder) writeIndices(r *bytes.Reader, count int) error {
+	for i := 0; i < count; i++ {
+		idx, _, err := leb128.DecodeUint32(r)
+		if err != nil {
+			return fmt.Errorf("read index: %w", err)
+		}
+		fmt.Fprintf(&e.buf, " %d", idx)
+	}
+	return nil
+}
+
+// writeBlockType writes the result or type use of a block, loop or if instruction, if it has one.
+func (e *moduleEncoder) writeBlockType(r *bytes.Reader) error {
+	b, err := r.ReadByte()
+	if err != nil {
+		return fmt.Errorf("read block type: %w", err)
+	}
+	switch b {
+	case 0x40: // empty
+		return nil
+	case wasm.ValueTypeI32, wasm.ValueTypeI64, wasm.ValueTypeF32, wasm.ValueTypeF64,
+		wasm.RefTypeFuncref, wasm.RefTypeExternref:
+		fmt.Fprintf(&e.buf, " (result %s)", valueTypeName(b))
+		return nil
+	}
+	if err = r.UnreadByte(); err != nil {
+		return err
+	}
+	typeIdx, _, err := leb128.DecodeInt33AsInt64(r)
+	if err != nil {
+		return fmt.Errorf("read block type: %w", err)
+	}
+	fmt.Fprintf(&e.buf, " (type %d)", typeIdx)
+	return nil
+}
+
+// naturalAlignments are the default alignment exponents of memory instructions, which can be elided in the text
+// format. Indexes are relative to wasm.OpcodeI32Load.
+var naturalAlignments = [...]uint32{
+	2, 3, 2, 3, // i32.load, i64.load, f32.load, f64.load
+	0, 0, 1, 1, // i32.load8_s, i32.load8_u, i32.load16_s, i32.load16_u
+	0, 0, 1, 1, 2, 2, // i64.load8_s, i64.load8_u, i64.load16_s, i64.load16_u, i64.load32_s, i64.load32_u
+	2, 3, 2, 3, // i32.store, i64.store, f32.store, f64.store
+	0, 1, // i32.store8, i32.store16
+	0, 1, 2, // i64.store8, i64.store16, i64.store32
+}
+
+// writeMemArg writes the offset and alignment of a memory instruction when they are not the defaults.
+// Ex. " offset=8 align=1"
+func (e *moduleEncoder) writeMemArg(op wasm.Opcode, r *bytes.Reader) error {
+	align, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("read alignment: %w", err)
+	}
+	offset, _, err := leb128.DecodeUint32(r)
+	if err != nil {
+		return fmt.Errorf("read offset: %w", err)
+	}
+	if offset != 0 {
+		fmt.Fprintf(&e.buf, " offset=%d", offset)
+	}
+	if align != naturalAlignments[op-wasm.OpcodeI32Load] {
+		fmt.Fprintf(&e.buf, " align=%d", uint64(1)<<align)
+	}
+	return nil
+}
+
+// valueTypeName is like wasm.ValueTypeName, except it also supports reference types.
+func valueTypeName(t wasm.ValueType) string {
+	switch t {
+	case wasm.RefTypeFuncref, wasm.RefTypeExternref:
+		return wasm.RefTypeName(t)
+	}
+	return wasm.ValueTypeName(t)
+}
+
+// formatFloat formats the bits of a float of the given size using the text format conventions for NaN and infinity.
+// NaN keeps its sign and payload, written in hex unless it is the canonical payload. Ex. "-nan:0x200000"
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#floating-point%E2%91%A6
+func formatFloat(bits uint64, bitSize int) string {
+	var v float64
+	var sign bool
+	var payload, canonicalPayload uint64
+	if bitSize == 32 {
+		v = float64(math.Float32frombits(uint32(bits)))
+		sign, payload, canonicalPayload = bits>>31 != 0, bits&(1<<23-1), 1<<22
+	} else {
+		v = math.Float64frombits(bits)
+		sign, payload, canonicalPayload = bits>>63 != 0, bits&(1<<52-1), 1<<51
+	}
+	switch {
+	case math.IsNaN(v):
+		ret := "nan"
+		if payload != canonicalPayload {
+			ret = fmt.Sprintf("nan:0x%x", payload)
+		}
+		if sign {
+			ret = "-" + ret
+		}
+		return ret
+	case math.IsInf(v, 1):
+		return "inf"
+	case math.IsInf(v, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, bitSize)
+}
+
+// writeString writes the bytes as a quoted string, escaping any non-printable or non-ASCII bytes as hex.
+// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#strings%E2%91%A0
+func writeString(buf *bytes.Buffer, s []byte) {
+	buf.WriteByte('"')
+	for _, b := range s {
+		switch {
+		case b == '"' || b == '\\':
+			buf.WriteByte('\\')
+			buf.WriteByte(b)
+		case b >= 0x20 && b < 0x7f:
+			buf.WriteByte(b)
+		default:
+			fmt.Fprintf(buf, "\\%02x", b)
+		}
+	}
+	buf.WriteByte('"')
+}
@@ -0,0 +1,321 @@
+package text
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+	"github.com/tetratelabs/wazero/internal/wasm"
+	"github.com/tetratelabs/wazero/internal/wasm/binary"
+)
+
+func TestEncodeModule(t *testing.T) {
+	zero := uint32(0)
+	tests := []struct {
+		name     string
+		input    *wasm.Module
+		expected string
+	}{
+		{
+			name:     "empty",
+			input:    &wasm.Module{},
+			expected: "(module\n)\n",
+		},
+		{
+			name:     "only name",
+			input:    &wasm.Module{NameSection: &wasm.NameSection{ModuleName: "tools"}},
+			expected: "(module $tools\n)\n",
+		},
+		{
+			name: "import func",
+			input: &wasm.Module{
+				TypeSection: []*wasm.FunctionType{i32i32i32i32_i32},
+				ImportSection: []*wasm.Import{{
+					Module: "wasi_snapshot_preview1", Name: "fd_write",
+					Type:     wasm.ExternTypeFunc,
+					DescFunc: 0,
+				}},
+				NameSection: &wasm.NameSection{
+					FunctionNames: wasm.NameMap{{Index: 0, Name: "wasi.fd_write"}},
+				},
+			},
+			expected: `(module
+  (type (func (param i32) (param i32) (param i32) (param i32) (result i32)))
+  (import "wasi_snapshot_preview1" "fd_write" (func $wasi.fd_write (type 0) (param i32) (param i32) (param i32) (param i32) (result i32)))
+)
+`,
+		},
+		{
+			name: "func body",
+			input: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection: []*wasm.Code{{
+					LocalTypes: []wasm.ValueType{wasm.ValueTypeI64},
+					Body: []byte{
+						wasm.OpcodeBlock, wasm.ValueTypeI32,
+						wasm.OpcodeI32Const, 0x7f, // -1
+						wasm.OpcodeIf, 0x40,
+						wasm.OpcodeNop,
+						wasm.OpcodeElse,
+						wasm.OpcodeBr, 0x01,
+						wasm.OpcodeEnd,
+						wasm.OpcodeEnd,
+						wasm.OpcodeI32Load, 0x02, 0x08, // align=4 (natural) offset=8
+						wasm.OpcodeI64Store8, 0x00, 0x00,
+						wasm.OpcodeMemoryGrow, 0x00,
+						wasm.OpcodeBrTable, 0x01, 0x00, 0x01,
+						wasm.OpcodeEnd,
+					},
+				}},
+			},
+			expected: `(module
+  (type (func))
+  (func (type 0) (local i64)
+    block (result i32)
+      i32.const -1
+      if
+        nop
+      else
+        br 1
+      end
+    end
+    i32.load offset=8
+    i64.store8
+    memory.grow
+    br_table 0 1
+  )
+)
+`,
+		},
+		{
+			name: "memory export start",
+			input: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: end}},
+				MemorySection:   &wasm.Memory{Min: 1, Max: 2, IsMaxEncoded: true},
+				ExportSection:   []*wasm.Export{{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0}},
+				StartSection:    &zero,
+			},
+			expected: `(module
+  (type (func))
+  (func (type 0)
+  )
+  (memory 1 2)
+  (export "memory" (memory 0))
+  (start 0)
+)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			wat, err := EncodeModule(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, wat)
+		})
+	}
+}
+
+func TestEncodeModule_RoundTrip(t *testing.T) {
+	// The "identity" module, encoded in the binary format.
+	identity := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeLocalGet, 0x00, wasm.OpcodeEnd}}},
+		ExportSection:   []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "identity", Index: 0}},
+		NameSection: &wasm.NameSection{
+			ModuleName:    "identity",
+			FunctionNames: wasm.NameMap{{Index: 0, Name: "identity"}},
+			LocalNames:    wasm.IndirectNameMap{{Index: 0, NameMap: wasm.NameMap{{Index: 0, Name: "x"}}}},
+		},
+	})
+
+	expected, err := binary.DecodeModule(identity, wasm.Features20191205, wasm.MemoryLimitPages)
+	require.NoError(t, err)
+
+	wat, err := EncodeModule(expected)
+	require.NoError(t, err)
+	require.Equal(t, `(module $identity
+  (type (func (param i32) (result i32)))
+  (func $identity (type 0) (param $x i32) (result i32)
+    local.get 0
+  )
+  (export "identity" (func 0))
+)
+`, wat)
+
+	actual, err := DecodeModule([]byte(wat), wasm.Features20191205, wasm.MemoryLimitPages)
+	require.NoError(t, err)
+	require.Equal(t, expected, actual)
+
+	// Encoding again is stable.
+	wat2, err := EncodeModule(actual)
+	require.NoError(t, err)
+	require.Equal(t, wat, wat2)
+
+	t.Run("each supported field", func(t *testing.T) {
+		zero := uint32(0)
+		expected := &wasm.Module{
+			TypeSection: []*wasm.FunctionType{v_v, {Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}}},
+			ImportSection: []*wasm.Import{{
+				Module: "env", Name: "hello",
+				Type:     wasm.ExternTypeFunc,
+				DescFunc: 0,
+			}},
+			FunctionSection: []wasm.Index{0, 1},
+			CodeSection: []*wasm.Code{
+				{Body: []byte{wasm.OpcodeCall, 0x00, wasm.OpcodeEnd}},
+				{Body: []byte{wasm.OpcodeLocalGet, 0x00, wasm.OpcodeI32Const, 0x01, wasm.OpcodeI32Sub, wasm.OpcodeEnd}},
+			},
+			MemorySection: &wasm.Memory{Min: 1, Max: 2, IsMaxEncoded: true},
+			ExportSection: []*wasm.Export{
+				{Type: wasm.ExternTypeFunc, Name: "dec", Index: 2},
+				{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0},
+			},
+			StartSection: &zero,
+			NameSection: &wasm.NameSection{
+				ModuleName:    "fields",
+				FunctionNames: wasm.NameMap{{Index: 0, Name: "env.hello"}, {Index: 1, Name: "start"}, {Index: 2, Name: "dec"}},
+				LocalNames:    wasm.IndirectNameMap{{Index: 2, NameMap: wasm.NameMap{{Index: 0, Name: "x"}}}},
+			},
+		}
+
+		wat, err := EncodeModule(expected)
+		require.NoError(t, err)
+
+		actual, err := DecodeModule([]byte(wat), wasm.Features20191205, wasm.MemoryLimitPages)
+		require.NoError(t, err)
+		require.Equal(t, expected, actual)
+	})
+}
+
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		name     string
+		bits     uint64
+		bitSize  int
+		expected string
+	}{
+		{name: "f32", bits: uint64(math.Float32bits(1.5)), bitSize: 32, expected: "1.5"},
+		{name: "f32 inf", bits: uint64(math.Float32bits(float32(math.Inf(-1)))), bitSize: 32, expected: "-inf"},
+		{name: "f32 nan", bits: 0x7fc00000, bitSize: 32, expected: "nan"},
+		{name: "f32 -nan", bits: 0xffc00000, bitSize: 32, expected: "-nan"},
+		{name: "f32 nan payload", bits: 0x7fa00000, bitSize: 32, expected: "nan:0x200000"},
+		{name: "f64", bits: math.Float64bits(-0.25), bitSize: 64, expected: "-0.25"},
+		{name: "f64 inf", bits: math.Float64bits(math.Inf(1)), bitSize: 64, expected: "inf"},
+		{name: "f64 nan", bits: 0x7ff8000000000000, bitSize: 64, expected: "nan"},
+		{name: "f64 -nan payload", bits: 0xfff0000000000001, bitSize: 64, expected: "-nan:0x1"},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, formatFloat(tc.bits, tc.bitSize))
+		})
+	}
+}
+
+func TestEncodeModule_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       *wasm.Module
+		expectedErr string
+	}{
+		{
+			name: "function without code",
+			input: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+			},
+			expectedErr: "function and code section have inconsistent lengths: 1 != 0",
+		},
+		{
+			name: "type index out of range",
+			input: &wasm.Module{
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: end}},
+			},
+			expectedErr: "func[0]: type index 0 out of range",
+		},
+		{
+			name: "unsupported opcode",
+			input: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: []byte{0xff, wasm.OpcodeEnd}}},
+			},
+			expectedErr: "func[0]: unsupported opcode: 0xff",
+		},
+		{
+			name: "missing end",
+			input: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeNop}}},
+			},
+			expectedErr: "func[0]: missing end",
+		},
+		{
+			name: "truncated f64.const",
+			input: &wasm.Module{
+				TypeSection:     []*wasm.FunctionType{v_v},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{Body: []byte{wasm.OpcodeF64Const, 0x00, 0x00, 0x00, 0x00}}},
+			},
+			expectedErr: "func[0]: f64.const: unexpected EOF",
+		},
+		{
+			name:        "table",
+			input:       &wasm.Module{TableSection: []*wasm.Table{{Min: 1, Type: wasm.RefTypeFuncref}}},
+			expectedErr: "table section is not yet supported by the text format",
+		},
+		{
+			name: "global",
+			input: &wasm.Module{GlobalSection: []*wasm.Global{{
+				Type: &wasm.GlobalType{ValType: i32},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0x01}},
+			}}},
+			expectedErr: "global section is not yet supported by the text format",
+		},
+		{
+			name:        "elem",
+			input:       &wasm.Module{ElementSection: []*wasm.ElementSegment{{Mode: wasm.ElementModeDeclarative}}},
+			expectedErr: "element section is not yet supported by the text format",
+		},
+		{
+			name:        "data",
+			input:       &wasm.Module{DataSection: []*wasm.DataSegment{{Init: []byte("hi")}}},
+			expectedErr: "data section is not yet supported by the text format",
+		},
+		{
+			name:        "import memory",
+			input:       &wasm.Module{ImportSection: []*wasm.Import{{Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 1}}}},
+			expectedErr: "import[0]: memory is not yet supported by the text format",
+		},
+		{
+			name:        "export global",
+			input:       &wasm.Module{ExportSection: []*wasm.Export{{Type: wasm.ExternTypeGlobal, Name: "g"}}},
+			expectedErr: "export[0]: global is not yet supported by the text format",
+		},
+		{
+			name:        "shared memory",
+			input:       &wasm.Module{MemorySection: &wasm.Memory{Min: 1, Max: 2, IsMaxEncoded: true, IsShared: true}},
+			expectedErr: "shared memory is not yet supported by the text format",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeModule(tc.input)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
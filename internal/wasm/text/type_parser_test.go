@@ -3,6 +3,7 @@ package text
 import (
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -25,6 +26,9 @@ var (
 )
 
 func TestTypeParser(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name       string
 		input      string
@@ -204,6 +208,9 @@ func TestTypeParser(t *testing.T) {
 }
 
 func TestTypeParser_Errors(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name, input, expectedErr string
 		enabledFeatures          wasm.Features
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 )
@@ -22,6 +23,9 @@ type typeUseParserTest struct {
 }
 
 func TestTypeUseParser_InlinesTypesWhenNotYetAdded(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []*typeUseParserTest{
 		{
 			name:                "empty",
@@ -453,6 +457,9 @@ func runTypeUseParserTests(t *testing.T, tests []*typeUseParserTest, tf typeUseT
 }
 
 func TestTypeUseParser_Errors(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	tests := []struct {
 		name, input, expectedErr string
 		enabledFeatures          wasm.Features
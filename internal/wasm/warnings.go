@@ -0,0 +1,63 @@
+package wasm
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/tetratelabs/wazero/internal/leb128"
+)
+
+// naturalAlignments are the log2 of the natural alignment of each memory instruction, indexed by its opcode minus
+// OpcodeI32Load. Ex. `i64.load` accesses 8 bytes, so its natural alignment is 3.
+var naturalAlignments = [OpcodeI64Store32 - OpcodeI32Load + 1]uint32{
+	2, 3, 2, 3, // i32.load, i64.load, f32.load, f64.load
+	0, 0, 1, 1, // i32.load8_s, i32.load8_u, i32.load16_s, i32.load16_u
+	0, 0, 1, 1, 2, 2, // i64.load8_s, i64.load8_u, i64.load16_s, i64.load16_u, i64.load32_s, i64.load32_u
+	2, 3, 2, 3, // i32.store, i64.store, f32.store, f64.store
+	0, 1, // i32.store8, i32.store16
+	0, 1, 2, // i64.store8, i64.store16, i64.store32
+}
+
+// Warnings returns problems in the module which don't affect its validity, in order of occurrence:
+// * Custom sections skipped by the decoder, as wazero doesn't use them. See SkippedCustomSections
+// * Memory instructions whose alignment hint is less than the natural alignment of the access, which is legal, but
+//   suggests the producer couldn't prove alignment.
+//
+// Note: An alignment hint larger than the natural alignment is invalid, so is an error in Validate, not a warning.
+// Note: The module must be valid, as Validate ensures the bodies can be decoded.
+func (m *Module) Warnings() (warnings []string) {
+	for _, name := range m.SkippedCustomSections {
+		warnings = append(warnings, fmt.Sprintf("custom section %q is unused", name))
+	}
+
+	importedFunctionCount := m.importCount(ExternTypeFunc)
+	for i, c := range m.CodeSection {
+		r := bytes.NewReader(c.Body)
+		for r.Len() > 0 {
+			offset := len(c.Body) - r.Len()
+			op, err := r.ReadByte()
+			if err != nil {
+				break
+			}
+			if op < OpcodeI32Load || op > OpcodeI64Store32 {
+				if err = skipImmediates(op, r); err != nil {
+					break
+				}
+				continue
+			}
+
+			align, _, err := leb128.DecodeUint32(r)
+			if err != nil {
+				break
+			}
+			if natural := naturalAlignments[op-OpcodeI32Load]; align < natural {
+				warnings = append(warnings, fmt.Sprintf("func[%d] %s at offset %d: alignment hint %d is less than natural alignment %d",
+					importedFunctionCount+uint32(i), InstructionName(op), offset, 1<<align, 1<<natural))
+			}
+			if err = skipUint32s(r, 1); err != nil { // offset
+				break
+			}
+		}
+	}
+	return
+}
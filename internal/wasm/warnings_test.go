@@ -0,0 +1,62 @@
+package wasm
+
+import (
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestModule_Warnings(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    *Module
+		expected []string
+	}{
+		{
+			name:  "none",
+			input: &Module{},
+		},
+		{
+			name:     "skipped custom sections",
+			input:    &Module{SkippedCustomSections: []string{"producers", "target_features"}},
+			expected: []string{`custom section "producers" is unused`, `custom section "target_features" is unused`},
+		},
+		{
+			name: "natural alignment",
+			input: &Module{
+				CodeSection: []*Code{{Body: []byte{
+					OpcodeI32Const, 0, OpcodeI64Load, 3, 0, OpcodeDrop,
+					OpcodeI32Const, 0, OpcodeI32Load8U, 0, 0, OpcodeDrop,
+					OpcodeEnd,
+				}}},
+			},
+		},
+		{
+			name: "under-aligned",
+			input: &Module{
+				ImportSection: []*Import{{Type: ExternTypeFunc}},
+				CodeSection: []*Code{
+					{Body: []byte{OpcodeEnd}},
+					{Body: []byte{
+						OpcodeF64Const, 0, 0, 0, 0, 0, 0, 0, 0, OpcodeDrop, // immediates are skipped
+						OpcodeI32Const, 0, OpcodeI64Load, 2, 8, OpcodeDrop,
+						OpcodeI32Const, 0, OpcodeI32Const, 0, OpcodeI32Store16, 0, 0,
+						OpcodeEnd,
+					}},
+				},
+			},
+			expected: []string{
+				"func[2] i64.load at offset 12: alignment hint 4 is less than natural alignment 8",
+				"func[2] i32.store16 at offset 20: alignment hint 1 is less than natural alignment 2",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.input.Warnings())
+		})
+	}
+}
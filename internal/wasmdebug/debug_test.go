@@ -116,7 +116,7 @@ wasm stack trace:
 				builder.AddFrame("x.y", nil, nil)
 				return builder.FromRecovered(wasmruntime.ErrRuntimeCallStackOverflow)
 			},
-			expectedErr: `wasm error: callstack overflow
+			expectedErr: `wasm error: call stack exhausted
 wasm stack trace:
 	wasi_snapshot_preview1.fd_write(i32,i32,i32,i32) i32
 	x.y()`,
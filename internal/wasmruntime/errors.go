@@ -1,12 +1,14 @@
 // Package wasmruntime contains internal symbols shared between modules for error handling.
 // Note: This is named wasmruntime to avoid conflicts with the normal go module.
-// Note: This only imports "api" as importing "wasm" would create a cyclic dependency.
+// Note: This only imports "api" and "sys" as importing "wasm" would create a cyclic dependency.
 package wasmruntime
 
+import "github.com/tetratelabs/wazero/sys"
+
 var (
 	// ErrRuntimeCallStackOverflow indicates that there are too many function calls,
-	// and the Engine terminated the execution.
-	ErrRuntimeCallStackOverflow = New("callstack overflow")
+	// and the Engine terminated the execution. This wraps sys.ErrCallStackExhausted.
+	ErrRuntimeCallStackOverflow = &Error{s: sys.ErrCallStackExhausted.Error(), cause: sys.ErrCallStackExhausted}
 	// ErrRuntimeInvalidConversionToInteger indicates the Wasm function tries to
 	// convert NaN floating point value to integers during trunc variant instructions.
 	ErrRuntimeInvalidConversionToInteger = New("invalid conversion to integer")
@@ -27,12 +29,17 @@ var (
 	ErrRuntimeInvalidTableAccess = New("invalid table access")
 	// ErrRuntimeIndirectCallTypeMismatch indicates that the type check failed during call_indirect.
 	ErrRuntimeIndirectCallTypeMismatch = New("indirect call type mismatch")
+	// ErrRuntimeMMIOStraddle indicates that the program tried to load or store bytes both inside and outside a range
+	// registered with experimental.MapMMIO.
+	ErrRuntimeMMIOStraddle = New("memory access straddles an MMIO region")
 )
 
 // Error is returned by a wasm.Engine during the execution of Wasm functions, and they indicate that the Wasm runtime
 // state is unrecoverable.
 type Error struct {
 	s string
+	// cause is an exported error this matches with errors.Is, if any.
+	cause error
 }
 
 func New(text string) *Error {
@@ -42,3 +49,8 @@ func New(text string) *Error {
 func (e *Error) Error() string {
 	return e.s
 }
+
+// Unwrap returns the exported error this corresponds to, if any. Ex. sys.ErrCallStackExhausted
+func (e *Error) Unwrap() error {
+	return e.cause
+}
@@ -215,6 +215,81 @@ func CompileFunctions(_ context.Context, enabledFeatures wasm.Features, module *
 	return ret, nil
 }
 
+// SaturateFloatToInt makes each OperationITruncFromF in the results non-trapping, so that NaN and out-of-range inputs
+// saturate instead of trapping, as if the function used the corresponding "trunc_sat" instruction.
+//
+// Note: This is not conformant to the WebAssembly specification. See wazero.RuntimeConfig WithLenientFloatToInt
+func SaturateFloatToInt(irs []*CompilationResult) {
+	for _, ir := range irs {
+		for _, op := range ir.Operations {
+			if o, ok := op.(*OperationITruncFromF); ok {
+				o.NonTrapping = true
+			}
+		}
+	}
+}
+
+// EliminateBoundsChecks sets MemoryImmediate.InBounds on each load or store in the results which is proven within
+// minMemoryBytes, the minimum size of the memory of the module. An access is proven when its base address is a
+// constant, and its end, the base plus the offset and size of the access, is within the minimum size.
+//
+// Note: This is only safe when the memory is never smaller than its minimum size, which holds for an imported memory
+// as well, as the import must have at least the minimum size it declares.
+func EliminateBoundsChecks(irs []*CompilationResult, minMemoryBytes uint64) {
+	for _, ir := range irs {
+		ops := ir.Operations
+		for i, op := range ops {
+			var arg *MemoryImmediate
+			var size uint64
+			isStore := false
+			switch o := op.(type) {
+			case *OperationLoad:
+				arg, size = o.Arg, o.Type.sizeInBytes()
+			case *OperationLoad8:
+				arg, size = o.Arg, 1
+			case *OperationLoad16:
+				arg, size = o.Arg, 2
+			case *OperationLoad32:
+				arg, size = o.Arg, 4
+			case *OperationStore:
+				arg, size, isStore = o.Arg, o.Type.sizeInBytes(), true
+			case *OperationStore8:
+				arg, size, isStore = o.Arg, 1, true
+			case *OperationStore16:
+				arg, size, isStore = o.Arg, 2, true
+			case *OperationStore32:
+				arg, size, isStore = o.Arg, 4, true
+			default:
+				continue
+			}
+
+			// The base address is below the value to store, so only prove stores of a constant value.
+			baseIndex := i - 1
+			if isStore {
+				if baseIndex < 1 || !isConst(ops[baseIndex]) {
+					continue
+				}
+				baseIndex--
+			}
+			if baseIndex < 0 {
+				continue
+			}
+			if base, ok := ops[baseIndex].(*OperationConstI32); ok {
+				arg.InBounds = uint64(base.Value)+uint64(arg.Offset)+size <= minMemoryBytes
+			}
+		}
+	}
+}
+
+// isConst returns true if the operation pushes a constant, and nothing else.
+func isConst(op Operation) bool {
+	switch op.(type) {
+	case *OperationConstI32, *OperationConstI64, *OperationConstF32, *OperationConstF64:
+		return true
+	}
+	return false
+}
+
 // Compile lowers given function instance into wazeroir operations
 // so that the resulting operations can be consumed by the interpreter
 // or the JIT compilation engine.
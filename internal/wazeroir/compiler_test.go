@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasm/text"
@@ -222,6 +223,9 @@ func TestCompile_BulkMemoryOperations(t *testing.T) {
 }
 
 func TestCompile_MultiValue(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	i32i32_i32i32 := &wasm.FunctionType{Params: []wasm.ValueType{
 		wasm.ValueTypeI32, wasm.ValueTypeI32},
 		Results: []wasm.ValueType{wasm.ValueTypeI32, wasm.ValueTypeI32},
@@ -498,6 +502,9 @@ func TestCompile_MultiValue(t *testing.T) {
 
 // TestCompile_NonTrappingFloatToIntConversion picks an arbitrary operator from "nontrapping-float-to-int-conversion".
 func TestCompile_NonTrappingFloatToIntConversion(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	module := requireModuleText(t, `(module
   (func (param f32) (result i32) local.get 0 i32.trunc_sat_f32_s)
 )`)
@@ -526,6 +533,9 @@ func TestCompile_NonTrappingFloatToIntConversion(t *testing.T) {
 
 // TestCompile_SignExtensionOps picks an arbitrary operator from "sign-extension-ops".
 func TestCompile_SignExtensionOps(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
 	module := requireModuleText(t, `(module
   (func (param i32) (result i32) local.get 0 i32.extend8_s)
 )`)
@@ -594,3 +604,79 @@ func TestCompile_CallIndirectNonZeroTableIndex(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expected, res[0])
 }
+
+func TestEliminateBoundsChecks(t *testing.T) {
+	const minMemoryBytes = 16
+	tests := []struct {
+		name     string
+		ops      []Operation
+		inBounds bool
+	}{
+		{
+			name:     "load const in bounds",
+			ops:      []Operation{&OperationConstI32{Value: 8}, &OperationLoad{Type: UnsignedTypeI64, Arg: &MemoryImmediate{}}},
+			inBounds: true,
+		},
+		{
+			name:     "load const with offset in bounds",
+			ops:      []Operation{&OperationConstI32{Value: 4}, &OperationLoad32{Arg: &MemoryImmediate{Offset: 8}}},
+			inBounds: true,
+		},
+		{
+			name: "load const out of bounds",
+			ops:  []Operation{&OperationConstI32{Value: 9}, &OperationLoad{Type: UnsignedTypeI64, Arg: &MemoryImmediate{}}},
+		},
+		{
+			name: "load offset out of bounds",
+			ops:  []Operation{&OperationConstI32{Value: 0}, &OperationLoad8{Arg: &MemoryImmediate{Offset: 16}}},
+		},
+		{
+			name: "load non-const",
+			ops:  []Operation{&OperationPick{Depth: 0}, &OperationLoad8{Arg: &MemoryImmediate{}}},
+		},
+		{
+			name:     "store const in bounds",
+			ops:      []Operation{&OperationConstI32{Value: 12}, &OperationConstF32{}, &OperationStore{Type: UnsignedTypeF32, Arg: &MemoryImmediate{}}},
+			inBounds: true,
+		},
+		{
+			name: "store const out of bounds",
+			ops:  []Operation{&OperationConstI32{Value: 15}, &OperationConstI32{}, &OperationStore16{Arg: &MemoryImmediate{}}},
+		},
+		{
+			name: "store non-const value",
+			ops:  []Operation{&OperationConstI32{}, &OperationPick{Depth: 1}, &OperationStore8{Arg: &MemoryImmediate{}}},
+		},
+		{
+			name: "store without base",
+			ops:  []Operation{&OperationConstI32{}, &OperationStore32{Arg: &MemoryImmediate{}}},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			EliminateBoundsChecks([]*CompilationResult{{Operations: tc.ops}}, minMemoryBytes)
+
+			var arg *MemoryImmediate
+			switch o := tc.ops[len(tc.ops)-1].(type) {
+			case *OperationLoad:
+				arg = o.Arg
+			case *OperationLoad8:
+				arg = o.Arg
+			case *OperationLoad32:
+				arg = o.Arg
+			case *OperationStore:
+				arg = o.Arg
+			case *OperationStore8:
+				arg = o.Arg
+			case *OperationStore16:
+				arg = o.Arg
+			case *OperationStore32:
+				arg = o.Arg
+			}
+			require.Equal(t, tc.inBounds, arg.InBounds)
+		})
+	}
+}
@@ -20,6 +20,25 @@ func Format(ops []Operation) string {
 	return buf.String()
 }
 
+// FormatIndexed is like Format, except each line is prefixed by the index of the operation in ops.
+//
+// Ex. The operations of `(func (param $x i32) (result i32) local.get 0)`:
+//	.entrypoint
+//	  0 	pick 0
+//	  1 	drop 1..1
+//	  2 	br .return
+func FormatIndexed(ops []Operation) string {
+	buf := bytes.NewBuffer(nil)
+
+	_, _ = buf.WriteString(EntrypointLabel + "\n")
+	for i, op := range ops {
+		_, _ = buf.WriteString(fmt.Sprintf("%3d ", i))
+		formatOperation(buf, op)
+	}
+
+	return buf.String()
+}
+
 func formatOperation(w io.StringWriter, b Operation) {
 	var str string
 	var isLabel bool
@@ -181,6 +200,30 @@ func formatOperation(w io.StringWriter, b Operation) {
 			out = "u64"
 		}
 		str = fmt.Sprintf("%s.extend_from.%s", out, in)
+	case *OperationSignExtend32From8:
+		str = "i32.extend8_s"
+	case *OperationSignExtend32From16:
+		str = "i32.extend16_s"
+	case *OperationSignExtend64From8:
+		str = "i64.extend8_s"
+	case *OperationSignExtend64From16:
+		str = "i64.extend16_s"
+	case *OperationSignExtend64From32:
+		str = "i64.extend32_s"
+	case *OperationMemoryInit:
+		str = fmt.Sprintf("memory.init %d", o.DataIndex)
+	case *OperationDataDrop:
+		str = fmt.Sprintf("data.drop %d", o.DataIndex)
+	case *OperationMemoryCopy:
+		str = "memory.copy"
+	case *OperationMemoryFill:
+		str = "memory.fill"
+	case *OperationTableInit:
+		str = fmt.Sprintf("table.init %d %d", o.TableIndex, o.ElemIndex)
+	case *OperationElemDrop:
+		str = fmt.Sprintf("elem.drop %d", o.ElemIndex)
+	case *OperationTableCopy:
+		str = fmt.Sprintf("table.copy %d %d", o.DstTableIndex, o.SrcTableIndex)
 	default:
 		panic("unreachable: a bug in wazeroir implementation")
 	}
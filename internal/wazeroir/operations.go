@@ -85,6 +85,14 @@ func (s UnsignedType) String() (ret string) {
 	return
 }
 
+// sizeInBytes returns the size of a value of the type in memory.
+func (s UnsignedType) sizeInBytes() uint64 {
+	if s == UnsignedTypeI32 || s == UnsignedTypeF32 {
+		return 4
+	}
+	return 8
+}
+
 type SignedType byte
 
 const (
@@ -540,6 +548,10 @@ type MemoryImmediate struct {
 	// Offset is the address offset added to the instruction's dynamic address operand, yielding a 33-bit effective
 	// address that is the zero-based index at which the memory is accessed. Default to zero.
 	Offset uint32
+
+	// InBounds is true when the access is proven within the minimum size of the memory, so an engine can skip its
+	// bounds check. See EliminateBoundsChecks
+	InBounds bool
 }
 
 type OperationLoad struct {
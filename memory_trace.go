@@ -0,0 +1,91 @@
+package wazero
+
+import (
+	"bytes"
+	"context"
+	"errors"
+)
+
+// MemoryWrite is a write to memory recorded by DiffMemoryWrites.
+type MemoryWrite struct {
+	// Offset is the offset in memory of the first byte written.
+	Offset uint32
+	// Data are the bytes written.
+	Data []byte
+}
+
+// MemoryWriteDivergence is the first write which differs between two runs. See DiffMemoryWrites
+type MemoryWriteDivergence struct {
+	// Index is the position of the write in the sequence of writes of each run, starting at zero.
+	Index int
+	// A and B are the writes of the first and second run at Index, or nil if that run made fewer writes.
+	A, B *MemoryWrite
+}
+
+// DiffMemoryWrites calls run twice, each with a new Runtime, recording the writes to memory made by guest instructions
+// per RuntimeConfig.WithMemoryWriteLog. This returns the first write which differs between the runs, or nil if both
+// made the same writes in the same order. This helps find where the executions of a nondeterministic module diverge.
+//
+// Ex. To find where two calls of "main" diverge:
+//	d, err := wazero.DiffMemoryWrites(ctx, wazero.NewRuntimeConfigInterpreter(), func(ctx context.Context, r wazero.Runtime) error {
+//		mod, err := r.InstantiateModuleFromCode(ctx, source)
+//		if err != nil {
+//			return err
+//		}
+//		defer mod.Close(ctx)
+//		_, err = mod.ExportedFunction("main").Call(ctx)
+//		return err
+//	})
+//	if d != nil {
+//		fmt.Printf("write %d differs: %v != %v\n", d.Index, d.A, d.B)
+//	}
+//
+// Only the offset and content of each write, and the order of writes, are compared. Ex. Writes which happened at
+// different times are equal if they are otherwise the same.
+//
+// Notes:
+// * The config must be of an engine which supports WithMemoryWriteLog. Ex. NewRuntimeConfigInterpreter
+// * This errs if either call of run errs, as the writes of a failed run are incomplete.
+func DiffMemoryWrites(ctx context.Context, config RuntimeConfig, run func(context.Context, Runtime) error) (*MemoryWriteDivergence, error) {
+	a, err := recordMemoryWrites(ctx, config, run)
+	if err != nil {
+		return nil, err
+	}
+	b, err := recordMemoryWrites(ctx, config, run)
+	if err != nil {
+		return nil, err
+	}
+	return diffMemoryWrites(a, b), nil
+}
+
+// recordMemoryWrites returns the writes made during run, in order.
+func recordMemoryWrites(ctx context.Context, config RuntimeConfig, run func(context.Context, Runtime) error) ([]*MemoryWrite, error) {
+	var writes []*MemoryWrite
+	r := NewRuntimeWithConfig(config.WithMemoryWriteLog(func(offset uint32, data []byte) {
+		writes = append(writes, &MemoryWrite{Offset: offset, Data: append([]byte{}, data...)}) // copy the view of memory.
+	}))
+	if _, ok := r.(*runtime).store.Engine.(memoryWriteLogger); !ok {
+		return nil, errors.New("memory write log is not supported by this engine: use NewRuntimeConfigInterpreter")
+	}
+	if err := run(ctx, r); err != nil {
+		return nil, err
+	}
+	return writes, nil
+}
+
+// diffMemoryWrites returns the first write which differs between a and b, or nil if they are the same.
+func diffMemoryWrites(a, b []*MemoryWrite) *MemoryWriteDivergence {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var wa, wb *MemoryWrite
+		if i < len(a) {
+			wa = a[i]
+		}
+		if i < len(b) {
+			wb = b[i]
+		}
+		if wa == nil || wb == nil || wa.Offset != wb.Offset || !bytes.Equal(wa.Data, wb.Data) {
+			return &MemoryWriteDivergence{Index: i, A: wa, B: wb}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package wazero
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/tetratelabs/wazero/internal/testing/require"
+)
+
+func TestDiffMemoryWrites(t *testing.T) {
+	// now is a fake clock, which advances on each call, so that each run reads a different time.
+	var clock uint64
+	now := func() uint64 {
+		clock += 1000
+		return clock
+	}
+
+	run := func(main string) func(context.Context, Runtime) error {
+		return func(ctx context.Context, r Runtime) error {
+			host, err := r.NewModuleBuilder("env").ExportFunction("now", now).Instantiate(ctx)
+			if err != nil {
+				return err
+			}
+			defer host.Close(ctx)
+
+			mod, err := r.InstantiateModuleFromCode(ctx, []byte(`(module
+	(import "env" "now" (func $now (result i64)))
+	(func $deterministic
+		i32.const 8 i32.const 42 i32.store
+		i32.const 16 i64.const 7 i64.store
+	)
+	(func $clock
+		i32.const 8 i32.const 42 i32.store
+		i32.const 16 call $now i64.store
+	)
+	(memory 1)
+	(export "deterministic" (func $deterministic))
+	(export "clock" (func $clock))
+)`))
+			if err != nil {
+				return err
+			}
+			defer mod.Close(ctx)
+			_, err = mod.ExportedFunction(main).Call(ctx)
+			return err
+		}
+	}
+
+	t.Run("deterministic", func(t *testing.T) {
+		d, err := DiffMemoryWrites(testCtx, NewRuntimeConfigInterpreter(), run("deterministic"))
+		require.NoError(t, err)
+		require.Nil(t, d)
+	})
+
+	t.Run("clock", func(t *testing.T) {
+		d, err := DiffMemoryWrites(testCtx, NewRuntimeConfigInterpreter(), run("clock"))
+		require.NoError(t, err)
+		require.Equal(t, &MemoryWriteDivergence{
+			Index: 1,                                                                    // the first write is the same.
+			A:     &MemoryWrite{Offset: 16, Data: []byte{0xe8, 0x03, 0, 0, 0, 0, 0, 0}}, // 1000
+			B:     &MemoryWrite{Offset: 16, Data: []byte{0xd0, 0x07, 0, 0, 0, 0, 0, 0}}, // 2000
+		}, d)
+	})
+
+	t.Run("run errs", func(t *testing.T) {
+		_, err := DiffMemoryWrites(testCtx, NewRuntimeConfigInterpreter(), func(context.Context, Runtime) error {
+			return errors.New("failed")
+		})
+		require.EqualError(t, err, "failed")
+	})
+
+	t.Run("unsupported engine", func(t *testing.T) {
+		if !JITSupported {
+			t.Skip("JIT is not supported on this platform")
+		}
+		_, err := DiffMemoryWrites(testCtx, NewRuntimeConfigJIT(), run("deterministic"))
+		require.EqualError(t, err, "memory write log is not supported by this engine: use NewRuntimeConfigInterpreter")
+	})
+}
+
+func TestDiffMemoryWrites_Lengths(t *testing.T) {
+	w := &MemoryWrite{Offset: 1, Data: []byte{1}}
+	require.Nil(t, diffMemoryWrites([]*MemoryWrite{w}, []*MemoryWrite{{Offset: 1, Data: []byte{1}}}))
+	require.Equal(t, &MemoryWriteDivergence{Index: 1, A: w}, diffMemoryWrites([]*MemoryWrite{w, w}, []*MemoryWrite{w}))
+	require.Equal(t, &MemoryWriteDivergence{Index: 0, B: w}, diffMemoryWrites(nil, []*MemoryWrite{w}))
+}
@@ -2,9 +2,21 @@
 package sys
 
 import (
+	"errors"
 	"fmt"
 )
 
+// ErrCallStackExhausted is wrapped by the error from api.Function Call when nested function calls exceed the call
+// stack limit, regardless of whether this was from direct or mutual recursion. The message matches the wording of the
+// WebAssembly specification tests, which expect this trap from "assert_exhaustion".
+//
+// Here's an example of how to detect runaway recursion:
+//	if _, err := fn.Call(ctx); errors.Is(err, sys.ErrCallStackExhausted) {
+//		// The guest likely has unbounded recursion.
+//	}
+//	--snip--
+var ErrCallStackExhausted = errors.New("call stack exhausted")
+
 // ExitError is returned to a caller of api.Function still running when api.Module CloseWithExitCode was invoked.
 // ExitCode zero value means success, while any other value is an error.
 //
@@ -7,11 +7,15 @@ package wasi
 import (
 	"context"
 	crand "crypto/rand"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"io/fs"
+	"os"
 	"path"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/tetratelabs/wazero"
@@ -329,7 +333,7 @@ const (
 	importPathUnlinkFile = `(import "wasi_snapshot_preview1" "path_unlink_file"
     (func $wasi.path_unlink_file (param $fd i32) (param $path i32) (param $path_len i32) (result (;errno;) i32)))`
 
-	// functionPollOneoff unlinks a file.
+	// functionPollOneoff concurrently polls for the occurrence of a set of events.
 	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-poll_oneoffin-constpointersubscription-out-pointerevent-nsubscriptions-size---errno-size
 	functionPollOneoff = "poll_oneoff"
 
@@ -770,7 +774,7 @@ func (a *snapshotPreview1) FdPrestatGet(ctx context.Context, m api.Module, fd ui
 	sys := sysCtx(m)
 
 	entry, ok := sys.OpenedFile(fd)
-	if !ok {
+	if !ok || entry.File != nil { // a file opened by the guest, not a pre-opened directory.
 		return ErrnoBadf
 	}
 
@@ -825,7 +829,7 @@ func (a *snapshotPreview1) FdPread(ctx context.Context, m api.Module, fd, iovs,
 //   * This should match the uint32le FdPrestatGet writes to offset `resultPrestat`+4
 //
 // The wasi.Errno returned is wasi.ErrnoSuccess except the following error conditions:
-// * wasi.ErrnoBadf - if `fd` is invalid
+// * wasi.ErrnoBadf - if `fd` is invalid or the `fd` is not a pre-opened directory.
 // * wasi.ErrnoFault - if `path` is an invalid offset due to the memory constraint
 // * wasi.ErrnoNametoolong - if `pathLen` is longer than the actual length of the result path
 //
@@ -845,7 +849,7 @@ func (a *snapshotPreview1) FdPrestatDirName(ctx context.Context, m api.Module, f
 	sys := sysCtx(m)
 
 	f, ok := sys.OpenedFile(fd)
-	if !ok {
+	if !ok || f.File != nil { // a file opened by the guest, not a pre-opened directory.
 		return ErrnoBadf
 	}
 
@@ -933,7 +937,7 @@ func (a *snapshotPreview1) FdRead(ctx context.Context, m api.Module, fd, iovs, i
 		if !ok {
 			return ErrnoFault
 		}
-		b, ok := m.Memory().Read(ctx, offset, l)
+		b, ok := experimental.ReadAlias(ctx, m.Memory(), offset, l)
 		if !ok {
 			return ErrnoFault
 		}
@@ -1038,7 +1042,7 @@ func (a *snapshotPreview1) FdTell(ctx context.Context, m api.Module, fd, resultO
 // * resultSize - the offset in `m.Memory` to write the number of bytes written
 //
 // The wasi.Errno returned is wasi.ErrnoSuccess except the following error conditions:
-// * wasi.ErrnoBadf - if `fd` is invalid
+// * wasi.ErrnoBadf - if `fd` is invalid, ex. neither an opened file nor configured by wazero.ModuleConfig WithWriter
 // * wasi.ErrnoFault - if `iovs` or `resultSize` contain an invalid offset due to the memory constraint
 // * wasi.ErrnoIo - if an IO related error happens during the operation
 //
@@ -1089,8 +1093,10 @@ func (a *snapshotPreview1) FdWrite(ctx context.Context, m api.Module, fd, iovs,
 	case fdStderr:
 		writer = sys.Stderr()
 	default:
-		// Check to see if the file descriptor is available
-		if f, ok := sys.OpenedFile(fd); !ok || f.File == nil {
+		// Check to see if the file descriptor has a writer (see wazero.ModuleConfig WithWriter) or is available
+		if w, ok := sys.Writer(fd); ok {
+			writer = w
+		} else if f, ok := sys.OpenedFile(fd); !ok || f.File == nil {
 			return ErrnoBadf
 			// fs.FS doesn't declare io.Writer, but implementations such as os.File implement it.
 		} else if writer, ok = f.File.(io.Writer); !ok {
@@ -1109,7 +1115,7 @@ func (a *snapshotPreview1) FdWrite(ctx context.Context, m api.Module, fd, iovs,
 		if !ok {
 			return ErrnoFault
 		}
-		b, ok := m.Memory().Read(ctx, offset, l)
+		b, ok := experimental.ReadAlias(ctx, m.Memory(), offset, l)
 		if !ok {
 			return ErrnoFault
 		}
@@ -1125,9 +1131,20 @@ func (a *snapshotPreview1) FdWrite(ctx context.Context, m api.Module, fd, iovs,
 	return ErrnoSuccess
 }
 
-// PathCreateDirectory is the WASI function named functionPathCreateDirectory
+// PathCreateDirectory is the WASI function named functionPathCreateDirectory, which creates a directory relative to
+// the directory fd. This returns ErrnoNosys unless its file system is an experimental.WritableFS.
+//
+// Note: This is similar to `mkdirat` in POSIX.
+// See https://linux.die.net/man/2/mkdirat
 func (a *snapshotPreview1) PathCreateDirectory(ctx context.Context, m api.Module, fd, path, pathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	wfs, name, errno := writablePath(ctx, m, fd, path, pathLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if err := wfs.Mkdir(name, 0o777); err != nil {
+		return toErrno(err)
+	}
+	return ErrnoSuccess
 }
 
 // PathFilestatGet is the WASI function named functionPathFilestatGet
@@ -1187,31 +1204,38 @@ func (a *snapshotPreview1) PathLink(ctx context.Context, m api.Module, oldFd, ol
 // Note: importPathOpen shows this signature in the WebAssembly 1.0 (20191205) Text Format.
 // Note: This is similar to `openat` in POSIX.
 // Note: The returned file descriptor is not guaranteed to be the lowest-numbered file
-// Note: Rights will never be implemented per https://github.com/WebAssembly/WASI/issues/469#issuecomment-1045251844
+// Note: Rights will never be enforced per https://github.com/WebAssembly/WASI/issues/469#issuecomment-1045251844
+// Note: Files are only writable when the file system of `fd` is an experimental.WritableFS. In that case, `oFlags`
+//       and the append flag of `fdFlags` apply, and a file is opened for write when `fsRightsBase` includes fd_write.
 // See https://github.com/WebAssembly/WASI/blob/main/phases/snapshot/docs.md#path_open
 // See https://linux.die.net/man/3/openat
 func (a *snapshotPreview1) PathOpen(ctx context.Context, m api.Module, fd, dirflags, pathPtr, pathLen, oflags uint32, fsRightsBase,
 	fsRightsInheriting uint64, fdflags, resultOpenedFd uint32) (errno Errno) {
 	sys := sysCtx(m)
 
-	dir, ok := sys.OpenedFile(fd)
-	if !ok || dir.FS == nil {
-		return ErrnoBadf
+	dir, name, errno := openedPath(ctx, m, fd, pathPtr, pathLen)
+	if errno != ErrnoSuccess {
+		return errno
 	}
 
-	b, ok := m.Memory().Read(ctx, pathPtr, pathLen)
-	if !ok {
-		return ErrnoFault
+	// TODO: Consider dirflags.
+	var entry *wasm.FileEntry
+	if wfs, ok := dir.FS.(experimental.WritableFS); ok {
+		entry, errno = openWritableFileEntry(wfs, name, oflags, fsRightsBase, fdflags)
+	} else {
+		entry, errno = openFileEntry(dir.FS, name)
 	}
-
-	// TODO: Consider dirflags and oflags. Also, allow non-read-only open based on config about the mount.
-	// Ex. allow os.O_RDONLY, os.O_WRONLY, or os.O_RDWR either by config flag or pattern on filename
-	// See #390
-	entry, errno := openFileEntry(dir.FS, path.Join(dir.Path, string(b)))
 	if errno != ErrnoSuccess {
 		return errno
 	}
 
+	if oflags&oflagsDirectory != 0 {
+		if st, err := entry.File.Stat(); err != nil || !st.IsDir() {
+			_ = entry.File.Close()
+			return ErrnoNotdir
+		}
+	}
+
 	if newFD, ok := sys.OpenFile(entry); !ok {
 		_ = entry.File.Close()
 		return ErrnoIo
@@ -1227,14 +1251,48 @@ func (a *snapshotPreview1) PathReadlink(ctx context.Context, m api.Module, fd, p
 	return ErrnoNosys // stubbed for GrainLang per #271
 }
 
-// PathRemoveDirectory is the WASI function named functionPathRemoveDirectory
+// PathRemoveDirectory is the WASI function named functionPathRemoveDirectory, which removes an empty directory
+// relative to the directory fd. This returns ErrnoNosys unless its file system is an experimental.WritableFS.
+//
+// Note: This is similar to `unlinkat` with `AT_REMOVEDIR` in POSIX.
+// See https://linux.die.net/man/2/unlinkat
 func (a *snapshotPreview1) PathRemoveDirectory(ctx context.Context, m api.Module, fd, path, pathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	wfs, name, errno := writablePath(ctx, m, fd, path, pathLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if st, err := wfs.Stat(name); err != nil {
+		return toErrno(err)
+	} else if !st.IsDir() {
+		return ErrnoNotdir
+	}
+	if err := wfs.Remove(name); err != nil {
+		return toErrno(err)
+	}
+	return ErrnoSuccess
 }
 
-// PathRename is the WASI function named functionPathRename
+// PathRename is the WASI function named functionPathRename, which renames a file or directory relative to the
+// directory fd to a path relative to the directory newFd. This returns ErrnoNosys unless their file system is an
+// experimental.WritableFS, and ErrnoXdev if they are in different file systems.
+//
+// Note: This is similar to `renameat` in POSIX.
+// See https://linux.die.net/man/2/renameat
 func (a *snapshotPreview1) PathRename(ctx context.Context, m api.Module, fd, oldPath, oldPathLen, newFd, newPath, newPathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	wfs, oldName, errno := writablePath(ctx, m, fd, oldPath, oldPathLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	newFS, newName, errno := writablePath(ctx, m, newFd, newPath, newPathLen)
+	if errno != ErrnoSuccess {
+		return errno
+	} else if newFS != wfs {
+		return ErrnoXdev
+	}
+	if err := wfs.Rename(oldName, newName); err != nil {
+		return toErrno(err)
+	}
+	return ErrnoSuccess
 }
 
 // PathSymlink is the WASI function named functionPathSymlink
@@ -1242,14 +1300,154 @@ func (a *snapshotPreview1) PathSymlink(ctx context.Context, m api.Module, oldPat
 	return ErrnoNosys // stubbed for GrainLang per #271
 }
 
-// PathUnlinkFile is the WASI function named functionPathUnlinkFile
+// PathUnlinkFile is the WASI function named functionPathUnlinkFile, which removes a file relative to the directory fd.
+// This returns ErrnoNosys unless its file system is an experimental.WritableFS.
+//
+// Note: This is similar to `unlinkat` in POSIX.
+// See https://linux.die.net/man/2/unlinkat
 func (a *snapshotPreview1) PathUnlinkFile(ctx context.Context, m api.Module, fd, path, pathLen uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	wfs, name, errno := writablePath(ctx, m, fd, path, pathLen)
+	if errno != ErrnoSuccess {
+		return errno
+	}
+	if st, err := wfs.Stat(name); err != nil {
+		return toErrno(err)
+	} else if st.IsDir() {
+		return ErrnoIsdir
+	}
+	if err := wfs.Remove(name); err != nil {
+		return toErrno(err)
+	}
+	return ErrnoSuccess
 }
 
-// PollOneoff is the WASI function named functionPollOneoff
+const (
+	// subscriptionLen is the size in bytes of a subscription in the "in" parameter of PollOneoff.
+	subscriptionLen = 48
+	// eventLen is the size in bytes of an event written to the "out" parameter of PollOneoff.
+	eventLen = 32
+
+	// eventTypeClock is the event type of a clock subscription, and the only type currently supported.
+	eventTypeClock = 0
+	// subscriptionClockAbstime is a flag which means the clock subscription timeout is absolute, not relative.
+	subscriptionClockAbstime = 1
+)
+
+// Flags and rights of PathOpen.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-oflags-flagsu16
+const (
+	oflagsCreat     = 1 << 0
+	oflagsDirectory = 1 << 1
+	oflagsExcl      = 1 << 2
+	oflagsTrunc     = 1 << 3
+
+	// fdflagsAppend means writes always append to the end of the file.
+	fdflagsAppend = 1 << 0
+
+	// rightFdWrite is the right to write to a file descriptor.
+	rightFdWrite = 1 << 6
+)
+
+// PollOneoff is the WASI function named functionPollOneoff that concurrently polls for the occurrence of a set of
+// events. This only supports clock subscriptions, which sleep until their timeout elapses.
+//
+// * in - the offset to read nsubscriptions subscriptions from m.Memory, each 48 bytes.
+// * out - the offset to write the resulting events to m.Memory, each 32 bytes.
+// * nsubscriptions - the count of subscriptions, which must be non-zero.
+// * resultNevents - the offset to write the count of events as a uint32 little-endian encoding.
+//
+// Sleeping is done by experimental.Sleeper when the experimental.Sys implements it, which allows guests to sleep
+// against a virtual clock. Otherwise, this sleeps until the timeout elapses or ctx is done. The order concurrent guests
+// wake in is decided by the experimental.Sleeper, as this only sleeps once, for the shortest timeout.
+//
+// Subscriptions besides clocks (fd_read and fd_write) are not supported, so their events are written with the error
+// ErrnoNotsup. When there are any such events, this doesn't sleep.
+//
+// Note: importPollOneoff shows this signature in the WebAssembly 1.0 (20191205) Text Format.
+// Note: This is similar to `poll` in POSIX.
+// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#poll_oneoff
+// See https://linux.die.net/man/3/poll
 func (a *snapshotPreview1) PollOneoff(ctx context.Context, m api.Module, in, out, nsubscriptions, resultNevents uint32) Errno {
-	return ErrnoNosys // stubbed for GrainLang per #271
+	if nsubscriptions == 0 {
+		return ErrnoInval
+	}
+
+	// Bound the size in uint64, as multiplying nsubscriptions could overflow uint32, before allocating timeouts.
+	mem := m.Memory()
+	if uint64(nsubscriptions)*subscriptionLen > uint64(mem.Size(ctx)) {
+		return ErrnoFault
+	}
+	subs, ok := experimental.ReadAlias(ctx, mem, in, nsubscriptions*subscriptionLen)
+	if !ok {
+		return ErrnoFault
+	}
+	events, ok := experimental.ReadAlias(ctx, mem, out, nsubscriptions*eventLen)
+	if !ok {
+		return ErrnoFault
+	}
+
+	// Find the shortest clock timeout, as that's when the first clock event occurs.
+	now := a.sys.TimeNowUnixNano()
+	timeouts := make([]uint64, nsubscriptions)
+	var timeout uint64
+	hasTimeout, hasUnsupported := false, false
+	for i := uint32(0); i < nsubscriptions; i++ {
+		sub := subs[i*subscriptionLen:]
+		if sub[8] != eventTypeClock {
+			hasUnsupported = true
+			continue
+		}
+		t := binary.LittleEndian.Uint64(sub[24:])
+		if binary.LittleEndian.Uint16(sub[40:])&subscriptionClockAbstime != 0 {
+			if t > now {
+				t -= now
+			} else {
+				t = 0
+			}
+		}
+		timeouts[i] = t
+		if !hasTimeout || t < timeout {
+			timeout = t
+		}
+		hasTimeout = true
+	}
+
+	// Unsupported subscriptions occur immediately, so only sleep when there are none.
+	if !hasUnsupported && timeout > 0 {
+		if sleeper, ok := a.sys.(experimental.Sleeper); ok {
+			sleeper.Sleep(ctx, timeout)
+		} else {
+			select {
+			case <-time.After(time.Duration(timeout)):
+			case <-ctx.Done():
+			}
+		}
+	}
+
+	// Write an event for each subscription that occurred.
+	nevents := uint32(0)
+	for i := uint32(0); i < nsubscriptions; i++ {
+		sub := subs[i*subscriptionLen:]
+		errno := ErrnoSuccess
+		if sub[8] != eventTypeClock {
+			errno = ErrnoNotsup
+		} else if hasUnsupported || timeouts[i] > timeout {
+			continue // this clock hasn't elapsed
+		}
+		event := events[nevents*eventLen : (nevents+1)*eventLen]
+		for j := range event {
+			event[j] = 0
+		}
+		copy(event, sub[0:8]) // userdata
+		binary.LittleEndian.PutUint16(event[8:], uint16(errno))
+		event[10] = sub[8] // type
+		nevents++
+	}
+
+	if !mem.WriteUint32Le(ctx, resultNevents, nevents) {
+		return ErrnoFault
+	}
+	return ErrnoSuccess
 }
 
 // ProcExit is the WASI function that terminates the execution of the module with an exit code.
@@ -1357,23 +1555,102 @@ func sysCtx(m api.Module) *wasm.SysContext {
 	}
 }
 
+// openedPath returns the directory fd and the name of the path read from memory, relative to the root of its file
+// system. This returns ErrnoNotcapable if the path escapes that root, such as "../foo".
+func openedPath(ctx context.Context, m api.Module, fd, pathPtr, pathLen uint32) (*wasm.FileEntry, string, Errno) {
+	sys := sysCtx(m)
+
+	dir, ok := sys.OpenedFile(fd)
+	if !ok || dir.FS == nil {
+		if errno, ok := sys.NoFSErrno(); ok {
+			return nil, "", errno
+		}
+		return nil, "", ErrnoBadf
+	}
+
+	b, ok := m.Memory().Read(ctx, pathPtr, pathLen)
+	if !ok {
+		return nil, "", ErrnoFault
+	}
+
+	name := path.Join(dir.Path, string(b))
+	if name == ".." || strings.HasPrefix(name, "../") {
+		return nil, "", ErrnoNotcapable
+	}
+	// fs.FS names are relative to its root, so a path in the root pre-open ("/") must not begin with a slash.
+	if name = strings.TrimPrefix(name, "/"); name == "" {
+		name = "."
+	}
+	return dir, name, ErrnoSuccess
+}
+
+// writablePath is like openedPath, except it returns ErrnoNosys unless the file system is an experimental.WritableFS.
+func writablePath(ctx context.Context, m api.Module, fd, pathPtr, pathLen uint32) (experimental.WritableFS, string, Errno) {
+	dir, name, errno := openedPath(ctx, m, fd, pathPtr, pathLen)
+	if errno != ErrnoSuccess {
+		return nil, "", errno
+	}
+	if wfs, ok := dir.FS.(experimental.WritableFS); ok {
+		return wfs, name, ErrnoSuccess
+	}
+	return nil, "", ErrnoNosys
+}
+
 func openFileEntry(rootFS fs.FS, pathName string) (*wasm.FileEntry, Errno) {
 	f, err := rootFS.Open(pathName)
 	if err != nil {
-		switch {
-		case errors.Is(err, fs.ErrNotExist):
-			return nil, ErrnoNoent
-		case errors.Is(err, fs.ErrExist):
-			return nil, ErrnoExist
-		default:
-			return nil, ErrnoIo
-		}
+		return nil, toErrno(err)
 	}
+	return &wasm.FileEntry{Path: pathName, FS: rootFS, File: f}, ErrnoSuccess
+}
 
-	// TODO: verify if oflags is a directory and fail with wasi.ErrnoNotdir if not
-	// See https://github.com/WebAssembly/WASI/blob/snapshot-01/phases/snapshot/docs.md#-oflags-flagsu16
+// openWritableFileEntry opens the file with flags corresponding to the parameters of PathOpen.
+func openWritableFileEntry(wfs experimental.WritableFS, pathName string, oflags uint32, fsRightsBase uint64, fdflags uint32) (*wasm.FileEntry, Errno) {
+	flag := os.O_RDONLY
+	if fsRightsBase&rightFdWrite != 0 {
+		flag = os.O_RDWR
+	}
+	if oflags&oflagsCreat != 0 {
+		flag |= os.O_CREATE
+	}
+	if oflags&oflagsExcl != 0 {
+		flag |= os.O_EXCL
+	}
+	if oflags&oflagsTrunc != 0 {
+		flag |= os.O_TRUNC
+	}
+	if fdflags&fdflagsAppend != 0 {
+		flag |= os.O_APPEND
+	}
 
-	return &wasm.FileEntry{Path: pathName, FS: rootFS, File: f}, ErrnoSuccess
+	f, err := wfs.OpenFile(pathName, flag, 0o666)
+	if err != nil {
+		return nil, toErrno(err)
+	}
+	return &wasm.FileEntry{Path: pathName, FS: wfs, File: f}, ErrnoSuccess
+}
+
+// toErrno converts an error from a file system to the closest Errno, defaulting to ErrnoIo.
+func toErrno(err error) Errno {
+	switch {
+	// Check syscall errors first, as syscall.ENOTEMPTY is also fs.ErrExist on some platforms.
+	case errors.Is(err, syscall.ENOTEMPTY):
+		return ErrnoNotempty
+	case errors.Is(err, syscall.EISDIR):
+		return ErrnoIsdir
+	case errors.Is(err, syscall.ENOTDIR):
+		return ErrnoNotdir
+	case errors.Is(err, fs.ErrNotExist):
+		return ErrnoNoent
+	case errors.Is(err, fs.ErrExist):
+		return ErrnoExist
+	case errors.Is(err, fs.ErrInvalid):
+		return ErrnoInval
+	case errors.Is(err, fs.ErrPermission):
+		return ErrnoPerm
+	default:
+		return ErrnoIo
+	}
 }
 
 func writeOffsetsAndNullTerminatedValues(ctx context.Context, mem api.Memory, values []string, offsets, bytes uint32) Errno {
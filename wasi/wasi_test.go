@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	_ "embed"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -12,14 +13,17 @@ import (
 	"math/rand"
 	"os"
 	"path"
+	"sync"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/tetratelabs/wazero"
 	"github.com/tetratelabs/wazero/api"
 	"github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
+	wasmbinary "github.com/tetratelabs/wazero/internal/wasm/binary"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -782,7 +786,12 @@ func TestSnapshotPreview1_FdPrestatGet_Errors(t *testing.T) {
 	fd := uint32(3)           // fd 3 will be opened for the "/tmp" directory after 0, 1, and 2, that are stdin/out/err
 	validAddress := uint32(0) // Arbitrary valid address as arguments to fd_prestat_get. We chose 0 here.
 
-	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{fd: {Path: "/tmp"}})
+	file, err := fstest.MapFS{"a": {}}.Open("a")
+	require.NoError(t, err)
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+		fd:     {Path: "/tmp"},
+		fd + 1: {Path: "/tmp/a", File: file}, // opened by the guest
+	})
 	require.NoError(t, err)
 
 	a, mod, _ := instantiateModule(testCtx, t, functionFdPrestatGet, importFdPrestatGet, sysCtx)
@@ -808,7 +817,12 @@ func TestSnapshotPreview1_FdPrestatGet_Errors(t *testing.T) {
 			resultPrestat: memorySize,
 			expectedErrno: ErrnoFault,
 		},
-		// TODO: non pre-opened file == api.ErrnoBadf
+		{
+			name:          "not pre-opened",
+			fd:            fd + 1,
+			resultPrestat: validAddress,
+			expectedErrno: ErrnoBadf,
+		},
 	}
 
 	for _, tt := range tests {
@@ -865,7 +879,12 @@ func TestSnapshotPreview1_FdPrestatDirName(t *testing.T) {
 
 func TestSnapshotPreview1_FdPrestatDirName_Errors(t *testing.T) {
 	fd := uint32(3) // arbitrary fd after 0, 1, and 2, that are stdin/out/err
-	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{fd: {Path: "/tmp"}})
+	file, err := fstest.MapFS{"a": {}}.Open("a")
+	require.NoError(t, err)
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{
+		fd:     {Path: "/tmp"},
+		fd + 1: {Path: "/tmp/a", File: file}, // opened by the guest
+	})
 	require.NoError(t, err)
 
 	a, mod, _ := instantiateModule(testCtx, t, functionFdPrestatDirName, importFdPrestatDirName, sysCtx)
@@ -910,7 +929,13 @@ func TestSnapshotPreview1_FdPrestatDirName_Errors(t *testing.T) {
 			pathLen:       pathLen,
 			expectedErrno: ErrnoBadf,
 		},
-		// TODO: non pre-opened file == wasi.ErrnoBadf
+		{
+			name:          "not pre-opened",
+			fd:            fd + 1,
+			path:          validAddress,
+			pathLen:       uint32(len("/tmp/a")),
+			expectedErrno: ErrnoBadf,
+		},
 	}
 
 	for _, tt := range tests {
@@ -923,6 +948,76 @@ func TestSnapshotPreview1_FdPrestatDirName_Errors(t *testing.T) {
 	}
 }
 
+// TestSnapshotPreview1_FdPrestat_Preopens ensures a guest enumerating pre-opened directories, as wasi-libc does on
+// startup, sees each configured in order, with the name length from fd_prestat_get matching fd_prestat_dir_name.
+func TestSnapshotPreview1_FdPrestat_Preopens(t *testing.T) {
+	r := wazero.NewRuntime()
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module `+importFdPrestatGet+importFdPrestatDirName+`
+  (memory 1)
+  (export "fd_prestat_get" (func $wasi.fd_prestat_get))
+  (export "fd_prestat_dir_name" (func $wasi.fd_prestat_dir_name))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	tests := []struct {
+		name     string
+		config   wazero.ModuleConfig
+		expected []string
+	}{
+		{
+			name:   "none",
+			config: wazero.NewModuleConfig(),
+		},
+		{
+			name:     "root defaults the working directory",
+			config:   wazero.NewModuleConfig().WithFS(fstest.MapFS{}),
+			expected: []string{"/", "."},
+		},
+		{
+			name:     "root and working directory",
+			config:   wazero.NewModuleConfig().WithWorkDirFS(fstest.MapFS{}).WithFS(fstest.MapFS{}),
+			expected: []string{".", "/"},
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, tc.config.WithName(t.Name()))
+			require.NoError(t, err)
+			defer mod.Close(testCtx)
+
+			var names []string
+			for fd := uint64(3); ; fd++ { // the first pre-opened file descriptor, if any.
+				results, err := mod.ExportedFunction("fd_prestat_get").Call(testCtx, fd, 0)
+				require.NoError(t, err)
+				if Errno(results[0]) == ErrnoBadf {
+					break // no more pre-opens
+				}
+				require.Equal(t, ErrnoSuccess, Errno(results[0]))
+
+				nameLen, ok := mod.Memory().ReadUint32Le(testCtx, 4)
+				require.True(t, ok)
+				results, err = mod.ExportedFunction("fd_prestat_dir_name").Call(testCtx, fd, 8, uint64(nameLen))
+				require.NoError(t, err)
+				require.Equal(t, ErrnoSuccess, Errno(results[0]))
+
+				name, ok := mod.Memory().Read(testCtx, 8, nameLen)
+				require.True(t, ok)
+				names = append(names, string(name))
+			}
+			require.Equal(t, tc.expected, names)
+		})
+	}
+
+}
+
 // TestSnapshotPreview1_FdPwrite only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_FdPwrite(t *testing.T) {
 	a, mod, fn := instantiateModule(testCtx, t, functionFdPwrite, importFdPwrite, nil)
@@ -1486,18 +1581,58 @@ func TestSnapshotPreview1_FdWrite_Errors(t *testing.T) {
 	}
 }
 
-// TestSnapshotPreview1_PathCreateDirectory only tests it is stubbed for GrainLang per #271
+func TestSnapshotPreview1_FdWrite_Writer(t *testing.T) {
+	r := wazero.NewRuntime()
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module `+importFdWrite+`
+  (memory 1)
+  (export "fd_write" (func $wasi.fd_write))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	var log bytes.Buffer
+	mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, wazero.NewModuleConfig().WithWriter(3, &log))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	iovs, resultSize := uint32(0), uint32(16)
+	require.True(t, mod.Memory().Write(testCtx, iovs, []byte{
+		8, 0, 0, 0, // = iovs[0].offset (where the data "hi" begins)
+		2, 0, 0, 0, // = iovs[0].length (how many bytes are in "hi")
+		'h', 'i', // iovs[0].length bytes
+	}))
+
+	fdWrite := mod.ExportedFunction("fd_write")
+	results, err := fdWrite.Call(testCtx, 3, uint64(iovs), 1, uint64(resultSize))
+	require.NoError(t, err)
+	require.Equal(t, ErrnoSuccess, Errno(results[0]), ErrnoName(Errno(results[0])))
+	require.Equal(t, "hi", log.String())
+	nwritten, ok := mod.Memory().ReadUint32Le(testCtx, resultSize)
+	require.True(t, ok)
+	require.Equal(t, uint32(2), nwritten)
+
+	// A file descriptor without a writer, which isn't an opened file, is still invalid.
+	results, err = fdWrite.Call(testCtx, 4, uint64(iovs), 1, uint64(resultSize))
+	require.NoError(t, err)
+	require.Equal(t, ErrnoBadf, Errno(results[0]), ErrnoName(Errno(results[0])))
+}
+
+// TestSnapshotPreview1_PathCreateDirectory only tests it is unsupported on a read-only file system. See TestSnapshotPreview1_MemFS
 func TestSnapshotPreview1_PathCreateDirectory(t *testing.T) {
-	a, mod, fn := instantiateModule(testCtx, t, functionPathCreateDirectory, importPathCreateDirectory, nil)
+	a, mod, fn := instantiateModule(testCtx, t, functionPathCreateDirectory, importPathCreateDirectory, readOnlySysContext(t))
 	defer mod.Close(testCtx)
 
 	t.Run("snapshotPreview1.PathCreateDirectory", func(t *testing.T) {
-		errno := a.PathCreateDirectory(testCtx, mod, 0, 0, 0)
+		errno := a.PathCreateDirectory(testCtx, mod, readOnlyFD, 0, 0)
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
 	})
 
 	t.Run(functionPathCreateDirectory, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0, 0)
+		results, err := fn.Call(testCtx, uint64(readOnlyFD), 0, 0)
 		require.NoError(t, err)
 		errno := Errno(results[0]) // results[0] is the errno
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
@@ -1623,6 +1758,254 @@ func TestSnapshotPreview1_PathOpen(t *testing.T) {
 	})
 }
 
+func TestSnapshotPreview1_PathOpen_NoFSErrno(t *testing.T) {
+	r := wazero.NewRuntime()
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module `+importPathOpen+`
+  (memory 1)
+  (export "path_open" (func $wasi.path_open))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	pathName := "wazero" // written to memory at offset zero.
+	tests := []struct {
+		name          string
+		config        wazero.ModuleConfig
+		expectedErrno Errno
+	}{
+		{
+			name:          "no FS",
+			config:        wazero.NewModuleConfig(),
+			expectedErrno: ErrnoBadf,
+		},
+		{
+			name:          "no FS with errno",
+			config:        wazero.NewModuleConfig().WithNoFSErrno(ErrnoAcces),
+			expectedErrno: ErrnoAcces,
+		},
+		{
+			name:          "FS with errno",
+			config:        wazero.NewModuleConfig().WithNoFSErrno(ErrnoAcces).WithFS(fstest.MapFS{}),
+			expectedErrno: ErrnoNoent, // the path doesn't exist in the FS.
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, tc.config.WithName(t.Name()))
+			require.NoError(t, err)
+			defer mod.Close(testCtx)
+			require.True(t, mod.Memory().Write(testCtx, 0, []byte(pathName)))
+
+			fd := uint64(3) // the first pre-opened file descriptor, if any.
+			results, err := mod.ExportedFunction("path_open").Call(testCtx, fd, 0, 0, uint64(len(pathName)), 0, 0, 0, 0, 16)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedErrno, Errno(results[0]), ErrnoName(Errno(results[0])))
+		})
+	}
+}
+
+// TestSnapshotPreview1_MemFS tests a guest can create, write, read, rename and remove files of an
+// experimental.MemFS, which is changed accordingly.
+func TestSnapshotPreview1_MemFS(t *testing.T) {
+	r := wazero.NewRuntime()
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module `+importPathCreateDirectory+importPathOpen+importFdWrite+
+		importFdRead+importFdClose+importPathRename+importPathUnlinkFile+importPathRemoveDirectory+`
+  (memory 1)
+  (export "path_create_directory" (func $wasi.path_create_directory))
+  (export "path_open" (func $wasi.path_open))
+  (export "fd_write" (func $wasi.fd_write))
+  (export "fd_read" (func $wasi.fd_read))
+  (export "fd_close" (func $wasi.fd_close))
+  (export "path_rename" (func $wasi.path_rename))
+  (export "path_unlink_file" (func $wasi.path_unlink_file))
+  (export "path_remove_directory" (func $wasi.path_remove_directory))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	memFS := experimental.NewMemFS()
+	mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, wazero.NewModuleConfig().WithWorkDirFS(memFS))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	workdirFD := uint64(3) // the first pre-opened file descriptor
+	const iovs, resultPtr, buf = uint32(128), uint32(192), uint32(256)
+	readWriteRights := uint64(rightFdWrite | 1<<1 /* fd_read */)
+
+	call := func(name string, params ...uint64) Errno {
+		results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+		return Errno(results[0])
+	}
+	// writePath writes the path to memory at the offset, returning the parameters to pass it.
+	writePath := func(offset uint32, pathName string) (uint64, uint64) {
+		require.True(t, mod.Memory().Write(testCtx, offset, []byte(pathName)))
+		return uint64(offset), uint64(len(pathName))
+	}
+	requireSuccess := func(errno Errno) {
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+	}
+	openFile := func(pathName string, oflags uint32, rights uint64) uint64 {
+		path, pathLen := writePath(0, pathName)
+		requireSuccess(call("path_open", workdirFD, 0, path, pathLen, uint64(oflags), rights, 0, 0, uint64(resultPtr)))
+		fd, ok := mod.Memory().ReadUint32Le(testCtx, resultPtr)
+		require.True(t, ok)
+		return uint64(fd)
+	}
+
+	path, pathLen := writePath(0, "dir")
+	requireSuccess(call("path_create_directory", workdirFD, path, pathLen))
+
+	// Create and write a file in the directory.
+	fd := openFile("dir/a.txt", oflagsCreat|oflagsExcl, readWriteRights)
+	require.True(t, mod.Memory().Write(testCtx, buf, []byte("wazero")))
+	require.True(t, mod.Memory().WriteUint32Le(testCtx, iovs, buf))
+	require.True(t, mod.Memory().WriteUint32Le(testCtx, iovs+4, 6))
+	requireSuccess(call("fd_write", fd, uint64(iovs), 1, uint64(resultPtr)))
+	requireSuccess(call("fd_close", fd))
+
+	// Read it back, after clearing the buffer.
+	require.True(t, mod.Memory().Write(testCtx, buf, make([]byte, 6)))
+	fd = openFile("dir/a.txt", 0, 1<<1 /* fd_read */)
+	requireSuccess(call("fd_read", fd, uint64(iovs), 1, uint64(resultPtr)))
+	nread, ok := mod.Memory().ReadUint32Le(testCtx, resultPtr)
+	require.True(t, ok)
+	read, ok := mod.Memory().Read(testCtx, buf, nread)
+	require.True(t, ok)
+	require.Equal(t, "wazero", string(read))
+	requireSuccess(call("fd_close", fd))
+
+	// Rename the file out of the directory.
+	oldPath, oldPathLen := writePath(0, "dir/a.txt")
+	newPath, newPathLen := writePath(64, "b.txt")
+	requireSuccess(call("path_rename", workdirFD, oldPath, oldPathLen, workdirFD, newPath, newPathLen))
+	data, err := fs.ReadFile(memFS, "b.txt")
+	require.NoError(t, err)
+	require.Equal(t, "wazero", string(data))
+	_, err = memFS.Stat("dir/a.txt")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	t.Run("errors", func(t *testing.T) {
+		tests := []struct {
+			name, function, pathName string
+			oflags                   uint32
+			expectedErrno            Errno
+		}{
+			{name: "create existing", function: "path_open", pathName: "b.txt", oflags: oflagsCreat | oflagsExcl, expectedErrno: ErrnoExist},
+			{name: "open file as directory", function: "path_open", pathName: "b.txt", oflags: oflagsDirectory, expectedErrno: ErrnoNotdir},
+			{name: "open outside root", function: "path_open", pathName: "../b.txt", expectedErrno: ErrnoNotcapable},
+			{name: "create outside root", function: "path_create_directory", pathName: "dir/../../dir", expectedErrno: ErrnoNotcapable},
+			{name: "unlink directory", function: "path_unlink_file", pathName: "dir", expectedErrno: ErrnoIsdir},
+			{name: "remove file as directory", function: "path_remove_directory", pathName: "b.txt", expectedErrno: ErrnoNotdir},
+			{name: "unlink missing", function: "path_unlink_file", pathName: "c.txt", expectedErrno: ErrnoNoent},
+		}
+
+		for _, tt := range tests {
+			tc := tt
+
+			t.Run(tc.name, func(t *testing.T) {
+				path, pathLen := writePath(0, tc.pathName)
+				var errno Errno
+				if tc.function == "path_open" {
+					errno = call(tc.function, workdirFD, 0, path, pathLen, uint64(tc.oflags), readWriteRights, 0, 0, uint64(resultPtr))
+				} else {
+					errno = call(tc.function, workdirFD, path, pathLen)
+				}
+				require.Equal(t, tc.expectedErrno, errno, ErrnoName(errno))
+			})
+		}
+	})
+
+	// Remove the file and the now empty directory.
+	path, pathLen = writePath(0, "b.txt")
+	requireSuccess(call("path_unlink_file", workdirFD, path, pathLen))
+	path, pathLen = writePath(0, "dir")
+	requireSuccess(call("path_remove_directory", workdirFD, path, pathLen))
+
+	entries, err := fs.ReadDir(memFS, ".")
+	require.NoError(t, err)
+	require.Zero(t, len(entries))
+}
+
+// TestSnapshotPreview1_MemFS_Root tests paths in the root pre-open of WithFS ("/"), which fs.FS names don't begin with.
+func TestSnapshotPreview1_MemFS_Root(t *testing.T) {
+	r := wazero.NewRuntime()
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module `+importPathCreateDirectory+importPathOpen+importFdWrite+importFdClose+`
+  (memory 1)
+  (export "path_create_directory" (func $wasi.path_create_directory))
+  (export "path_open" (func $wasi.path_open))
+  (export "fd_write" (func $wasi.fd_write))
+  (export "fd_close" (func $wasi.fd_close))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	memFS := experimental.NewMemFS()
+	mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, wazero.NewModuleConfig().WithFS(memFS))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	rootFD, workdirFD := uint64(3), uint64(4) // WithFS pre-opens "/", then defaults "." to the same file system.
+	const iovs, resultPtr, buf = uint32(128), uint32(192), uint32(256)
+
+	call := func(name string, params ...uint64) Errno {
+		results, err := mod.ExportedFunction(name).Call(testCtx, params...)
+		require.NoError(t, err)
+		return Errno(results[0])
+	}
+	writePath := func(pathName string) (uint64, uint64) {
+		require.True(t, mod.Memory().Write(testCtx, 0, []byte(pathName)))
+		return 0, uint64(len(pathName))
+	}
+	requireSuccess := func(errno Errno) {
+		require.Equal(t, ErrnoSuccess, errno, ErrnoName(errno))
+	}
+
+	path, pathLen := writePath("dir")
+	requireSuccess(call("path_create_directory", rootFD, path, pathLen))
+
+	// Create and write a file in the directory, via each pre-open.
+	require.True(t, mod.Memory().Write(testCtx, buf, []byte("wazero")))
+	require.True(t, mod.Memory().WriteUint32Le(testCtx, iovs, buf))
+	require.True(t, mod.Memory().WriteUint32Le(testCtx, iovs+4, 6))
+	for _, tc := range []struct {
+		dirFD    uint64
+		pathName string
+	}{
+		{dirFD: rootFD, pathName: "dir/a.txt"},
+		{dirFD: rootFD, pathName: "/dir/b.txt"},
+		{dirFD: workdirFD, pathName: "dir/c.txt"},
+	} {
+		path, pathLen = writePath(tc.pathName)
+		requireSuccess(call("path_open", tc.dirFD, 0, path, pathLen, uint64(oflagsCreat), uint64(rightFdWrite), 0, 0, uint64(resultPtr)))
+		fd, ok := mod.Memory().ReadUint32Le(testCtx, resultPtr)
+		require.True(t, ok)
+		requireSuccess(call("fd_write", uint64(fd), uint64(iovs), 1, uint64(resultPtr)))
+		requireSuccess(call("fd_close", uint64(fd)))
+	}
+
+	for _, name := range []string{"dir/a.txt", "dir/b.txt", "dir/c.txt"} {
+		data, err := fs.ReadFile(memFS, name)
+		require.NoError(t, err)
+		require.Equal(t, "wazero", string(data))
+	}
+}
+
 func TestSnapshotPreview1_PathOpen_Errors(t *testing.T) {
 	validFD := uint32(3) // arbitrary valid fd after 0, 1, and 2, that are stdin/out/err
 	pathName := "wazero"
@@ -1708,36 +2091,36 @@ func TestSnapshotPreview1_PathReadlink(t *testing.T) {
 	})
 }
 
-// TestSnapshotPreview1_PathRemoveDirectory only tests it is stubbed for GrainLang per #271
+// TestSnapshotPreview1_PathRemoveDirectory only tests it is unsupported on a read-only file system. See TestSnapshotPreview1_MemFS
 func TestSnapshotPreview1_PathRemoveDirectory(t *testing.T) {
-	a, mod, fn := instantiateModule(testCtx, t, functionPathRemoveDirectory, importPathRemoveDirectory, nil)
+	a, mod, fn := instantiateModule(testCtx, t, functionPathRemoveDirectory, importPathRemoveDirectory, readOnlySysContext(t))
 	defer mod.Close(testCtx)
 
 	t.Run("snapshotPreview1.PathRemoveDirectory", func(t *testing.T) {
-		errno := a.PathRemoveDirectory(testCtx, mod, 0, 0, 0)
+		errno := a.PathRemoveDirectory(testCtx, mod, readOnlyFD, 0, 0)
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
 	})
 
 	t.Run(functionPathRemoveDirectory, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0, 0)
+		results, err := fn.Call(testCtx, uint64(readOnlyFD), 0, 0)
 		require.NoError(t, err)
 		errno := Errno(results[0]) // results[0] is the errno
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
 	})
 }
 
-// TestSnapshotPreview1_PathRename only tests it is stubbed for GrainLang per #271
+// TestSnapshotPreview1_PathRename only tests it is unsupported on a read-only file system. See TestSnapshotPreview1_MemFS
 func TestSnapshotPreview1_PathRename(t *testing.T) {
-	a, mod, fn := instantiateModule(testCtx, t, functionPathRename, importPathRename, nil)
+	a, mod, fn := instantiateModule(testCtx, t, functionPathRename, importPathRename, readOnlySysContext(t))
 	defer mod.Close(testCtx)
 
 	t.Run("snapshotPreview1.PathRename", func(t *testing.T) {
-		errno := a.PathRename(testCtx, mod, 0, 0, 0, 0, 0, 0)
+		errno := a.PathRename(testCtx, mod, readOnlyFD, 0, 0, readOnlyFD, 0, 0)
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
 	})
 
 	t.Run(functionPathRename, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0, 0, 0, 0, 0)
+		results, err := fn.Call(testCtx, uint64(readOnlyFD), 0, 0, uint64(readOnlyFD), 0, 0)
 		require.NoError(t, err)
 		errno := Errno(results[0]) // results[0] is the errno
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
@@ -1762,42 +2145,291 @@ func TestSnapshotPreview1_PathSymlink(t *testing.T) {
 	})
 }
 
-// TestSnapshotPreview1_PathUnlinkFile only tests it is stubbed for GrainLang per #271
+// TestSnapshotPreview1_PathUnlinkFile only tests it is unsupported on a read-only file system. See TestSnapshotPreview1_MemFS
 func TestSnapshotPreview1_PathUnlinkFile(t *testing.T) {
-	a, mod, fn := instantiateModule(testCtx, t, functionPathUnlinkFile, importPathUnlinkFile, nil)
+	a, mod, fn := instantiateModule(testCtx, t, functionPathUnlinkFile, importPathUnlinkFile, readOnlySysContext(t))
 	defer mod.Close(testCtx)
 
 	t.Run("snapshotPreview1.PathUnlinkFile", func(t *testing.T) {
-		errno := a.PathUnlinkFile(testCtx, mod, 0, 0, 0)
+		errno := a.PathUnlinkFile(testCtx, mod, readOnlyFD, 0, 0)
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
 	})
 
 	t.Run(functionPathUnlinkFile, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0, 0)
+		results, err := fn.Call(testCtx, uint64(readOnlyFD), 0, 0)
 		require.NoError(t, err)
 		errno := Errno(results[0]) // results[0] is the errno
 		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
 	})
 }
 
-// TestSnapshotPreview1_PollOneoff only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_PollOneoff(t *testing.T) {
-	a, mod, fn := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, nil)
-	defer mod.Close(testCtx)
+	clock := &virtualClock{now: epochNanos, participants: 1}
+	ctx := context.WithValue(context.Background(), experimental.SysKey{}, clock)
+
+	a, mod, fn := instantiateModule(ctx, t, functionPollOneoff, importPollOneoff, nil)
+	defer mod.Close(ctx)
+
+	in, out, nsubscriptions, resultNevents := uint32(0), uint32(48), uint32(1), uint32(80)
+	subscription := clockSubscription(7, uint64(time.Hour), false)
+	expectedEvent := []byte{
+		7, 0, 0, 0, 0, 0, 0, 0, // userdata
+		0, 0, // errno
+		eventTypeClock,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, // fd_readwrite, unused for clocks
+	}
+
+	requireEvent := func(t *testing.T) {
+		event, ok := mod.Memory().Read(ctx, out, eventLen)
+		require.True(t, ok)
+		require.Equal(t, expectedEvent, event)
+		nevents, ok := mod.Memory().ReadUint32Le(ctx, resultNevents)
+		require.True(t, ok)
+		require.Equal(t, uint32(1), nevents)
+	}
 
 	t.Run("snapshotPreview1.PollOneoff", func(t *testing.T) {
-		errno := a.PollOneoff(testCtx, mod, 0, 0, 0, 0)
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+		maskMemory(t, ctx, mod, int(resultNevents+4))
+		require.True(t, mod.Memory().Write(ctx, in, subscription))
+		before := clock.TimeNowUnixNano()
+
+		errno := a.PollOneoff(ctx, mod, in, out, nsubscriptions, resultNevents)
+		require.Zero(t, errno, ErrnoName(errno))
+
+		require.Equal(t, before+uint64(time.Hour), clock.TimeNowUnixNano())
+		requireEvent(t)
 	})
 
 	t.Run(functionPollOneoff, func(t *testing.T) {
-		results, err := fn.Call(testCtx, 0, 0, 0, 0)
+		maskMemory(t, ctx, mod, int(resultNevents+4))
+		require.True(t, mod.Memory().Write(ctx, in, subscription))
+		before := clock.TimeNowUnixNano()
+
+		results, err := fn.Call(ctx, uint64(in), uint64(out), uint64(nsubscriptions), uint64(resultNevents))
 		require.NoError(t, err)
 		errno := Errno(results[0]) // results[0] is the errno
-		require.Equal(t, ErrnoNosys, errno, ErrnoName(errno))
+		require.Zero(t, errno, ErrnoName(errno))
+
+		require.Equal(t, before+uint64(time.Hour), clock.TimeNowUnixNano())
+		requireEvent(t)
+	})
+
+	t.Run("abstime", func(t *testing.T) {
+		maskMemory(t, ctx, mod, int(resultNevents+4))
+		deadline := clock.TimeNowUnixNano() + uint64(time.Minute)
+		require.True(t, mod.Memory().Write(ctx, in, clockSubscription(7, deadline, true)))
+
+		errno := a.PollOneoff(ctx, mod, in, out, nsubscriptions, resultNevents)
+		require.Zero(t, errno, ErrnoName(errno))
+
+		require.Equal(t, deadline, clock.TimeNowUnixNano())
+		requireEvent(t)
 	})
 }
 
+// TestSnapshotPreview1_PollOneoff_Canceled ensures sleeping without an experimental.Sleeper stops when the context is
+// done, instead of blocking until the timeout elapses.
+func TestSnapshotPreview1_PollOneoff_Canceled(t *testing.T) {
+	a, mod, _ := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, nil)
+	defer mod.Close(testCtx)
+
+	in, out, nsubscriptions, resultNevents := uint32(0), uint32(48), uint32(1), uint32(80)
+	require.True(t, mod.Memory().Write(testCtx, in, clockSubscription(7, uint64(time.Hour), false)))
+
+	ctx, cancel := context.WithCancel(testCtx)
+	cancel()
+
+	done := make(chan Errno, 1)
+	go func() {
+		done <- a.PollOneoff(ctx, mod, in, out, nsubscriptions, resultNevents)
+	}()
+	select {
+	case errno := <-done:
+		require.Zero(t, errno, ErrnoName(errno))
+	case <-time.After(10 * time.Second):
+		t.Fatal("PollOneoff didn't return when the context was done")
+	}
+}
+
+// TestSnapshotPreview1_PollOneoff_VirtualClock ensures concurrent guests sleeping on a virtual clock wake in order of
+// their deadlines, without any real delay.
+func TestSnapshotPreview1_PollOneoff_VirtualClock(t *testing.T) {
+	clock := &virtualClock{now: epochNanos, participants: 2}
+	ctx := context.WithValue(context.Background(), experimental.SysKey{}, clock)
+
+	type wake struct {
+		name  string
+		now   uint64
+		errno Errno
+		err   error
+	}
+	wakes := make(chan *wake, 2)
+
+	for _, g := range []struct {
+		name     string
+		duration time.Duration
+	}{
+		{name: "a", duration: 2 * time.Hour},
+		{name: "b", duration: time.Hour},
+	} {
+		g := g
+		_, mod, fn := instantiateModule(ctx, t, functionPollOneoff, importPollOneoff, nil)
+		defer mod.Close(ctx)
+		require.True(t, mod.Memory().Write(ctx, 0, clockSubscription(0, uint64(g.duration), false)))
+
+		go func() {
+			defer clock.done()
+			results, err := fn.Call(ctx, 0, 48, 1, 80)
+			w := &wake{name: g.name, now: clock.TimeNowUnixNano(), err: err}
+			if err == nil {
+				w.errno = Errno(results[0])
+			}
+			wakes <- w
+		}()
+	}
+
+	for _, expected := range []struct {
+		name string
+		now  uint64
+	}{
+		{name: "b", now: epochNanos + uint64(time.Hour)},
+		{name: "a", now: epochNanos + uint64(2*time.Hour)},
+	} {
+		w := <-wakes
+		require.NoError(t, w.err)
+		require.Zero(t, w.errno, ErrnoName(w.errno))
+		require.Equal(t, expected.name, w.name)
+		require.Equal(t, expected.now, w.now)
+	}
+}
+
+func TestSnapshotPreview1_PollOneoff_Errors(t *testing.T) {
+	a, mod, _ := instantiateModule(testCtx, t, functionPollOneoff, importPollOneoff, nil)
+	defer mod.Close(testCtx)
+
+	memorySize := mod.Memory().Size(testCtx)
+
+	tests := []struct {
+		name                                   string
+		in, out, nsubscriptions, resultNevents uint32
+		expectedErrno                          Errno
+	}{
+		{
+			name:          "no subscriptions",
+			expectedErrno: ErrnoInval,
+		},
+		{
+			name:           "in out of range",
+			in:             memorySize - subscriptionLen + 1,
+			nsubscriptions: 1,
+			expectedErrno:  ErrnoFault,
+		},
+		{
+			name:           "out out of range",
+			out:            memorySize - eventLen + 1,
+			nsubscriptions: 1,
+			expectedErrno:  ErrnoFault,
+		},
+		{
+			name:           "nsubscriptions overflows uint32 size",
+			nsubscriptions: 1 << 28, // 1<<28 * 48 == 3<<32, which would wrap to zero.
+			expectedErrno:  ErrnoFault,
+		},
+		{
+			name:           "resultNevents out of range",
+			nsubscriptions: 1,
+			resultNevents:  memorySize - 4 + 1,
+			expectedErrno:  ErrnoFault,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			maskMemory(t, testCtx, mod, subscriptionLen)
+			// Zero the subscription, so that it is a clock with no timeout.
+			require.True(t, mod.Memory().Write(testCtx, tc.in, make([]byte, subscriptionLen)) || tc.expectedErrno == ErrnoFault)
+
+			errno := a.PollOneoff(testCtx, mod, tc.in, tc.out, tc.nsubscriptions, tc.resultNevents)
+			require.Equal(t, tc.expectedErrno, errno, ErrnoName(errno))
+		})
+	}
+}
+
+// clockSubscription returns a relative or absolute clock subscription in the format read by PollOneoff.
+func clockSubscription(userdata, timeout uint64, abstime bool) []byte {
+	sub := make([]byte, subscriptionLen)
+	binary.LittleEndian.PutUint64(sub, userdata)
+	sub[8] = eventTypeClock
+	binary.LittleEndian.PutUint64(sub[24:], timeout)
+	if abstime {
+		binary.LittleEndian.PutUint16(sub[40:], subscriptionClockAbstime)
+	}
+	return sub
+}
+
+// compile-time check to ensure virtualClock implements experimental.Sleeper.
+var _ experimental.Sleeper = &virtualClock{}
+
+// virtualClock is an experimental.Sys whose time only advances when all participants are sleeping. When that's the
+// case, the clock advances to the earliest deadline, waking the corresponding sleeper.
+type virtualClock struct {
+	fakeSys
+
+	mu           sync.Mutex
+	now          uint64
+	participants int
+	sleepers     []*virtualSleeper
+}
+
+type virtualSleeper struct {
+	deadline uint64
+	wake     chan struct{}
+}
+
+func (c *virtualClock) TimeNowUnixNano() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep implements experimental.Sleeper.Sleep
+func (c *virtualClock) Sleep(_ context.Context, ns uint64) {
+	c.mu.Lock()
+	s := &virtualSleeper{deadline: c.now + ns, wake: make(chan struct{})}
+	c.sleepers = append(c.sleepers, s)
+	c.advance()
+	c.mu.Unlock()
+	<-s.wake
+}
+
+// done is called when a participant will no longer sleep.
+func (c *virtualClock) done() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.participants--
+	c.advance()
+}
+
+// advance wakes the sleeper with the earliest deadline, if all participants are sleeping. This must be called with mu
+// locked.
+func (c *virtualClock) advance() {
+	if len(c.sleepers) == 0 || len(c.sleepers) < c.participants {
+		return
+	}
+	next := 0
+	for i, s := range c.sleepers {
+		if s.deadline < c.sleepers[next].deadline {
+			next = i
+		}
+	}
+	s := c.sleepers[next]
+	c.sleepers = append(c.sleepers[:next], c.sleepers[next+1:]...)
+	c.now = s.deadline
+	close(s.wake)
+}
+
 func TestSnapshotPreview1_ProcExit(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -1829,6 +2461,173 @@ func TestSnapshotPreview1_ProcExit(t *testing.T) {
 	}
 }
 
+func TestSnapshotPreview1_ProcExit_ExitCodeAsError(t *testing.T) {
+	r := wazero.NewRuntime()
+
+	_, err := InstantiateSnapshotPreview1(testCtx, r)
+	require.NoError(t, err)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_snapshot_preview1" "proc_exit" (func $wasi.proc_exit (param $rval i32)))
+  (func $main (param $rval i32) local.get 0 call $wasi.proc_exit)
+  (export "main" (func $main))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	zero, one := uint32(0), uint32(1)
+	tests := []struct {
+		name            string
+		config          wazero.ModuleConfig
+		exitCode        uint32
+		expectedErrCode *uint32
+	}{
+		{
+			name:            "default exit(0)", // an error, as before WithExitCodeAsError existed
+			config:          wazero.NewModuleConfig(),
+			exitCode:        0,
+			expectedErrCode: &zero,
+		},
+		{
+			name:            "default exit(1)",
+			config:          wazero.NewModuleConfig(),
+			exitCode:        1,
+			expectedErrCode: &one,
+		},
+		{
+			name:     "exit code as error exit(0)",
+			config:   wazero.NewModuleConfig().WithExitCodeAsError(true),
+			exitCode: 0,
+		},
+		{
+			name:            "exit code as error exit(1)",
+			config:          wazero.NewModuleConfig().WithExitCodeAsError(true),
+			exitCode:        1,
+			expectedErrCode: &one,
+		},
+		{
+			name:     "exit code not as error exit(0)",
+			config:   wazero.NewModuleConfig().WithExitCodeAsError(false),
+			exitCode: 0,
+		},
+		{
+			name:     "exit code not as error exit(1)",
+			config:   wazero.NewModuleConfig().WithExitCodeAsError(false),
+			exitCode: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, tc.config.WithName(t.Name()))
+			require.NoError(t, err)
+			defer mod.Close(testCtx)
+
+			results, err := mod.ExportedFunction("main").Call(testCtx, uint64(tc.exitCode))
+			if tc.expectedErrCode == nil {
+				require.NoError(t, err)
+				require.Nil(t, results)
+			} else {
+				require.Equal(t, sys.NewExitError(t.Name(), *tc.expectedErrCode), err)
+			}
+
+			// Regardless, the module exited, so can no longer be called.
+			_, err = mod.ExportedFunction("main").Call(testCtx, 0)
+			require.Equal(t, sys.NewExitError(t.Name(), tc.exitCode), err)
+		})
+	}
+
+	t.Run("start function", func(t *testing.T) {
+		config := wazero.NewModuleConfig().WithExitCodeAsError(true)
+		start, err := r.CompileModule(testCtx, []byte(`(module
+  (import "wasi_snapshot_preview1" "proc_exit" (func $wasi.proc_exit (param $rval i32)))
+  (func $exit0 i32.const 0 call $wasi.proc_exit)
+  (func $exit1 i32.const 1 call $wasi.proc_exit)
+  (export "exit0" (func $exit0))
+  (export "exit1" (func $exit1))
+)`))
+		require.NoError(t, err)
+		defer start.Close(testCtx)
+
+		// exit(0) is a normal termination, so later start functions aren't called.
+		mod, err := r.InstantiateModuleWithConfig(testCtx, start, config.WithName("exit0").WithStartFunctions("exit0", "exit1"))
+		require.NoError(t, err)
+		require.Equal(t, sys.NewExitError("exit0", 0), mod.(*wasm.CallContext).FailIfClosed())
+
+		_, err = r.InstantiateModuleWithConfig(testCtx, start, config.WithName("exit1").WithStartFunctions("exit1"))
+		require.Equal(t, sys.NewExitError("exit1", 1), err)
+	})
+}
+
+// TestSnapshotPreview1_ProcExit_Unwinds ensures proc_exit unwinds the guest, instead of returning to it, when called
+// from any exported function, including one called by a host function during another call.
+func TestSnapshotPreview1_ProcExit_Unwinds(t *testing.T) {
+	configs := map[string]func() wazero.RuntimeConfig{"interpreter": wazero.NewRuntimeConfigInterpreter}
+	if wazero.JITSupported {
+		configs["jit"] = wazero.NewRuntimeConfigJIT
+	}
+
+	for name, newConfig := range configs {
+		newConfig := newConfig
+
+		t.Run(name, func(t *testing.T) {
+			r := wazero.NewRuntimeWithConfig(newConfig())
+
+			_, err := InstantiateSnapshotPreview1(testCtx, r)
+			require.NoError(t, err)
+
+			// reenter calls "exit" in the module which called it, recording the error.
+			var innerErr error
+			_, err = r.NewModuleBuilder("host").ExportFunction("reenter", func(ctx context.Context, m api.Module, exitCode uint32) {
+				_, innerErr = m.ExportedFunction("exit").Call(ctx, uint64(exitCode))
+			}).Instantiate(testCtx)
+			require.NoError(t, err)
+
+			// Each function traps if the guest continues after the call which exits.
+			i32 := wasm.FunctionType{Params: []wasm.ValueType{wasm.ValueTypeI32}}
+			compiled, err := r.CompileModule(testCtx, wasmbinary.EncodeModule(&wasm.Module{
+				TypeSection: []*wasm.FunctionType{&i32},
+				ImportSection: []*wasm.Import{
+					{Module: "wasi_snapshot_preview1", Name: "proc_exit", Type: wasm.ExternTypeFunc, DescFunc: 0},
+					{Module: "host", Name: "reenter", Type: wasm.ExternTypeFunc, DescFunc: 0},
+				},
+				FunctionSection: []wasm.Index{0, 0},
+				CodeSection: []*wasm.Code{
+					{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 0, wasm.OpcodeUnreachable, wasm.OpcodeEnd}},
+					{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCall, 1, wasm.OpcodeUnreachable, wasm.OpcodeEnd}},
+				},
+				ExportSection: []*wasm.Export{
+					{Name: "exit", Type: wasm.ExternTypeFunc, Index: 2},
+					{Name: "outer", Type: wasm.ExternTypeFunc, Index: 3},
+				},
+			}))
+			require.NoError(t, err)
+			defer compiled.Close(testCtx)
+
+			t.Run("exported function", func(t *testing.T) {
+				mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, wazero.NewModuleConfig().WithName("exit"))
+				require.NoError(t, err)
+				defer mod.Close(testCtx)
+
+				_, err = mod.ExportedFunction("exit").Call(testCtx, 3)
+				require.Equal(t, sys.NewExitError("exit", 3), err)
+			})
+
+			t.Run("through host function", func(t *testing.T) {
+				mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, wazero.NewModuleConfig().WithName("outer"))
+				require.NoError(t, err)
+				defer mod.Close(testCtx)
+
+				_, err = mod.ExportedFunction("outer").Call(testCtx, 5)
+				require.Equal(t, sys.NewExitError("outer", 5), err)
+				require.Equal(t, sys.NewExitError("outer", 5), innerErr)
+			})
+		})
+	}
+}
+
 // TestSnapshotPreview1_ProcRaise only tests it is stubbed for GrainLang per #271
 func TestSnapshotPreview1_ProcRaise(t *testing.T) {
 	a, mod, fn := instantiateModule(testCtx, t, functionProcRaise, importProcRaise, nil)
@@ -2056,6 +2855,16 @@ func instantiateModule(ctx context.Context, t *testing.T, wasifunction, wasiimpo
 	return a, mod, fn
 }
 
+// readOnlyFD is the file descriptor of the directory opened by readOnlySysContext.
+const readOnlyFD = 3
+
+// readOnlySysContext returns a context with a read-only file system opened as readOnlyFD.
+func readOnlySysContext(t *testing.T) *wasm.SysContext {
+	sysCtx, err := newSysContext(nil, nil, map[uint32]*wasm.FileEntry{readOnlyFD: {Path: ".", FS: fstest.MapFS{}}})
+	require.NoError(t, err)
+	return sysCtx
+}
+
 func newSysContext(args, environ []string, openedFiles map[uint32]*wasm.FileEntry) (sysCtx *wasm.SysContext, err error) {
 	return wasm.NewSysContext(math.MaxUint32, args, environ, new(bytes.Buffer), nil, nil, openedFiles)
 }
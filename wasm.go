@@ -5,12 +5,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/tetratelabs/wazero/api"
 	experimentalapi "github.com/tetratelabs/wazero/experimental"
 	"github.com/tetratelabs/wazero/internal/wasm"
 	"github.com/tetratelabs/wazero/internal/wasm/binary"
 	"github.com/tetratelabs/wazero/internal/wasm/text"
+	"github.com/tetratelabs/wazero/internal/wasmruntime"
 	"github.com/tetratelabs/wazero/sys"
 )
 
@@ -51,6 +53,17 @@ type Runtime interface {
 	// See https://www.w3.org/TR/2019/REC-wasm-core-1-20191205/#name-section%E2%91%A0
 	CompileModule(ctx context.Context, source []byte) (CompiledCode, error)
 
+	// CompileModuleCollectingErrors is like CompileModule, except it doesn't stop at the first invalid function.
+	// This is useful for tooling that reports all problems in a module at once.
+	//
+	// The result includes a FunctionError for each invalid function, in order of function index. The error is only
+	// returned for problems that aren't isolated to a function, such as an invalid source or export. When both exist,
+	// both are returned.
+	//
+	// Note: CompileResult.Compiled is only set when there are neither errors nor any CompileResult.FunctionErrors, as
+	// a module with any invalid function cannot be instantiated.
+	CompileModuleCollectingErrors(ctx context.Context, source []byte) (*CompileResult, error)
+
 	// InstantiateModuleFromCode instantiates a module from the WebAssembly 1.0 (20191205) text or binary source or
 	// errs if invalid.
 	//
@@ -115,6 +128,22 @@ type Runtime interface {
 	// Note: When the context is nil, it defaults to context.Background.
 	// Note: Config is copied during instantiation: Later changes to config do not affect the instantiated result.
 	InstantiateModuleWithConfig(ctx context.Context, compiled CompiledCode, config ModuleConfig) (api.Module, error)
+
+	// InstantiateModules instantiates modules which may import each other, under the names from their custom name
+	// sections. Each module is instantiated after any others in compiled that it imports, and the results are in the
+	// same order as compiled.
+	//
+	// Ex.
+	//	ctx := context.Background()
+	//	r := wazero.NewRuntime()
+	//	// "app" imports "lib", and is instantiated after it, regardless of order.
+	//	mods, _ := r.InstantiateModules(ctx, appCompiled, libCompiled)
+	//
+	// This errs before instantiating any module when their imports form a cycle, naming the modules in it. If any
+	// module fails to instantiate, those already instantiated by this call are closed.
+	//
+	// Note: When the context is nil, it defaults to context.Background.
+	InstantiateModules(ctx context.Context, compiled ...CompiledCode) ([]api.Module, error)
 }
 
 func NewRuntime() Runtime {
@@ -127,20 +156,280 @@ func NewRuntimeWithConfig(rConfig RuntimeConfig) Runtime {
 	if !ok {
 		panic(fmt.Errorf("unsupported wazero.RuntimeConfig implementation: %#v", rConfig))
 	}
+	engine := config.newEngine(config.enabledFeatures)
+	if v, ok := engine.(resultValidator); ok && config.validateResults {
+		v.EnableResultValidation()
+	}
+	if v, ok := engine.(labelTracer); ok && config.labelTracing {
+		v.EnableLabelTracing()
+	}
+	if v, ok := engine.(lenientFloatToInt); ok && config.lenientFloatToInt {
+		v.EnableLenientFloatToInt()
+	}
+	if v, ok := engine.(boundsCheckOptimizer); ok && config.optimizeBoundsChecks {
+		v.EnableBoundsCheckOptimization()
+	}
+	if v, ok := engine.(memoryWriteLogger); ok && config.memoryWriteLog != nil {
+		v.SetMemoryWriteLog(config.memoryWriteLog)
+	}
+	if v, ok := engine.(memoryGrowthListener); ok && config.memoryGrowthListener != nil {
+		v.SetMemoryGrowthListener(config.memoryGrowthListener)
+	}
+	if v, ok := engine.(nullFuncrefHandler); ok && config.nullFuncrefHandler != nil {
+		v.SetNullFuncrefHandler(config.nullFuncrefHandler)
+	}
+	store := wasm.NewStore(config.enabledFeatures, engine)
+	store.MemoryCapacityPages = config.memoryCapacityPages
+	store.CallHooks = config.callHooks
+	store.FunctionMaxTypes = config.maxFunctionTypes
+	if v, ok := engine.(perModuleTypeIDs); ok && config.perModuleTypeIDs {
+		v.EnablePerModuleTypeIDs()
+		store.PerModuleTypeIDs = true
+	}
+	defaultStartFunctions := config.defaultStartFunctions
+	if defaultStartFunctions == nil {
+		defaultStartFunctions = []string{"_start"}
+	}
 	return &runtime{
-		store:               wasm.NewStore(config.enabledFeatures, config.newEngine(config.enabledFeatures)),
-		enabledFeatures:     config.enabledFeatures,
-		memoryLimitPages:    config.memoryLimitPages,
-		memoryCapacityPages: config.memoryCapacityPages,
+		store:                 store,
+		enabledFeatures:       config.enabledFeatures,
+		memoryLimitPages:      config.memoryLimitPages,
+		memoryCapacityPages:   config.memoryCapacityPages,
+		maxModuleSize:         config.maxModuleSize,
+		maxBlockNesting:       config.maxBlockNesting,
+		maxResults:            config.maxResults,
+		tableSizeLimit:        config.tableSizeLimit,
+		disallowStartSection:  config.disallowStartSection,
+		disallowMemoryImport:  config.disallowMemoryImport,
+		validationWarnings:    config.validationWarnings,
+		defaultStartFunctions: defaultStartFunctions,
 	}
 }
 
+// instantiateLazyImports instantiates placeholders for any function imports declared with ModuleConfig.WithLazyImport,
+// and returns a copy of the module which imports them instead.
+func (r *runtime) instantiateLazyImports(ctx context.Context, name string, module *wasm.Module, keys map[string]struct{}) (*wasm.Module, *wasm.LazyImports, api.Module, error) {
+	hostModuleName := name + "[lazy imports]"
+	hostModule, lazyImports, err := wasm.NewLazyImports(hostModuleName, module, keys, r.enabledFeatures)
+	if err != nil || lazyImports == nil {
+		return module, nil, nil, err
+	}
+
+	if err = r.store.Engine.CompileModule(ctx, hostModule); err != nil {
+		return nil, nil, nil, err
+	}
+	// The placeholders are unique to this instantiation, so release the cache once instantiated.
+	defer r.store.Engine.DeleteCompiledModule(hostModule)
+
+	mod, err := r.store.Instantiate(ctx, hostModule, hostModuleName, nil, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return lazyImports.ReplaceImports(hostModuleName, module), lazyImports, mod, nil
+}
+
+// BindImport binds the function to an import of the module declared with ModuleConfig.WithLazyImport, replacing any
+// function previously bound. This errs if the import wasn't declared lazy, or the function signature doesn't match.
+//
+// Note: This is safe to call while functions in the module are executing.
+func BindImport(mod api.Module, moduleName, name string, fn api.Function) error {
+	if callCtx, ok := mod.(*wasm.CallContext); ok {
+		return callCtx.BindImport(moduleName, name, fn)
+	}
+	return fmt.Errorf("unsupported api.Module implementation: %#v", mod)
+}
+
+// WatchGlobal sets a function called with the old and new value whenever the mutable global exported by the module
+// changes, replacing any previous one. A nil function removes the watch. This errs if the global isn't exported, or
+// is immutable, as it would never change.
+//
+// Ex. To log each change of the global "counter":
+//	err := wazero.WatchGlobal(mod, "counter", func(old, new uint64) {
+//		log.Printf("counter: %d -> %d", old, new)
+//	})
+//
+// Note: This errs unless the module was instantiated by a Runtime configured with NewRuntimeConfigInterpreter, as other
+// engines don't intercept changes made by the guest.
+func WatchGlobal(mod api.Module, name string, fn func(old, new uint64)) error {
+	if callCtx, ok := mod.(*wasm.CallContext); ok {
+		return callCtx.WatchGlobal(name, fn)
+	}
+	return fmt.Errorf("unsupported api.Module implementation: %#v", mod)
+}
+
+// RecentStdout returns the most recent bytes the module wrote to stdout, as retained by
+// ModuleConfig.WithStdoutRingBuffer. This returns nil if the module wasn't configured with one.
+//
+// Note: These are raw bytes, so may begin in the middle of a multi-byte UTF-8 character.
+func RecentStdout(mod api.Module) ([]byte, error) {
+	if callCtx, ok := mod.(*wasm.CallContext); ok {
+		return callCtx.RecentStdout(), nil
+	}
+	return nil, fmt.Errorf("unsupported api.Module implementation: %#v", mod)
+}
+
+// RecentStderr returns the most recent bytes the module wrote to stderr, as retained by
+// ModuleConfig.WithStderrRingBuffer. This returns nil if the module wasn't configured with one.
+//
+// Ex. To include recent errors when a function traps:
+//	if _, err := mod.ExportedFunction("run").Call(ctx); err != nil {
+//		stderr, _ := wazero.RecentStderr(mod)
+//		return fmt.Errorf("%w\nstderr: %s", err, stderr)
+//	}
+//
+// Note: These are raw bytes, so may begin in the middle of a multi-byte UTF-8 character.
+func RecentStderr(mod api.Module) ([]byte, error) {
+	if callCtx, ok := mod.(*wasm.CallContext); ok {
+		return callCtx.RecentStderr(), nil
+	}
+	return nil, fmt.Errorf("unsupported api.Module implementation: %#v", mod)
+}
+
+// MemoryGrowthPages returns how many pages the memory of the module grew beyond its initial minimum, ex. to detect
+// leaks by checking it after each call. This returns zero if the module never grew its memory or has none.
+//
+// Note: An imported memory is counted from the minimum of the module that defined it.
+func MemoryGrowthPages(mod api.Module) (uint32, error) {
+	if callCtx, ok := mod.(*wasm.CallContext); ok {
+		return callCtx.MemoryGrowthPages(), nil
+	}
+	return 0, fmt.Errorf("unsupported api.Module implementation: %#v", mod)
+}
+
+// Caller calls the same api.Function repeatedly, reusing its parameter and result buffers. This avoids the per-call
+// allocations of api.Function Call, which adds up when a host calls a function in a tight loop. See NewCaller
+//
+// Ex.
+//	caller, _ := wazero.NewCaller(mod, mod.ExportedFunction("add"))
+//	for _, p := range pairs {
+//		params := caller.Params()
+//		params[0], params[1] = p.x, p.y
+//		if err := caller.Call(ctx); err != nil {
+//			return err
+//		}
+//		sum += caller.Results()[0]
+//	}
+//
+// Note: A Caller is not safe for concurrent use, as calls share the same buffers. Use a Caller per goroutine.
+type Caller interface {
+	// Params returns the parameters of the next call, sized to api.Function ParamTypes. Set these before calling Call.
+	Params() []uint64
+
+	// Results returns the results of the last call, sized to api.Function ResultTypes. These are overwritten by each
+	// call, so copy any values which must outlive the next one.
+	Results() []uint64
+
+	// Call calls the function with Params, overwriting Results.
+	Call(ctx context.Context) error
+}
+
+// NewCaller returns a Caller of the function, which must be exported by the module.
+func NewCaller(mod api.Module, fn api.Function) (Caller, error) {
+	if callCtx, ok := mod.(*wasm.CallContext); ok {
+		return callCtx.NewCaller(fn)
+	}
+	return nil, fmt.Errorf("unsupported api.Module implementation: %#v", mod)
+}
+
+// CallWithLimits calls the function, which must be exported by the module, with memory.grow limited to maxPages for
+// the duration of the call. The memory keeps any pages it grew to after the call, and its own maximum is unchanged.
+// This errs before calling fn if the memory is already larger than maxPages.
+//
+// Ex. To run an untrusted parser with at most 2 MiB of memory, while other functions of the module grow to the max:
+//	results, err := wazero.CallWithLimits(ctx, mod, mod.ExportedFunction("parse"), 32, offset, length)
+//
+// Note: The limit only applies to this call, including any host functions it calls back into the module, and not to
+// concurrent calls of functions using the same memory.
+func CallWithLimits(ctx context.Context, mod api.Module, fn api.Function, maxPages uint32, params ...uint64) ([]uint64, error) {
+	if callCtx, ok := mod.(*wasm.CallContext); ok {
+		return callCtx.CallWithLimits(ctx, fn, maxPages, params...)
+	}
+	return nil, fmt.Errorf("unsupported api.Module implementation: %#v", mod)
+}
+
+// resultValidator is implemented by engines that support RuntimeConfig.WithResultValidation.
+type resultValidator interface {
+	EnableResultValidation()
+}
+
+// labelTracer is implemented by engines that support RuntimeConfig.WithLabelTracing.
+type labelTracer interface {
+	EnableLabelTracing()
+}
+
+// lenientFloatToInt is implemented by engines that support RuntimeConfig.WithLenientFloatToInt.
+type lenientFloatToInt interface {
+	EnableLenientFloatToInt()
+}
+
+// boundsCheckOptimizer is implemented by engines that support RuntimeConfig.WithOptimizeBoundsChecks.
+type boundsCheckOptimizer interface {
+	EnableBoundsCheckOptimization()
+}
+
+// memoryWriteLogger is implemented by engines that support RuntimeConfig.WithMemoryWriteLog.
+type memoryWriteLogger interface {
+	SetMemoryWriteLog(func(offset uint32, data []byte))
+}
+
+// nullFuncrefHandler is implemented by engines that support RuntimeConfig.WithNullFuncrefHandler.
+type nullFuncrefHandler interface {
+	SetNullFuncrefHandler(func(tableIndex, offset uint32) error)
+}
+
+// perModuleTypeIDs is implemented by engines that support RuntimeConfig.WithPerModuleTypeIDs.
+type perModuleTypeIDs interface {
+	EnablePerModuleTypeIDs()
+}
+
+// memoryGrowthListener is implemented by engines that support RuntimeConfig.WithMemoryGrowthListener.
+type memoryGrowthListener interface {
+	SetMemoryGrowthListener(func(memoryIndex, before, delta uint32, ok bool))
+}
+
 // runtime allows decoupling of public interfaces from internal representation.
 type runtime struct {
-	enabledFeatures     wasm.Features
-	store               *wasm.Store
-	memoryLimitPages    uint32
-	memoryCapacityPages func(minPages uint32, maxPages *uint32) uint32
+	enabledFeatures      wasm.Features
+	store                *wasm.Store
+	memoryLimitPages     uint32
+	memoryCapacityPages  func(minPages uint32, maxPages *uint32) uint32
+	maxModuleSize        int
+	maxBlockNesting      int
+	maxResults           int
+	tableSizeLimit       *uint32
+	disallowStartSection bool
+	disallowMemoryImport bool
+	// defaultStartFunctions are the start functions of a module whose ModuleConfig.WithStartFunctions isn't set.
+	defaultStartFunctions []string
+	validationWarnings    func(warning string)
+}
+
+// CompileResult is the result of Runtime.CompileModuleCollectingErrors.
+type CompileResult struct {
+	// Compiled is the compiled module, or nil if there were any errors.
+	Compiled CompiledCode
+
+	// FunctionErrors are the errors isolated to a function, in order of function index.
+	FunctionErrors []*FunctionError
+}
+
+// FunctionError is an error isolated to a function defined in a module.
+type FunctionError struct {
+	// Index is the index of the function in the function index namespace, which includes any imported functions.
+	Index uint32
+
+	// Err describes why the function is invalid. Ex. "invalid function[0]: i32.add missing i32"
+	Err error
+}
+
+// Error implements error
+func (e *FunctionError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap implements errors.Unwrap
+func (e *FunctionError) Unwrap() error {
+	return e.Err
 }
 
 // Module implements Runtime.Module
@@ -150,12 +439,58 @@ func (r *runtime) Module(moduleName string) api.Module {
 
 // CompileModule implements Runtime.CompileModule
 func (r *runtime) CompileModule(ctx context.Context, source []byte) (CompiledCode, error) {
+	if compiled, _, err := r.compileModule(ctx, source, false); err != nil {
+		return nil, err
+	} else {
+		return compiled, nil
+	}
+}
+
+// CompileModuleCollectingErrors implements Runtime.CompileModuleCollectingErrors
+func (r *runtime) CompileModuleCollectingErrors(ctx context.Context, source []byte) (*CompileResult, error) {
+	compiled, functionErrs, err := r.compileModule(ctx, source, true)
+	result := &CompileResult{}
+	if compiled != nil { // avoid a typed nil
+		result.Compiled = compiled
+	}
+	for _, fe := range functionErrs {
+		result.FunctionErrors = append(result.FunctionErrors, &FunctionError{Index: fe.Index, Err: fe.Err})
+	}
+	return result, err
+}
+
+// compileModule implements CompileModule and CompileModuleCollectingErrors. When collectFunctionErrors is false, the
+// first invalid function is returned as the error instead of in functionErrs.
+func (r *runtime) compileModule(ctx context.Context, source []byte, collectFunctionErrors bool) (compiled *compiledCode, functionErrs []*wasm.FunctionError, err error) {
+	internal, functionErrs, err := r.decodeModule(source, collectFunctionErrors)
+	if internal == nil {
+		return nil, functionErrs, err
+	}
+
+	if err = r.store.Engine.CompileModule(ctx, internal); err != nil {
+		return nil, nil, err
+	}
+
+	return &compiledCode{
+		module:            internal,
+		compiledEngine:    r.store.Engine,
+		usesFloatingPoint: internal.UsesFloatingPoint(),
+	}, nil, nil
+}
+
+// decodeModule decodes and validates the source per the configuration of this runtime, returning a module ready to
+// compile. When there are function errors, this returns them with a nil module.
+func (r *runtime) decodeModule(source []byte, collectFunctionErrors bool) (internal *wasm.Module, functionErrs []*wasm.FunctionError, err error) {
 	if source == nil {
-		return nil, errors.New("source == nil")
+		return nil, nil, errors.New("source == nil")
 	}
 
 	if len(source) < 8 { // Ex. less than magic+version in binary or '(module)' in text
-		return nil, errors.New("invalid source")
+		return nil, nil, errors.New("invalid source")
+	}
+
+	if r.maxModuleSize > 0 && len(source) > r.maxModuleSize {
+		return nil, nil, fmt.Errorf("source size %d > max module size %d", len(source), r.maxModuleSize)
 	}
 
 	// Peek to see if this is a binary or text format
@@ -167,19 +502,46 @@ func (r *runtime) CompileModule(ctx context.Context, source []byte) (CompiledCod
 	}
 
 	if r.memoryLimitPages > wasm.MemoryLimitPages {
-		return nil, fmt.Errorf("memoryLimitPages %d (%s) > specification max %d (%s)",
+		return nil, nil, fmt.Errorf("memoryLimitPages %d (%s) > specification max %d (%s)",
 			r.memoryLimitPages, wasm.PagesToUnitOfBytes(r.memoryLimitPages),
 			wasm.MemoryLimitPages, wasm.PagesToUnitOfBytes(wasm.MemoryLimitPages))
 	}
 
-	internal, err := decoder(source, r.enabledFeatures, r.memoryLimitPages)
+	internal, err = decoder(source, r.enabledFeatures, r.memoryLimitPages)
 
 	if err != nil {
-		return nil, err
-	} else if err = internal.Validate(r.enabledFeatures); err != nil {
-		// TODO: decoders should validate before returning, as that allows
-		// them to err with the correct source position.
-		return nil, err
+		return nil, nil, err
+	}
+
+	if r.maxBlockNesting > 0 {
+		if err = internal.ValidateBlockNesting(r.maxBlockNesting); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// TODO: decoders should validate before returning, as that allows
+	// them to err with the correct source position.
+	if collectFunctionErrors {
+		functionErrs, err = internal.ValidateCollectingErrors(r.enabledFeatures)
+	} else {
+		err = internal.Validate(r.enabledFeatures)
+	}
+	if err != nil {
+		return nil, functionErrs, err
+	} else if len(functionErrs) > 0 {
+		return nil, functionErrs, nil
+	}
+
+	if r.disallowStartSection && internal.StartSection != nil {
+		return nil, nil, fmt.Errorf("start section disallowed: func[%d]", *internal.StartSection)
+	}
+
+	if r.disallowMemoryImport {
+		for idx, imp := range internal.ImportSection {
+			if imp.Type == wasm.ExternTypeMemory {
+				return nil, nil, fmt.Errorf("memory import disallowed: import[%d] memory[%s.%s]", idx, imp.Module, imp.Name)
+			}
+		}
 	}
 
 	// Determine the correct memory capacity, if a memory was defined.
@@ -192,17 +554,73 @@ func (r *runtime) CompileModule(ctx context.Context, source []byte) (CompiledCod
 			}
 		}
 		if err = r.setMemoryCapacity(memoryName, mem); err != nil {
-			return nil, err
+			return nil, nil, err
+		}
+	}
+
+	if limit := r.tableSizeLimit; limit != nil {
+		if err = limitTables(internal.TableSection, *limit); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if r.maxResults > 0 {
+		if err = limitResults(internal, r.maxResults); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if r.validationWarnings != nil {
+		for _, w := range internal.Warnings() {
+			r.validationWarnings(w)
 		}
 	}
 
 	internal.AssignModuleID(source)
+	return internal, nil, nil
+}
 
-	if err = r.store.Engine.CompileModule(ctx, internal); err != nil {
-		return nil, err
+// limitTables errs if any table exceeds the limit, or otherwise sets the max of each table which has none to it.
+func limitTables(tables []*wasm.Table, limit uint32) error {
+	for i, t := range tables {
+		if t.Min > limit {
+			return fmt.Errorf("table[%d]: min %d elements over limit of %d elements", i, t.Min, limit)
+		} else if t.Max == nil {
+			max := limit
+			t.Max = &max
+		} else if *t.Max > limit {
+			return fmt.Errorf("table[%d]: max %d elements over limit of %d elements", i, *t.Max, limit)
+		}
 	}
+	return nil
+}
 
-	return &compiledCode{module: internal, compiledEngine: r.store.Engine}, nil
+// limitResults errs if any function, including an imported one, returns more results than the limit.
+//
+// Note: The module must be valid, as type indices aren't checked.
+func limitResults(m *wasm.Module, limit int) error {
+	var funcIdx wasm.Index // imported functions are numbered before those in the function section.
+	check := func(typeIdx wasm.Index) error {
+		if results := len(m.TypeSection[typeIdx].Results); results > limit {
+			return fmt.Errorf("func[%d]: %d results over limit of %d results", funcIdx, results, limit)
+		}
+		funcIdx++
+		return nil
+	}
+	for _, imp := range m.ImportSection {
+		if imp.Type != wasm.ExternTypeFunc {
+			continue
+		}
+		if err := check(imp.DescFunc); err != nil {
+			return err
+		}
+	}
+	for _, typeIdx := range m.FunctionSection {
+		if err := check(typeIdx); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // InstantiateModuleFromCode implements Runtime.InstantiateModuleFromCode
@@ -254,7 +672,21 @@ func (r *runtime) InstantiateModuleWithConfig(ctx context.Context, compiled Comp
 		name = code.module.NameSection.ModuleName
 	}
 
-	module := config.replaceImports(code.module)
+	module, err := config.replaceImports(code.module)
+	if err != nil {
+		return
+	}
+	if module, err = config.overrideImportMemoryLimits(module); err != nil {
+		return
+	}
+
+	var lazyImports *wasm.LazyImports
+	var lazyImportsModule api.Module
+	if config.lazyImports != nil {
+		if module, lazyImports, lazyImportsModule, err = r.instantiateLazyImports(ctx, name, module, config.lazyImports); err != nil {
+			return
+		}
+	}
 
 	var functionListenerFactory experimentalapi.FunctionListenerFactory
 	if ctx != nil { // Test to see if internal code are using an experimental feature.
@@ -263,16 +695,67 @@ func (r *runtime) InstantiateModuleWithConfig(ctx context.Context, compiled Comp
 		}
 	}
 
-	mod, err = r.store.Instantiate(ctx, module, name, sysCtx, functionListenerFactory)
+	var preStart func(context.Context, *wasm.CallContext) error
+	if hook := config.preStartHook; hook != nil {
+		preStart = func(ctx context.Context, callCtx *wasm.CallContext) error {
+			if err := hook(ctx, callCtx); err != nil {
+				return fmt.Errorf("module[%s] pre-start hook failed: %w", name, err)
+			}
+			return nil
+		}
+	}
+
+	mod, err = r.store.InstantiateWithPreStart(ctx, module, name, sysCtx, functionListenerFactory, preStart)
 	if err != nil {
+		var startErr *wasm.StartError
+		if config.trapSink != nil && errors.As(err, &startErr) {
+			if trap, ok := newTrapInfo(name, startErr.FuncName, err); ok {
+				config.trapSink(trap)
+			}
+		}
+		if lazyImportsModule != nil {
+			_ = lazyImportsModule.Close(ctx)
+		}
 		return
 	}
 
-	for _, fn := range config.startFunctions {
+	callCtx := mod.(*wasm.CallContext)
+	atomic.AddInt64(&code.instances, 1)
+	callCtx.SetOnClose(func() { atomic.AddInt64(&code.instances, -1) })
+	if lazyImports != nil {
+		callCtx.SetLazyImports(lazyImports, lazyImportsModule.(*wasm.CallContext))
+	}
+	if config.exitCodeAsError != nil {
+		callCtx.SetExitCodeAsError(*config.exitCodeAsError)
+	}
+	if trapSink := config.trapSink; trapSink != nil {
+		callCtx.SetTrapSink(func(funcName string, err error) {
+			if trap, ok := newTrapInfo(name, funcName, err); ok {
+				trapSink(trap)
+			}
+		})
+	}
+
+	isReactor := code.WASIModuleKind() == WASIModuleKindReactor
+	if isReactor { // guard "_initialize" regardless of who calls it.
+		callCtx.SetReactor()
+	}
+	startFunctions := config.startFunctions
+	if startFunctions == nil { // not set, so use the default of the runtime, initializing any reactor first.
+		startFunctions = r.defaultStartFunctions
+		if isReactor && !containsString(startFunctions, wasm.ReactorInitFunction) {
+			startFunctions = append([]string{wasm.ReactorInitFunction}, startFunctions...)
+		}
+	}
+
+	for _, fn := range startFunctions {
 		start := mod.ExportedFunction(fn)
 		if start == nil {
 			continue
 		}
+		if isReactor && fn == wasm.ReactorInitFunction && callCtx.ReactorInitialized() {
+			continue // only once, even if listed more than once.
+		}
 		if _, err = start.Call(ctx); err != nil {
 			if _, ok := err.(*sys.ExitError); ok {
 				return
@@ -280,10 +763,74 @@ func (r *runtime) InstantiateModuleWithConfig(ctx context.Context, compiled Comp
 			err = fmt.Errorf("module[%s] function[%s] failed: %w", name, fn, err)
 			return
 		}
+		if callCtx.FailIfClosed() != nil {
+			return // exited without error per WithExitCodeAsError, so don't call any other start functions.
+		}
+	}
+
+	if config.postStartHook != nil {
+		if err = config.postStartHook(ctx, mod); err != nil {
+			_ = mod.Close(ctx)
+			return nil, fmt.Errorf("module[%s] post-start hook failed: %w", name, err)
+		}
 	}
 	return
 }
 
+// newTrapInfo returns the TrapInfo of a call to the function, or false if the call didn't fail with a trap.
+func newTrapInfo(moduleName, funcName string, err error) (TrapInfo, bool) {
+	var trap *wasmruntime.Error
+	if !errors.As(err, &trap) {
+		return TrapInfo{}, false
+	}
+	return TrapInfo{Module: moduleName, Function: funcName, Reason: trap.Error(), Err: err}, true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// InstantiateModules implements Runtime.InstantiateModules
+func (r *runtime) InstantiateModules(ctx context.Context, compiled ...CompiledCode) ([]api.Module, error) {
+	names := make([]string, len(compiled))
+	modules := make([]*wasm.Module, len(compiled))
+	for i, c := range compiled {
+		code, ok := c.(*compiledCode)
+		if !ok {
+			panic(fmt.Errorf("unsupported wazero.CompiledCode implementation: %#v", c))
+		}
+		if code.module.NameSection != nil {
+			names[i] = code.module.NameSection.ModuleName
+		}
+		modules[i] = code.module
+	}
+
+	order, err := wasm.ImportOrder(names, modules)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]api.Module, len(compiled))
+	for _, i := range order {
+		mod, err := r.InstantiateModule(ctx, compiled[i])
+		if err != nil {
+			for _, m := range ret {
+				if m != nil {
+					_ = m.Close(ctx)
+				}
+			}
+			return nil, err
+		}
+		ret[i] = mod
+	}
+	return ret, nil
+}
+
 // setMemoryCapacity sets wasm.Memory cap using the function supplied by RuntimeConfig.WithMemoryCapacityPages.
 func (r *runtime) setMemoryCapacity(name string, mem *wasm.Memory) error {
 	var max *uint32
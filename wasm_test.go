@@ -3,11 +3,14 @@ package wazero
 import (
 	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"math"
 	"testing"
+	"time"
 
 	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/internal/buildoptions"
 	"github.com/tetratelabs/wazero/internal/leb128"
 	"github.com/tetratelabs/wazero/internal/testing/require"
 	"github.com/tetratelabs/wazero/internal/wasm"
@@ -97,9 +100,70 @@ func TestRuntime_CompileModule(t *testing.T) {
 			IsMaxEncoded: true,
 		}, code.module.MemorySection)
 	})
+
+	t.Run("WithDisallowStartSection - no start section", func(t *testing.T) {
+		r := NewRuntimeWithConfig(NewRuntimeConfig().WithDisallowStartSection(true))
+
+		m, err := r.CompileModule(testCtx, []byte(`(module (func $init))`))
+		require.NoError(t, err)
+		require.NoError(t, m.Close(testCtx))
+	})
+
+	t.Run("WithDisallowMemoryImport - own memory", func(t *testing.T) {
+		r := NewRuntimeWithConfig(NewRuntimeConfig().WithDisallowMemoryImport(true))
+
+		m, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+			TypeSection: []*wasm.FunctionType{{}},
+			ImportSection: []*wasm.Import{
+				{Type: wasm.ExternTypeFunc, Module: "env", Name: "f", DescFunc: 0},
+				{Type: wasm.ExternTypeGlobal, Module: "env", Name: "g", DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeI32}},
+				{Type: wasm.ExternTypeTable, Module: "env", Name: "t", DescTable: &wasm.Table{Type: wasm.RefTypeFuncref}},
+			},
+			MemorySection: &wasm.Memory{Min: 1, Max: 1},
+		}))
+		require.NoError(t, err)
+		require.NoError(t, m.Close(testCtx))
+	})
+}
+
+func TestRuntime_WithMemoryCapacityPages_Grow(t *testing.T) {
+	// capacities are the result of the capacity function for the minPages it is called with.
+	capacities := map[uint32]uint32{1: 3, 4: 6, 7: 0, 8: 12}
+	var minPages []uint32
+	r := NewRuntimeWithConfig(NewRuntimeConfig().
+		WithMemoryCapacityPages(func(min uint32, max *uint32) uint32 {
+			require.Equal(t, uint32(10), *max)
+			minPages = append(minPages, min)
+			return capacities[min]
+		}))
+
+	mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module (memory 1 10) (export "memory" (memory 0)))`))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	mem := mod.Memory().(*wasm.MemoryInstance)
+	require.Equal(t, uint32(3), mem.Cap) // from compilation
+
+	tests := []struct {
+		delta, expectedCap uint32
+	}{
+		{delta: 1, expectedCap: 3},  // within capacity
+		{delta: 1, expectedCap: 3},  // at capacity
+		{delta: 1, expectedCap: 6},  // re-evaluated with the new size
+		{delta: 3, expectedCap: 7},  // clamped up to the new size
+		{delta: 1, expectedCap: 10}, // clamped down to the max
+		{delta: 2, expectedCap: 10}, // within capacity
+	}
+	for _, tc := range tests {
+		require.NotEqual(t, uint32(0xffffffff), mem.Grow(testCtx, tc.delta))
+		require.Equal(t, tc.expectedCap, mem.Cap)
+		require.True(t, wasm.MemoryPagesToBytesNum(tc.expectedCap) <= uint64(cap(mem.Buffer)))
+	}
+	require.Equal(t, []uint32{1, 4, 7, 8}, minPages)
 }
 
 func TestRuntime_CompileModule_Errors(t *testing.T) {
+	maxTableSize := uint32(20)
 	tests := []struct {
 		name        string
 		runtime     Runtime
@@ -152,52 +216,1196 @@ func TestRuntime_CompileModule_Errors(t *testing.T) {
 			source:      binary.EncodeModule(&wasm.Module{MemorySection: &wasm.Memory{Min: 2, Max: 3, IsMaxEncoded: true}}),
 			expectedErr: "section memory: max 3 pages (192 Ki) over limit of 2 pages (128 Ki)",
 		},
+		{
+			name:        "source larger than max module size",
+			runtime:     NewRuntimeWithConfig(NewRuntimeConfig().WithMaxModuleSize(8)),
+			source:      []byte(`(module (memory 1))`),
+			expectedErr: "source size 19 > max module size 8",
+		},
+		{
+			name: "section size exceeds source",
+			source: append(append(binary.Magic, 0x01, 0x00, 0x00, 0x00),
+				wasm.SectionIDData, 0xff, 0xff, 0xff, 0xff, 0x0f, 0x01),
+			expectedErr: "section data: size 4294967295 exceeds the remaining 1 bytes",
+		},
+		{
+			name:        "table min over limit",
+			runtime:     NewRuntimeWithConfig(NewRuntimeConfig().WithTableSizeLimit(10)),
+			source:      binary.EncodeModule(&wasm.Module{TableSection: []*wasm.Table{{Min: 11, Type: wasm.RefTypeFuncref}}}),
+			expectedErr: "table[0]: min 11 elements over limit of 10 elements",
+		},
+		{
+			name:        "table max over limit",
+			runtime:     NewRuntimeWithConfig(NewRuntimeConfig().WithTableSizeLimit(10)),
+			source:      binary.EncodeModule(&wasm.Module{TableSection: []*wasm.Table{{Min: 1, Max: &maxTableSize, Type: wasm.RefTypeFuncref}}}),
+			expectedErr: "table[0]: max 20 elements over limit of 10 elements",
+		},
+		{
+			name:        "start section disallowed",
+			runtime:     NewRuntimeWithConfig(NewRuntimeConfig().WithDisallowStartSection(true)),
+			source:      []byte(`(module (func $noop) (func $init) (start $init))`),
+			expectedErr: "start section disallowed: func[1]",
+		},
+		{
+			name:    "memory import disallowed",
+			runtime: NewRuntimeWithConfig(NewRuntimeConfig().WithDisallowMemoryImport(true)),
+			source: binary.EncodeModule(&wasm.Module{ImportSection: []*wasm.Import{
+				{Type: wasm.ExternTypeMemory, Module: "env", Name: "memory", DescMem: &wasm.Memory{Min: 1, Max: wasm.MemoryLimitPages}},
+			}}),
+			expectedErr: "memory import disallowed: import[0] memory[env.memory]",
+		},
+		{
+			name:        "imported start function with params",
+			source:      []byte(`(module (import "env" "init" (func $init (param i32))) (start $init))`),
+			expectedErr: "invalid start function: func[0] must have an empty (nullary) signature: i32_v",
+		},
+	}
+
+	r := NewRuntime()
+	for _, tt := range tests {
+		tc := tt
+
+		if tc.runtime == nil {
+			tc.runtime = r
+		}
+
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.runtime.CompileModule(testCtx, tc.source)
+			require.EqualError(t, err, tc.expectedErr)
+		})
+	}
+}
+
+func TestRuntime_WithMaxModuleSize(t *testing.T) {
+	source := binary.EncodeModule(&wasm.Module{
+		MemorySection: &wasm.Memory{Min: 16},
+		DataSection: []*wasm.DataSegment{{
+			OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:             make([]byte, 1<<20), // 1MiB
+		}},
+	})
+
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithMaxModuleSize(len(source)))
+	compiled, err := r.CompileModule(testCtx, source)
+	require.NoError(t, err)
+	require.NoError(t, compiled.Close(testCtx))
+
+	r = NewRuntimeWithConfig(NewRuntimeConfig().WithMaxModuleSize(len(source) - 1))
+	_, err = r.CompileModule(testCtx, source)
+	require.EqualError(t, err, fmt.Sprintf("source size %d > max module size %d", len(source), len(source)-1))
+}
+
+func TestRuntime_CompileModule_DataCountSection(t *testing.T) {
+	one := uint32(1)
+	module := func(body ...byte) []byte {
+		return binary.EncodeModule(&wasm.Module{
+			TypeSection:      []*wasm.FunctionType{{}},
+			FunctionSection:  []wasm.Index{0},
+			MemorySection:    &wasm.Memory{Min: 1},
+			DataCountSection: &one,
+			CodeSection:      []*wasm.Code{{Body: append(body, wasm.OpcodeEnd)}},
+			DataSection: []*wasm.DataSegment{{
+				OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+				Init:             []byte{1},
+			}},
+		})
+	}
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithWasmCore1())
+
+	// Without bulk memory operations, the section is allowed, as only the instructions are gated on the feature.
+	compiled, err := r.CompileModule(testCtx, module())
+	require.NoError(t, err)
+	require.NoError(t, compiled.Close(testCtx))
+
+	_, err = r.CompileModule(testCtx, module(wasm.OpcodeMiscPrefix, wasm.OpcodeMiscDataDrop, 0))
+	require.EqualError(t, err, `invalid function[0]: data.drop invalid as feature "bulk-memory-operations" is disabled`)
+}
+
+func TestRuntime_WithTableSizeLimit(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	five := uint32(5)
+	source := binary.EncodeModule(&wasm.Module{TableSection: []*wasm.Table{
+		{Min: 1, Type: wasm.RefTypeFuncref},
+		{Min: 1, Max: &five, Type: wasm.RefTypeFuncref},
+	}})
+
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithFeatureReferenceTypes(true).WithTableSizeLimit(10))
+	compiled, err := r.CompileModule(testCtx, source)
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	// Like memory, a table without a max defaults its max to the limit, while a smaller max is retained.
+	ten := uint32(10)
+	require.Equal(t, []*wasm.Table{
+		{Min: 1, Max: &ten, Type: wasm.RefTypeFuncref},
+		{Min: 1, Max: &five, Type: wasm.RefTypeFuncref},
+	}, compiled.(*compiledCode).module.TableSection)
+}
+
+func TestRuntime_CompileModuleCollectingErrors(t *testing.T) {
+	r := NewRuntime()
+
+	t.Run("valid", func(t *testing.T) {
+		result, err := r.CompileModuleCollectingErrors(testCtx, []byte(`(module (func $noop))`))
+		require.NoError(t, err)
+		require.Nil(t, result.FunctionErrors)
+		require.NotNil(t, result.Compiled)
+		require.NoError(t, result.Compiled.Close(testCtx))
+	})
+
+	// brokenFunctions has two functions which are invalid independently of each other. The import shows indices are in
+	// the function index namespace.
+	brokenFunctions := &wasm.Module{
+		TypeSection: []*wasm.FunctionType{{}, {Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "f", Type: wasm.ExternTypeFunc, DescFunc: 0},
+		},
+		FunctionSection: []wasm.Index{1, 0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeEnd}},                         // missing result
+			{Body: []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeEnd}}, // unexpected result
+			{Body: []byte{wasm.OpcodeI32Const, 1, wasm.OpcodeEnd}}, // valid
+		},
+	}
+
+	t.Run("function errors", func(t *testing.T) {
+		result, err := r.CompileModuleCollectingErrors(testCtx, binary.EncodeModule(brokenFunctions))
+		require.NoError(t, err)
+		require.Nil(t, result.Compiled)
+		require.Equal(t, 2, len(result.FunctionErrors))
+
+		require.Equal(t, uint32(1), result.FunctionErrors[0].Index)
+		require.EqualError(t, result.FunctionErrors[0], "invalid function[0]: not enough results\n\thave ()\n\twant (i32)")
+		require.Equal(t, uint32(2), result.FunctionErrors[1].Index)
+		require.EqualError(t, result.FunctionErrors[1], "invalid function[1]: too many results\n\thave (i32)\n\twant ()")
+	})
+
+	t.Run("function errors are separate from module errors", func(t *testing.T) {
+		m := *brokenFunctions // shallow copy
+		m.ExportSection = []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "missing", Index: 100}}
+
+		result, err := r.CompileModuleCollectingErrors(testCtx, binary.EncodeModule(&m))
+		require.EqualError(t, err, "unknown function for export[\"missing\"]")
+		require.Nil(t, result.Compiled)
+		require.Equal(t, 2, len(result.FunctionErrors))
+
+		// CompileModule only returns the first function error.
+		_, err = r.CompileModule(testCtx, binary.EncodeModule(&m))
+		require.EqualError(t, err, result.FunctionErrors[0].Error())
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		result, err := r.CompileModuleCollectingErrors(testCtx, []byte(`(modular)`))
+		require.EqualError(t, err, "1:2: unexpected field: modular")
+		require.Nil(t, result.Compiled)
+		require.Nil(t, result.FunctionErrors)
+	})
+}
+
+func TestRuntime_setMemoryCapacity(t *testing.T) {
+	tests := []struct {
+		name        string
+		runtime     *runtime
+		mem         *wasm.Memory
+		expectedErr string
+	}{
+		{
+			name: "cap ok",
+			runtime: &runtime{memoryCapacityPages: func(minPages uint32, maxPages *uint32) uint32 {
+				return 3
+			}, memoryLimitPages: 3},
+			mem: &wasm.Memory{Min: 2},
+		},
+		{
+			name: "cap < min",
+			runtime: &runtime{memoryCapacityPages: func(minPages uint32, maxPages *uint32) uint32 {
+				return 1
+			}, memoryLimitPages: 3},
+			mem:         &wasm.Memory{Min: 2},
+			expectedErr: "memory[memory] capacity 1 pages (64 Ki) less than minimum 2 pages (128 Ki)",
+		},
+		{
+			name: "cap > maxLimit",
+			runtime: &runtime{memoryCapacityPages: func(minPages uint32, maxPages *uint32) uint32 {
+				return 4
+			}, memoryLimitPages: 3},
+			mem:         &wasm.Memory{Min: 2},
+			expectedErr: "memory[memory] capacity 4 pages (256 Ki) over limit of 3 pages (192 Ki)",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.runtime.setMemoryCapacity("memory", tc.mem)
+			if tc.expectedErr == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRuntime_WithLenientFloatToInt(t *testing.T) {
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeF32}, Results: []wasm.ValueType{wasm.ValueTypeI32}}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32TruncF32S, wasm.OpcodeEnd, // the trapping variant.
+		}}},
+		ExportSection: []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "trunc", Index: 0}},
+	})
+
+	configs := map[string]func() RuntimeConfig{"interpreter": NewRuntimeConfigInterpreter}
+	if JITSupported {
+		configs["jit"] = NewRuntimeConfigJIT
+	}
+
+	for name, newConfig := range configs {
+		newConfig := newConfig
+
+		t.Run(name, func(t *testing.T) {
+			call := func(config RuntimeConfig, param float32) ([]uint64, error) {
+				m, err := NewRuntimeWithConfig(config).InstantiateModuleFromCode(testCtx, source)
+				require.NoError(t, err)
+				defer m.Close(testCtx)
+				return m.ExportedFunction("trunc").Call(testCtx, api.EncodeF32(param))
+			}
+
+			// By default, this traps per the specification.
+			_, err := call(newConfig(), float32(math.NaN()))
+			require.Error(t, err)
+
+			results, err := call(newConfig().WithLenientFloatToInt(true), float32(math.NaN()))
+			require.NoError(t, err)
+			require.Equal(t, []uint64{0}, results)
+
+			results, err = call(newConfig().WithLenientFloatToInt(true), float32(math.Inf(1)))
+			require.NoError(t, err)
+			require.Equal(t, []uint64{math.MaxInt32}, results)
+		})
+	}
+}
+
+func TestRuntime_WithOptimizeBoundsChecks(t *testing.T) {
+	if !JITSupported {
+		t.Skip()
+	}
+
+	loadConst := func(addr uint32) []byte {
+		body := append([]byte{wasm.OpcodeI32Const}, leb128.EncodeInt32(int32(addr))...)
+		return append(body, wasm.OpcodeI32Load, 0x2, 0x0, wasm.OpcodeEnd)
+	}
+	i32 := wasm.ValueTypeI32
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+		},
+		FunctionSection: []wasm.Index{0, 0, 1},
+		MemorySection:   &wasm.Memory{Min: 1, Max: 1},
+		CodeSection: []*wasm.Code{
+			{Body: loadConst(wasm.MemoryPageSize - 4)},                                           // proven in bounds.
+			{Body: loadConst(wasm.MemoryPageSize - 3)},                                           // constant, but not in bounds.
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Load, 0x2, 0x0, wasm.OpcodeEnd}}, // not constant.
+		},
+		ExportSection: []*wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "in_bounds", Index: 0},
+			{Type: wasm.ExternTypeFunc, Name: "out_of_bounds", Index: 1},
+			{Type: wasm.ExternTypeFunc, Name: "load", Index: 2},
+			{Type: wasm.ExternTypeMemory, Name: "memory", Index: 0},
+		},
+	})
+
+	r := NewRuntimeWithConfig(NewRuntimeConfigJIT().WithOptimizeBoundsChecks(true))
+	m, err := r.InstantiateModuleFromCode(testCtx, source)
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+
+	require.True(t, m.Memory().WriteUint32Le(testCtx, wasm.MemoryPageSize-4, 42))
+
+	results, err := m.ExportedFunction("in_bounds").Call(testCtx)
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	_, err = m.ExportedFunction("out_of_bounds").Call(testCtx)
+	require.Contains(t, err.Error(), "out of bounds memory access")
+
+	results, err = m.ExportedFunction("load").Call(testCtx, uint64(wasm.MemoryPageSize-4))
+	require.NoError(t, err)
+	require.Equal(t, []uint64{42}, results)
+
+	_, err = m.ExportedFunction("load").Call(testCtx, uint64(wasm.MemoryPageSize-3))
+	require.Contains(t, err.Error(), "out of bounds memory access")
+}
+
+func TestRuntime_WithCallHook(t *testing.T) {
+	type hookKey struct{}
+
+	var events []string
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithCallHook(
+		func(ctx context.Context, mod api.Module, fn api.FunctionDefinition) context.Context {
+			events = append(events, "before "+mod.Name()+"."+fn.Name())
+			return context.WithValue(ctx, hookKey{}, fn.Name())
+		},
+		func(ctx context.Context, mod api.Module, fn api.FunctionDefinition, err error) {
+			events = append(events, fmt.Sprintf("after %s.%s(%v) err=%v", mod.Name(), fn.Name(), ctx.Value(hookKey{}), err))
+		},
+	))
+
+	// reenter calls "leaf" in the calling module, which isn't hooked as it happens during another call.
+	_, err := r.NewModuleBuilder("env").ExportFunction("reenter", func(ctx context.Context, m api.Module) {
+		_, err := m.ExportedFunction("leaf").Call(ctx)
+		require.NoError(t, err)
+	}).Instantiate(testCtx)
+	require.NoError(t, err)
+
+	m, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $guest
+  (import "env" "reenter" (func $env.reenter))
+  (func $leaf)
+  (func $middle call $leaf call $env.reenter)
+  (func $outer call $middle)
+  (export "leaf" (func $leaf))
+  (export "outer" (func $outer))
+)`))
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+
+	_, err = m.ExportedFunction("outer").Call(testCtx)
+	require.NoError(t, err)
+	_, err = m.ExportedFunction("leaf").Call(testCtx)
+	require.NoError(t, err)
+
+	// A Caller is hooked the same as api.Function Call.
+	caller, err := NewCaller(m, m.ExportedFunction("outer"))
+	require.NoError(t, err)
+	require.NoError(t, caller.Call(testCtx))
+
+	require.Equal(t, []string{
+		"before guest.outer",
+		"after guest.outer(outer) err=<nil>",
+		"before guest.leaf",
+		"after guest.leaf(leaf) err=<nil>",
+		"before guest.outer",
+		"after guest.outer(outer) err=<nil>",
+	}, events)
+}
+
+func TestCompiledCode_InstanceCount(t *testing.T) {
+	r := NewRuntime()
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+  (func $add (param i32 i32) (result i32) local.get 0 local.get 1 i32.add)
+  (export "add" (func $add))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+	require.Zero(t, compiled.InstanceCount())
+
+	var mods []api.Module
+	for i := 0; i < 3; i++ {
+		m, err := r.InstantiateModuleWithConfig(testCtx, compiled, NewModuleConfig().WithName(fmt.Sprintf("m%d", i)))
+		require.NoError(t, err)
+		defer m.Close(testCtx)
+		mods = append(mods, m)
+	}
+	require.Equal(t, 3, compiled.InstanceCount())
+
+	// Closing one doesn't affect the others, which share its compiled code.
+	require.NoError(t, mods[1].Close(testCtx))
+	require.Equal(t, 2, compiled.InstanceCount())
+
+	for _, m := range []api.Module{mods[0], mods[2]} {
+		results, err := m.ExportedFunction("add").Call(testCtx, 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, []uint64{3}, results)
+	}
+
+	// Closing again doesn't change the count.
+	require.NoError(t, mods[1].Close(testCtx))
+	require.Equal(t, 2, compiled.InstanceCount())
+}
+
+// growthEvent is a call to the function set by RuntimeConfig.WithMemoryGrowthListener.
+type growthEvent struct {
+	memoryIndex, before, delta uint32
+	ok                         bool
+}
+
+func TestRuntime_WithMemoryGrowthListener(t *testing.T) {
+	configs := map[string]func() RuntimeConfig{"interpreter": NewRuntimeConfigInterpreter}
+	if JITSupported {
+		configs["jit"] = NewRuntimeConfigJIT
+	}
+
+	for name, newConfig := range configs {
+		newConfig := newConfig
+
+		t.Run(name, func(t *testing.T) {
+			var events []growthEvent
+			r := NewRuntimeWithConfig(newConfig().WithMemoryGrowthListener(func(memoryIndex, before, delta uint32, ok bool) {
+				events = append(events, growthEvent{memoryIndex, before, delta, ok})
+			}))
+
+			m, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module
+  (memory 1 4)
+  (func $grow (param $delta i32) (result i32) local.get 0 memory.grow)
+  (export "grow" (func $grow))
+)`))
+			require.NoError(t, err)
+			defer m.Close(testCtx)
+
+			grow := m.ExportedFunction("grow")
+			for _, delta := range []uint64{1, 2, 1} { // the last exceeds the maximum
+				_, err = grow.Call(testCtx, delta)
+				require.NoError(t, err)
+			}
+
+			require.Equal(t, []growthEvent{
+				{memoryIndex: 0, before: 1, delta: 1, ok: true},
+				{memoryIndex: 0, before: 2, delta: 2, ok: true},
+				{memoryIndex: 0, before: 4, delta: 1, ok: false},
+			}, events)
+		})
+	}
+}
+
+func TestRuntime_WithMaxBlockNesting(t *testing.T) {
+	// nestedBlocksModule has a function which nests depth blocks.
+	nestedBlocksModule := func(depth int) []byte {
+		var body []byte
+		for i := 0; i < depth; i++ {
+			body = append(body, wasm.OpcodeBlock, 0x40) // 0x40 is the empty block type
+		}
+		for i := 0; i <= depth; i++ {
+			body = append(body, wasm.OpcodeEnd)
+		}
+		return binary.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{}},
+			FunctionSection: []wasm.Index{0},
+			CodeSection:     []*wasm.Code{{Body: body}},
+		})
+	}
+
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithMaxBlockNesting(1000))
+
+	_, err := r.CompileModule(testCtx, nestedBlocksModule(1000))
+	require.NoError(t, err)
+
+	_, err = r.CompileModule(testCtx, nestedBlocksModule(1001))
+	require.EqualError(t, err, "invalid function[0]: block nesting exceeds 1000 at offset 2000")
+
+	// The default is no limit.
+	_, err = NewRuntime().CompileModule(testCtx, nestedBlocksModule(1001))
+	require.NoError(t, err)
+}
+
+func TestRuntime_WithMaxResults(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	i32 := wasm.ValueTypeI32
+	// source imports a function returning one result and defines one returning three.
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{i32}},
+			{Results: []wasm.ValueType{i32, i32, i32}},
+		},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "one", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 1, wasm.OpcodeI32Const, 2, wasm.OpcodeI32Const, 3, wasm.OpcodeEnd,
+		}}},
+	})
+	config := NewRuntimeConfig().WithFeatureMultiValue(true)
+
+	_, err := NewRuntimeWithConfig(config.WithMaxResults(2)).CompileModule(testCtx, source)
+	require.EqualError(t, err, "func[1]: 3 results over limit of 2 results")
+
+	// Imported functions are numbered first, and imports of other types aren't functions.
+	_, err = NewRuntimeWithConfig(config.WithMaxResults(2)).CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{{Results: []wasm.ValueType{i32, i32, i32}}},
+		ImportSection: []*wasm.Import{
+			{Module: "env", Name: "memory", Type: wasm.ExternTypeMemory, DescMem: &wasm.Memory{Min: 1}},
+			{Module: "env", Name: "three", Type: wasm.ExternTypeFunc, DescFunc: 0},
+		},
+	}))
+	require.EqualError(t, err, "func[0]: 3 results over limit of 2 results")
+
+	// Single-result functions, such as the import, are allowed by any limit.
+	_, err = NewRuntimeWithConfig(config.WithMaxResults(1)).CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:   []*wasm.FunctionType{{Results: []wasm.ValueType{i32}}},
+		ImportSection: []*wasm.Import{{Module: "env", Name: "one", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+	}))
+	require.NoError(t, err)
+
+	_, err = NewRuntimeWithConfig(config.WithMaxResults(3)).CompileModule(testCtx, source)
+	require.NoError(t, err)
+
+	// The default is no limit.
+	_, err = NewRuntimeWithConfig(config).CompileModule(testCtx, source)
+	require.NoError(t, err)
+}
+
+func TestRuntime_WithMaxFunctionTypes(t *testing.T) {
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithMaxFunctionTypes(2))
+
+	_, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $two
+  (type (func))
+  (type (func (param i32)))
+)`))
+	require.NoError(t, err)
+
+	// Types already in the runtime don't count again, so this only adds one, exceeding the limit.
+	_, err = r.InstantiateModuleFromCode(testCtx, []byte(`(module $three
+  (type (func (param i32)))
+  (type (func (param i64)))
+)`))
+	require.EqualError(t, err, "too many function types in a store")
+}
+
+func TestRuntime_WithValidationWarnings(t *testing.T) {
+	// i32LoadModule loads an i32 with the given alignment hint, and includes a custom section wazero doesn't use.
+	i32LoadModule := func(align byte) []byte {
+		bin := binary.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{}},
+			FunctionSection: []wasm.Index{0},
+			MemorySection:   &wasm.Memory{Min: 1},
+			CodeSection: []*wasm.Code{{Body: []byte{
+				wasm.OpcodeI32Const, 0, wasm.OpcodeI32Load, align, 0, wasm.OpcodeDrop, wasm.OpcodeEnd,
+			}}},
+		})
+		return append(bin, wasm.SectionIDCustom, 0x0a, // 10 bytes in this section
+			0x09, 'p', 'r', 'o', 'd', 'u', 'c', 'e', 'r', 's')
+	}
+
+	var warnings []string
+	r := NewRuntimeWithConfig(NewRuntimeConfig().WithValidationWarnings(func(warning string) {
+		warnings = append(warnings, warning)
+	}))
+
+	t.Run("under-aligned", func(t *testing.T) {
+		warnings = nil
+		code, err := r.CompileModule(testCtx, i32LoadModule(0))
+		require.NoError(t, err)
+		defer code.Close(testCtx)
+
+		require.Equal(t, []string{
+			`custom section "producers" is unused`,
+			"func[0] i32.load at offset 2: alignment hint 1 is less than natural alignment 4",
+		}, warnings)
+	})
+
+	t.Run("over-aligned is an error", func(t *testing.T) {
+		warnings = nil
+		_, err := r.CompileModule(testCtx, i32LoadModule(3))
+		require.Error(t, err)
+		require.Nil(t, warnings)
+	})
+}
+
+func TestRuntime_WithNullFuncrefHandler(t *testing.T) {
+	zero := wasm.Index(0)
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Results: []wasm.ValueType{wasm.ValueTypeI32}},
+			{Params: []wasm.ValueType{wasm.ValueTypeI32}, Results: []wasm.ValueType{wasm.ValueTypeI32}},
+		},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 42, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeCallIndirect, 0, 0, wasm.OpcodeEnd}},
+		},
+		TableSection: []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{ // element 1 is null
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&zero},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{{Name: "dispatch", Type: wasm.ExternTypeFunc, Index: 1}},
+	})
+
+	type nullElement struct{ tableIndex, offset uint32 }
+	var handled []nullElement
+	var handlerErr error
+	handler := func(tableIndex, offset uint32) error {
+		handled = append(handled, nullElement{tableIndex, offset})
+		return handlerErr
+	}
+
+	tests := []struct {
+		name            string
+		config          RuntimeConfig
+		handlerErr      error
+		offset          uint64
+		expectedResults []uint64
+		expectedErr     string
+		expectedHandled []nullElement
+	}{
+		{
+			name:            "not null",
+			config:          NewRuntimeConfigInterpreter().WithNullFuncrefHandler(handler),
+			offset:          0,
+			expectedResults: []uint64{42},
+		},
+		{
+			name:        "null without handler",
+			config:      NewRuntimeConfigInterpreter(),
+			offset:      1,
+			expectedErr: "invalid table access",
+		},
+		{
+			name:            "null handled",
+			config:          NewRuntimeConfigInterpreter().WithNullFuncrefHandler(handler),
+			offset:          1,
+			expectedResults: []uint64{0},
+			expectedHandled: []nullElement{{0, 1}},
+		},
+		{
+			name:            "null handler error",
+			config:          NewRuntimeConfigInterpreter().WithNullFuncrefHandler(handler),
+			handlerErr:      errors.New("no function at 1"),
+			offset:          1,
+			expectedErr:     "no function at 1",
+			expectedHandled: []nullElement{{0, 1}},
+		},
+		{
+			name:        "out of bounds isn't null",
+			config:      NewRuntimeConfigInterpreter().WithNullFuncrefHandler(handler),
+			offset:      2,
+			expectedErr: "invalid table access",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			handled, handlerErr = nil, tc.handlerErr
+
+			r := NewRuntimeWithConfig(tc.config)
+			m, err := r.InstantiateModuleFromCode(testCtx, source)
+			require.NoError(t, err)
+			defer m.Close(testCtx)
+
+			results, err := m.ExportedFunction("dispatch").Call(testCtx, tc.offset)
+			if tc.expectedErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tc.expectedErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.expectedResults, results)
+			}
+			require.Equal(t, tc.expectedHandled, handled)
+		})
+	}
+}
+
+func TestRuntime_WithPerModuleTypeIDs(t *testing.T) {
+	i32, i64 := wasm.ValueTypeI32, wasm.ValueTypeI64
+	zero, one := wasm.Index(0), wasm.Index(1)
+	// The table module exports a table whose element 0 is `(func (param i32) (result i32))` and 1 is
+	// `(func (param i64) (result i64))`. Either way, the function returns its param plus one.
+	tableSource := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+			{Params: []wasm.ValueType{i64}, Results: []wasm.ValueType{i64}},
+		},
+		FunctionSection: []wasm.Index{0, 1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeEnd}},
+			{Body: []byte{wasm.OpcodeLocalGet, 0, wasm.OpcodeI64Const, 1, wasm.OpcodeI64Add, wasm.OpcodeEnd}},
+		},
+		TableSection: []*wasm.Table{{Min: 2, Type: wasm.RefTypeFuncref}},
+		ElementSection: []*wasm.ElementSegment{{
+			OffsetExpr: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			Init:       []*wasm.Index{&zero, &one},
+			Type:       wasm.RefTypeFuncref,
+		}},
+		ExportSection: []*wasm.Export{{Name: "table", Type: wasm.ExternTypeTable, Index: 0}},
+		NameSection:   &wasm.NameSection{ModuleName: "table"},
+	})
+	// The caller module imports the table, and its "call" function calls the element at the offset of its param with
+	// 41, as a `(func (param i32) (result i32))`. Its types are in the opposite order to the table module, so when
+	// type IDs are per-module, the ID of its i32 type is the same as the i64 type of the table module.
+	callerSource := binary.EncodeModule(&wasm.Module{
+		TypeSection: []*wasm.FunctionType{
+			{Params: []wasm.ValueType{i64}, Results: []wasm.ValueType{i64}},
+			{Params: []wasm.ValueType{i32}, Results: []wasm.ValueType{i32}},
+		},
+		ImportSection: []*wasm.Import{
+			{Type: wasm.ExternTypeTable, Module: "table", Name: "table", DescTable: &wasm.Table{Min: 2, Type: wasm.RefTypeFuncref}},
+		},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{
+			{Body: []byte{wasm.OpcodeI32Const, 41, wasm.OpcodeLocalGet, 0, wasm.OpcodeCallIndirect, 1, 0, wasm.OpcodeEnd}},
+		},
+		ExportSection: []*wasm.Export{{Name: "call", Type: wasm.ExternTypeFunc, Index: 0}},
+		NameSection:   &wasm.NameSection{ModuleName: "caller"},
+	})
+
+	for _, perModuleTypeIDs := range []bool{false, true} {
+		t.Run(fmt.Sprintf("perModuleTypeIDs=%v", perModuleTypeIDs), func(t *testing.T) {
+			r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().WithPerModuleTypeIDs(perModuleTypeIDs))
+
+			table, err := r.InstantiateModuleFromCode(testCtx, tableSource)
+			require.NoError(t, err)
+			defer table.Close(testCtx)
+
+			caller, err := r.InstantiateModuleFromCode(testCtx, callerSource)
+			require.NoError(t, err)
+			defer caller.Close(testCtx)
+
+			results, err := caller.ExportedFunction("call").Call(testCtx, 0)
+			require.NoError(t, err)
+			require.Equal(t, []uint64{42}, results)
+
+			_, err = caller.ExportedFunction("call").Call(testCtx, 1)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "indirect call type mismatch")
+		})
+	}
+}
+
+func TestRuntime_MemoryWriteLog(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	type write struct {
+		offset uint32
+		data   []byte
+	}
+	var log []write
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter().
+		WithFeatureBulkMemoryOperations(true).
+		WithMemoryWriteLog(func(offset uint32, data []byte) {
+			log = append(log, write{offset, append([]byte{}, data...)}) // copy as data is a view of memory.
+		}))
+
+	source := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		MemorySection:   &wasm.Memory{Min: 1},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 8, wasm.OpcodeI32Const, 42,
+			wasm.OpcodeI32Store, 0x2, 0x0, // i32.store align=4 offset=0
+			wasm.OpcodeI32Const, 16, wasm.OpcodeI64Const, 0x7f, // -1
+			wasm.OpcodeI64Store, 0x3, 0x4, // i64.store align=8 offset=4
+			wasm.OpcodeI32Const, 32, wasm.OpcodeI32Const, 1,
+			wasm.OpcodeI32Store8, 0x0, 0x0, // i32.store8 align=1 offset=0
+			wasm.OpcodeI32Const, 33, wasm.OpcodeI32Const, 7, wasm.OpcodeI32Const, 3,
+			wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryFill, 0x0, // memory.fill 33..36 with 7
+			wasm.OpcodeI32Const, 48, wasm.OpcodeI32Const, 32, wasm.OpcodeI32Const, 4,
+			wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryCopy, 0x0, 0x0, // memory.copy 32..36 to 48
+			wasm.OpcodeI32Const, 60, wasm.OpcodeI32Const, 7, wasm.OpcodeI32Const, 0,
+			wasm.OpcodeMiscPrefix, wasm.OpcodeMiscMemoryFill, 0x0, // zero-length, so not logged
+			wasm.OpcodeEnd,
+		}}},
+		ExportSection: []*wasm.Export{{Type: wasm.ExternTypeFunc, Name: "store", Index: 0}},
+	})
+
+	m, err := r.InstantiateModuleFromCode(testCtx, source)
+	require.NoError(t, err)
+	defer m.Close(testCtx)
+
+	_, err = m.ExportedFunction("store").Call(testCtx)
+	require.NoError(t, err)
+
+	require.Equal(t, []write{
+		{offset: 8, data: []byte{42, 0, 0, 0}},
+		{offset: 20, data: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}},
+		{offset: 32, data: []byte{1}},
+		{offset: 33, data: []byte{7, 7, 7}},
+		{offset: 48, data: []byte{1, 7, 7, 7}},
+	}, log)
+}
+
+func TestRuntime_InstantiateModules(t *testing.T) {
+	r := NewRuntime()
+
+	compile := func(source string) CompiledCode {
+		compiled, err := r.CompileModule(testCtx, []byte(source))
+		require.NoError(t, err)
+		return compiled
+	}
+
+	t.Run("instantiated in import order", func(t *testing.T) {
+		app := compile(`(module $app
+	(import "lib" "one" (func $one (result i32)))
+	(func $two (result i32) call $one call $one i32.add)
+	(export "two" (func $two))
+)`)
+		lib := compile(`(module $lib (func $one (result i32) i32.const 1) (export "one" (func $one)))`)
+
+		mods, err := r.InstantiateModules(testCtx, app, lib)
+		require.NoError(t, err)
+		require.Equal(t, 2, len(mods))
+		require.Equal(t, "app", mods[0].Name())
+		require.Equal(t, "lib", mods[1].Name())
+		defer mods[0].Close(testCtx)
+		defer mods[1].Close(testCtx)
+
+		results, err := mods[0].ExportedFunction("two").Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), results[0])
+	})
+
+	t.Run("unnamed", func(t *testing.T) {
+		app := compile(`(module
+	(import "lib3" "one" (func $one (result i32)))
+	(export "one" (func $one))
+)`)
+		lib := compile(`(module $lib3 (func $one (result i32) i32.const 1) (export "one" (func $one)))`)
+
+		mods, err := r.InstantiateModules(testCtx, app, lib)
+		require.NoError(t, err)
+		require.Equal(t, "", mods[0].Name())
+		defer mods[0].Close(testCtx)
+		defer mods[1].Close(testCtx)
+
+		results, err := mods[0].ExportedFunction("one").Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), results[0])
+	})
+
+	t.Run("two module cycle", func(t *testing.T) {
+		a := compile(`(module $a
+	(import "b" "f" (func $g))
+	(func $f) (export "f" (func $f))
+)`)
+		b := compile(`(module $b
+	(import "a" "f" (func $g))
+	(func $f) (export "f" (func $f))
+)`)
+
+		_, err := r.InstantiateModules(testCtx, a, b)
+		require.EqualError(t, err, "import cycle: a -> b -> a")
+		require.Nil(t, r.Module("a"))
+		require.Nil(t, r.Module("b"))
+	})
+
+	t.Run("self-import", func(t *testing.T) {
+		self := compile(`(module $self
+	(import "self" "f" (func $g))
+	(func $f) (export "f" (func $f))
+)`)
+
+		_, err := r.InstantiateModules(testCtx, self)
+		require.EqualError(t, err, "import cycle: self -> self")
+	})
+
+	t.Run("closes instantiated modules on error", func(t *testing.T) {
+		lib := compile(`(module $lib2 (func $one (result i32) i32.const 1) (export "one" (func $one)))`)
+		app := compile(`(module $app2 (import "lib2" "two" (func $two (result i32))))`)
+
+		_, err := r.InstantiateModules(testCtx, app, lib)
+		require.EqualError(t, err, `"two" is not exported in module "lib2"`)
+		require.Nil(t, r.Module("lib2"))
+	})
+}
+
+func TestWatchGlobal(t *testing.T) {
+	i32 := wasm.ValueTypeI32
+	watchedWasm := binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{}},
+		FunctionSection: []wasm.Index{0},
+		CodeSection: []*wasm.Code{{Body: []byte{ // counter++
+			wasm.OpcodeGlobalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeGlobalSet, 0, wasm.OpcodeEnd,
+		}}},
+		GlobalSection: []*wasm.Global{
+			{
+				Type: &wasm.GlobalType{ValType: i32, Mutable: true},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			},
+			{
+				Type: &wasm.GlobalType{ValType: i32},
+				Init: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}},
+			},
+		},
+		ExportSection: []*wasm.Export{
+			{Type: wasm.ExternTypeFunc, Name: "increment", Index: 0},
+			{Type: wasm.ExternTypeGlobal, Name: "counter", Index: 0},
+			{Type: wasm.ExternTypeGlobal, Name: "constant", Index: 1},
+		},
+		NameSection: &wasm.NameSection{ModuleName: "watched"},
+	})
+
+	r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter())
+	mod, err := r.InstantiateModuleFromCode(testCtx, watchedWasm)
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+
+	var changes [][2]uint64
+	require.NoError(t, WatchGlobal(mod, "counter", func(old, new uint64) {
+		changes = append(changes, [2]uint64{old, new})
+	}))
+
+	increment := mod.ExportedFunction("increment")
+	for i := 0; i < 3; i++ {
+		_, err = increment.Call(testCtx)
+		require.NoError(t, err)
 	}
+	require.Equal(t, [][2]uint64{{0, 1}, {1, 2}, {2, 3}}, changes)
+
+	// Changes by the host also fire, but not setting the same value.
+	counter := mod.ExportedGlobal("counter").(api.MutableGlobal)
+	counter.Set(testCtx, 10)
+	counter.Set(testCtx, 10)
+	require.Equal(t, [][2]uint64{{0, 1}, {1, 2}, {2, 3}, {3, 10}}, changes)
+
+	t.Run("nil removes the watch", func(t *testing.T) {
+		require.NoError(t, WatchGlobal(mod, "counter", nil))
+		_, err = increment.Call(testCtx)
+		require.NoError(t, err)
+		require.Equal(t, 4, len(changes))
+	})
+
+	t.Run("immutable", func(t *testing.T) {
+		err := WatchGlobal(mod, "constant", func(old, new uint64) {})
+		require.EqualError(t, err, `global "constant" in module "watched" is immutable, so never changes`)
+	})
+
+	t.Run("not exported", func(t *testing.T) {
+		err := WatchGlobal(mod, "missing", func(old, new uint64) {})
+		require.EqualError(t, err, `"missing" is not exported in module "watched"`)
+	})
+
+	t.Run("JIT", func(t *testing.T) {
+		if !JITSupported {
+			t.Skip()
+		}
+
+		r := NewRuntimeWithConfig(NewRuntimeConfigJIT())
+		mod, err := r.InstantiateModuleFromCode(testCtx, watchedWasm)
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		err = WatchGlobal(mod, "counter", func(old, new uint64) {})
+		require.EqualError(t, err, `module "watched" can't watch globals: its engine doesn't intercept global.set`)
+	})
+}
+
+func TestMemoryGrowthPages(t *testing.T) {
+	r := NewRuntime()
+
+	t.Run("grown", func(t *testing.T) {
+		mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $grown
+  (memory 2)
+  (func $grow (param i32) (result i32) local.get 0 memory.grow)
+  (export "grow" (func $grow))
+)`))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		pages, err := MemoryGrowthPages(mod)
+		require.NoError(t, err)
+		require.Zero(t, pages)
+
+		grow := mod.ExportedFunction("grow")
+		for _, delta := range []uint64{1, 2} {
+			_, err = grow.Call(testCtx, delta)
+			require.NoError(t, err)
+		}
+		pages, err = MemoryGrowthPages(mod)
+		require.NoError(t, err)
+		require.Equal(t, uint32(3), pages)
+	})
+
+	t.Run("no memory", func(t *testing.T) {
+		mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $no_memory)`))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		pages, err := MemoryGrowthPages(mod)
+		require.NoError(t, err)
+		require.Zero(t, pages)
+	})
+}
+
+func TestNewCaller(t *testing.T) {
+	if buildoptions.IsCore1Only {
+		t.Skip("built with wazero_core1")
+	}
+	tests := []struct {
+		name   string
+		config RuntimeConfig
+	}{
+		{name: "interpreter", config: NewRuntimeConfigInterpreter().WithFeatureMultiValue(true)},
+		{name: "default", config: NewRuntimeConfig().WithFeatureMultiValue(true)},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(tc.config)
+
+			host, err := r.NewModuleBuilder("host").
+				ExportFunction("swap", func(x, y uint32) (uint32, uint32) { return y, x }).
+				Instantiate(testCtx)
+			require.NoError(t, err)
+			defer host.Close(testCtx)
+
+			guest, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $guest
+	(import "host" "swap" (func $swap (param i32 i32) (result i32 i32)))
+	(memory 1)
+	(func $load_add (param i32 i32) (result i32) local.get 0 i32.load local.get 1 i32.add)
+	(export "load_add" (func $load_add))
+	(export "swap" (func $swap))
+)`))
+			require.NoError(t, err)
+			defer guest.Close(testCtx)
+
+			t.Run("wasm function", func(t *testing.T) {
+				caller, err := NewCaller(guest, guest.ExportedFunction("load_add"))
+				require.NoError(t, err)
+				require.Equal(t, []uint64{0, 0}, caller.Params())
+				require.Equal(t, []uint64{0}, caller.Results())
+
+				for _, y := range []uint64{10, 20, 30} {
+					caller.Params()[1] = y
+					require.NoError(t, caller.Call(testCtx))
+					require.Equal(t, []uint64{y}, caller.Results()) // overwritten, not appended
+				}
+
+				// Ensure the caller is still usable after a trap.
+				caller.Params()[0] = 70000
+				require.Error(t, caller.Call(testCtx))
+				caller.Params()[0], caller.Params()[1] = 0, 5
+				require.NoError(t, caller.Call(testCtx))
+				require.Equal(t, []uint64{5}, caller.Results())
+			})
+
+			t.Run("imported host function", func(t *testing.T) {
+				caller, err := NewCaller(guest, guest.ExportedFunction("swap"))
+				require.NoError(t, err)
+
+				params := caller.Params()
+				params[0], params[1] = 1, 2
+				require.NoError(t, caller.Call(testCtx))
+				require.Equal(t, []uint64{2, 1}, caller.Results())
+			})
+
+			t.Run("function of another module", func(t *testing.T) {
+				_, err := NewCaller(guest, host.ExportedFunction("swap"))
+				require.EqualError(t, err, "host.swap is not a function of module[guest]")
+			})
+		})
+	}
+}
+
+func TestCallWithLimits(t *testing.T) {
+	tests := []struct {
+		name   string
+		config RuntimeConfig
+	}{
+		{name: "interpreter", config: NewRuntimeConfigInterpreter()},
+		{name: "default", config: NewRuntimeConfig()},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(tc.config)
+
+			mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $limited
+	(memory 1 10)
+	(func $grow (param i32) (result i32) local.get 0 memory.grow)
+	(export "grow" (func $grow))
+)`))
+			require.NoError(t, err)
+			defer mod.Close(testCtx)
+			grow := mod.ExportedFunction("grow")
+
+			// Growing to 3 pages exceeds the per-call limit of 2 pages, so memory.grow returns -1.
+			results, err := CallWithLimits(testCtx, mod, grow, 2, 2)
+			require.NoError(t, err)
+			require.Equal(t, uint64(0xffffffff), results[0])
+
+			// Growing within the limit succeeds, returning the previous size.
+			results, err = CallWithLimits(testCtx, mod, grow, 2, 1)
+			require.NoError(t, err)
+			require.Equal(t, uint64(1), results[0])
+
+			// The memory is already over the limit, so the function isn't called.
+			_, err = CallWithLimits(testCtx, mod, grow, 1, 0)
+			require.EqualError(t, err, "memory of 2 pages is already over the limit of 1 pages")
+
+			// Without a limit, the memory grows up to its own maximum.
+			results, err = grow.Call(testCtx, 8)
+			require.NoError(t, err)
+			require.Equal(t, uint64(2), results[0])
+			require.Equal(t, uint32(10*65536), mod.Memory().Size(testCtx))
+		})
+	}
+
+	t.Run("function of another module", func(t *testing.T) {
+		r := NewRuntime()
+		host, err := r.NewModuleBuilder("host").ExportFunction("f", func() {}).Instantiate(testCtx)
+		require.NoError(t, err)
+		defer host.Close(testCtx)
+		mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $other)`))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		_, err = CallWithLimits(testCtx, mod, host.ExportedFunction("f"), 1)
+		require.EqualError(t, err, "host.f is not a function of module[other]")
+	})
+}
 
+func TestRuntime_InstantiateModuleWithConfig_WithImportMemoryLimits(t *testing.T) {
 	r := NewRuntime()
-	for _, tt := range tests {
-		tc := tt
 
-		if tc.runtime == nil {
-			tc.runtime = r
-		}
+	env, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $env (memory 1 4) (export "memory" (memory 0)))`))
+	require.NoError(t, err)
+	defer env.Close(testCtx)
 
-		t.Run(tc.name, func(t *testing.T) {
-			_, err := tc.runtime.CompileModule(testCtx, tc.source)
-			require.EqualError(t, err, tc.expectedErr)
-		})
-	}
-}
+	// The guest accepts a memory of any size.
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{{
+			Type: wasm.ExternTypeMemory, Module: "env", Name: "memory", DescMem: &wasm.Memory{Min: 1, Max: wasm.MemoryLimitPages},
+		}},
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
 
-func TestRuntime_setMemoryCapacity(t *testing.T) {
 	tests := []struct {
 		name        string
-		runtime     *runtime
-		mem         *wasm.Memory
+		config      ModuleConfig
 		expectedErr string
 	}{
 		{
-			name: "cap ok",
-			runtime: &runtime{memoryCapacityPages: func(minPages uint32, maxPages *uint32) uint32 {
-				return 3
-			}, memoryLimitPages: 3},
-			mem: &wasm.Memory{Min: 2},
+			name:   "declared",
+			config: NewModuleConfig(),
 		},
 		{
-			name: "cap < min",
-			runtime: &runtime{memoryCapacityPages: func(minPages uint32, maxPages *uint32) uint32 {
-				return 1
-			}, memoryLimitPages: 3},
-			mem:         &wasm.Memory{Min: 2},
-			expectedErr: "memory[memory] capacity 1 pages (64 Ki) less than minimum 2 pages (128 Ki)",
+			name:   "tightened",
+			config: NewModuleConfig().WithImportMemoryLimits("env", "memory", 1, 4),
 		},
 		{
-			name: "cap > maxLimit",
-			runtime: &runtime{memoryCapacityPages: func(minPages uint32, maxPages *uint32) uint32 {
-				return 4
-			}, memoryLimitPages: 3},
-			mem:         &wasm.Memory{Min: 2},
-			expectedErr: "memory[memory] capacity 4 pages (256 Ki) over limit of 3 pages (192 Ki)",
+			name:        "max less than exported",
+			config:      NewModuleConfig().WithImportMemoryLimits("env", "memory", 1, 2),
+			expectedErr: "import[0] memory[env.memory]: maximum size mismatch: 2 < 4",
+		},
+		{
+			name:        "min more than exported",
+			config:      NewModuleConfig().WithImportMemoryLimits("env", "memory", 2, 4),
+			expectedErr: "import[0] memory[env.memory]: minimum size mismatch: 2 > 1",
+		},
+		{
+			name:        "min less than declared",
+			config:      NewModuleConfig().WithImportMemoryLimits("env", "memory", 0, 4),
+			expectedErr: "import[0] memory[env.memory]: minimum size override 0 < declared 1",
+		},
+		{
+			name:        "min more than max",
+			config:      NewModuleConfig().WithImportMemoryLimits("env", "memory", 2, 1),
+			expectedErr: "import[0] memory[env.memory]: minimum size override 2 > maximum 1",
 		},
 	}
 
@@ -205,16 +1413,96 @@ func TestRuntime_setMemoryCapacity(t *testing.T) {
 		tc := tt
 
 		t.Run(tc.name, func(t *testing.T) {
-			err := tc.runtime.setMemoryCapacity("memory", tc.mem)
-			if tc.expectedErr == "" {
-				require.NoError(t, err)
-			} else {
+			guest, err := r.InstantiateModuleWithConfig(testCtx, compiled, tc.config)
+			if tc.expectedErr != "" {
 				require.EqualError(t, err, tc.expectedErr)
+				return
 			}
+			require.NoError(t, err)
+			require.NoError(t, guest.Close(testCtx))
 		})
 	}
 }
 
+func TestRuntime_InstantiateModuleWithConfig_WithLazyImport(t *testing.T) {
+	r := NewRuntime()
+
+	// The guest imports "add" from a plugin which doesn't exist when it is instantiated.
+	compiled, err := r.CompileModule(testCtx, []byte(`(module $guest
+	(import "plugin" "add" (func $add (param i32 i32) (result i32)))
+	(func $run (param i32 i32) (result i32) local.get 0 local.get 1 call $add)
+	(export "run" (func $run))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	config := NewModuleConfig().WithLazyImport("plugin", "add")
+	guest, err := r.InstantiateModuleWithConfig(testCtx, compiled, config)
+	require.NoError(t, err)
+
+	run := guest.ExportedFunction("run")
+
+	t.Run("not yet bound", func(t *testing.T) {
+		_, err = run.Call(testCtx, 1, 2)
+		require.EqualError(t, err, `wasm error: import not yet bound: plugin.add
+wasm stack trace:
+	guest[lazy imports].plugin.add(i32,i32) i32
+	guest.run(i32,i32) i32`)
+	})
+
+	plugin, err := r.NewModuleBuilder("plugin").
+		ExportFunction("add", func(x, y uint32) uint32 { return x + y }).
+		ExportFunction("sub", func(x, y uint32) uint32 { return x - y }).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	defer plugin.Close(testCtx)
+
+	t.Run("bound", func(t *testing.T) {
+		require.NoError(t, BindImport(guest, "plugin", "add", plugin.ExportedFunction("add")))
+
+		results, err := run.Call(testCtx, 1, 2)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), results[0])
+	})
+
+	t.Run("rebound", func(t *testing.T) {
+		require.NoError(t, BindImport(guest, "plugin", "add", plugin.ExportedFunction("sub")))
+
+		results, err := run.Call(testCtx, 3, 2)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), results[0])
+	})
+
+	t.Run("not lazy", func(t *testing.T) {
+		err := BindImport(guest, "plugin", "sub", plugin.ExportedFunction("sub"))
+		require.EqualError(t, err, "plugin.sub is not a lazy import of module[guest]")
+	})
+
+	t.Run("nil function", func(t *testing.T) {
+		err := BindImport(guest, "plugin", "add", plugin.ExportedFunction("missing"))
+		require.EqualError(t, err, "function is nil binding plugin.add")
+	})
+
+	t.Run("signature mismatch", func(t *testing.T) {
+		noop, err := r.NewModuleBuilder("noop").ExportFunction("noop", func() {}).Instantiate(testCtx)
+		require.NoError(t, err)
+		defer noop.Close(testCtx)
+
+		err = BindImport(guest, "plugin", "add", noop.ExportedFunction("noop"))
+		require.EqualError(t, err, "signature mismatch binding plugin.add: i32i32_i32 != v_v")
+	})
+
+	t.Run("closes placeholders", func(t *testing.T) {
+		require.NoError(t, guest.Close(testCtx))
+		require.Nil(t, r.Module("guest[lazy imports]"))
+
+		// The same name can be re-used, as the placeholders were closed.
+		guest, err := r.InstantiateModuleWithConfig(testCtx, compiled, config)
+		require.NoError(t, err)
+		require.NoError(t, guest.Close(testCtx))
+	})
+}
+
 // TestModule_Memory only covers a couple cases to avoid duplication of internal/wasm/runtime_test.go
 func TestModule_Memory(t *testing.T) {
 	tests := []struct {
@@ -425,6 +1713,286 @@ func TestRuntime_InstantiateModule_UsesContext(t *testing.T) {
 	require.True(t, calledStart)
 }
 
+func TestInstantiateModule_ImportedStartFunction(t *testing.T) {
+	r := NewRuntime()
+
+	var calledBy []string
+	host, err := r.NewModuleBuilder("env").
+		ExportFunction("init", func(m api.Module) {
+			calledBy = append(calledBy, m.Name())
+		}).
+		ExportFunction("fail", func() {
+			panic(errors.New("init failed"))
+		}).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	// The start section is the index of the imported host function, which is called in the context of the importer.
+	mod, err := r.InstantiateModuleFromCode(testCtx, []byte(`(module $guest
+	(import "env" "init" (func $init))
+	(func $noop)
+	(start $init)
+)`))
+	require.NoError(t, err)
+	defer mod.Close(testCtx)
+	require.Equal(t, []string{"guest"}, calledBy)
+
+	_, err = r.InstantiateModuleFromCode(testCtx, []byte(`(module $failing
+	(import "env" "fail" (func $fail))
+	(start $fail)
+)`))
+	require.EqualError(t, err, `start imported env.fail failed: init failed (recovered by wazero)
+wasm stack trace:
+	env.fail()`)
+	require.Nil(t, r.Module("failing"))
+}
+
+func TestInstantiateModule_Reactor(t *testing.T) {
+	r := NewRuntime()
+
+	var initCount, callCount int
+	host, err := r.NewModuleBuilder("env").
+		ExportFunction("init", func() { initCount++ }).
+		ExportFunction("call", func() { callCount++ }).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+	defer host.Close(testCtx)
+
+	compiled, err := r.CompileModule(testCtx, []byte(`(module
+	(import "env" "init" (func $env.init))
+	(import "env" "call" (func $env.call))
+	(func $initialize call $env.init)
+	(func $run call $env.call)
+	(export "_initialize" (func $initialize))
+	(export "run" (func $run))
+)`))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+	require.Equal(t, WASIModuleKindReactor, compiled.WASIModuleKind())
+
+	tests := []struct {
+		name              string
+		config            ModuleConfig
+		expectedInitCount int
+	}{
+		{name: "default", config: NewModuleConfig(), expectedInitCount: 1},
+		{name: "listed", config: NewModuleConfig().WithStartFunctions("_initialize"), expectedInitCount: 1},
+		{name: "listed twice", config: NewModuleConfig().WithStartFunctions("_initialize", "_initialize"), expectedInitCount: 1},
+		// Setting start functions explicitly opts out of calling "_initialize" automatically.
+		{name: "not listed", config: NewModuleConfig().WithStartFunctions(), expectedInitCount: 0},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			initCount, callCount = 0, 0
+
+			mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, tc.config.WithName(t.Name()))
+			require.NoError(t, err)
+			defer mod.Close(testCtx)
+			require.Equal(t, tc.expectedInitCount, initCount)
+
+			// The reactor stays resident, so its exports are callable any number of times.
+			for i := 0; i < 3; i++ {
+				_, err = mod.ExportedFunction("run").Call(testCtx)
+				require.NoError(t, err)
+			}
+			require.Equal(t, 3, callCount)
+			if tc.expectedInitCount == 0 { // the host is responsible for initializing.
+				_, err = mod.ExportedFunction("_initialize").Call(testCtx)
+				require.NoError(t, err)
+			}
+
+			// However, it must not be initialized again.
+			_, err = mod.ExportedFunction("_initialize").Call(testCtx)
+			require.EqualError(t, err, fmt.Sprintf("module[%s] function[_initialize] was already called: a reactor is initialized only once", t.Name()))
+			require.Equal(t, 1, initCount)
+		})
+	}
+}
+
+func TestRuntime_WithDefaultStartFunctions(t *testing.T) {
+	// The module exports both "_initialize" and "_start", so isn't a reactor whose "_initialize" is always called.
+	source := []byte(`(module
+	(import "env" "initialize" (func $env.initialize))
+	(import "env" "start" (func $env.start))
+	(func $initialize call $env.initialize)
+	(func $start call $env.start)
+	(export "_initialize" (func $initialize))
+	(export "_start" (func $start))
+)`)
+
+	tests := []struct {
+		name     string
+		rConfig  RuntimeConfig
+		config   ModuleConfig // nil to instantiate without a config
+		expected []string
+	}{
+		{
+			name:     "default",
+			rConfig:  NewRuntimeConfig(),
+			expected: []string{"start"},
+		},
+		{
+			name:     "runtime default",
+			rConfig:  NewRuntimeConfig().WithDefaultStartFunctions("_initialize"),
+			expected: []string{"initialize"},
+		},
+		{
+			name:     "runtime default with module config",
+			rConfig:  NewRuntimeConfig().WithDefaultStartFunctions("_initialize"),
+			config:   NewModuleConfig().WithName("config"),
+			expected: []string{"initialize"},
+		},
+		{
+			name:     "module config overrides runtime default",
+			rConfig:  NewRuntimeConfig().WithDefaultStartFunctions("_initialize"),
+			config:   NewModuleConfig().WithStartFunctions("_start"),
+			expected: []string{"start"},
+		},
+		{
+			name:    "module config overrides runtime default with none",
+			rConfig: NewRuntimeConfig().WithDefaultStartFunctions("_initialize"),
+			config:  NewModuleConfig().WithStartFunctions(),
+		},
+		{
+			name:    "runtime default none",
+			rConfig: NewRuntimeConfig().WithDefaultStartFunctions(),
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(tc.rConfig)
+
+			var called []string
+			_, err := r.NewModuleBuilder("env").
+				ExportFunction("initialize", func() { called = append(called, "initialize") }).
+				ExportFunction("start", func() { called = append(called, "start") }).
+				Instantiate(testCtx)
+			require.NoError(t, err)
+
+			compiled, err := r.CompileModule(testCtx, source)
+			require.NoError(t, err)
+			if tc.config == nil {
+				_, err = r.InstantiateModule(testCtx, compiled)
+			} else {
+				_, err = r.InstantiateModuleWithConfig(testCtx, compiled, tc.config)
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, called)
+		})
+	}
+}
+
+func TestInstantiateModule_StartFunctionDeadline(t *testing.T) {
+	// spin returns a body which increments local 0 until it reaches iterations, a LEB128 encoded int32.
+	spin := func(iterations ...byte) []byte {
+		return append(append([]byte{
+			wasm.OpcodeLoop, 0x40,
+			wasm.OpcodeLocalGet, 0, wasm.OpcodeI32Const, 1, wasm.OpcodeI32Add, wasm.OpcodeLocalTee, 0,
+			wasm.OpcodeI32Const}, iterations...),
+			wasm.OpcodeI32LtU, wasm.OpcodeBrIf, 0,
+			wasm.OpcodeEnd,
+		)
+	}
+	zero := wasm.Index(0)
+
+	tests := []struct {
+		name        string
+		body        []byte
+		timeout     time.Duration
+		expectedErr error
+	}{
+		{
+			name:        "infinite loop",
+			body:        []byte{wasm.OpcodeLoop, 0x40, wasm.OpcodeBr, 0, wasm.OpcodeEnd, wasm.OpcodeEnd},
+			timeout:     10 * time.Millisecond,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name: "infinite loop via br_table",
+			body: []byte{
+				wasm.OpcodeLoop, 0x40, wasm.OpcodeI32Const, 0, wasm.OpcodeBrTable, 0, 0, wasm.OpcodeEnd, wasm.OpcodeEnd,
+			},
+			timeout:     10 * time.Millisecond,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			// Each call spins before recursing, so the deadline passes before the call stack is exhausted.
+			name:        "infinite recursion",
+			body:        append(spin(0x80, 0x80, 0x40 /* 1<<20 */), wasm.OpcodeCall, 0, wasm.OpcodeEnd),
+			timeout:     10 * time.Millisecond,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name:        "deadline already passed",
+			body:        []byte{wasm.OpcodeCall, 0, wasm.OpcodeEnd},
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name:    "long init under the deadline",
+			body:    append(spin(0x80, 0x80, 0x04 /* 1<<16 */), wasm.OpcodeEnd),
+			timeout: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			r := NewRuntimeWithConfig(NewRuntimeConfigInterpreter())
+			compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+				TypeSection:     []*wasm.FunctionType{{}},
+				FunctionSection: []wasm.Index{0},
+				CodeSection:     []*wasm.Code{{LocalTypes: []wasm.ValueType{wasm.ValueTypeI32}, Body: tc.body}},
+				StartSection:    &zero,
+			}))
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(testCtx, tc.timeout)
+			defer cancel()
+			_, err = r.InstantiateModule(ctx, compiled)
+			if tc.expectedErr == nil {
+				require.NoError(t, err)
+			} else {
+				require.ErrorIs(t, err, tc.expectedErr)
+				require.Contains(t, err.Error(), "start function[0] failed: context deadline exceeded")
+
+				// The module isn't registered, so instantiating it again fails the same way.
+				_, err = r.InstantiateModule(ctx, compiled)
+				require.ErrorIs(t, err, tc.expectedErr)
+			}
+		})
+	}
+}
+
+func TestInstantiateModule_DataSegmentOutOfBounds(t *testing.T) {
+	r := NewRuntime()
+
+	env, err := r.NewModuleBuilder("env").ExportGlobalI32("offset", 65535).Instantiate(testCtx)
+	require.NoError(t, err)
+	defer env.Close(testCtx)
+
+	// The second segment is at an offset from the imported global, so only its first byte is within memory.
+	_, err = r.InstantiateModuleFromCode(testCtx, binary.EncodeModule(&wasm.Module{
+		ImportSection: []*wasm.Import{{
+			Module: "env", Name: "offset", Type: wasm.ExternTypeGlobal,
+			DescGlobal: &wasm.GlobalType{ValType: wasm.ValueTypeI32},
+		}},
+		MemorySection: &wasm.Memory{Min: 1},
+		DataSection: []*wasm.DataSegment{
+			{OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeI32Const, Data: []byte{0}}, Init: []byte{1}},
+			{OffsetExpression: &wasm.ConstantExpression{Opcode: wasm.OpcodeGlobalGet, Data: []byte{0}}, Init: []byte{1, 2}},
+		},
+	}))
+	require.EqualError(t, err, "data[1]: out of bounds memory access: offset 65535 (from global[0]) + length 2 > memory size 65536")
+}
+
 // TestInstantiateModuleFromCode_DoesntEnforce_Start ensures wapc-go work when modules import WASI, but don't export "_start".
 func TestInstantiateModuleFromCode_DoesntEnforce_Start(t *testing.T) {
 	r := NewRuntime()
@@ -532,6 +2100,178 @@ func TestInstantiateModuleWithConfig_ExitError(t *testing.T) {
 	require.Equal(t, err, sys.NewExitError("env", 2))
 }
 
+func TestInstantiateModuleWithConfig_WithTrapSink(t *testing.T) {
+	// trapModule exports "crash", which executes `unreachable`, and "ok", which doesn't trap.
+	trapModule := func(startSection *wasm.Index) []byte {
+		return binary.EncodeModule(&wasm.Module{
+			TypeSection:     []*wasm.FunctionType{{}},
+			FunctionSection: []wasm.Index{0, 0},
+			CodeSection: []*wasm.Code{
+				{Body: []byte{wasm.OpcodeUnreachable, wasm.OpcodeEnd}},
+				{Body: []byte{wasm.OpcodeEnd}},
+			},
+			ExportSection: []*wasm.Export{
+				{Type: wasm.ExternTypeFunc, Name: "crash", Index: 0},
+				{Type: wasm.ExternTypeFunc, Name: "ok", Index: 1},
+			},
+			StartSection: startSection,
+			NameSection:  &wasm.NameSection{FunctionNames: wasm.NameMap{{Index: 0, Name: "crash"}, {Index: 1, Name: "ok"}}},
+		})
+	}
+
+	r := NewRuntime()
+	compiled, err := r.CompileModule(testCtx, trapModule(nil))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	// Instantiate two modules from the same code, each with its own sink.
+	sinks := map[string][]TrapInfo{}
+	instantiate := func(name string) api.Module {
+		config := NewModuleConfig().WithName(name).WithTrapSink(func(trap TrapInfo) {
+			sinks[name] = append(sinks[name], trap)
+		})
+		mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, config)
+		require.NoError(t, err)
+		return mod
+	}
+	a, b := instantiate("a"), instantiate("b")
+	defer a.Close(testCtx)
+	defer b.Close(testCtx)
+
+	_, err = a.ExportedFunction("crash").Call(testCtx)
+	require.Error(t, err)
+	_, err = a.ExportedFunction("ok").Call(testCtx)
+	require.NoError(t, err)
+	_, err = b.ExportedFunction("crash").Call(testCtx)
+	require.Error(t, err)
+	_, err = b.ExportedFunction("crash").Call(testCtx)
+	require.Error(t, err)
+
+	require.Equal(t, 1, len(sinks["a"]))
+	require.Equal(t, TrapInfo{Module: "a", Function: "a.crash", Reason: "unreachable", Err: sinks["a"][0].Err}, sinks["a"][0])
+	require.Contains(t, sinks["a"][0].Err.Error(), "wasm error: unreachable")
+	require.Equal(t, 2, len(sinks["b"]))
+	require.Equal(t, "b.crash", sinks["b"][1].Function)
+
+	t.Run("caller", func(t *testing.T) {
+		caller, err := NewCaller(a, a.ExportedFunction("crash"))
+		require.NoError(t, err)
+		require.Error(t, caller.Call(testCtx))
+
+		require.Equal(t, 2, len(sinks["a"]))
+		require.Equal(t, "a.crash", sinks["a"][1].Function)
+	})
+
+	t.Run("start function", func(t *testing.T) {
+		var traps []TrapInfo
+		startIndex := wasm.Index(0)
+		startCompiled, err := r.CompileModule(testCtx, trapModule(&startIndex))
+		require.NoError(t, err)
+		defer startCompiled.Close(testCtx)
+
+		_, err = r.InstantiateModuleWithConfig(testCtx, startCompiled, NewModuleConfig().WithName("c").
+			WithTrapSink(func(trap TrapInfo) { traps = append(traps, trap) }))
+		require.Error(t, err)
+
+		require.Equal(t, 1, len(traps))
+		require.Equal(t, TrapInfo{Module: "c", Function: "c.crash", Reason: "unreachable", Err: err}, traps[0])
+	})
+
+	t.Run("start functions", func(t *testing.T) {
+		var traps []TrapInfo
+		_, err := r.InstantiateModuleWithConfig(testCtx, compiled, NewModuleConfig().WithName("d").
+			WithStartFunctions("ok", "crash").
+			WithTrapSink(func(trap TrapInfo) { traps = append(traps, trap) }))
+		require.Error(t, err)
+
+		require.Equal(t, 1, len(traps))
+		require.Equal(t, "d.crash", traps[0].Function)
+	})
+}
+
+func TestInstantiateModuleWithConfig_StartHooks(t *testing.T) {
+	r := NewRuntime()
+
+	var events []string
+	_, err := r.NewModuleBuilder("env").
+		ExportFunction("record", func(v uint32) { events = append(events, fmt.Sprintf("start read %d", v)) }).
+		Instantiate(testCtx)
+	require.NoError(t, err)
+
+	// The start function records the i32 at offset zero of memory.
+	startIndex := wasm.Index(1)
+	compiled, err := r.CompileModule(testCtx, binary.EncodeModule(&wasm.Module{
+		TypeSection:     []*wasm.FunctionType{{Params: []wasm.ValueType{wasm.ValueTypeI32}}, {}},
+		ImportSection:   []*wasm.Import{{Module: "env", Name: "record", Type: wasm.ExternTypeFunc, DescFunc: 0}},
+		FunctionSection: []wasm.Index{1},
+		CodeSection: []*wasm.Code{{Body: []byte{
+			wasm.OpcodeI32Const, 0, wasm.OpcodeI32Load, 2, 0, wasm.OpcodeCall, 0, wasm.OpcodeEnd,
+		}}},
+		MemorySection: &wasm.Memory{Min: 1},
+		StartSection:  &startIndex,
+	}))
+	require.NoError(t, err)
+	defer compiled.Close(testCtx)
+
+	preStart := func(ctx context.Context, mod api.Module) error {
+		events = append(events, "pre-start")
+		require.True(t, mod.Memory().WriteUint32Le(ctx, 0, 42))
+		return nil
+	}
+	postStart := func(ctx context.Context, mod api.Module) error {
+		events = append(events, "post-start")
+		return nil
+	}
+	failing := func(context.Context, api.Module) error { return errors.New("ice cream") }
+
+	t.Run("ok", func(t *testing.T) {
+		events = nil
+		mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, NewModuleConfig().WithName("ok").
+			WithPreStartHook(preStart).WithPostStartHook(postStart))
+		require.NoError(t, err)
+		defer mod.Close(testCtx)
+
+		require.Equal(t, []string{"pre-start", "start read 42", "post-start"}, events)
+	})
+
+	tests := []struct {
+		name           string
+		config         ModuleConfig
+		expectedEvents []string
+		expectedErr    string
+	}{
+		{
+			name:        "pre-start hook fails",
+			config:      NewModuleConfig().WithPreStartHook(failing).WithPostStartHook(postStart),
+			expectedErr: "module[failing] pre-start hook failed: ice cream",
+		},
+		{
+			name:           "post-start hook fails",
+			config:         NewModuleConfig().WithPreStartHook(preStart).WithPostStartHook(failing),
+			expectedEvents: []string{"pre-start", "start read 42"},
+			expectedErr:    "module[failing] post-start hook failed: ice cream",
+		},
+	}
+
+	for _, tt := range tests {
+		tc := tt
+
+		t.Run(tc.name, func(t *testing.T) {
+			events = nil
+			mod, err := r.InstantiateModuleWithConfig(testCtx, compiled, tc.config.WithName("failing"))
+			require.EqualError(t, err, tc.expectedErr)
+			require.Nil(t, mod)
+			require.Equal(t, tc.expectedEvents, events)
+
+			// The name was released, so can be used again.
+			require.Nil(t, r.Module("failing"))
+			mod, err = r.InstantiateModuleWithConfig(testCtx, compiled, NewModuleConfig().WithName("failing"))
+			require.NoError(t, err)
+			require.NoError(t, mod.Close(testCtx))
+		})
+	}
+}
+
 // requireImportAndExportFunction re-exports a host function because only host functions can see the propagated context.
 func requireImportAndExportFunction(t *testing.T, r Runtime, hostFn func(ctx context.Context) uint64, functionName string) ([]byte, func(context.Context) error) {
 	mod, err := r.NewModuleBuilder("host").ExportFunction(functionName, hostFn).Instantiate(testCtx)